@@ -0,0 +1,274 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/migrations"
+	"inventory-ledger/src/services"
+)
+
+// runCommand - Dispatch subcommand CLI (go run . <command> [args])
+func runCommand(db *gorm.DB, migrator *migrations.Migrator, service *services.InventoryService, name string, args []string) error {
+	switch name {
+	case "migrate":
+		return runMigrate(migrator, args)
+	case "rebuild-balances":
+		return runRebuildBalances(service)
+	case "revalue":
+		return runRevalue(service)
+	case "import":
+		return runImport(db, service, args)
+	case "chain":
+		return runChain(db, service, args)
+	case "integrity":
+		return runIntegrity(db, service, args)
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+// runMigrate - go run . migrate up | down [-steps N] | status
+func runMigrate(migrator *migrations.Migrator, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up | down [-steps N] | status")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			log.Printf("✅ Applied %04d_%s", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			log.Println("Schema is up to date")
+		}
+		return err
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "jumlah migrasi yang di-rollback")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *steps < 1 {
+			return fmt.Errorf("-steps must be at least 1")
+		}
+
+		reverted, err := migrator.Down(*steps)
+		for _, m := range reverted {
+			log.Printf("↩️  Reverted %04d_%s", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, use up, down or status", args[0])
+	}
+}
+
+// runChain - go run . chain verify [-org UUID] [-item ID] | seal
+func runChain(db *gorm.DB, service *services.InventoryService, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: chain verify [-org UUID] [-item ID] | seal")
+	}
+
+	chainService := &services.ChainService{DB: db, Inventory: service}
+
+	switch args[0] {
+	case "verify":
+		fs := flag.NewFlagSet("chain verify", flag.ContinueOnError)
+		orgStr := fs.String("org", "", "organization_id (kosong = semua)")
+		itemID := fs.Uint("item", 0, "item_id (0 = semua)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		orgID, err := parseOrgFlag(*orgStr)
+		if err != nil {
+			return err
+		}
+
+		result, err := chainService.Verify(orgID, *itemID)
+		if err != nil {
+			return err
+		}
+		for _, report := range result.Broken {
+			fmt.Printf("%s org=%s item=%d seq=%d id=%s: %s\n", report.Table, report.OrganizationID,
+				report.ItemID, report.Break.Seq, report.Break.ID, report.Break.Reason)
+		}
+
+		log.Printf("Chains: %d, rows: %d, unsealed: %d, broken: %d",
+			result.Chains, result.Rows, result.Unsealed, len(result.Broken))
+		if !result.Valid {
+			return fmt.Errorf("ledger hash chain is broken")
+		}
+		return nil
+	case "seal":
+		log.Println("🔗 Sealing rows created before the hash chain...")
+
+		sealed, err := chainService.Seal()
+		if err != nil {
+			return err
+		}
+
+		log.Printf("✅ Sealed %d rows", sealed)
+		return nil
+	default:
+		return fmt.Errorf("unknown chain command %q, use verify or seal", args[0])
+	}
+}
+
+// runIntegrity - go run . integrity [-org UUID] [-item ID] [-repair]
+func runIntegrity(db *gorm.DB, service *services.InventoryService, args []string) error {
+	fs := flag.NewFlagSet("integrity", flag.ContinueOnError)
+	orgStr := fs.String("org", "", "organization_id (kosong = semua)")
+	itemID := fs.Uint("item", 0, "item_id (0 = semua)")
+	repair := fs.Bool("repair", false, "recalculate sequence dengan saldo/opname yang rusak")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	orgID, err := parseOrgFlag(*orgStr)
+	if err != nil {
+		return err
+	}
+
+	integrityService := &services.IntegrityService{DB: db, Inventory: service}
+	report, err := integrityService.Check(orgID, *itemID, *repair)
+	if report != nil {
+		for _, issue := range report.Issues {
+			fmt.Printf("%-20s org=%s item=%d id=%s date=%s: %s\n", issue.Kind, issue.OrganizationID,
+				issue.ItemID, issue.InventoryID, issue.TxnDate.Format(time.RFC3339), issue.Message)
+		}
+		for _, repaired := range report.Repaired {
+			log.Printf("🔧 Recalculated org=%s item=%d from %s", repaired.OrganizationID,
+				repaired.ItemID, repaired.FromDate.Format(time.RFC3339))
+		}
+		for _, rejected := range report.Rejected {
+			log.Printf("⛔ Repair rejected org=%s item=%d from %s: %s", rejected.OrganizationID,
+				rejected.ItemID, rejected.FromDate.Format(time.RFC3339), rejected.Reason)
+		}
+		log.Printf("Sequences: %d, rows: %d, issues: %d, repaired: %d, unresolved: %d",
+			report.Sequences, report.Rows, len(report.Issues), len(report.Repaired), report.Unresolved)
+	}
+	if err != nil {
+		return err
+	}
+	if report.Unresolved > 0 {
+		return fmt.Errorf("%d unresolved integrity issues", report.Unresolved)
+	}
+	return nil
+}
+
+// parseOrgFlag - Flag -org opsional (kosong = semua org)
+func parseOrgFlag(value string) (uuid.UUID, error) {
+	if value == "" {
+		return uuid.Nil, nil
+	}
+	orgID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid -org: %w", err)
+	}
+	return orgID, nil
+}
+
+// runRebuildBalances - Regenerate stock_balances dari inventories
+func runRebuildBalances(service *services.InventoryService) error {
+	log.Println("🔁 Rebuilding stock_balances from inventories...")
+
+	count, err := service.RebuildStockBalances()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("✅ Rebuilt %d stock balances", count)
+	return nil
+}
+
+// runRevalue - Nilai ulang cost_amount/balance_value seluruh ledger (setelah migrasi valuation)
+func runRevalue(service *services.InventoryService) error {
+	log.Println("🔁 Revaluing inventory ledger...")
+
+	report, err := service.RevalueAll()
+	if err != nil {
+		return err
+	}
+
+	for _, skipped := range report.Skipped {
+		log.Printf("⛔ Skipped item=%d: %s", skipped.ItemID, skipped.Reason)
+	}
+	log.Printf("✅ Revalued %d of %d items", report.Revalued, report.Items)
+	if len(report.Skipped) > 0 {
+		return fmt.Errorf("%d items touch a closed period, reopen it to revalue them", len(report.Skipped))
+	}
+	return nil
+}
+
+// runImport - Import stok_awal/penerimaan dari CSV/XLSX
+// Contoh: go run . import -file stok_awal.xlsx -changed-by admin -dry-run
+func runImport(db *gorm.DB, service *services.InventoryService, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	path := fs.String("file", "", "path file .csv atau .xlsx")
+	changedBy := fs.String("changed-by", "", "user yang melakukan import")
+	reason := fs.String("reason", "", "alasan/keterangan import")
+	dryRun := fs.Bool("dry-run", false, "validasi saja tanpa posting")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" || *changedBy == "" {
+		fs.Usage()
+		return fmt.Errorf("-file and -changed-by are required")
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rows, err := services.ParseImportFile(*path, file)
+	if err != nil {
+		return err
+	}
+
+	var reasonPtr *string
+	if *reason != "" {
+		reasonPtr = reason
+	}
+
+	importService := &services.ImportService{DB: db, Inventory: service}
+	report, err := importService.Import(rows, *changedBy, reasonPtr, *dryRun)
+	if report != nil {
+		printImportReport(report)
+	}
+	return err
+}
+
+// printImportReport - Tampilkan hasil import per baris yang error
+func printImportReport(report *services.ImportReport) {
+	for _, row := range report.Rows {
+		for _, msg := range row.Errors {
+			fmt.Printf("line %d (%s/%s): %s\n", row.Line, row.OrganizationCode, row.ItemCode, msg)
+		}
+	}
+
+	log.Printf("Rows: total=%d valid=%d invalid=%d posted=%d dry_run=%v",
+		report.TotalRows, report.ValidRows, report.InvalidRows, report.PostedRows, report.DryRun)
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -9,6 +13,8 @@ import (
 
 	"inventory-ledger/src/config"
 	"inventory-ledger/src/handlers"
+	"inventory-ledger/src/middlewares"
+	"inventory-ledger/src/migrations"
 	"inventory-ledger/src/models"
 	"inventory-ledger/src/repositories"
 	"inventory-ledger/src/routes"
@@ -16,18 +22,22 @@ import (
 )
 
 func main() {
-	db := config.InitDB()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	time.Local = cfg.Location()
+	gin.SetMode(cfg.Server.GinMode)
 
-	db.AutoMigrate(
-		&models.Organization{},
-		&models.Item{},
-		&models.Inventory{},
-		&models.InventoryHistory{},
-	)
+	db := config.InitDB(cfg)
 
-	// Insert sample data jika kosong
-	if err := seedSampleData(db); err != nil {
-		log.Printf("Failed to seed sample data: %v", err)
+	migrator := &migrations.Migrator{DB: db}
+
+	// "migrate" dijalankan manual, command lain & server selalu pakai skema terbaru
+	if len(os.Args) < 2 || os.Args[1] != "migrate" {
+		if _, err := migrator.Up(); err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
 	}
 
 	// Initialize repository
@@ -39,25 +49,162 @@ func main() {
 		Repo: repo,
 	}
 
+	// Jalankan subcommand CLI jika ada (misal: rebuild-balances)
+	if len(os.Args) > 1 {
+		if err := runCommand(db, migrator, service, os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalf("Command %s failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	// Insert sample data jika kosong
+	if cfg.SeedSampleData {
+		if err := seedSampleData(db); err != nil {
+			log.Printf("Failed to seed sample data: %v", err)
+		}
+	}
+
+	importService := &services.ImportService{
+		DB:        db,
+		Inventory: service,
+	}
+
 	// Initialize handler
 	handler := &handlers.InventoryHandler{
 		Service: service,
 	}
+	importHandler := &handlers.ImportHandler{
+		Service: importService,
+	}
+	organizationHandler := &handlers.OrganizationHandler{
+		Service: &services.OrganizationService{
+			DB:   db,
+			Repo: &repositories.OrganizationRepository{DB: db},
+		},
+	}
+	locationHandler := &handlers.LocationHandler{
+		Service: &services.LocationService{
+			DB:   db,
+			Repo: &repositories.LocationRepository{DB: db},
+		},
+	}
+	itemHandler := &handlers.ItemHandler{
+		Service: &services.ItemService{
+			DB:        db,
+			Repo:      &repositories.ItemRepository{DB: db},
+			Inventory: service,
+		},
+	}
+
+	periodHandler := &handlers.PeriodHandler{
+		Service: &services.PeriodService{
+			DB:        db,
+			Inventory: service,
+		},
+	}
+
+	adminHandler := &handlers.AdminHandler{
+		Chain: &services.ChainService{
+			DB:        db,
+			Inventory: service,
+		},
+		Integrity: &services.IntegrityService{
+			DB:        db,
+			Inventory: service,
+		},
+	}
+
+	webhookService := &services.WebhookService{
+		DB:           db,
+		Client:       &http.Client{Timeout: cfg.Webhook.Timeout.Duration},
+		PollInterval: cfg.Webhook.PollInterval.Duration,
+		MaxAttempts:  cfg.Webhook.MaxAttempts,
+	}
+	webhookHandler := &handlers.WebhookHandler{
+		Service: webhookService,
+	}
+
+	alertHandler := &handlers.AlertHandler{
+		Service: &services.AlertService{
+			DB:        db,
+			Inventory: service,
+		},
+	}
+
+	// Notifikasi alert stok punya antrian retry sendiri (memakai poll/timeout/attempts webhook)
+	alertNotifications := &services.AlertNotificationDispatcher{
+		DB:           db,
+		Notifiers:    alertNotifiers(cfg.Alert, cfg.Webhook.Timeout.Duration),
+		PollInterval: cfg.Webhook.PollInterval.Duration,
+		Timeout:      cfg.Webhook.Timeout.Duration,
+		MaxAttempts:  cfg.Webhook.MaxAttempts,
+	}
+
+	// Dispatcher outbox: kirim domain event ke sink di background (webhook & alert lewat antrian sendiri)
+	if cfg.Outbox.Enabled {
+		dispatcher := &services.OutboxDispatcher{
+			DB: db,
+			Sinks: []services.EventSink{
+				services.LogSink{},
+				services.WebhookSink{Service: webhookService},
+				services.AlertNotifierSink{Dispatcher: alertNotifications},
+			},
+			BatchSize:    cfg.Outbox.BatchSize,
+			PollInterval: cfg.Outbox.PollInterval.Duration,
+			MaxAttempts:  cfg.Outbox.MaxAttempts,
+		}
+		go dispatcher.Run(context.Background())
+		go webhookService.Run(context.Background())
+		go alertNotifications.Run(context.Background())
+	}
 
 	// Setup router dengan recovery middleware
 	router := gin.Default()
 
+	idempotency := middlewares.Idempotency(&repositories.IdempotencyRepository{DB: db},
+		int64(cfg.Server.MaxBodyBytes), cfg.Server.IdempotencyTTL.Duration)
+
 	api := router.Group("/api/v1")
-	routes.RegisterInventoryRoutes(api.Group("/inventory"), handler)
+	inventoryGroup := api.Group("/inventory")
+	routes.RegisterInventoryRoutes(inventoryGroup, handler, idempotency)
+	routes.RegisterImportRoutes(inventoryGroup, importHandler, idempotency)
+	organizationGroup := api.Group("/organizations")
+	routes.RegisterOrganizationRoutes(organizationGroup, organizationHandler)
+	routes.RegisterLocationRoutes(organizationGroup, locationHandler)
+	routes.RegisterPeriodRoutes(organizationGroup, periodHandler)
+	routes.RegisterStockLevelRoutes(organizationGroup, alertHandler)
+	routes.RegisterItemRoutes(api.Group("/items"), itemHandler)
+	routes.RegisterAdminRoutes(api.Group("/admin"), adminHandler)
+	routes.RegisterWebhookRoutes(api.Group("/webhooks"), webhookHandler)
+	routes.RegisterAlertRoutes(api.Group("/alerts"), alertHandler)
+	routes.RegisterSerialRoutes(api.Group("/serials"), handler)
 
 	// Start server
-	port := ":8080"
-
-	if err := router.Run(port); err != nil {
+	if err := router.Run(cfg.Server.ListenAddr); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
 
+// alertNotifiers - Notifier alert level stok sesuai config (nama sudah divalidasi di config.Load)
+func alertNotifiers(cfg config.AlertConfig, timeout time.Duration) []services.AlertNotifier {
+	var notifiers []services.AlertNotifier
+	for _, name := range cfg.Notifiers {
+		switch name {
+		case "log":
+			notifiers = append(notifiers, services.LogNotifier{})
+		case "smtp":
+			notifiers = append(notifiers, services.SMTPNotifier{Addr: cfg.SMTPAddr, From: cfg.SMTPFrom, To: cfg.SMTPTo, Timeout: timeout})
+		case "webhook":
+			notifiers = append(notifiers, services.WebhookNotifier{
+				URL:    cfg.WebhookURL,
+				Secret: cfg.WebhookSecret,
+				Client: &http.Client{Timeout: timeout},
+			})
+		}
+	}
+	return notifiers
+}
+
 func seedSampleData(db *gorm.DB) error {
 	var orgCount int64
 	db.Model(&models.Organization{}).Count(&orgCount)
@@ -0,0 +1,398 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// ============ CONFIG TYPES ============
+
+// Config - Konfigurasi aplikasi. Urutan prioritas: default < file (CONFIG_FILE) < env var
+type Config struct {
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	Outbox   OutboxConfig   `yaml:"outbox" toml:"outbox"`
+	Webhook  WebhookConfig  `yaml:"webhook" toml:"webhook"`
+	Alert    AlertConfig    `yaml:"alert" toml:"alert"`
+
+	LogLevel       string `yaml:"log_level" toml:"log_level"`               // debug, info, warn, error, silent
+	SeedSampleData bool   `yaml:"seed_sample_data" toml:"seed_sample_data"` // isi data contoh jika tabel kosong
+	Timezone       string `yaml:"timezone" toml:"timezone"`                 // nama IANA, misal Asia/Jakarta
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	Name     string `yaml:"name" toml:"name"`
+	SSLMode  string `yaml:"sslmode" toml:"sslmode"`
+
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+}
+
+type ServerConfig struct {
+	ListenAddr string `yaml:"listen_addr" toml:"listen_addr"`
+	GinMode    string `yaml:"gin_mode" toml:"gin_mode"` // debug, release, test
+
+	MaxBodyBytes   int      `yaml:"max_body_bytes" toml:"max_body_bytes"`   // batas body request ber-Idempotency-Key
+	IdempotencyTTL Duration `yaml:"idempotency_ttl" toml:"idempotency_ttl"` // key pending lebih lama dari ini dianggap bebas
+}
+
+// OutboxConfig - Dispatcher event outbox (hanya jalan di mode server, bukan subcommand CLI)
+type OutboxConfig struct {
+	Enabled      bool     `yaml:"enabled" toml:"enabled"`
+	PollInterval Duration `yaml:"poll_interval" toml:"poll_interval"`
+	BatchSize    int      `yaml:"batch_size" toml:"batch_size"`
+	MaxAttempts  int      `yaml:"max_attempts" toml:"max_attempts"` // setelah ini event jadi dead
+}
+
+// WebhookConfig - Pengiriman webhook keluar (jalan bersama dispatcher outbox)
+type WebhookConfig struct {
+	PollInterval Duration `yaml:"poll_interval" toml:"poll_interval"`
+	Timeout      Duration `yaml:"timeout" toml:"timeout"`           // per request HTTP
+	MaxAttempts  int      `yaml:"max_attempts" toml:"max_attempts"` // setelah ini delivery jadi failed
+}
+
+// AlertConfig - Notifier alert level stok (dikirim lewat dispatcher outbox)
+type AlertConfig struct {
+	Notifiers []string `yaml:"notifiers" toml:"notifiers"` // log, smtp, webhook; kosong = hanya tercatat di /alerts
+
+	SMTPAddr string   `yaml:"smtp_addr" toml:"smtp_addr"` // host:port, misal localhost:1025 (MailHog)
+	SMTPFrom string   `yaml:"smtp_from" toml:"smtp_from"`
+	SMTPTo   []string `yaml:"smtp_to" toml:"smtp_to"`
+
+	WebhookURL    string `yaml:"webhook_url" toml:"webhook_url"`
+	WebhookSecret string `yaml:"webhook_secret" toml:"webhook_secret"` // opsional, untuk header signature
+}
+
+// Duration - time.Duration yang bisa dibaca dari string "30m" di YAML/TOML/env
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.Duration.String()), nil
+}
+
+// ============ LOAD ============
+
+// Default - Nilai default (setara dengan konfigurasi hardcoded sebelumnya)
+func Default() *Config {
+	return &Config{
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            5432,
+			Name:            "inventory",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration{30 * time.Minute},
+		},
+		Server: ServerConfig{
+			ListenAddr:     ":8080",
+			GinMode:        "debug",
+			MaxBodyBytes:   32 << 20,
+			IdempotencyTTL: Duration{10 * time.Minute},
+		},
+		Outbox: OutboxConfig{
+			Enabled:      true,
+			PollInterval: Duration{time.Second},
+			BatchSize:    100,
+			MaxAttempts:  10,
+		},
+		Webhook: WebhookConfig{
+			PollInterval: Duration{time.Second},
+			Timeout:      Duration{10 * time.Second},
+			MaxAttempts:  8,
+		},
+		Alert: AlertConfig{
+			Notifiers: []string{"log"},
+		},
+		LogLevel:       "warn",
+		SeedSampleData: true,
+		Timezone:       "UTC",
+	}
+}
+
+// Load - Default, lalu file dari CONFIG_FILE (jika ada), lalu override env var, lalu validasi
+func Load() (*Config, error) {
+	cfg := Default()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile - Baca .yaml/.yml/.toml; field yang tidak dikenal dianggap error (typo)
+func (c *Config) loadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return yaml.UnmarshalWithOptions(content, c, yaml.DisallowUnknownField())
+	case ".toml":
+		err := toml.NewDecoder(bytes.NewReader(content)).DisallowUnknownFields().Decode(c)
+		// Error strict go-toml tidak menyebut key-nya, sebutkan supaya typo mudah dicari
+		var strict *toml.StrictMissingError
+		if errors.As(err, &strict) {
+			keys := make([]string, len(strict.Errors))
+			for i, missing := range strict.Errors {
+				keys[i] = strings.Join(missing.Key(), ".")
+			}
+			return fmt.Errorf("unknown fields: %s", strings.Join(keys, ", "))
+		}
+		return err
+	default:
+		return errors.New("unsupported config format, use .yaml, .yml or .toml")
+	}
+}
+
+// loadEnv - Override dari env var (hanya yang di-set)
+func (c *Config) loadEnv() error {
+	var errs []error
+
+	setString := func(name string, target *string) {
+		if value, ok := os.LookupEnv(name); ok {
+			*target = value
+		}
+	}
+	setInt := func(name string, target *int) {
+		if value, ok := os.LookupEnv(name); ok {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be an integer", name))
+				return
+			}
+			*target = parsed
+		}
+	}
+	setBool := func(name string, target *bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be true or false", name))
+				return
+			}
+			*target = parsed
+		}
+	}
+	setList := func(name string, target *[]string) {
+		if value, ok := os.LookupEnv(name); ok {
+			*target = nil
+			for _, part := range strings.Split(value, ",") {
+				if part = strings.TrimSpace(part); part != "" {
+					*target = append(*target, part)
+				}
+			}
+		}
+	}
+	setDuration := func(name string, target *Duration) {
+		if value, ok := os.LookupEnv(name); ok {
+			if err := target.UnmarshalText([]byte(value)); err != nil {
+				errs = append(errs, fmt.Errorf("%s must be a duration like 30m", name))
+			}
+		}
+	}
+
+	setString("DB_HOST", &c.Database.Host)
+	setInt("DB_PORT", &c.Database.Port)
+	setString("DB_USER", &c.Database.User)
+	setString("DB_PASSWORD", &c.Database.Password)
+	setString("DB_NAME", &c.Database.Name)
+	setString("DB_SSLMODE", &c.Database.SSLMode)
+	setInt("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	setInt("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	setDuration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+
+	setString("LISTEN_ADDR", &c.Server.ListenAddr)
+	setString("GIN_MODE", &c.Server.GinMode)
+	setInt("MAX_BODY_BYTES", &c.Server.MaxBodyBytes)
+	setDuration("IDEMPOTENCY_TTL", &c.Server.IdempotencyTTL)
+
+	setBool("OUTBOX_ENABLED", &c.Outbox.Enabled)
+	setDuration("OUTBOX_POLL_INTERVAL", &c.Outbox.PollInterval)
+	setInt("OUTBOX_BATCH_SIZE", &c.Outbox.BatchSize)
+	setInt("OUTBOX_MAX_ATTEMPTS", &c.Outbox.MaxAttempts)
+
+	setDuration("WEBHOOK_POLL_INTERVAL", &c.Webhook.PollInterval)
+	setDuration("WEBHOOK_TIMEOUT", &c.Webhook.Timeout)
+	setInt("WEBHOOK_MAX_ATTEMPTS", &c.Webhook.MaxAttempts)
+
+	setList("ALERT_NOTIFIERS", &c.Alert.Notifiers)
+	setString("ALERT_SMTP_ADDR", &c.Alert.SMTPAddr)
+	setString("ALERT_SMTP_FROM", &c.Alert.SMTPFrom)
+	setList("ALERT_SMTP_TO", &c.Alert.SMTPTo)
+	setString("ALERT_WEBHOOK_URL", &c.Alert.WebhookURL)
+	setString("ALERT_WEBHOOK_SECRET", &c.Alert.WebhookSecret)
+
+	setString("LOG_LEVEL", &c.LogLevel)
+	setBool("SEED_SAMPLE_DATA", &c.SeedSampleData)
+	setString("APP_TIMEZONE", &c.Timezone)
+
+	return errors.Join(errs...)
+}
+
+// ============ VALIDATION ============
+
+// Validate - Cek semua field, semua error dilaporkan sekaligus
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Database.Host == "" {
+		errs = append(errs, errors.New("database host is required"))
+	}
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		errs = append(errs, errors.New("database port must be between 1 and 65535"))
+	}
+	if c.Database.Name == "" {
+		errs = append(errs, errors.New("database name is required"))
+	}
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("invalid database sslmode %q", c.Database.SSLMode))
+	}
+	if c.Database.MaxOpenConns < 1 {
+		errs = append(errs, errors.New("database max_open_conns must be at least 1"))
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, errors.New("database max_idle_conns must be between 0 and max_open_conns"))
+	}
+	if c.Database.ConnMaxLifetime.Duration < 0 {
+		errs = append(errs, errors.New("database conn_max_lifetime cannot be negative"))
+	}
+
+	if c.Server.ListenAddr == "" {
+		errs = append(errs, errors.New("server listen_addr is required"))
+	}
+	switch c.Server.GinMode {
+	case "debug", "release", "test":
+	default:
+		errs = append(errs, fmt.Errorf("invalid gin_mode %q, use debug, release or test", c.Server.GinMode))
+	}
+	if c.Server.MaxBodyBytes < 1 {
+		errs = append(errs, errors.New("server max_body_bytes must be at least 1"))
+	}
+	if c.Server.IdempotencyTTL.Duration <= 0 {
+		errs = append(errs, errors.New("server idempotency_ttl must be positive"))
+	}
+
+	if c.Outbox.PollInterval.Duration <= 0 {
+		errs = append(errs, errors.New("outbox poll_interval must be positive"))
+	}
+	if c.Outbox.BatchSize < 1 {
+		errs = append(errs, errors.New("outbox batch_size must be at least 1"))
+	}
+	if c.Outbox.MaxAttempts < 1 {
+		errs = append(errs, errors.New("outbox max_attempts must be at least 1"))
+	}
+
+	if c.Webhook.PollInterval.Duration <= 0 {
+		errs = append(errs, errors.New("webhook poll_interval must be positive"))
+	}
+	if c.Webhook.Timeout.Duration <= 0 {
+		errs = append(errs, errors.New("webhook timeout must be positive"))
+	}
+	if c.Webhook.MaxAttempts < 1 {
+		errs = append(errs, errors.New("webhook max_attempts must be at least 1"))
+	}
+
+	for _, notifier := range c.Alert.Notifiers {
+		switch notifier {
+		case "log":
+		case "smtp":
+			if c.Alert.SMTPAddr == "" || c.Alert.SMTPFrom == "" || len(c.Alert.SMTPTo) == 0 {
+				errs = append(errs, errors.New("alert smtp notifier requires smtp_addr, smtp_from and smtp_to"))
+			}
+		case "webhook":
+			if c.Alert.WebhookURL == "" {
+				errs = append(errs, errors.New("alert webhook notifier requires webhook_url"))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("invalid alert notifier %q, use log, smtp or webhook", notifier))
+		}
+	}
+
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error", "silent":
+	default:
+		errs = append(errs, fmt.Errorf("invalid log_level %q, use debug, info, warn, error or silent", c.LogLevel))
+	}
+
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		errs = append(errs, fmt.Errorf("invalid timezone %q", c.Timezone))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+// Location - Timezone aplikasi (sudah divalidasi di Load)
+func (c *Config) Location() *time.Location {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// DSN - Connection string PostgreSQL dari bagian-bagian config
+func (c DatabaseConfig) DSN(timezone string) string {
+	parts := []string{
+		"host=" + quoteDSN(c.Host),
+		"port=" + strconv.Itoa(c.Port),
+		"user=" + quoteDSN(c.User),
+		"password=" + quoteDSN(c.Password),
+		"dbname=" + quoteDSN(c.Name),
+		"sslmode=" + c.SSLMode,
+		"TimeZone=" + quoteDSN(timezone),
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteDSN - Quote value DSN key=value (kosong / spasi / kutip)
+func quoteDSN(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + replacer.Replace(value) + "'"
+}
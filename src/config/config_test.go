@@ -0,0 +1,313 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// configEnv - Semua env var yang dibaca Load (dikosongkan per test supaya environment mesin tidak ikut)
+var configEnv = []string{
+	"CONFIG_FILE",
+	"DB_HOST", "DB_PORT", "DB_USER", "DB_PASSWORD", "DB_NAME", "DB_SSLMODE",
+	"DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "DB_CONN_MAX_LIFETIME",
+	"LISTEN_ADDR", "GIN_MODE", "MAX_BODY_BYTES", "IDEMPOTENCY_TTL",
+	"OUTBOX_ENABLED", "OUTBOX_POLL_INTERVAL", "OUTBOX_BATCH_SIZE", "OUTBOX_MAX_ATTEMPTS",
+	"WEBHOOK_POLL_INTERVAL", "WEBHOOK_TIMEOUT", "WEBHOOK_MAX_ATTEMPTS",
+	"ALERT_NOTIFIERS", "ALERT_SMTP_ADDR", "ALERT_SMTP_FROM", "ALERT_SMTP_TO",
+	"ALERT_WEBHOOK_URL", "ALERT_WEBHOOK_SECRET",
+	"LOG_LEVEL", "SEED_SAMPLE_DATA", "APP_TIMEZONE",
+}
+
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range configEnv {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+// writeConfigFile - Tulis file config sementara, return path-nya
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// ============ LOAD ============
+
+func TestLoadDefaults(t *testing.T) {
+	clearConfigEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load defaults: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("expected defaults, got %+v", cfg)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+database:
+  host: db.internal
+  port: 6432
+  name: inventory_file
+server:
+  gin_mode: release
+outbox:
+  batch_size: 50
+log_level: info
+`,
+		"config.toml": `
+log_level = "info"
+
+[database]
+host = "db.internal"
+port = 6432
+name = "inventory_file"
+
+[server]
+gin_mode = "release"
+
+[outbox]
+batch_size = 50
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			clearConfigEnv(t)
+			t.Setenv("CONFIG_FILE", writeConfigFile(t, name, content))
+			// Env menang atas file, file menang atas default
+			t.Setenv("DB_HOST", "db.env")
+			t.Setenv("OUTBOX_BATCH_SIZE", "25")
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+
+			if cfg.Database.Host != "db.env" || cfg.Outbox.BatchSize != 25 {
+				t.Errorf("env should override file: host=%q batch_size=%d", cfg.Database.Host, cfg.Outbox.BatchSize)
+			}
+			if cfg.Database.Port != 6432 || cfg.Database.Name != "inventory_file" ||
+				cfg.Server.GinMode != "release" || cfg.LogLevel != "info" {
+				t.Errorf("file should override defaults: %+v", cfg)
+			}
+			if cfg.Database.SSLMode != "disable" || cfg.Server.ListenAddr != ":8080" || cfg.Outbox.MaxAttempts != 10 {
+				t.Errorf("unset fields should keep defaults: %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{"unknown yaml key", "config.yaml", "database:\n  hots: db.internal\n", "hots"},
+		{"unknown yaml section", "config.yml", "sever:\n  gin_mode: release\n", "sever"},
+		{"unknown toml key", "config.toml", "[database]\nhots = \"db.internal\"\n", "hots"},
+		{"unknown toml section", "config.toml", "[sever]\ngin_mode = \"release\"\n", "sever"},
+		{"bad yaml duration", "config.yaml", "outbox:\n  poll_interval: soon\n", "soon"},
+		{"unsupported format", "config.json", "{}", "unsupported config format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			t.Setenv("CONFIG_FILE", writeConfigFile(t, tt.file, tt.content))
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		clearConfigEnv(t)
+		t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "missing.yaml") {
+			t.Errorf("expected error for missing file, got %v", err)
+		}
+	})
+}
+
+func TestLoadEnvParsing(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("DB_CONN_MAX_LIFETIME", "1h30m")
+	t.Setenv("IDEMPOTENCY_TTL", "90s")
+	t.Setenv("WEBHOOK_TIMEOUT", "250ms")
+	t.Setenv("OUTBOX_ENABLED", "false")
+	t.Setenv("ALERT_NOTIFIERS", " log , smtp,, ")
+	t.Setenv("ALERT_SMTP_ADDR", "localhost:1025")
+	t.Setenv("ALERT_SMTP_FROM", "stok@example.com")
+	t.Setenv("ALERT_SMTP_TO", "a@example.com,b@example.com")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if cfg.Database.ConnMaxLifetime.Duration != 90*time.Minute || cfg.Server.IdempotencyTTL.Duration != 90*time.Second ||
+		cfg.Webhook.Timeout.Duration != 250*time.Millisecond {
+		t.Errorf("unexpected durations: %v %v %v",
+			cfg.Database.ConnMaxLifetime, cfg.Server.IdempotencyTTL, cfg.Webhook.Timeout)
+	}
+	if cfg.Outbox.Enabled {
+		t.Error("OUTBOX_ENABLED=false should disable the outbox")
+	}
+	if !reflect.DeepEqual(cfg.Alert.Notifiers, []string{"log", "smtp"}) {
+		t.Errorf("notifiers should be trimmed and skip blanks, got %q", cfg.Alert.Notifiers)
+	}
+	if !reflect.DeepEqual(cfg.Alert.SMTPTo, []string{"a@example.com", "b@example.com"}) {
+		t.Errorf("unexpected smtp_to: %q", cfg.Alert.SMTPTo)
+	}
+
+	// List kosong di env menghapus default
+	t.Setenv("ALERT_NOTIFIERS", "")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(cfg.Alert.Notifiers) != 0 {
+		t.Errorf("empty ALERT_NOTIFIERS should clear notifiers, got %q", cfg.Alert.Notifiers)
+	}
+}
+
+func TestLoadEnvErrors(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("DB_PORT", "five")
+	t.Setenv("OUTBOX_ENABLED", "maybe")
+	t.Setenv("WEBHOOK_TIMEOUT", "10")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("expected env parse errors")
+	}
+	// Semua env var yang salah dilaporkan sekaligus
+	for _, want := range []string{"DB_PORT must be an integer", "OUTBOX_ENABLED must be true or false",
+		"WEBHOOK_TIMEOUT must be a duration"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
+
+// ============ VALIDATION ============
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string
+	}{
+		{"empty host", func(c *Config) { c.Database.Host = "" }, "database host is required"},
+		{"port out of range", func(c *Config) { c.Database.Port = 70000 }, "database port must be between 1 and 65535"},
+		{"bad sslmode", func(c *Config) { c.Database.SSLMode = "on" }, `invalid database sslmode "on"`},
+		{"idle above open", func(c *Config) { c.Database.MaxIdleConns = 30 }, "max_idle_conns must be between 0 and max_open_conns"},
+		{"negative lifetime", func(c *Config) { c.Database.ConnMaxLifetime.Duration = -time.Second }, "conn_max_lifetime cannot be negative"},
+		{"bad gin mode", func(c *Config) { c.Server.GinMode = "prod" }, `invalid gin_mode "prod"`},
+		{"zero body limit", func(c *Config) { c.Server.MaxBodyBytes = 0 }, "max_body_bytes must be at least 1"},
+		{"zero idempotency ttl", func(c *Config) { c.Server.IdempotencyTTL.Duration = 0 }, "idempotency_ttl must be positive"},
+		{"zero poll interval", func(c *Config) { c.Outbox.PollInterval.Duration = 0 }, "outbox poll_interval must be positive"},
+		{"zero webhook timeout", func(c *Config) { c.Webhook.Timeout.Duration = 0 }, "webhook timeout must be positive"},
+		{"smtp without address", func(c *Config) { c.Alert.Notifiers = []string{"smtp"} }, "smtp notifier requires"},
+		{"webhook without url", func(c *Config) { c.Alert.Notifiers = []string{"webhook"} }, "webhook notifier requires webhook_url"},
+		{"unknown notifier", func(c *Config) { c.Alert.Notifiers = []string{"slack"} }, `invalid alert notifier "slack"`},
+		{"bad log level", func(c *Config) { c.LogLevel = "verbose" }, `invalid log_level "verbose"`},
+		{"bad timezone", func(c *Config) { c.Timezone = "Mars/Olympus" }, `invalid timezone "Mars/Olympus"`},
+	}
+
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("defaults should be valid: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// Semua error dilaporkan sekaligus
+	cfg := Default()
+	cfg.Database.Host, cfg.LogLevel = "", "verbose"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "database host is required") ||
+		!strings.Contains(err.Error(), "invalid log_level") {
+		t.Errorf("expected both errors, got %v", err)
+	}
+}
+
+// ============ DSN ============
+
+func TestQuoteDSN(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"inventory", "inventory"},
+		{"", "''"},
+		{"my db", "'my db'"},
+		{"it's", `'it\'s'`},
+		{`back\slash`, `'back\\slash'`},
+		{`a b'c\d`, `'a b\'c\\d'`},
+	}
+
+	for _, tt := range tests {
+		if got := quoteDSN(tt.value); got != tt.want {
+			t.Errorf("quoteDSN(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDSNRoundTrip(t *testing.T) {
+	// DSN harus dibaca ulang oleh driver pgx dengan nilai yang sama persis
+	cfg := Default().Database
+	cfg.User = "stok admin"
+	cfg.Password = `p@ss 'w\rd`
+	cfg.Name = "inventory's db"
+
+	parsed, err := pgconn.ParseConfig(cfg.DSN("Asia/Jakarta"))
+	if err != nil {
+		t.Fatalf("parse dsn: %v", err)
+	}
+	if parsed.User != cfg.User || parsed.Password != cfg.Password || parsed.Database != cfg.Name ||
+		parsed.Host != "localhost" || parsed.Port != 5432 {
+		t.Errorf("dsn did not round-trip: user=%q password=%q database=%q host=%q port=%d",
+			parsed.User, parsed.Password, parsed.Database, parsed.Host, parsed.Port)
+	}
+	if parsed.RuntimeParams["TimeZone"] != "Asia/Jakarta" {
+		t.Errorf("unexpected timezone: %q", parsed.RuntimeParams["TimeZone"])
+	}
+
+	// Password kosong tetap ter-quote supaya key berikutnya tidak ikut terbaca sebagai password
+	cfg.Password = ""
+	parsed, err = pgconn.ParseConfig(cfg.DSN("UTC"))
+	if err != nil {
+		t.Fatalf("parse dsn: %v", err)
+	}
+	if parsed.Password != "" || parsed.Database != cfg.Name {
+		t.Errorf("empty password leaked into other keys: password=%q database=%q", parsed.Password, parsed.Database)
+	}
+}
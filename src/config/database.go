@@ -2,16 +2,53 @@ package config
 
 import (
 	"log"
+	"os"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
-func InitDB() *gorm.DB {
-	dsn := "host=localhost user= password= dbname=inventory port=5432 sslmode=disable"
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+func InitDB(cfg *Config) *gorm.DB {
+	gormLogger := logger.New(
+		log.New(os.Stdout, "\r\n", log.LstdFlags),
+		logger.Config{
+			SlowThreshold:             time.Second,
+			LogLevel:                  gormLogLevel(cfg.LogLevel),
+			IgnoreRecordNotFoundError: true,
+			Colorful:                  cfg.Server.GinMode == "debug",
+		},
+	)
+
+	db, err := gorm.Open(postgres.Open(cfg.Database.DSN(cfg.Timezone)), &gorm.Config{
+		Logger: gormLogger,
+	})
 	if err != nil {
 		log.Fatal("failed to connect database")
 	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal("failed to get database handle")
+	}
+	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime.Duration)
+
 	return db
 }
+
+// gormLogLevel - Mapping log_level aplikasi ke level logger GORM
+func gormLogLevel(level string) logger.LogLevel {
+	switch level {
+	case "debug":
+		return logger.Info // log semua query SQL
+	case "info", "warn":
+		return logger.Warn
+	case "error":
+		return logger.Error
+	default:
+		return logger.Silent
+	}
+}
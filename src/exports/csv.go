@@ -0,0 +1,49 @@
+package exports
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// writeCSV - Meta di atas, baris kosong, header + data, lalu footer
+func writeCSV(w io.Writer, table Table) error {
+	writer := csv.NewWriter(w)
+
+	if table.Title != "" {
+		if err := writer.Write([]string{table.Title}); err != nil {
+			return err
+		}
+	}
+	for _, meta := range table.Meta {
+		if err := writer.Write([]string{meta[0], meta[1]}); err != nil {
+			return err
+		}
+	}
+	if table.Title != "" || len(table.Meta) > 0 {
+		if err := writer.Write([]string{}); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Write(table.Headers); err != nil {
+		return err
+	}
+	for _, row := range table.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = formatCell(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	for _, footer := range table.Footer {
+		if err := writer.Write([]string{footer[0], footer[1]}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,86 @@
+package exports
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/shopspring/decimal"
+)
+
+// writePDF - Layout landscape A4 siap cetak, header tabel diulang tiap halaman
+func writePDF(w io.Writer, table Table) error {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(true, 12)
+
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	usableWidth := pageWidth - left - right
+
+	colWidth := usableWidth
+	if len(table.Headers) > 0 {
+		colWidth = usableWidth / float64(len(table.Headers))
+	}
+
+	drawHeader := func() {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		for _, header := range table.Headers {
+			pdf.CellFormat(colWidth, 7, tr(header), "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+	}
+
+	pdf.SetHeaderFunc(func() {
+		if pdf.PageNo() > 1 {
+			drawHeader()
+		}
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 5, tr(table.Title), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, "Page "+strconv.Itoa(pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	pdf.AddPage()
+
+	if table.Title != "" {
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.CellFormat(0, 8, tr(table.Title), "", 1, "L", false, 0, "")
+	}
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, meta := range table.Meta {
+		pdf.CellFormat(45, 6, tr(meta[0]), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, tr(meta[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(3)
+
+	drawHeader()
+	for _, row := range table.Rows {
+		for _, value := range row {
+			align := "L"
+			switch value.(type) {
+			case int, int64, uint, float64, decimal.Decimal:
+				align = "R"
+			}
+			pdf.CellFormat(colWidth, 6, tr(formatCell(value)), "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	if len(table.Footer) > 0 {
+		pdf.Ln(3)
+		pdf.SetFont("Helvetica", "B", 10)
+		for _, footer := range table.Footer {
+			pdf.CellFormat(45, 6, tr(footer[0]), "", 0, "L", false, 0, "")
+			pdf.CellFormat(0, 6, tr(footer[1]), "", 1, "L", false, 0, "")
+		}
+	}
+
+	return pdf.Output(w)
+}
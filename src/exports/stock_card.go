@@ -0,0 +1,105 @@
+package exports
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"inventory-ledger/src/models"
+)
+
+// StockCardTable - Kartu stok: saldo awal, pergerakan masuk/keluar, saldo akhir
+func StockCardTable(card *models.StockCard) Table {
+	table := Table{
+		Title: "Kartu Stok " + card.ItemCode,
+		Meta: [][2]string{
+			{"Organization", fmt.Sprintf("%s - %s", card.OrganizationCode, card.OrganizationName)},
+			{"Item", fmt.Sprintf("%s - %s", card.ItemCode, card.ItemName)},
+			{"Unit", card.Unit},
+			{"Period", formatPeriod(card.FromDate, card.ToDate)},
+			{"Opening Balance", card.OpeningBalance.String()},
+		},
+		Headers: []string{"Date", "Type", "Reference", "Notes", "In", "Out", "Balance"},
+		Rows:    make([][]interface{}, 0, len(card.Lines)),
+		Footer: [][2]string{
+			{"Total In", card.TotalIn.String()},
+			{"Total Out", card.TotalOut.String()},
+			{"Closing Balance", card.ClosingBalance.String()},
+		},
+	}
+
+	for _, line := range card.Lines {
+		ref := ""
+		if line.RefID != nil {
+			ref = line.RefID.String()
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			line.TxnDate, line.Type, ref, line.Notes, line.In, line.Out, line.Balance,
+		})
+	}
+
+	return table
+}
+
+// OrganizationSummaryTable - Saldo semua item di satu organisasi (byLocation: tambah kolom lokasi,
+// baris tanpa lokasi = stok yang belum ditempatkan)
+func OrganizationSummaryTable(orgID string, summary []map[string]interface{}, byLocation bool) Table {
+	table := Table{
+		Title: "Stock Summary by Organization",
+		Meta: [][2]string{
+			{"Organization", orgID},
+			{"Generated At", time.Now().Format(time.RFC3339)},
+		},
+		Headers: []string{"Item Code", "Item Name", "Unit", "Current Stock", "Stock Value", "Average Cost", "Last Transaction"},
+		Rows:    make([][]interface{}, 0, len(summary)),
+	}
+	if byLocation {
+		table.Headers = append([]string{"Location Code"}, table.Headers...)
+	}
+
+	for _, row := range summary {
+		cells := []interface{}{
+			row["item_code"], row["item_name"], row["unit"], row["current_stock"],
+			row["stock_value"], row["average_cost"], row["last_transaction"],
+		}
+		if byLocation {
+			cells = append([]interface{}{row["location_code"]}, cells...)
+		}
+		table.Rows = append(table.Rows, cells)
+	}
+
+	return table
+}
+
+// ItemSummaryTable - Saldo satu item di semua organisasi
+func ItemSummaryTable(itemID uint, summary []map[string]interface{}) Table {
+	table := Table{
+		Title: "Stock Summary by Item",
+		Meta: [][2]string{
+			{"Item", strconv.FormatUint(uint64(itemID), 10)},
+			{"Generated At", time.Now().Format(time.RFC3339)},
+		},
+		Headers: []string{"Organization Code", "Organization Name", "Current Stock", "Stock Value", "Average Cost", "Last Transaction"},
+		Rows:    make([][]interface{}, 0, len(summary)),
+	}
+
+	for _, row := range summary {
+		table.Rows = append(table.Rows, []interface{}{
+			row["organization_code"], row["organization_name"], row["current_stock"],
+			row["stock_value"], row["average_cost"], row["last_transaction"],
+		})
+	}
+
+	return table
+}
+
+func formatPeriod(fromDate, toDate time.Time) string {
+	from, to := "beginning", "now"
+	if !fromDate.IsZero() {
+		from = fromDate.Format("2006-01-02")
+	}
+	if !toDate.IsZero() {
+		to = toDate.Format("2006-01-02")
+	}
+	return from + " s/d " + to
+}
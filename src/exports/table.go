@@ -0,0 +1,160 @@
+package exports
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============ FORMATS ============
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatPDF  = "pdf"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported export format, use csv, xlsx or pdf")
+	ErrNotAcceptable     = errors.New("none of the types in Accept can be served, use application/json, text/csv, application/vnd.openxmlformats-officedocument.spreadsheetml.sheet or application/pdf")
+)
+
+// contentTypes - Mapping format → MIME type (juga dipakai untuk negosiasi header Accept)
+var contentTypes = map[string]string{
+	FormatCSV:  "text/csv",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatPDF:  "application/pdf",
+}
+
+// acceptFormats - Kandidat negosiasi Accept, "" = JSON (response biasa, menang jika seri di wildcard)
+var acceptFormats = []struct {
+	format      string
+	contentType string
+}{
+	{"", "application/json"},
+	{FormatCSV, contentTypes[FormatCSV]},
+	{FormatXLSX, contentTypes[FormatXLSX]},
+	{FormatPDF, contentTypes[FormatPDF]},
+}
+
+// Table - Representasi tabel generik yang bisa ditulis ke CSV/XLSX/PDF
+type Table struct {
+	Title   string
+	Meta    [][2]string // pasangan label/nilai di atas tabel
+	Headers []string
+	Rows    [][]interface{}
+	Footer  [][2]string // pasangan label/nilai di bawah tabel (misal saldo akhir)
+}
+
+// IsSupported - Cek format export yang dikenal
+func IsSupported(format string) bool {
+	_, ok := contentTypes[format]
+	return ok
+}
+
+// ContentType - MIME type untuk format export
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// FormatFromAccept - Format dari header Accept dengan q-value (q=0 = ditolak). "" = JSON, juga untuk
+// header kosong dan */*; q sama → yang lebih dulu di header. ok=false jika tidak ada yang bisa dilayani.
+func FormatFromAccept(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return "", true
+	}
+
+	type mediaRange struct {
+		mime string
+		q    float64
+	}
+	ranges := make([]mediaRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		r := mediaRange{mime: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		if r.mime == "" {
+			continue
+		}
+		for _, param := range params[1:] {
+			key, value, _ := strings.Cut(param, "=")
+			if strings.EqualFold(strings.TrimSpace(key), "q") {
+				q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err != nil || q < 0 || q > 1 {
+					q = 0
+				}
+				r.q = q
+			}
+		}
+		ranges = append(ranges, r)
+	}
+
+	best, bestQ, bestIndex := "", 0.0, len(ranges)
+	for _, candidate := range acceptFormats {
+		// Range paling spesifik yang cocok menentukan q kandidat (text/csv > text/* > */*)
+		q, index, specificity := 0.0, len(ranges), 0
+		for i, r := range ranges {
+			if s := mediaRangeSpecificity(r.mime, candidate.contentType); s > specificity {
+				q, index, specificity = r.q, i, s
+			}
+		}
+		if q > bestQ || (q > 0 && q == bestQ && index < bestIndex) {
+			best, bestQ, bestIndex = candidate.format, q, index
+		}
+	}
+	if bestQ == 0 {
+		return "", false
+	}
+	return best, true
+}
+
+// mediaRangeSpecificity - 3 = sama persis, 2 = type/*, 1 = */*, 0 = tidak cocok
+func mediaRangeSpecificity(mediaRange, contentType string) int {
+	switch {
+	case mediaRange == contentType:
+		return 3
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(mediaRange, "*")):
+		return 2
+	case mediaRange == "*/*":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Write - Tulis tabel sesuai format
+func Write(w io.Writer, format string, table Table) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, table)
+	case FormatXLSX:
+		return writeXLSX(w, table)
+	case FormatPDF:
+		return writePDF(w, table)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// formatCell - Representasi string untuk CSV/PDF
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format("2006-01-02 15:04")
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,208 @@
+package exports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+
+	"inventory-ledger/src/models"
+)
+
+// ============ ACCEPT NEGOTIATION ============
+
+func TestFormatFromAccept(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"", "", true},
+		{"application/json", "", true},
+		{"*/*", "", true},
+		{"text/csv", FormatCSV, true},
+		{"TEXT/CSV; charset=utf-8", FormatCSV, true},
+		{"application/pdf, text/csv", FormatPDF, true},
+		// q-value: yang paling tinggi menang, bukan urutan di header
+		{"text/csv;q=0.5, application/pdf", FormatPDF, true},
+		{"application/pdf;q=0.2, " + contentTypes[FormatXLSX] + ";q=0.9", FormatXLSX, true},
+		// Tipe spesifik menang atas wildcard; wildcard saja = JSON
+		{"text/csv, */*;q=0.1", FormatCSV, true},
+		{"text/*", FormatCSV, true},
+		{"application/pdf;q=0.8, */*", "", true},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "", true},
+		// q=0 = ditolak, juga lewat wildcard
+		{"text/csv;q=0, */*", "", true},
+		{"application/json;q=0, application/pdf;q=0.3, */*;q=0.1", FormatPDF, true},
+		{"application/*;q=0, text/csv;q=0.2", FormatCSV, true},
+		// Tidak ada yang bisa dilayani → 406
+		{"image/png", "", false},
+		{"text/csv;q=0", "", false},
+		{"*/*;q=0", "", false},
+		{"text/csv;q=abc", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			got, ok := FormatFromAccept(tt.accept)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("FormatFromAccept(%q) = %q, %v; want %q, %v", tt.accept, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// ============ STOCK CARD ============
+
+func testStockCard() *models.StockCard {
+	refID := uuid.MustParse("7b0c5a52-4f1e-4a3a-9f0e-1c2d3e4f5a6b")
+	notes := "PO 12, gudang \"utama\""
+	return &models.StockCard{
+		OrganizationCode: "ORG001",
+		OrganizationName: "Gudang Pusat",
+		ItemCode:         "ITEM001",
+		ItemName:         "Beras",
+		Unit:             "kg",
+		FromDate:         time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		ToDate:           time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+		OpeningBalance:   decimal.NewFromInt(100),
+		TotalIn:          decimal.RequireFromString("25.5"),
+		TotalOut:         decimal.NewFromInt(40),
+		ClosingBalance:   decimal.RequireFromString("85.5"),
+		Lines: []models.StockCardLine{
+			{
+				TxnDate: time.Date(2025, 1, 5, 9, 30, 0, 0, time.UTC), Type: "penerimaan", RefID: &refID, Notes: &notes,
+				In: decimal.RequireFromString("25.5"), Out: decimal.Zero, Balance: decimal.RequireFromString("125.5"),
+			},
+			{
+				TxnDate: time.Date(2025, 1, 20, 14, 0, 0, 0, time.UTC), Type: "pemakaian",
+				In: decimal.Zero, Out: decimal.NewFromInt(40), Balance: decimal.RequireFromString("85.5"),
+			},
+		},
+	}
+}
+
+func TestStockCardCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, StockCardTable(testStockCard())); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+
+	reader := csv.NewReader(&buf)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("read csv back: %v", err)
+	}
+
+	want := [][]string{
+		{"Kartu Stok ITEM001"},
+		{"Organization", "ORG001 - Gudang Pusat"},
+		{"Item", "ITEM001 - Beras"},
+		{"Unit", "kg"},
+		{"Period", "2025-01-01 s/d 2025-01-31"},
+		{"Opening Balance", "100"},
+		{"Date", "Type", "Reference", "Notes", "In", "Out", "Balance"},
+		{"2025-01-05 09:30", "penerimaan", "7b0c5a52-4f1e-4a3a-9f0e-1c2d3e4f5a6b", "PO 12, gudang \"utama\"", "25.5", "0", "125.5"},
+		{"2025-01-20 14:00", "pemakaian", "", "", "0", "40", "85.5"},
+		{"Total In", "25.5"},
+		{"Total Out", "40"},
+		{"Closing Balance", "85.5"},
+	}
+	// encoding/csv melewati baris kosong pemisah meta dan header
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("unexpected csv:\n got %q\nwant %q", records, want)
+	}
+}
+
+func TestStockCardXLSXRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatXLSX, StockCardTable(testStockCard())); err != nil {
+		t.Fatalf("write xlsx: %v", err)
+	}
+
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("open xlsx: %v", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) != 1 || sheets[0] != "Kartu Stok ITEM001" {
+		t.Fatalf("unexpected sheets: %q", sheets)
+	}
+	rows, err := f.GetRows(sheets[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Judul + 5 meta + baris kosong + header + 2 baris + 3 footer
+	if len(rows) != 13 {
+		t.Fatalf("expected 13 rows, got %d: %q", len(rows), rows)
+	}
+	if rows[0][0] != "Kartu Stok ITEM001" || len(rows[6]) != 0 {
+		t.Errorf("unexpected title/spacer: %q %q", rows[0], rows[6])
+	}
+	if !reflect.DeepEqual(rows[7], []string{"Date", "Type", "Reference", "Notes", "In", "Out", "Balance"}) {
+		t.Errorf("unexpected header: %q", rows[7])
+	}
+	if !reflect.DeepEqual(rows[8], []string{"2025-01-05 09:30", "penerimaan", "7b0c5a52-4f1e-4a3a-9f0e-1c2d3e4f5a6b",
+		"PO 12, gudang \"utama\"", "25.5", "0", "125.5"}) {
+		t.Errorf("unexpected first line: %q", rows[8])
+	}
+	if !reflect.DeepEqual(rows[12], []string{"Closing Balance", "85.5"}) {
+		t.Errorf("unexpected footer: %q", rows[12])
+	}
+
+	// Tanggal tersimpan sebagai date cell, angka tetap numerik
+	dateType, err := f.GetCellType(sheets[0], "A10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := f.GetCellValue(sheets[0], "A10", excelize.Options{RawCellValue: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, err := excelize.ExcelDateToTime(mustFloat(t, raw), false); err != nil ||
+		!parsed.Equal(time.Date(2025, 1, 20, 14, 0, 0, 0, time.UTC)) {
+		t.Errorf("date cell A10 = %q (%v), parsed %v", raw, dateType, parsed)
+	}
+	balanceType, err := f.GetCellType(sheets[0], "G10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balanceType == excelize.CellTypeSharedString || balanceType == excelize.CellTypeInlineString {
+		t.Errorf("balance cell should be numeric, got type %v", balanceType)
+	}
+}
+
+func TestStockCardPDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatPDF, StockCardTable(testStockCard())); err != nil {
+		t.Fatalf("write pdf: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Errorf("expected a PDF document, got %q", buf.Bytes()[:min(buf.Len(), 16)])
+	}
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "docx", Table{}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func mustFloat(t *testing.T, value string) float64 {
+	t.Helper()
+	parsed, err := decimal.NewFromString(value)
+	if err != nil {
+		t.Fatalf("not a number: %q", value)
+	}
+	return parsed.InexactFloat64()
+}
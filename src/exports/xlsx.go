@@ -0,0 +1,142 @@
+package exports
+
+import (
+	"io"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+)
+
+// writeXLSX - Satu sheet, angka tetap numerik dan tanggal sebagai date cell
+func writeXLSX(w io.Writer, table Table) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Sheet1"
+	if table.Title != "" {
+		sheet = sheetName(table.Title)
+		if err := f.SetSheetName("Sheet1", sheet); err != nil {
+			return err
+		}
+	}
+
+	boldStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	dateStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: stringPtr("yyyy-mm-dd hh:mm")})
+	if err != nil {
+		return err
+	}
+
+	row := 1
+	if table.Title != "" {
+		if err := setRow(f, sheet, row, []interface{}{table.Title}); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(sheet, "A1", "A1", boldStyle); err != nil {
+			return err
+		}
+		row++
+	}
+	for _, meta := range table.Meta {
+		if err := setRow(f, sheet, row, []interface{}{meta[0], meta[1]}); err != nil {
+			return err
+		}
+		row++
+	}
+	if row > 1 {
+		row++
+	}
+
+	headers := make([]interface{}, len(table.Headers))
+	for i, header := range table.Headers {
+		headers[i] = header
+	}
+	if err := setRow(f, sheet, row, headers); err != nil {
+		return err
+	}
+	if len(headers) > 0 {
+		start, _ := excelize.CoordinatesToCellName(1, row)
+		end, _ := excelize.CoordinatesToCellName(len(headers), row)
+		if err := f.SetCellStyle(sheet, start, end, boldStyle); err != nil {
+			return err
+		}
+	}
+	row++
+
+	for _, values := range table.Rows {
+		cells := make([]interface{}, len(values))
+		for i, value := range values {
+			cells[i] = xlsxValue(value)
+		}
+		if err := setRow(f, sheet, row, cells); err != nil {
+			return err
+		}
+		for i, value := range cells {
+			if _, ok := value.(time.Time); ok {
+				cell, _ := excelize.CoordinatesToCellName(i+1, row)
+				if err := f.SetCellStyle(sheet, cell, cell, dateStyle); err != nil {
+					return err
+				}
+			}
+		}
+		row++
+	}
+
+	for _, footer := range table.Footer {
+		if err := setRow(f, sheet, row, []interface{}{footer[0], footer[1]}); err != nil {
+			return err
+		}
+		row++
+	}
+
+	_, err = f.WriteTo(w)
+	return err
+}
+
+func setRow(f *excelize.File, sheet string, row int, values []interface{}) error {
+	cell, err := excelize.CoordinatesToCellName(1, row)
+	if err != nil {
+		return err
+	}
+	return f.SetSheetRow(sheet, cell, &values)
+}
+
+// xlsxValue - Biarkan angka/tanggal native, sisanya string
+func xlsxValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int, int64, uint, float64:
+		return v
+	case decimal.Decimal:
+		return v.InexactFloat64()
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v
+	default:
+		return formatCell(v)
+	}
+}
+
+// sheetName - Nama sheet Excel maksimal 31 karakter tanpa karakter terlarang
+func sheetName(title string) string {
+	invalid := map[rune]bool{':': true, '\\': true, '/': true, '?': true, '*': true, '[': true, ']': true}
+	name := make([]rune, 0, 31)
+	for _, r := range title {
+		if invalid[r] {
+			r = '-'
+		}
+		name = append(name, r)
+		if len(name) == 31 {
+			break
+		}
+	}
+	return string(name)
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
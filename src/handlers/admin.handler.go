@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/services"
+)
+
+type AdminHandler struct {
+	Chain     *services.ChainService
+	Integrity *services.IntegrityService
+}
+
+// adminScope - Parse query organization_id & item_id (opsional, kosong = semua)
+func adminScope(c *gin.Context) (uuid.UUID, uint, bool) {
+	var orgID uuid.UUID
+	if orgIDStr := c.Query("organization_id"); orgIDStr != "" {
+		var err error
+		orgID, err = uuid.Parse(orgIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization_id"})
+			return orgID, 0, false
+		}
+	}
+
+	var itemID uint
+	if itemIDStr := c.Query("item_id"); itemIDStr != "" {
+		parsed, err := strconv.ParseUint(itemIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item_id"})
+			return orgID, 0, false
+		}
+		itemID = uint(parsed)
+	}
+
+	return orgID, itemID, true
+}
+
+// VerifyLedgerChain - Telusuri hash chain ledger (opsional organization_id & item_id)
+func (h *AdminHandler) VerifyLedgerChain(c *gin.Context) {
+	orgID, itemID, ok := adminScope(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.Chain.Verify(orgID, itemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "Ledger hash chain is intact"
+	if !result.Valid {
+		message = "Ledger hash chain is broken"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    result,
+	})
+}
+
+// CheckIntegrity - Scan saldo, opname, leg mutasi, stok_awal ganda & saldo negatif (read-only,
+// repair hanya lewat CLI: go run . integrity -repair)
+func (h *AdminHandler) CheckIntegrity(c *gin.Context) {
+	orgID, itemID, ok := adminScope(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.Integrity.Check(orgID, itemID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "No integrity issues found"
+	if len(report.Issues) > 0 {
+		message = "Integrity issues found"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    report,
+	})
+}
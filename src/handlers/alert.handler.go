@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+	"inventory-ledger/src/requests"
+	"inventory-ledger/src/services"
+)
+
+type AlertHandler struct {
+	Service *services.AlertService
+}
+
+// alertErrorStatus - 404 alert/level/org/item tidak ada, 409 acknowledge alert yang bukan open, sisanya 400
+func alertErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrAlertNotFound), errors.Is(err, services.ErrStockLevelNotFound),
+		errors.Is(err, services.ErrOrganizationNotFound), errors.Is(err, services.ErrItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrAlertNotOpen):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// stockLevelParams - Parse :id (organisasi) dan :item_id (opsional)
+func stockLevelParams(c *gin.Context, withItem bool) (uuid.UUID, uint, bool) {
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		return uuid.Nil, 0, false
+	}
+	if !withItem {
+		return orgID, 0, true
+	}
+
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		return orgID, 0, false
+	}
+	return orgID, uint(itemID), true
+}
+
+// ListAlerts - List alert (query: organization_id, item_id, state, type, page, limit)
+func (h *AlertHandler) ListAlerts(c *gin.Context) {
+	orgID, itemID, ok := adminScope(c)
+	if !ok {
+		return
+	}
+
+	state := models.StockAlertState(c.Query("state"))
+	switch state {
+	case "", models.StockAlertOpen, models.StockAlertAcknowledged, models.StockAlertResolved:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid state, use open, acknowledged or resolved"})
+		return
+	}
+	alertType := models.StockAlertType(c.Query("type"))
+	switch alertType {
+	case "", models.StockAlertBelowMin, models.StockAlertReorder, models.StockAlertAboveMax:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid type, use below_min, reorder or above_max"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	alerts, total, err := h.Service.ListAlerts(services.AlertFilter{
+		OrganizationID: orgID,
+		ItemID:         itemID,
+		State:          state,
+		AlertType:      alertType,
+		Page:           page,
+		Limit:          limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": alerts,
+		"meta": listMeta(repositories.MasterDataFilter{Page: page, Limit: limit}, total),
+	})
+}
+
+// GetAlert - Detail alert
+func (h *AlertHandler) GetAlert(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid alert id"})
+		return
+	}
+
+	alert, err := h.Service.GetAlert(id)
+	if err != nil {
+		c.JSON(alertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": alert})
+}
+
+// AcknowledgeAlert - Tandai alert open sudah ditangani (resolve tetap otomatis dari saldo)
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid alert id"})
+		return
+	}
+
+	var req requests.AcknowledgeAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alert, err := h.Service.AcknowledgeAlert(services.AcknowledgeAlertRequest{
+		AlertID:        id,
+		AcknowledgedBy: req.AcknowledgedBy,
+		Note:           req.Note,
+	})
+	if err != nil {
+		c.JSON(alertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alert acknowledged successfully",
+		"data":    alert,
+	})
+}
+
+// ListStockLevels - Level stok semua item di organisasi
+func (h *AlertHandler) ListStockLevels(c *gin.Context) {
+	orgID, _, ok := stockLevelParams(c, false)
+	if !ok {
+		return
+	}
+
+	levels, err := h.Service.ListStockLevels(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": levels})
+}
+
+// SetStockLevel - Set level min/max/reorder org+item, langsung dievaluasi terhadap saldo sekarang
+func (h *AlertHandler) SetStockLevel(c *gin.Context) {
+	orgID, itemID, ok := stockLevelParams(c, true)
+	if !ok {
+		return
+	}
+
+	var req requests.StockLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	level, err := h.Service.SetStockLevel(services.StockLevelRequest{
+		OrganizationID: orgID,
+		ItemID:         itemID,
+		MinQty:         req.MinQty,
+		MaxQty:         req.MaxQty,
+		ReorderPoint:   req.ReorderPoint,
+		UpdatedBy:      req.UpdatedBy,
+	})
+	if err != nil {
+		c.JSON(alertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Stock level saved successfully",
+		"data":    level,
+	})
+}
+
+// DeleteStockLevel - Berhenti memantau org+item; alert aktif ikut resolved
+func (h *AlertHandler) DeleteStockLevel(c *gin.Context) {
+	orgID, itemID, ok := stockLevelParams(c, true)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteStockLevel(orgID, itemID); err != nil {
+		c.JSON(alertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Stock level deleted successfully"})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"inventory-ledger/src/services"
+)
+
+type ImportHandler struct {
+	Service *services.ImportService
+}
+
+// ImportTransactions - Upload CSV/XLSX stok_awal & penerimaan (multipart field "file")
+func (h *ImportHandler) ImportTransactions(c *gin.Context) {
+	changedBy := c.PostForm("changed_by")
+	if changedBy == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "changed_by is required"})
+		return
+	}
+
+	var reason *string
+	if r := c.PostForm("reason"); r != "" {
+		reason = &r
+	}
+
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", c.DefaultPostForm("dry_run", "false")))
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	rows, err := services.ParseImportFile(fileHeader.Filename, file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.Service.Import(rows, changedBy, reason, dryRun)
+	if err != nil {
+		if report == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		status := http.StatusBadRequest
+		if !errors.Is(err, services.ErrImportInvalid) {
+			// Gagal saat posting batch: error database / cascade tetap 5xx supaya bisa di-retry
+			status = batchErrorStatus(c, err)
+		}
+		c.JSON(status, gin.H{
+			"error":  err.Error(),
+			"report": report,
+		})
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Import validated successfully (dry run)",
+			"report":  report,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Import posted successfully",
+		"report":  report,
+	})
+}
@@ -1,14 +1,21 @@
 package handlers
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"math"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 
+	"inventory-ledger/src/exports"
+	"inventory-ledger/src/repositories"
 	"inventory-ledger/src/requests"
 	"inventory-ledger/src/services"
 )
@@ -17,6 +24,74 @@ type InventoryHandler struct {
 	Service *services.InventoryService
 }
 
+// postingErrorStatus - Status HTTP error posting. Cascade revaluation / rollback yang terblokir posting
+// lain → 503 + Retry-After (aman di-retry, key idempotency dilepas); selain itu fallback.
+func postingErrorStatus(c *gin.Context, err error, fallback int) int {
+	if errors.Is(err, services.ErrRevaluationBusy) || errors.Is(err, services.ErrRollbackBusy) {
+		c.Header("Retry-After", "1")
+		return http.StatusServiceUnavailable
+	}
+	return fallback
+}
+
+// transactionErrorStatus - Status HTTP error posting tunggal. Hanya error validasi / domain yang 4xx;
+// error lain (database, koneksi, lock) → 5xx supaya key idempotency dilepas dan request bisa di-retry.
+func transactionErrorStatus(c *gin.Context, err error) int {
+	switch {
+	case errors.Is(err, services.ErrInventoryNotFound), errors.Is(err, services.ErrOrganizationNotFound),
+		errors.Is(err, services.ErrItemNotFound), errors.Is(err, services.ErrLocationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPeriodClosed), errors.Is(err, services.ErrStokAwalExists):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrNegativeStock), errors.Is(err, services.ErrInsufficientSourceStock),
+		errors.Is(err, services.ErrInsufficientLotStock), errors.Is(err, services.ErrInsufficientLocationStock),
+		errors.Is(err, services.ErrSerialNotInStock), errors.Is(err, services.ErrSerialAlreadyInStock):
+		return http.StatusUnprocessableEntity
+	case isPostingValidationError(err):
+		return http.StatusBadRequest
+	default:
+		return postingErrorStatus(c, err, http.StatusInternalServerError)
+	}
+}
+
+// postingValidationErrors - Error validasi request posting (amount, satuan, harga, lot, seri, lokasi)
+var postingValidationErrors = []error{
+	services.ErrZeroAmount, services.ErrPemakaianAmountSign, services.ErrPenerimaanAmountSign,
+	services.ErrInvalidTransactionType, services.ErrNegativePhysicalQuantity, services.ErrInvalidQuantity,
+	services.ErrInvalidMutationQuantity, services.ErrMutationDirectionChanged,
+	services.ErrOrganizationInactive, services.ErrItemInactive, services.ErrLocationInactive,
+	services.ErrInvalidLocationMove, services.ErrInvalidUnit, services.ErrUnitNotAllowed,
+	services.ErrInvalidUnitCost, services.ErrUnitCostNotAllowed,
+	services.ErrLotNotTracked, services.ErrLotRequired, services.ErrLotExpiryMismatch,
+	services.ErrInvalidLotAllocation, services.ErrLotOpnameNotSupported,
+	services.ErrSerialNotTracked, services.ErrSerialCountMismatch, services.ErrDuplicateSerial,
+	services.ErrSerialLotConflict,
+}
+
+// isPostingValidationError - Cek err terhadap postingValidationErrors
+func isPostingValidationError(err error) bool {
+	for _, target := range postingValidationErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// batchErrorStatus - Status HTTP error batch posting: error khusus batch, sisanya seperti
+// transactionErrorStatus (error tak terduga → 5xx supaya batch bisa di-retry)
+func batchErrorStatus(c *gin.Context, err error) int {
+	switch {
+	case errors.Is(err, services.ErrBatchValidation), errors.Is(err, services.ErrBatchEmpty),
+		errors.Is(err, services.ErrBatchTooLarge):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrBatchRejected):
+		return http.StatusUnprocessableEntity
+	default:
+		return transactionErrorStatus(c, err)
+	}
+}
+
 // ============ GET ENDPOINTS ============
 
 // GetCurrentBalance - Get current balance
@@ -47,6 +122,33 @@ func (h *InventoryHandler) GetCurrentBalance(c *gin.Context) {
 	})
 }
 
+// GetCostLayers - Layer FIFO yang masih terbuka (item average: kosong)
+func (h *InventoryHandler) GetCostLayers(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Query("organization_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization_id"})
+		return
+	}
+
+	itemID, err := strconv.Atoi(c.Query("item_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item_id"})
+		return
+	}
+
+	layers, err := h.Service.GetCostLayers(orgID, uint(itemID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"organization_id": orgID,
+		"item_id":         itemID,
+		"layers":          layers,
+	})
+}
+
 // GetBalanceAt - Get historical balance
 func (h *InventoryHandler) GetBalanceAt(c *gin.Context) {
 	orgID, err := uuid.Parse(c.Query("organization_id"))
@@ -113,6 +215,22 @@ func (h *InventoryHandler) GetTransactions(c *gin.Context) {
 		toDate = time.Date(toDate.Year(), toDate.Month(), toDate.Day(), 23, 59, 59, 0, toDate.Location())
 	}
 
+	// Export = kartu stok lengkap untuk rentang tanggal, tanpa pagination
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+	if format != "" {
+		card, err := h.Service.GetStockCard(orgID, uint(itemID), fromDate, toDate)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		filename := fmt.Sprintf("stock-card-%s-%s", card.OrganizationCode, card.ItemCode)
+		writeExport(c, format, filename, exports.StockCardTable(card))
+		return
+	}
+
 	transactions, total, err := h.Service.GetTransactions(
 		orgID, uint(itemID), fromDate, toDate, page, limit,
 	)
@@ -135,7 +253,8 @@ func (h *InventoryHandler) GetTransactions(c *gin.Context) {
 	})
 }
 
-// GetOrganizationSummary - Get org summary
+// GetOrganizationSummary - Get org summary (query opsional: location_id = roll-up subtree lokasi,
+// by_location=true = satu baris per item+lokasi)
 func (h *InventoryHandler) GetOrganizationSummary(c *gin.Context) {
 	orgID, err := uuid.Parse(c.Query("organization_id"))
 	if err != nil {
@@ -143,14 +262,47 @@ func (h *InventoryHandler) GetOrganizationSummary(c *gin.Context) {
 		return
 	}
 
-	summary, err := h.Service.GetOrganizationSummary(orgID)
+	var scope repositories.LocationScope
+	if locationStr := c.Query("location_id"); locationStr != "" {
+		locationID, err := uuid.Parse(locationStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid location_id"})
+			return
+		}
+		scope.LocationID = &locationID
+	}
+	if byLocation := c.Query("by_location"); byLocation != "" {
+		if scope.DrillDown, err = strconv.ParseBool(byLocation); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid by_location, use true or false"})
+			return
+		}
+	}
+
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.Service.GetOrganizationSummary(orgID, scope)
+	if errors.Is(err, services.ErrLocationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if format != "" {
+		writeExport(c, format, "summary-org-"+orgID.String(),
+			exports.OrganizationSummaryTable(orgID.String(), summary, scope.LocationID != nil || scope.DrillDown))
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"organization_id": orgID,
+		"location_id":     scope.LocationID,
+		"by_location":     scope.DrillDown,
 		"summary":         summary,
 		"generated_at":    time.Now().Format(time.RFC3339),
 	})
@@ -164,12 +316,22 @@ func (h *InventoryHandler) GetItemSummary(c *gin.Context) {
 		return
 	}
 
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
 	summary, err := h.Service.GetItemSummary(uint(itemID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if format != "" {
+		writeExport(c, format, fmt.Sprintf("summary-item-%d", itemID), exports.ItemSummaryTable(uint(itemID), summary))
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"item_id":      itemID,
 		"summary":      summary,
@@ -194,12 +356,20 @@ func (h *InventoryHandler) CreateTransaction(c *gin.Context) {
 		}
 	}
 
+	expiryDate, err := parseExpiryDate(req.ExpiryDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	serviceReq := services.CreateTransactionRequest{
 		OrganizationID: req.OrganizationID,
 		ItemID:         req.ItemID,
 		TxnDate:        txnDate,
 		Amount:         req.Amount,
+		Unit:           req.Unit,
 		Type:           req.Type,
+		UnitCost:       req.UnitCost,
 		ChangedBy:      req.ChangedBy,
 		Reason:         req.Reason,
 		RefID:          req.RefID,
@@ -207,11 +377,16 @@ func (h *InventoryHandler) CreateTransaction(c *gin.Context) {
 		Source:         req.Source,
 		PageCode:       req.PageCode,
 		Notes:          req.Notes,
+		LotNumber:      req.LotNumber,
+		ExpiryDate:     expiryDate,
+		Lots:           lotQuantities(req.Lots),
+		SerialNumbers:  req.SerialNumbers,
+		LocationID:     req.LocationID,
 	}
 
 	inventory, err := h.Service.CreateTransaction(serviceReq)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(transactionErrorStatus(c, err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -221,17 +396,114 @@ func (h *InventoryHandler) CreateTransaction(c *gin.Context) {
 	})
 }
 
+// CreateTransactionBatch - Posting banyak transaksi sekaligus (all-or-nothing)
+func (h *InventoryHandler) CreateTransactionBatch(c *gin.Context) {
+	var req requests.BatchTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validasi semua baris dulu, laporkan error per baris
+	results := make([]services.BatchLineResult, len(req.Lines))
+	serviceReqs := make([]services.CreateTransactionRequest, len(req.Lines))
+	valid := true
+
+	for i, line := range req.Lines {
+		results[i].Line = i + 1
+		if line.ChangedBy == "" {
+			line.ChangedBy = req.ChangedBy
+		}
+		if err := binding.Validator.ValidateStruct(line); err != nil {
+			results[i].Error = err.Error()
+			valid = false
+			continue
+		}
+
+		txnDate, err := time.Parse(time.RFC3339, line.TxnDate)
+		if err != nil {
+			txnDate, err = time.Parse("2006-01-02T15:04:05", line.TxnDate)
+			if err != nil {
+				results[i].Error = "invalid txn_date format. Use RFC3339 or YYYY-MM-DDTHH:MM:SS"
+				valid = false
+				continue
+			}
+		}
+
+		expiryDate, err := parseExpiryDate(line.ExpiryDate)
+		if err != nil {
+			results[i].Error = err.Error()
+			valid = false
+			continue
+		}
+
+		serviceReqs[i] = services.CreateTransactionRequest{
+			OrganizationID: line.OrganizationID,
+			ItemID:         line.ItemID,
+			TxnDate:        txnDate,
+			Amount:         line.Amount,
+			Unit:           line.Unit,
+			Type:           line.Type,
+			UnitCost:       line.UnitCost,
+			ChangedBy:      line.ChangedBy,
+			Reason:         line.Reason,
+			RefID:          line.RefID,
+			TargetID:       line.TargetID,
+			Source:         line.Source,
+			PageCode:       line.PageCode,
+			Notes:          line.Notes,
+			LotNumber:      line.LotNumber,
+			ExpiryDate:     expiryDate,
+			Lots:           lotQuantities(line.Lots),
+			SerialNumbers:  line.SerialNumbers,
+			LocationID:     line.LocationID,
+		}
+	}
+
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   services.ErrBatchValidation.Error(),
+			"results": results,
+		})
+		return
+	}
+
+	results, err := h.Service.CreateTransactionBatch(serviceReqs, req.ChangedBy, req.Reason)
+	if err != nil {
+		c.JSON(batchErrorStatus(c, err), gin.H{
+			"error":   err.Error(),
+			"results": results,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Batch posted successfully",
+		"data":    results,
+	})
+}
+
 // ============ MUTATION ============
 type MutationRequest struct {
-	FromOrganizationID uuid.UUID  `json:"from_organization_id" binding:"required"`
-	ToOrganizationID   uuid.UUID  `json:"to_organization_id" binding:"required"`
-	ItemID             uint       `json:"item_id" binding:"required"`
-	Quantity           int        `json:"quantity" binding:"required,min=1"`
-	TxnDate            string     `json:"txn_date" binding:"required"`
-	ChangedBy          string     `json:"changed_by" binding:"required"`
-	Reason             *string    `json:"reason,omitempty"`
-	RefID              *uuid.UUID `json:"ref_id,omitempty"`
-	Notes              *string    `json:"notes,omitempty"`
+	FromOrganizationID uuid.UUID       `json:"from_organization_id" binding:"required"`
+	ToOrganizationID   uuid.UUID       `json:"to_organization_id" binding:"required"`
+	ItemID             uint            `json:"item_id" binding:"required"`
+	Quantity           decimal.Decimal `json:"quantity"`
+	Unit               string          `json:"unit,omitempty" binding:"omitempty,max=20"`
+	TxnDate            string          `json:"txn_date" binding:"required"`
+	ChangedBy          string          `json:"changed_by" binding:"required"`
+	Reason             *string         `json:"reason,omitempty"`
+	RefID              *uuid.UUID      `json:"ref_id,omitempty"`
+	Notes              *string         `json:"notes,omitempty"`
+
+	// Item ber-lot: lot yang dipindahkan (kosong = FEFO)
+	Lots []requests.LotQuantityRequest `json:"lots,omitempty" binding:"omitempty,dive"`
+	// Item serialized: nomor seri unit yang dipindahkan (wajib, satu per unit)
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+
+	// Lokasi di org asal / tujuan (kosong = belum ditempatkan)
+	FromLocationID *uuid.UUID `json:"from_location_id,omitempty"`
+	ToLocationID   *uuid.UUID `json:"to_location_id,omitempty"`
 }
 
 // CreateMutation - Create stock mutation
@@ -255,16 +527,21 @@ func (h *InventoryHandler) CreateMutation(c *gin.Context) {
 		ToOrganizationID:   req.ToOrganizationID,
 		ItemID:             req.ItemID,
 		Quantity:           req.Quantity,
+		Unit:               req.Unit,
 		TxnDate:            txnDate,
 		ChangedBy:          req.ChangedBy,
 		Reason:             req.Reason,
 		RefID:              req.RefID,
 		Notes:              req.Notes,
+		Lots:               lotQuantities(req.Lots),
+		SerialNumbers:      req.SerialNumbers,
+		FromLocationID:     req.FromLocationID,
+		ToLocationID:       req.ToLocationID,
 	}
 
 	err = h.Service.CreateMutation(serviceReq)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(transactionErrorStatus(c, err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -275,14 +552,21 @@ func (h *InventoryHandler) CreateMutation(c *gin.Context) {
 
 // ============ OPNAME ============
 type OpnameRequest struct {
-	OrganizationID uuid.UUID  `json:"organization_id" binding:"required"`
-	ItemID         uint       `json:"item_id" binding:"required"`
-	PhysicalQty    int        `json:"physical_qty" binding:"required"`
-	TxnDate        string     `json:"txn_date" binding:"required"`
-	ChangedBy      string     `json:"changed_by" binding:"required"`
-	Reason         *string    `json:"reason,omitempty"`
-	RefID          *uuid.UUID `json:"ref_id,omitempty"`
-	Notes          *string    `json:"notes,omitempty"`
+	OrganizationID uuid.UUID       `json:"organization_id" binding:"required"`
+	ItemID         uint            `json:"item_id" binding:"required"`
+	PhysicalQty    decimal.Decimal `json:"physical_qty"`
+	Unit           string          `json:"unit,omitempty" binding:"omitempty,max=20"`
+	TxnDate        string          `json:"txn_date" binding:"required"`
+	ChangedBy      string          `json:"changed_by" binding:"required"`
+	Reason         *string         `json:"reason,omitempty"`
+	RefID          *uuid.UUID      `json:"ref_id,omitempty"`
+	Notes          *string         `json:"notes,omitempty"`
+
+	// Item serialized: nomor seri yang dihitung (jumlah = physical_qty)
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+
+	// Lokasi yang menampung selisih opname (kosong = belum ditempatkan)
+	LocationID *uuid.UUID `json:"location_id,omitempty"`
 }
 
 // CreateOpname - Create stock opname
@@ -306,16 +590,19 @@ func (h *InventoryHandler) CreateOpname(c *gin.Context) {
 		OrganizationID: req.OrganizationID,
 		ItemID:         req.ItemID,
 		PhysicalQty:    req.PhysicalQty,
+		Unit:           req.Unit,
 		TxnDate:        txnDate,
 		ChangedBy:      req.ChangedBy,
 		Reason:         req.Reason,
 		RefID:          req.RefID,
 		Notes:          req.Notes,
+		SerialNumbers:  req.SerialNumbers,
+		LocationID:     req.LocationID,
 	}
 
 	inventory, err := h.Service.CreateOpname(serviceReq)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(transactionErrorStatus(c, err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -327,13 +614,18 @@ func (h *InventoryHandler) CreateOpname(c *gin.Context) {
 
 // ============ UPDATE ============
 type UpdateTransactionRequest struct {
-	InventoryID uuid.UUID  `json:"inventory_id" binding:"required"`
-	TxnDate     string     `json:"txn_date" binding:"required"`
-	Amount      int        `json:"amount" binding:"required"`
-	ChangedBy   string     `json:"changed_by" binding:"required"`
-	Reason      *string    `json:"reason,omitempty"`
-	TargetID    *uuid.UUID `json:"target_id,omitempty"`
-	Notes       *string    `json:"notes,omitempty"`
+	InventoryID uuid.UUID        `json:"inventory_id" binding:"required"`
+	TxnDate     string           `json:"txn_date" binding:"required"`
+	Amount      decimal.Decimal  `json:"amount"`
+	Unit        string           `json:"unit,omitempty" binding:"omitempty,max=20"`
+	UnitCost    *decimal.Decimal `json:"unit_cost,omitempty"`
+	ChangedBy   string           `json:"changed_by" binding:"required"`
+	Reason      *string          `json:"reason,omitempty"`
+	TargetID    *uuid.UUID       `json:"target_id,omitempty"`
+	Notes       *string          `json:"notes,omitempty"`
+
+	// Item serialized: nomor seri baris pengganti (kosong = nomor seri lama)
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
 }
 
 func (h *InventoryHandler) UpdateTransaction(c *gin.Context) {
@@ -356,15 +648,19 @@ func (h *InventoryHandler) UpdateTransaction(c *gin.Context) {
 		InventoryID: req.InventoryID,
 		TxnDate:     txnDate,
 		Amount:      req.Amount,
+		Unit:        req.Unit,
+		UnitCost:    req.UnitCost,
 		ChangedBy:   req.ChangedBy,
 		Reason:      req.Reason,
 		TargetID:    req.TargetID,
 		Notes:       req.Notes,
+
+		SerialNumbers: req.SerialNumbers,
 	}
 
 	err = h.Service.UpdateTransaction(serviceReq)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(transactionErrorStatus(c, err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -394,7 +690,7 @@ func (h *InventoryHandler) DeleteTransaction(c *gin.Context) {
 
 	err = h.Service.DeleteTransaction(inventoryID, req.DeletedBy, req.Reason)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(transactionErrorStatus(c, err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -413,7 +709,7 @@ func (h *InventoryHandler) RollbackTransaction(c *gin.Context) {
 
 	err := h.Service.RollbackTransaction(req.HistoryID, req.ChangedBy, req.Reason)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(postingErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -423,6 +719,31 @@ func (h *InventoryHandler) RollbackTransaction(c *gin.Context) {
 	})
 }
 
+// PreviewRollback - Lihat efek rollback tanpa menyimpan apa pun
+func (h *InventoryHandler) PreviewRollback(c *gin.Context) {
+	var req requests.RollbackPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changedBy := req.ChangedBy
+	if changedBy == "" {
+		changedBy = "preview"
+	}
+
+	preview, err := h.Service.PreviewRollback(req.HistoryID, changedBy)
+	if err != nil {
+		c.JSON(postingErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Rollback preview, nothing was changed",
+		"data":    preview,
+	})
+}
+
 // GetHistory - Get inventory history for audit trail
 func (h *InventoryHandler) GetHistory(c *gin.Context) {
 	orgIDStr := c.Query("organization_id")
@@ -471,3 +792,63 @@ func (h *InventoryHandler) GetHistory(c *gin.Context) {
 		},
 	})
 }
+
+// ============ EXPORT HELPERS ============
+
+// exportFormat - Format export dari query format= (mengalahkan Accept) atau header Accept.
+// Return "" untuk response JSON biasa; ok=false jika format tidak dikenal / Accept tidak bisa
+// dilayani (response 400 / 406 sudah dikirim).
+func exportFormat(c *gin.Context) (string, bool) {
+	switch format := c.Query("format"); format {
+	case "":
+		format, ok := exports.FormatFromAccept(c.GetHeader("Accept"))
+		if !ok {
+			c.JSON(http.StatusNotAcceptable, gin.H{"error": exports.ErrNotAcceptable.Error()})
+		}
+		return format, ok
+	case "json":
+		return "", true
+	default:
+		if !exports.IsSupported(format) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": exports.ErrUnsupportedFormat.Error()})
+			return "", false
+		}
+		return format, true
+	}
+}
+
+// writeExport - Render tabel ke buffer dulu supaya error tidak menghasilkan file setengah jadi
+func writeExport(c *gin.Context, format, filename string, table exports.Table) {
+	var buf bytes.Buffer
+	if err := exports.Write(&buf, format, table); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+"."+format))
+	c.Data(http.StatusOK, exports.ContentType(format), buf.Bytes())
+}
+
+// GetHistoryDiff - Diff terstruktur antara snapshot sebelum & sesudah satu perubahan
+func (h *InventoryHandler) GetHistoryDiff(c *gin.Context) {
+	historyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid history id"})
+		return
+	}
+
+	diff, err := h.Service.GetHistoryDiff(historyID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrHistoryNotFound), errors.Is(err, services.ErrHistoryPairNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrHistoryNotDiffable):
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": diff})
+}
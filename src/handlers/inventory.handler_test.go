@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"inventory-ledger/src/exports"
+	"inventory-ledger/src/services"
+)
+
+// ============ EXPORT FORMAT ============
+
+func TestExportFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		query  string
+		accept string
+		want   string
+		status int // 0 = lanjut ke handler (ok=true)
+	}{
+		{name: "default json", want: ""},
+		{name: "accept csv", accept: "text/csv", want: exports.FormatCSV},
+		{name: "accept q-values", accept: "text/csv;q=0.4, application/pdf;q=0.9", want: exports.FormatPDF},
+		{name: "accept wildcard", accept: "*/*", want: ""},
+		// format= mengalahkan Accept, termasuk Accept yang tidak bisa dilayani
+		{name: "query overrides accept", query: "xlsx", accept: "text/csv", want: exports.FormatXLSX},
+		{name: "query json overrides accept", query: "json", accept: "application/pdf", want: ""},
+		{name: "query rescues unacceptable accept", query: "pdf", accept: "image/png", want: exports.FormatPDF},
+		{name: "unacceptable accept", accept: "image/png", status: http.StatusNotAcceptable},
+		{name: "everything refused", accept: "*/*;q=0", status: http.StatusNotAcceptable},
+		{name: "unknown query format", query: "docx", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			target := "/summary/org"
+			if tt.query != "" {
+				target += "?format=" + tt.query
+			}
+			c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+			if tt.accept != "" {
+				c.Request.Header.Set("Accept", tt.accept)
+			}
+
+			format, ok := exportFormat(c)
+			if tt.status != 0 {
+				if ok || w.Code != tt.status {
+					t.Errorf("expected %d and ok=false, got %d ok=%v", tt.status, w.Code, ok)
+				}
+				return
+			}
+			if !ok || format != tt.want {
+				t.Errorf("expected %q, got %q ok=%v", tt.want, format, ok)
+			}
+			if c.Writer.Written() {
+				t.Error("response should not be written when the handler continues")
+			}
+		})
+	}
+}
+
+// ============ POSTING ERROR STATUS ============
+
+func TestTransactionErrorStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"validation", services.ErrZeroAmount, http.StatusBadRequest},
+		{"unit", services.ErrUnitNotAllowed, http.StatusBadRequest},
+		{"wrapped serial count", fmt.Errorf("%w: keluar on 2025-01-01", services.ErrSerialCountMismatch), http.StatusBadRequest},
+		{"inventory not found", services.ErrInventoryNotFound, http.StatusNotFound},
+		{"location not found", services.ErrLocationNotFound, http.StatusNotFound},
+		{"period closed", &services.PeriodClosedError{}, http.StatusConflict},
+		{"stok awal exists", services.ErrStokAwalExists, http.StatusConflict},
+		{"negative stock", &services.NegativeStockError{}, http.StatusUnprocessableEntity},
+		{"source stock", services.ErrInsufficientSourceStock, http.StatusUnprocessableEntity},
+		{"lot stock", fmt.Errorf("%w LOT-1", services.ErrInsufficientLotStock), http.StatusUnprocessableEntity},
+		// Error database / lock tidak boleh 4xx: key idempotency harus dilepas
+		{"database", errors.New("pq: connection reset by peer"), http.StatusInternalServerError},
+		{"lock timeout", errors.New("canceling statement due to lock timeout"), http.StatusInternalServerError},
+		{"revaluation busy", services.ErrRevaluationBusy, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			if got := transactionErrorStatus(c, tt.err); got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/requests"
+	"inventory-ledger/src/services"
+)
+
+type ItemHandler struct {
+	Service *services.ItemService
+}
+
+// ListItems - List item (query: q, active, page, limit)
+func (h *ItemHandler) ListItems(c *gin.Context) {
+	filter, ok := masterDataFilter(c)
+	if !ok {
+		return
+	}
+
+	items, total, err := h.Service.ListItems(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": items,
+		"meta": listMeta(filter, total),
+	})
+}
+
+// GetItem - Detail item
+func (h *ItemHandler) GetItem(c *gin.Context) {
+	id, ok := itemIDParam(c)
+	if !ok {
+		return
+	}
+
+	item, err := h.Service.GetItem(id)
+	if err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": item})
+}
+
+// CreateItem - Buat item baru
+func (h *ItemHandler) CreateItem(c *gin.Context) {
+	var req requests.ItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.Service.CreateItem(services.ItemRequest{
+		Code:          req.Code,
+		Name:          req.Name,
+		Unit:          req.Unit,
+		CostingMethod: models.CostingMethod(req.CostingMethod),
+		TrackLots:     req.TrackLots,
+		Serialized:    req.Serialized,
+	})
+	if err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Item created successfully",
+		"data":    item,
+	})
+}
+
+// UpdateItem - Ubah code/name/unit item
+func (h *ItemHandler) UpdateItem(c *gin.Context) {
+	id, ok := itemIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req requests.ItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.Service.UpdateItem(id, services.ItemRequest{
+		Code:          req.Code,
+		Name:          req.Name,
+		Unit:          req.Unit,
+		CostingMethod: models.CostingMethod(req.CostingMethod),
+		TrackLots:     req.TrackLots,
+		Serialized:    req.Serialized,
+	})
+	if err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Item updated successfully",
+		"data":    item,
+	})
+}
+
+// DeactivateItem - Nonaktifkan item (soft, history tetap)
+func (h *ItemHandler) DeactivateItem(c *gin.Context) {
+	h.setActive(c, false, "Item deactivated successfully")
+}
+
+// ActivateItem - Aktifkan kembali item
+func (h *ItemHandler) ActivateItem(c *gin.Context) {
+	h.setActive(c, true, "Item activated successfully")
+}
+
+func (h *ItemHandler) setActive(c *gin.Context, active bool, message string) {
+	id, ok := itemIDParam(c)
+	if !ok {
+		return
+	}
+
+	item, err := h.Service.SetItemActive(id, active)
+	if err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    item,
+	})
+}
+
+// ============ UNITS ============
+
+// GetItemUnits - Satuan dasar + satuan alternatif item
+func (h *ItemHandler) GetItemUnits(c *gin.Context) {
+	id, ok := itemIDParam(c)
+	if !ok {
+		return
+	}
+
+	units, err := h.Service.GetItemUnits(id)
+	if err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": units})
+}
+
+// SetItemUnit - Tambah / ubah faktor konversi satuan alternatif
+func (h *ItemHandler) SetItemUnit(c *gin.Context) {
+	id, ok := itemIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req requests.ItemUnitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	unit, err := h.Service.SetItemUnit(id, c.Param("unit"), req.Factor)
+	if err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Item unit saved successfully",
+		"data":    unit,
+	})
+}
+
+// DeleteItemUnit - Hapus satuan alternatif
+func (h *ItemHandler) DeleteItemUnit(c *gin.Context) {
+	id, ok := itemIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteItemUnit(id, c.Param("unit")); err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item unit deleted successfully"})
+}
+
+func itemIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"inventory-ledger/src/requests"
+	"inventory-ledger/src/services"
+)
+
+type LocationHandler struct {
+	Service *services.LocationService
+}
+
+// locationParams - Parse :id (organisasi) dan :location_id (opsional)
+func locationParams(c *gin.Context, withLocation bool) (uuid.UUID, uuid.UUID, bool) {
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	if !withLocation {
+		return orgID, uuid.Nil, true
+	}
+
+	locationID, err := uuid.Parse(c.Param("location_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid location id"})
+		return orgID, uuid.Nil, false
+	}
+	return orgID, locationID, true
+}
+
+// ListLocations - List lokasi organisasi (query: q, active, page, limit)
+func (h *LocationHandler) ListLocations(c *gin.Context) {
+	orgID, _, ok := locationParams(c, false)
+	if !ok {
+		return
+	}
+	filter, ok := masterDataFilter(c)
+	if !ok {
+		return
+	}
+
+	locations, total, err := h.Service.ListLocations(orgID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": locations,
+		"meta": listMeta(filter, total),
+	})
+}
+
+// GetLocation - Detail lokasi
+func (h *LocationHandler) GetLocation(c *gin.Context) {
+	orgID, locationID, ok := locationParams(c, true)
+	if !ok {
+		return
+	}
+
+	location, err := h.Service.GetLocation(orgID, locationID)
+	if err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": location})
+}
+
+// CreateLocation - Buat lokasi baru (zona / rak / bin)
+func (h *LocationHandler) CreateLocation(c *gin.Context) {
+	orgID, _, ok := locationParams(c, false)
+	if !ok {
+		return
+	}
+
+	var req requests.LocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	location, err := h.Service.CreateLocation(orgID, services.LocationRequest{
+		Code:     req.Code,
+		Name:     req.Name,
+		ParentID: req.ParentID,
+	})
+	if err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Location created successfully",
+		"data":    location,
+	})
+}
+
+// UpdateLocation - Ubah name/code/parent lokasi
+func (h *LocationHandler) UpdateLocation(c *gin.Context) {
+	orgID, locationID, ok := locationParams(c, true)
+	if !ok {
+		return
+	}
+
+	var req requests.LocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	location, err := h.Service.UpdateLocation(orgID, locationID, services.LocationRequest{
+		Code:     req.Code,
+		Name:     req.Name,
+		ParentID: req.ParentID,
+	})
+	if err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Location updated successfully",
+		"data":    location,
+	})
+}
+
+// DeactivateLocation - Nonaktifkan lokasi (soft, ledger tetap)
+func (h *LocationHandler) DeactivateLocation(c *gin.Context) {
+	h.setActive(c, false, "Location deactivated successfully")
+}
+
+// ActivateLocation - Aktifkan kembali lokasi
+func (h *LocationHandler) ActivateLocation(c *gin.Context) {
+	h.setActive(c, true, "Location activated successfully")
+}
+
+func (h *LocationHandler) setActive(c *gin.Context, active bool, message string) {
+	orgID, locationID, ok := locationParams(c, true)
+	if !ok {
+		return
+	}
+
+	location, err := h.Service.SetLocationActive(orgID, locationID, active)
+	if err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    location,
+	})
+}
+
+// ============ LOCATION MOVE ============
+type LocationMoveRequest struct {
+	OrganizationID uuid.UUID       `json:"organization_id" binding:"required"`
+	ItemID         uint            `json:"item_id" binding:"required"`
+	FromLocationID *uuid.UUID      `json:"from_location_id,omitempty"` // kosong = belum ditempatkan
+	ToLocationID   *uuid.UUID      `json:"to_location_id,omitempty"`   // kosong = belum ditempatkan
+	Quantity       decimal.Decimal `json:"quantity"`
+	Unit           string          `json:"unit,omitempty" binding:"omitempty,max=20"`
+	TxnDate        string          `json:"txn_date" binding:"required"`
+	ChangedBy      string          `json:"changed_by" binding:"required"`
+	Reason         *string         `json:"reason,omitempty"`
+	Notes          *string         `json:"notes,omitempty"`
+
+	// Item ber-lot: lot yang dipindahkan (kosong = FEFO)
+	Lots []requests.LotQuantityRequest `json:"lots,omitempty" binding:"omitempty,dive"`
+	// Item serialized: nomor seri unit yang dipindahkan (wajib, satu per unit)
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+}
+
+// CreateLocationMove - Pindah stok antar lokasi di dalam satu organisasi
+func (h *InventoryHandler) CreateLocationMove(c *gin.Context) {
+	var req LocationMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	txnDate, err := time.Parse(time.RFC3339, req.TxnDate)
+	if err != nil {
+		txnDate, err = time.Parse("2006-01-02T15:04:05", req.TxnDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid txn_date format"})
+			return
+		}
+	}
+
+	err = h.Service.CreateLocationMove(services.LocationMoveRequest{
+		OrganizationID: req.OrganizationID,
+		ItemID:         req.ItemID,
+		FromLocationID: req.FromLocationID,
+		ToLocationID:   req.ToLocationID,
+		Quantity:       req.Quantity,
+		Unit:           req.Unit,
+		TxnDate:        txnDate,
+		ChangedBy:      req.ChangedBy,
+		Reason:         req.Reason,
+		Notes:          req.Notes,
+		Lots:           lotQuantities(req.Lots),
+		SerialNumbers:  req.SerialNumbers,
+	})
+	if err != nil {
+		c.JSON(transactionErrorStatus(c, err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Location move completed successfully",
+	})
+}
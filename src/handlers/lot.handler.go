@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/repositories"
+	"inventory-ledger/src/requests"
+	"inventory-ledger/src/services"
+)
+
+// GetLotBalances - Saldo per lot org+item (urut FEFO)
+func (h *InventoryHandler) GetLotBalances(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Query("organization_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization_id"})
+		return
+	}
+
+	itemID, err := strconv.Atoi(c.Query("item_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item_id"})
+		return
+	}
+
+	lots, err := h.Service.GetLotBalances(orgID, uint(itemID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"organization_id": orgID,
+		"item_id":         itemID,
+		"lots":            lots,
+	})
+}
+
+// GetExpiringLots - Lot bersaldo yang kedaluwarsa dalam `days` hari (query: days, organization_id, item_id, page, limit)
+func (h *InventoryHandler) GetExpiringLots(c *gin.Context) {
+	orgID, itemID, ok := adminScope(c)
+	if !ok {
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	lots, total, err := h.Service.GetExpiringLots(services.ExpiringLotsRequest{
+		OrganizationID: orgID,
+		ItemID:         itemID,
+		Days:           days,
+		Page:           page,
+		Limit:          limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"days": days,
+		"data": lots,
+		"meta": listMeta(repositories.MasterDataFilter{Page: page, Limit: limit}, total),
+	})
+}
+
+// parseExpiryDate - expiry_date opsional, format YYYY-MM-DD
+func parseExpiryDate(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", *value)
+	if err != nil {
+		return nil, errors.New("invalid expiry_date format. Use YYYY-MM-DD")
+	}
+	return &date, nil
+}
+
+// lotQuantities - Request lots → service
+func lotQuantities(lots []requests.LotQuantityRequest) []services.LotQuantity {
+	if len(lots) == 0 {
+		return nil
+	}
+	result := make([]services.LotQuantity, len(lots))
+	for i, lot := range lots {
+		result[i] = services.LotQuantity{LotNumber: lot.LotNumber, Quantity: lot.Quantity}
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"inventory-ledger/src/repositories"
+	"inventory-ledger/src/services"
+)
+
+// masterDataFilter - Parse query q, active, page, limit untuk list master data
+func masterDataFilter(c *gin.Context) (repositories.MasterDataFilter, bool) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := repositories.MasterDataFilter{
+		Query: c.Query("q"),
+		Page:  page,
+		Limit: limit,
+	}
+
+	if activeStr := c.Query("active"); activeStr != "" {
+		active, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid active, use true or false"})
+			return filter, false
+		}
+		filter.Active = &active
+	}
+
+	return filter, true
+}
+
+// listMeta - Meta pagination (sama dengan GetHistory)
+func listMeta(filter repositories.MasterDataFilter, total int64) gin.H {
+	return gin.H{
+		"page":        filter.Page,
+		"limit":       filter.Limit,
+		"total":       total,
+		"total_pages": int(math.Ceil(float64(total) / float64(filter.Limit))),
+	}
+}
+
+// masterDataErrorStatus - 404 not found, 409 kode duplikat / track_lots & serialized terkunci, sisanya 400
+func masterDataErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrOrganizationNotFound), errors.Is(err, services.ErrItemNotFound),
+		errors.Is(err, services.ErrItemUnitNotFound), errors.Is(err, services.ErrLocationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrOrganizationCodeTaken), errors.Is(err, services.ErrItemCodeTaken),
+		errors.Is(err, services.ErrLocationCodeTaken), errors.Is(err, services.ErrLotTrackingLocked),
+		errors.Is(err, services.ErrSerializedLocked), errors.Is(err, services.ErrBaseUnitLocked):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
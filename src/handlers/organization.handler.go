@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/requests"
+	"inventory-ledger/src/services"
+)
+
+type OrganizationHandler struct {
+	Service *services.OrganizationService
+}
+
+// ListOrganizations - List organisasi (query: q, active, page, limit)
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	filter, ok := masterDataFilter(c)
+	if !ok {
+		return
+	}
+
+	orgs, total, err := h.Service.ListOrganizations(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": orgs,
+		"meta": listMeta(filter, total),
+	})
+}
+
+// GetOrganization - Detail organisasi
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		return
+	}
+
+	org, err := h.Service.GetOrganization(id)
+	if err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": org})
+}
+
+// CreateOrganization - Buat organisasi baru
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var req requests.OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.Service.CreateOrganization(services.OrganizationRequest{
+		Name:                req.Name,
+		Code:                req.Code,
+		NegativeStockPolicy: models.NegativeStockPolicy(req.NegativeStockPolicy),
+	})
+	if err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Organization created successfully",
+		"data":    org,
+	})
+}
+
+// UpdateOrganization - Ubah name/code organisasi
+func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		return
+	}
+
+	var req requests.OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.Service.UpdateOrganization(id, services.OrganizationRequest{
+		Name:                req.Name,
+		Code:                req.Code,
+		NegativeStockPolicy: models.NegativeStockPolicy(req.NegativeStockPolicy),
+	})
+	if err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Organization updated successfully",
+		"data":    org,
+	})
+}
+
+// DeactivateOrganization - Nonaktifkan organisasi (soft, history tetap)
+func (h *OrganizationHandler) DeactivateOrganization(c *gin.Context) {
+	h.setActive(c, false, "Organization deactivated successfully")
+}
+
+// ActivateOrganization - Aktifkan kembali organisasi
+func (h *OrganizationHandler) ActivateOrganization(c *gin.Context) {
+	h.setActive(c, true, "Organization activated successfully")
+}
+
+func (h *OrganizationHandler) setActive(c *gin.Context, active bool, message string) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		return
+	}
+
+	org, err := h.Service.SetOrganizationActive(id, active)
+	if err != nil {
+		c.JSON(masterDataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    org,
+	})
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/requests"
+	"inventory-ledger/src/services"
+)
+
+type PeriodHandler struct {
+	Service *services.PeriodService
+}
+
+// periodErrorStatus - 404 org/closing tidak ada, 409 konflik urutan closing, sisanya 400
+func periodErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrOrganizationNotFound), errors.Is(err, services.ErrClosingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPeriodAlreadyClosed), errors.Is(err, services.ErrClosingAlreadyReopened),
+		errors.Is(err, services.ErrClosingNotLatest):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// periodParams - Parse :id (organisasi) dan :closing_id (opsional)
+func periodParams(c *gin.Context, withClosing bool) (uuid.UUID, uuid.UUID, bool) {
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	if !withClosing {
+		return orgID, uuid.Nil, true
+	}
+
+	closingID, err := uuid.Parse(c.Param("closing_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid closing id"})
+		return orgID, uuid.Nil, false
+	}
+	return orgID, closingID, true
+}
+
+// ListPeriodClosings - Riwayat tutup/buka buku organisasi
+func (h *PeriodHandler) ListPeriodClosings(c *gin.Context) {
+	orgID, _, ok := periodParams(c, false)
+	if !ok {
+		return
+	}
+
+	closings, err := h.Service.ListClosings(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": closings})
+}
+
+// GetPeriodClosing - Detail closing + saldo akhir per item
+func (h *PeriodHandler) GetPeriodClosing(c *gin.Context) {
+	orgID, closingID, ok := periodParams(c, true)
+	if !ok {
+		return
+	}
+
+	closing, err := h.Service.GetClosing(orgID, closingID)
+	if err != nil {
+		c.JSON(periodErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": closing})
+}
+
+// ClosePeriod - Tutup buku sampai period_end (YYYY-MM-DD, inklusif)
+func (h *PeriodHandler) ClosePeriod(c *gin.Context) {
+	orgID, _, ok := periodParams(c, false)
+	if !ok {
+		return
+	}
+
+	var req requests.ClosePeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	periodEnd, err := time.Parse("2006-01-02", req.PeriodEnd)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid period_end format. Use YYYY-MM-DD"})
+		return
+	}
+
+	closing, err := h.Service.ClosePeriod(services.ClosePeriodRequest{
+		OrganizationID: orgID,
+		PeriodEnd:      periodEnd,
+		ClosedBy:       req.ClosedBy,
+		Reason:         req.Reason,
+	})
+	if err != nil {
+		c.JSON(periodErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Period closed successfully",
+		"data":    closing,
+	})
+}
+
+// ReopenPeriod - Buka kembali closing aktif terakhir (reason wajib)
+func (h *PeriodHandler) ReopenPeriod(c *gin.Context) {
+	orgID, closingID, ok := periodParams(c, true)
+	if !ok {
+		return
+	}
+
+	var req requests.ReopenPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	closing, err := h.Service.ReopenPeriod(services.ReopenPeriodRequest{
+		OrganizationID: orgID,
+		ClosingID:      closingID,
+		ReopenedBy:     req.ReopenedBy,
+		Reason:         req.Reason,
+	})
+	if err != nil {
+		c.JSON(periodErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Period reopened successfully",
+		"data":    closing,
+	})
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"inventory-ledger/src/services"
+)
+
+// GetSerialTrail - Posisi & jejak pergerakan satu nomor seri lintas organisasi (query opsional: item_id)
+func (h *InventoryHandler) GetSerialTrail(c *gin.Context) {
+	var itemID uint
+	if itemIDStr := c.Query("item_id"); itemIDStr != "" {
+		parsed, err := strconv.ParseUint(itemIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item_id"})
+			return
+		}
+		itemID = uint(parsed)
+	}
+
+	units, err := h.Service.GetSerialTrail(c.Param("sn"), itemID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrSerialNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": units})
+}
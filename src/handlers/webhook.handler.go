@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+	"inventory-ledger/src/requests"
+	"inventory-ledger/src/services"
+)
+
+type WebhookHandler struct {
+	Service *services.WebhookService
+}
+
+// webhookErrorStatus - 404 subscription/delivery/org tidak ada, 409 redelivery saat masih pending, sisanya 400
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound), errors.Is(err, services.ErrWebhookDeliveryNotFound),
+		errors.Is(err, services.ErrOrganizationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrWebhookDeliveryPending):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// webhookParams - Parse :id (subscription) dan :delivery_id (opsional)
+func webhookParams(c *gin.Context, withDelivery bool) (uuid.UUID, uuid.UUID, bool) {
+	subscriptionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	if !withDelivery {
+		return subscriptionID, uuid.Nil, true
+	}
+
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery id"})
+		return subscriptionID, uuid.Nil, false
+	}
+	return subscriptionID, deliveryID, true
+}
+
+func toWebhookSubscriptionRequest(req requests.WebhookSubscriptionRequest) services.WebhookSubscriptionRequest {
+	return services.WebhookSubscriptionRequest{
+		Name:            req.Name,
+		URL:             req.URL,
+		Secret:          req.Secret,
+		EventTypes:      req.EventTypes,
+		OrganizationIDs: req.OrganizationIDs,
+		IsActive:        req.IsActive,
+	}
+}
+
+// ListWebhooks - Semua subscription (secret tidak ditampilkan)
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	subscriptions, err := h.Service.ListSubscriptions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": subscriptions})
+}
+
+// GetWebhook - Detail subscription
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, _, ok := webhookParams(c, false)
+	if !ok {
+		return
+	}
+
+	subscription, err := h.Service.GetSubscription(id)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": subscription})
+}
+
+// CreateWebhook - Buat subscription. Secret hanya dikembalikan sekali di response ini.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req requests.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.Service.CreateSubscription(toWebhookSubscriptionRequest(req))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook created successfully",
+		"data":    subscription,
+		"secret":  subscription.Secret,
+	})
+}
+
+// UpdateWebhook - Ubah subscription (secret kosong = tetap)
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, _, ok := webhookParams(c, false)
+	if !ok {
+		return
+	}
+
+	var req requests.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.Service.UpdateSubscription(id, toWebhookSubscriptionRequest(req))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook updated successfully",
+		"data":    subscription,
+	})
+}
+
+// DeleteWebhook - Hapus subscription beserta delivery log
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, _, ok := webhookParams(c, false)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteSubscription(id); err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// ListWebhookDeliveries - Delivery log (query: status, page, limit)
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	id, _, ok := webhookParams(c, false)
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	switch models.WebhookDeliveryStatus(status) {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status, use pending, delivered or failed"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	deliveries, total, err := h.Service.ListDeliveries(id, status, page, limit)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": deliveries,
+		"meta": listMeta(repositories.MasterDataFilter{Page: page, Limit: limit}, total),
+	})
+}
+
+// GetWebhookDelivery - Detail delivery (payload, status code & response terakhir)
+func (h *WebhookHandler) GetWebhookDelivery(c *gin.Context) {
+	id, deliveryID, ok := webhookParams(c, true)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.Service.GetDelivery(id, deliveryID)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": delivery})
+}
+
+// RedeliverWebhook - Kirim ulang delivery sebagai delivery baru
+func (h *WebhookHandler) RedeliverWebhook(c *gin.Context) {
+	id, deliveryID, ok := webhookParams(c, true)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.Service.Redeliver(id, deliveryID)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Webhook redelivery queued",
+		"data":    delivery,
+	})
+}
@@ -0,0 +1,99 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/services"
+)
+
+// ============ TEST SCENARIO 14: HISTORY DIFF ============
+func TestHistoryDiff(t *testing.T) {
+	orgID := uuid.New()
+	testDB.Create(&models.Organization{ID: orgID, Name: "Diff Org", Code: "ORG-DIFF"})
+
+	base := time.Date(2024, 12, 1, 8, 0, 0, 0, time.UTC)
+	post := func(offset time.Duration, amount int, txnType string) *models.Inventory {
+		inv, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID,
+			ItemID:         testItemID,
+			TxnDate:        base.Add(offset),
+			Amount:         models.Qty(int64(amount)),
+			Type:           txnType,
+			ChangedBy:      "diff_test",
+		})
+		assertNoError(t, err)
+		return inv
+	}
+
+	post(0, 100, "stok_awal")
+	receipt := post(24*time.Hour, 50, "penerimaan")
+	usage := post(48*time.Hour, -30, "pemakaian")
+
+	err := testService.UpdateTransaction(services.UpdateTransactionRequest{
+		InventoryID: receipt.ID,
+		TxnDate:     receipt.TxnDate,
+		Amount:      models.Qty(70),
+		ChangedBy:   "diff_test",
+		Reason:      stringPtr("salah input"),
+	})
+	assertNoError(t, err)
+
+	var before, after models.InventoryHistory
+	assertNoError(t, testDB.Where("trigger_inventory_id = ? AND action = ?", receipt.ID, "UPDATE_BEFORE").Take(&before).Error)
+	assertNoError(t, testDB.Where("organization_id = ? AND action = ?", orgID, "UPDATE_AFTER").Take(&after).Error)
+
+	t.Run("SC31: Update pair is linked and diffed from either side", func(t *testing.T) {
+		if before.PairedHistoryID == nil || *before.PairedHistoryID != after.ID {
+			t.Fatalf("UPDATE_BEFORE not paired with UPDATE_AFTER: %v", before.PairedHistoryID)
+		}
+		if after.PairedHistoryID == nil || *after.PairedHistoryID != before.ID {
+			t.Fatalf("UPDATE_AFTER not paired with UPDATE_BEFORE: %v", after.PairedHistoryID)
+		}
+
+		for _, historyID := range []uuid.UUID{before.ID, after.ID} {
+			diff, err := testService.GetHistoryDiff(historyID)
+			assertNoError(t, err)
+
+			assertEqual(t, "paired_history_id", diff.PairedBy, "paired by")
+			assertEqual(t, 0, len(diff.Added), "added")
+			assertEqual(t, 0, len(diff.Removed), "removed")
+			assertEqual(t, 0, diff.UnchangedCount, "unchanged")
+			assertEqual(t, 2, len(diff.Changed), "changed")
+
+			// Baris yang diedit: baris lama -> baris pengganti lewat trigger
+			edited := diff.Changed[0]
+			assertEqual(t, "trigger", edited.MatchedBy, "edited matched by")
+			assertEqual(t, receipt.ID, edited.Before.InventoryID, "edited before id")
+			assertEqual(t, 20, edited.AmountDelta, "edited amount delta")
+			assertEqual(t, 20, edited.BalanceDelta, "edited balance delta")
+
+			// Baris setelahnya: saldo ikut bergeser (snapshot after sudah di-recalculate)
+			following := diff.Changed[1]
+			assertEqual(t, "inventory_id", following.MatchedBy, "following matched by")
+			assertEqual(t, usage.ID, following.After.InventoryID, "following id")
+			assertEqual(t, 0, following.AmountDelta, "following amount delta")
+			assertEqual(t, 140, following.After.Balance, "following balance")
+			assertEqual(t, 20, following.BalanceDelta, "following balance delta")
+		}
+	})
+
+	t.Run("SC31: Non-diffable and missing history are rejected", func(t *testing.T) {
+		var create models.InventoryHistory
+		assertNoError(t, testDB.Where("trigger_inventory_id = ? AND action = ?", usage.ID, "CREATE").Take(&create).Error)
+
+		_, err := testService.GetHistoryDiff(create.ID)
+		if !errors.Is(err, services.ErrHistoryNotDiffable) {
+			t.Fatalf("expected ErrHistoryNotDiffable, got %v", err)
+		}
+
+		_, err = testService.GetHistoryDiff(uuid.New())
+		if !errors.Is(err, services.ErrHistoryNotFound) {
+			t.Fatalf("expected ErrHistoryNotFound, got %v", err)
+		}
+	})
+}
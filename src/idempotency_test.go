@@ -0,0 +1,141 @@
+package services_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/middlewares"
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+)
+
+// ============ TEST SCENARIO 28: IDEMPOTENCY KEYS ============
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Recovery di luar middleware, sama seperti gin.Default() di main.go
+	newEngine := func(handler gin.HandlerFunc) *gin.Engine {
+		engine := gin.New()
+		engine.Use(gin.Recovery())
+		idempotency := middlewares.Idempotency(&repositories.IdempotencyRepository{DB: testDB}, 1<<10, time.Minute)
+		engine.POST("/post", idempotency, handler)
+		return engine
+	}
+	send := func(engine *gin.Engine, key, contentType string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/post", bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set(middlewares.IdempotencyHeader, key)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	sendJSON := func(engine *gin.Engine, key, body string) *httptest.ResponseRecorder {
+		return send(engine, key, "application/json", []byte(body))
+	}
+
+	t.Run("SC61: Identical retries replay, changed bodies and in-flight keys conflict", func(t *testing.T) {
+		calls := 0
+		var engine *gin.Engine
+		var nested *httptest.ResponseRecorder
+		engine = newEngine(func(c *gin.Context) {
+			calls++
+			if c.GetHeader("X-Nested") != "" {
+				// Retry datang saat request pertama masih diproses
+				nested = sendJSON(engine, c.GetHeader(middlewares.IdempotencyHeader), `{"amount":1}`)
+			}
+			c.JSON(http.StatusCreated, gin.H{"call": calls})
+		})
+
+		key := uuid.NewString()
+		first := sendJSON(engine, key, `{"amount":1}`)
+		assertEqual(t, http.StatusCreated, first.Code, "first status")
+		replay := sendJSON(engine, key, `{"amount":1}`)
+		assertEqual(t, http.StatusCreated, replay.Code, "replay status")
+		assertEqual(t, "true", replay.Header().Get("Idempotent-Replayed"), "replay header")
+		assertEqual(t, first.Body.String(), replay.Body.String(), "replay body")
+		assertEqual(t, 1, calls, "handler runs once")
+
+		mismatch := sendJSON(engine, key, `{"amount":2}`)
+		assertEqual(t, http.StatusConflict, mismatch.Code, "mismatched body")
+		assertEqual(t, 1, calls, "mismatch does not run handler")
+
+		req := httptest.NewRequest(http.MethodPost, "/post", bytes.NewReader([]byte(`{"amount":1}`)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middlewares.IdempotencyHeader, uuid.NewString())
+		req.Header.Set("X-Nested", "true")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assertEqual(t, http.StatusCreated, w.Code, "outer request")
+		assertEqual(t, http.StatusConflict, nested.Code, "in-flight retry")
+		assertEqual(t, 2, calls, "in-flight retry does not run handler")
+	})
+
+	t.Run("SC62: Server errors, panics and stale pending keys free the key for retry", func(t *testing.T) {
+		failures := 0
+		engine := newEngine(func(c *gin.Context) {
+			switch failures++; failures {
+			case 1:
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "busy"})
+			case 2:
+				panic("handler crashed")
+			default:
+				c.JSON(http.StatusCreated, gin.H{"ok": true})
+			}
+		})
+
+		key := uuid.NewString()
+		assertEqual(t, http.StatusServiceUnavailable, sendJSON(engine, key, `{}`).Code, "5xx")
+		assertEqual(t, http.StatusInternalServerError, sendJSON(engine, key, `{}`).Code, "panic")
+		assertEqual(t, http.StatusCreated, sendJSON(engine, key, `{}`).Code, "retry after 5xx and panic")
+		assertEqual(t, 3, failures, "handler runs on each retry")
+
+		// Key pending dari request yang mati lebih lama dari TTL diambil alih
+		stale := uuid.NewString()
+		assertNoError(t, testDB.Create(&models.IdempotencyKey{
+			Key: stale, Method: http.MethodPost, Path: "/post", RequestHash: "stale",
+			Status: models.IdempotencyStatusPending, CreatedAt: time.Now().Add(-time.Hour),
+		}).Error)
+		assertEqual(t, http.StatusCreated, sendJSON(engine, stale, `{}`).Code, "stale pending key")
+		replay := sendJSON(engine, stale, `{}`)
+		assertEqual(t, "true", replay.Header().Get("Idempotent-Replayed"), "stale key completed by new owner")
+	})
+
+	t.Run("SC63: Multipart retries hash the file contents and oversized bodies are rejected", func(t *testing.T) {
+		calls := 0
+		engine := newEngine(func(c *gin.Context) {
+			calls++
+			if _, err := c.FormFile("file"); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusCreated, gin.H{"changed_by": c.PostForm("changed_by")})
+		})
+		upload := func(key, content string) *httptest.ResponseRecorder {
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body) // boundary acak per request
+			assertNoError(t, form.WriteField("changed_by", "importer"))
+			part, err := form.CreateFormFile("file", "stock.csv")
+			assertNoError(t, err)
+			part.Write([]byte(content))
+			assertNoError(t, form.Close())
+			return send(engine, key, form.FormDataContentType(), body.Bytes())
+		}
+
+		key := uuid.NewString()
+		assertEqual(t, http.StatusCreated, upload(key, "item_code,amount\nITEM001,5\n").Code, "first upload")
+		retry := upload(key, "item_code,amount\nITEM001,5\n")
+		assertEqual(t, "true", retry.Header().Get("Idempotent-Replayed"), "retry with new boundary replays")
+		assertEqual(t, http.StatusConflict, upload(key, "item_code,amount\nITEM001,6\n").Code, "different file")
+		assertEqual(t, 1, calls, "handler runs once")
+
+		tooLarge := sendJSON(engine, uuid.NewString(), `{"notes":"`+string(bytes.Repeat([]byte("x"), 2<<10))+`"}`)
+		assertEqual(t, http.StatusRequestEntityTooLarge, tooLarge.Code, "body over limit")
+	})
+}
@@ -0,0 +1,135 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/services"
+)
+
+// ============ TEST SCENARIO 16: INTEGRITY CHECK & REPAIR ============
+func TestIntegrityCheck(t *testing.T) {
+	orgID := uuid.New()
+	otherOrgID := uuid.New()
+	testDB.Create(&models.Organization{ID: orgID, Name: "Integrity Check Org", Code: "ORG-INTEGRITY"})
+	testDB.Create(&models.Organization{ID: otherOrgID, Name: "Integrity Other Org", Code: "ORG-INTEGRITY-2"})
+
+	integrityService := &services.IntegrityService{DB: testDB, Inventory: testService}
+
+	base := time.Date(2025, 2, 3, 8, 0, 0, 0, time.UTC)
+	post := func(offset time.Duration, amount int, txnType string) *models.Inventory {
+		inv, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID,
+			ItemID:         testItemID,
+			TxnDate:        base.Add(offset),
+			Amount:         models.Qty(int64(amount)),
+			Type:           txnType,
+			ChangedBy:      "integrity_check_test",
+		})
+		assertNoError(t, err)
+		return inv
+	}
+
+	post(0, 100, "stok_awal")
+	receipt := post(24*time.Hour, 50, "penerimaan")
+	post(48*time.Hour, -30, "pemakaian")
+
+	t.Run("SC34: Clean sequence has no issues", func(t *testing.T) {
+		report, err := integrityService.Check(orgID, testItemID, false)
+		assertNoError(t, err)
+		assertEqual(t, 1, report.Sequences, "sequences")
+		assertEqual(t, 3, report.Rows, "rows")
+		assertEqual(t, 0, len(report.Issues), "issues")
+	})
+
+	t.Run("SC35: Corruption is reported and balance breaks are repaired", func(t *testing.T) {
+		// Saldo dirusak langsung di database
+		assertNoError(t, testDB.Exec("UPDATE inventories SET balance = 999 WHERE id = ?", receipt.ID).Error)
+
+		// stok_awal ganda + leg mutasi tanpa pasangan, ditulis langsung tanpa service
+		refID := uuid.New()
+		assertNoError(t, testDB.Create(&models.Inventory{
+			OrganizationID: orgID, ItemID: testItemID, TxnDate: base.Add(72 * time.Hour),
+			Amount: models.Qty(10), Balance: models.Qty(130), Type: models.InventoryTypeStokAwal, CreatedBy: "integrity_check_test",
+		}).Error)
+		orphan := models.Inventory{
+			OrganizationID: orgID, ItemID: testItemID, TxnDate: base.Add(96 * time.Hour),
+			Amount: models.Qty(-200), Balance: models.Qty(-70), Type: models.InventoryTypeMutation, RefID: &refID,
+			FromOrganizationID: &orgID, ToOrganizationID: &otherOrgID, CreatedBy: "integrity_check_test",
+		}
+		assertNoError(t, testDB.Create(&orphan).Error)
+
+		report, err := integrityService.Check(orgID, testItemID, false)
+		assertNoError(t, err)
+		assertEqual(t, 1, report.Counts[services.IssueBalanceChainBreak], "balance breaks")
+		assertEqual(t, 1, report.Counts[services.IssueDuplicateStokAwal], "duplicate stok_awal")
+		assertEqual(t, 1, report.Counts[services.IssueOrphanMutationLeg], "orphan legs")
+		assertEqual(t, 1, report.Counts[services.IssueNegativeBalance], "negative balance")
+		assertEqual(t, 0, len(report.Repaired), "nothing repaired without -repair")
+
+		for _, issue := range report.Issues {
+			if issue.Kind == services.IssueBalanceChainBreak {
+				assertEqual(t, receipt.ID, issue.InventoryID, "first broken link")
+				assertEqual(t, 150, *issue.Expected, "expected balance")
+			}
+		}
+
+		// Repair lewat chokepoint recalculation: policy block menolak sequence yang tetap negatif
+		report, err = integrityService.Check(orgID, testItemID, true)
+		assertNoError(t, err)
+		assertEqual(t, 0, len(report.Repaired), "repaired sequences under block policy")
+		assertEqual(t, 1, len(report.Rejected), "rejected sequences under block policy")
+		assertEqual(t, 4, report.Unresolved, "balance break stays unresolved")
+		var untouched models.Inventory
+		assertNoError(t, testDB.First(&untouched, "id = ?", receipt.ID).Error)
+		assertEqual(t, 999, untouched.Balance, "receipt balance after rejected repair")
+
+		assertNoError(t, testDB.Model(&models.Organization{}).Where("id = ?", orgID).
+			Update("negative_stock_policy", models.NegativeStockWarn).Error)
+
+		report, err = integrityService.Check(orgID, testItemID, true)
+		assertNoError(t, err)
+		assertEqual(t, 1, len(report.Repaired), "repaired sequences")
+		assertEqual(t, 3, report.Unresolved, "stok_awal, orphan and negative need manual fixes")
+
+		var repaired models.Inventory
+		assertNoError(t, testDB.First(&repaired, "id = ?", receipt.ID).Error)
+		assertEqual(t, 150, repaired.Balance, "receipt balance after repair")
+
+		report, err = integrityService.Check(orgID, testItemID, false)
+		assertNoError(t, err)
+		assertEqual(t, 0, report.Counts[services.IssueBalanceChainBreak], "balance breaks after repair")
+	})
+	t.Run("SC67: Orphan references from before the foreign keys are reported", func(t *testing.T) {
+		// Baris yatim hanya bisa ditulis tanpa FK; semuanya di-rollback di akhir
+		errRollback := errors.New("rollback")
+		missingItemID := uint(987654)
+		err := testDB.Transaction(func(tx *gorm.DB) error {
+			assertNoError(t, tx.Exec("ALTER TABLE inventories DROP CONSTRAINT fk_inventories_item").Error)
+			orphan := models.Inventory{
+				OrganizationID: orgID, ItemID: missingItemID, TxnDate: base,
+				Amount: models.Qty(5), Balance: models.Qty(5), Type: models.InventoryTypeStokAwal, CreatedBy: "integrity_check_test",
+			}
+			assertNoError(t, tx.Create(&orphan).Error)
+
+			report, err := (&services.IntegrityService{DB: tx, Inventory: testService}).Check(orgID, missingItemID, false)
+			assertNoError(t, err)
+			assertEqual(t, 1, report.Counts[services.IssueOrphanReference], "orphan references")
+			assertEqual(t, 1, report.Unresolved, "orphans need manual cleanup")
+			for _, issue := range report.Issues {
+				if issue.Kind == services.IssueOrphanReference {
+					assertEqual(t, orphan.ID, issue.InventoryID, "orphan row")
+				}
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("expected rollback, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package services_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/services"
+)
+
+// assertBalanceChain - Running balance harus sama dengan kumulatif amount (opname reset ke physical)
+func assertBalanceChain(t *testing.T, orgID uuid.UUID, itemID uint) decimal.Decimal {
+	t.Helper()
+
+	var transactions []models.Inventory
+	testDB.Where("organization_id = ? AND item_id = ? AND deleted_at IS NULL", orgID, itemID).
+		Order("txn_date ASC, created_at ASC").
+		Find(&transactions)
+
+	runningBalance := decimal.Zero
+	for i, tx := range transactions {
+		if tx.Type == models.InventoryTypeOpname && tx.PhysicalQty != nil {
+			runningBalance = *tx.PhysicalQty
+		} else {
+			runningBalance = runningBalance.Add(tx.Amount)
+		}
+		if !runningBalance.Equal(tx.Balance) {
+			t.Fatalf("Transaction %d (%s) has inconsistent balance. Expected %s, got %s",
+				i+1, tx.ID, runningBalance, tx.Balance)
+		}
+	}
+	return runningBalance
+}
+
+// ============ TEST SCENARIO 9: CONCURRENT POSTINGS ============
+func TestConcurrentPostings(t *testing.T) {
+	sqlDB, err := testDB.DB()
+	assertNoError(t, err)
+	sqlDB.SetMaxOpenConns(20)
+
+	t.Run("SC21: Parallel postings on same org+item keep balance chain", func(t *testing.T) {
+		orgID := uuid.New()
+		testDB.Create(&models.Organization{ID: orgID, Name: "Concurrency Org", Code: "ORG-CONC"})
+
+		base := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+		_, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID,
+			ItemID:         testItemID,
+			TxnDate:        base,
+			Amount:         models.Qty(1000),
+			Type:           "stok_awal",
+			ChangedBy:      "concurrency_test",
+		})
+		assertNoError(t, err)
+
+		const workers = 300
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		expected := 1000
+
+		for i := 0; i < workers; i++ {
+			amount, txnType := 5, "penerimaan"
+			if i%3 == 0 {
+				amount, txnType = -2, "pemakaian"
+			}
+			expected += amount
+
+			wg.Add(1)
+			go func(i, amount int, txnType string) {
+				defer wg.Done()
+				// Tanggal diacak supaya banyak posting backdated yang memicu RecalculateForward
+				_, err := testService.CreateTransaction(services.CreateTransactionRequest{
+					OrganizationID: orgID,
+					ItemID:         testItemID,
+					TxnDate:        base.Add(time.Duration((i*7)%97+1) * time.Hour),
+					Amount:         models.Qty(int64(amount)),
+					Type:           txnType,
+					ChangedBy:      "concurrency_test",
+				})
+				errs <- err
+			}(i, amount, txnType)
+		}
+
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assertNoError(t, err)
+		}
+
+		final := assertBalanceChain(t, orgID, testItemID)
+		assertEqual(t, expected, final)
+
+		current, err := testService.GetCurrentBalance(orgID, testItemID)
+		assertNoError(t, err)
+		assertEqual(t, expected, current)
+	})
+
+	t.Run("SC22: Opposite mutations in parallel do not deadlock", func(t *testing.T) {
+		orgA, orgB := uuid.New(), uuid.New()
+		testDB.Create(&models.Organization{ID: orgA, Name: "Concurrency A", Code: "ORG-CONC-A"})
+		testDB.Create(&models.Organization{ID: orgB, Name: "Concurrency B", Code: "ORG-CONC-B"})
+
+		base := time.Date(2025, 2, 1, 8, 0, 0, 0, time.UTC)
+		for _, orgID := range []uuid.UUID{orgA, orgB} {
+			_, err := testService.CreateTransaction(services.CreateTransactionRequest{
+				OrganizationID: orgID,
+				ItemID:         testItemID,
+				TxnDate:        base,
+				Amount:         models.Qty(500),
+				Type:           "stok_awal",
+				ChangedBy:      "concurrency_test",
+			})
+			assertNoError(t, err)
+		}
+
+		const workers = 100
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+
+		for i := 0; i < workers; i++ {
+			from, to := orgA, orgB
+			if i%2 == 1 {
+				from, to = orgB, orgA
+			}
+
+			wg.Add(1)
+			go func(i int, from, to uuid.UUID) {
+				defer wg.Done()
+				errs <- testService.CreateMutation(services.MutationRequest{
+					FromOrganizationID: from,
+					ToOrganizationID:   to,
+					ItemID:             testItemID,
+					Quantity:           models.Qty(1),
+					TxnDate:            base.Add(time.Duration(i+1) * time.Minute),
+					ChangedBy:          "concurrency_test",
+				})
+			}(i, from, to)
+		}
+
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assertNoError(t, err)
+		}
+
+		// 50 mutasi tiap arah saling meniadakan
+		assertEqual(t, 500, assertBalanceChain(t, orgA, testItemID))
+		assertEqual(t, 500, assertBalanceChain(t, orgB, testItemID))
+	})
+}
@@ -8,11 +8,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
+	"inventory-ledger/src/migrations"
 	"inventory-ledger/src/models"
 	"inventory-ledger/src/repositories"
 	"inventory-ledger/src/services"
@@ -47,19 +49,16 @@ func setupTestDB() *gorm.DB {
 		panic("failed to connect database")
 	}
 
-	// Auto migrate
-	db.AutoMigrate(
-		&models.Inventory{},
-		&models.InventoryHistory{},
-		&models.Organization{},
-		&models.Item{},
-	)
+	// Skema dari migrasi SQL yang sama dengan aplikasi
+	if _, err := (&migrations.Migrator{DB: db}).Up(); err != nil {
+		panic(fmt.Sprintf("failed to migrate database: %v", err))
+	}
 
 	return db
 }
 
 func cleanupTestDB(db *gorm.DB) {
-	db.Exec("TRUNCATE inventories, inventory_histories, organizations, items RESTART IDENTITY CASCADE")
+	db.Exec("TRUNCATE inventories, inventory_histories, stock_balances, outbox_events, webhook_subscriptions, alert_notifications, idempotency_keys, organizations, items RESTART IDENTITY CASCADE")
 }
 
 func setupTestData(db *gorm.DB) {
@@ -129,7 +128,7 @@ func assertError(t *testing.T, err error, expectedMsg string) {
 
 func assertEqual(t *testing.T, expected, actual interface{}, msg ...string) {
 	t.Helper()
-	if expected != actual {
+	if !equalValues(expected, actual) {
 		message := ""
 		if len(msg) > 0 {
 			message = msg[0]
@@ -138,6 +137,24 @@ func assertEqual(t *testing.T, expected, actual interface{}, msg ...string) {
 	}
 }
 
+// equalValues - Kuantitas decimal dibandingkan nilainya (10 == 10.000000), selain itu ==
+func equalValues(expected, actual interface{}) bool {
+	qty, ok := actual.(decimal.Decimal)
+	if !ok {
+		return expected == actual
+	}
+	switch e := expected.(type) {
+	case int:
+		return qty.Equal(decimal.NewFromInt(int64(e)))
+	case string:
+		want, err := decimal.NewFromString(e)
+		return err == nil && qty.Equal(want)
+	case decimal.Decimal:
+		return qty.Equal(e)
+	}
+	return false
+}
+
 // ============ TEST SCENARIO 1: BASIC TRANSACTION FLOW ============
 func TestBasicTransactionFlow(t *testing.T) {
 	t.Run("SC1: Create penerimaan and verify balance", func(t *testing.T) {
@@ -145,7 +162,7 @@ func TestBasicTransactionFlow(t *testing.T) {
 			OrganizationID: testOrg1ID,
 			ItemID:         testItemID,
 			TxnDate:        time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
-			Amount:         100,
+			Amount:         models.Qty(100),
 			Type:           "penerimaan",
 			ChangedBy:      "user1",
 		}
@@ -171,7 +188,7 @@ func TestBasicTransactionFlow(t *testing.T) {
 			OrganizationID: testOrg1ID,
 			ItemID:         testItemID,
 			TxnDate:        time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
-			Amount:         -30,
+			Amount:         models.Qty(-30),
 			Type:           "pemakaian",
 			ChangedBy:      "user1",
 		}
@@ -197,7 +214,7 @@ func TestBasicTransactionFlow(t *testing.T) {
 			OrganizationID: newOrgID,
 			ItemID:         testItemID,
 			TxnDate:        time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
-			Amount:         50,
+			Amount:         models.Qty(50),
 			Type:           "stok_awal",
 			ChangedBy:      "admin",
 		}
@@ -211,7 +228,7 @@ func TestBasicTransactionFlow(t *testing.T) {
 			OrganizationID: newOrgID,
 			ItemID:         testItemID,
 			TxnDate:        time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
-			Amount:         20,
+			Amount:         models.Qty(20),
 			Type:           "stok_awal",
 			ChangedBy:      "admin",
 		}
@@ -228,7 +245,7 @@ func TestUpdateTransaction(t *testing.T) {
 		OrganizationID: testOrg2ID,
 		ItemID:         testItemID,
 		TxnDate:        time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC),
-		Amount:         200,
+		Amount:         models.Qty(200),
 		Type:           "penerimaan",
 		ChangedBy:      "user1",
 	}
@@ -238,7 +255,7 @@ func TestUpdateTransaction(t *testing.T) {
 		OrganizationID: testOrg2ID,
 		ItemID:         testItemID,
 		TxnDate:        time.Date(2024, 2, 2, 10, 0, 0, 0, time.UTC),
-		Amount:         -50,
+		Amount:         models.Qty(-50),
 		Type:           "pemakaian",
 		ChangedBy:      "user1",
 	}
@@ -253,7 +270,7 @@ func TestUpdateTransaction(t *testing.T) {
 		updateReq := services.UpdateTransactionRequest{
 			InventoryID: inv1.ID,
 			TxnDate:     time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC),
-			Amount:      250, // Increase by 50
+			Amount:      models.Qty(250), // Increase by 50
 			ChangedBy:   "user2",
 			Reason:      stringPtr("Correction"),
 		}
@@ -277,7 +294,7 @@ func TestUpdateTransaction(t *testing.T) {
 			OrganizationID: testOrg2ID,
 			ItemID:         testItemID,
 			TxnDate:        time.Date(2024, 2, 10, 10, 0, 0, 0, time.UTC),
-			Amount:         -20,
+			Amount:         models.Qty(-20),
 			Type:           "pemakaian",
 			ChangedBy:      "user1",
 		}
@@ -287,7 +304,7 @@ func TestUpdateTransaction(t *testing.T) {
 		updateReq := services.UpdateTransactionRequest{
 			InventoryID: inv.ID,
 			TxnDate:     time.Date(2024, 2, 5, 10, 0, 0, 0, time.UTC), // Earlier date
-			Amount:      -20,
+			Amount:      models.Qty(-20),
 			ChangedBy:   "user2",
 		}
 
@@ -304,12 +321,12 @@ func TestUpdateTransaction(t *testing.T) {
 		updateReq := services.UpdateTransactionRequest{
 			InventoryID: uuid.New(), // Random ID
 			TxnDate:     time.Now(),
-			Amount:      100,
+			Amount:      models.Qty(100),
 			ChangedBy:   "user1",
 		}
 
 		err := testService.UpdateTransaction(updateReq)
-		assertError(t, err, "record not found")
+		assertError(t, err, services.ErrInventoryNotFound.Error())
 	})
 }
 
@@ -321,7 +338,7 @@ func TestDeleteTransaction(t *testing.T) {
 			OrganizationID: testOrg1ID,
 			ItemID:         testItemID,
 			TxnDate:        time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
-			Amount:         100,
+			Amount:         models.Qty(100),
 			Type:           "penerimaan",
 			ChangedBy:      "user1",
 		}
@@ -331,7 +348,7 @@ func TestDeleteTransaction(t *testing.T) {
 			OrganizationID: testOrg1ID,
 			ItemID:         testItemID,
 			TxnDate:        time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC),
-			Amount:         -30,
+			Amount:         models.Qty(-30),
 			Type:           "pemakaian",
 			ChangedBy:      "user1",
 		}
@@ -341,7 +358,7 @@ func TestDeleteTransaction(t *testing.T) {
 			OrganizationID: testOrg1ID,
 			ItemID:         testItemID,
 			TxnDate:        time.Date(2024, 3, 3, 10, 0, 0, 0, time.UTC),
-			Amount:         -20,
+			Amount:         models.Qty(-20),
 			Type:           "pemakaian",
 			ChangedBy:      "user1",
 		}
@@ -379,7 +396,7 @@ func TestMutation(t *testing.T) {
 			OrganizationID: testOrg1ID,
 			ItemID:         testItemID,
 			TxnDate:        time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC),
-			Amount:         500,
+			Amount:         models.Qty(500),
 			Type:           "penerimaan",
 			ChangedBy:      "user1",
 		}
@@ -393,7 +410,7 @@ func TestMutation(t *testing.T) {
 			FromOrganizationID: testOrg1ID,
 			ToOrganizationID:   testOrg2ID,
 			ItemID:             testItemID,
-			Quantity:           150,
+			Quantity:           models.Qty(150),
 			TxnDate:            time.Date(2024, 4, 2, 10, 0, 0, 0, time.UTC),
 			ChangedBy:          "admin",
 			Reason:             stringPtr("Stock transfer"),
@@ -406,8 +423,8 @@ func TestMutation(t *testing.T) {
 		sourceAfter, _ := testService.GetCurrentBalance(testOrg1ID, testItemID)
 		destAfter, _ := testService.GetCurrentBalance(testOrg2ID, testItemID)
 
-		assertEqual(t, sourceBefore-150, sourceAfter) // 500 - 150 = 350
-		assertEqual(t, destBefore+150, destAfter)     // 0 + 150 = 150
+		assertEqual(t, sourceBefore.Sub(models.Qty(150)), sourceAfter) // 500 - 150 = 350
+		assertEqual(t, destBefore.Add(models.Qty(150)), destAfter)     // 0 + 150 = 150
 	})
 
 	t.Run("SC9: Mutation with insufficient stock", func(t *testing.T) {
@@ -415,7 +432,7 @@ func TestMutation(t *testing.T) {
 			FromOrganizationID: testOrg1ID,
 			ToOrganizationID:   testOrg2ID,
 			ItemID:             testItemID,
-			Quantity:           1000, // More than available
+			Quantity:           models.Qty(1000), // More than available
 			TxnDate:            time.Date(2024, 4, 3, 10, 0, 0, 0, time.UTC),
 			ChangedBy:          "admin",
 		}
@@ -433,7 +450,7 @@ func TestOpname(t *testing.T) {
 			OrganizationID: testOrg1ID,
 			ItemID:         testItemID,
 			TxnDate:        time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
-			Amount:         100,
+			Amount:         models.Qty(100),
 			Type:           "penerimaan",
 			ChangedBy:      "user1",
 		}
@@ -443,7 +460,7 @@ func TestOpname(t *testing.T) {
 		opnameReq := services.OpnameRequest{
 			OrganizationID: testOrg1ID,
 			ItemID:         testItemID,
-			PhysicalQty:    120,
+			PhysicalQty:    models.Qty(120),
 			TxnDate:        time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC),
 			ChangedBy:      "auditor",
 			Reason:         stringPtr("Monthly stock take"),
@@ -464,7 +481,7 @@ func TestOpname(t *testing.T) {
 		opnameReq := services.OpnameRequest{
 			OrganizationID: testOrg1ID,
 			ItemID:         testItemID,
-			PhysicalQty:    80,
+			PhysicalQty:    models.Qty(80),
 			TxnDate:        time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC),
 			ChangedBy:      "auditor",
 		}
@@ -490,7 +507,7 @@ func TestEdgeCases(t *testing.T) {
 				OrganizationID: testOrg1ID,
 				ItemID:         testItemID,
 				TxnDate:        baseTime.Add(time.Duration(i) * time.Millisecond),
-				Amount:         10 * i,
+				Amount:         models.Qty(int64(10 * i)),
 				Type:           "penerimaan",
 				ChangedBy:      "user1",
 			}
@@ -531,7 +548,7 @@ func TestEdgeCases(t *testing.T) {
 			OrganizationID: testOrg1ID,
 			ItemID:         testItemID,
 			TxnDate:        time.Now(),
-			Amount:         100,
+			Amount:         models.Qty(100),
 			Type:           "invalid_type", // Invalid
 			ChangedBy:      "user1",
 		}
@@ -545,7 +562,7 @@ func TestEdgeCases(t *testing.T) {
 			OrganizationID: testOrg1ID,
 			ItemID:         testItemID,
 			TxnDate:        time.Now(),
-			Amount:         -100, // Negative for penerimaan
+			Amount:         models.Qty(-100), // Negative for penerimaan
 			Type:           "penerimaan",
 			ChangedBy:      "user1",
 		}
@@ -559,7 +576,7 @@ func TestEdgeCases(t *testing.T) {
 // ============ TEST SCENARIO 7: SUMMARY REPORTS ============
 func TestSummaryReports(t *testing.T) {
 	t.Run("SC16: Organization summary", func(t *testing.T) {
-		summary, err := testService.GetOrganizationSummary(testOrg1ID)
+		summary, err := testService.GetOrganizationSummary(testOrg1ID, repositories.LocationScope{})
 		assertNoError(t, err)
 		assert.True(t, len(summary) > 0)
 
@@ -593,6 +610,32 @@ func TestSummaryReports(t *testing.T) {
 		assert.True(t, orgsFound[testOrg1ID], "Org1 should be in summary")
 		assert.True(t, orgsFound[testOrg2ID], "Org2 should be in summary")
 	})
+
+	t.Run("SC20: Stock balance projection matches ledger after rebuild", func(t *testing.T) {
+		before, err := testService.GetCurrentBalance(testOrg1ID, testItemID)
+		assertNoError(t, err)
+
+		_, err = testService.RebuildStockBalances()
+		assertNoError(t, err)
+
+		after, err := testService.GetCurrentBalance(testOrg1ID, testItemID)
+		assertNoError(t, err)
+		assertEqual(t, before, after)
+
+		var latest models.Inventory
+		testDB.Where("organization_id = ? AND item_id = ? AND deleted_at IS NULL", testOrg1ID, testItemID).
+			Order("txn_date DESC, created_at DESC").
+			First(&latest)
+		assertEqual(t, latest.Balance, after)
+
+		summary, err := testService.GetOrganizationSummary(testOrg1ID, repositories.LocationScope{})
+		assertNoError(t, err)
+		for _, item := range summary {
+			if itemID, ok := item["item_id"].(uint); ok && itemID == testItemID {
+				assert.Equal(t, after, item["current_stock"])
+			}
+		}
+	})
 }
 
 // ============ TEST SCENARIO 8: DATA INTEGRITY ============
@@ -607,7 +650,7 @@ func TestDataIntegrity(t *testing.T) {
 			OrganizationID: testOrg2ID,
 			ItemID:         testItemID,
 			TxnDate:        time.Now(),
-			Amount:         50,
+			Amount:         models.Qty(50),
 			Type:           "penerimaan",
 			ChangedBy:      "integrity_test",
 		}
@@ -617,7 +660,7 @@ func TestDataIntegrity(t *testing.T) {
 		updateReq := services.UpdateTransactionRequest{
 			InventoryID: inv.ID,
 			TxnDate:     time.Now(),
-			Amount:      75,
+			Amount:      models.Qty(75),
 			ChangedBy:   "integrity_test",
 		}
 		testService.UpdateTransaction(updateReq)
@@ -660,7 +703,7 @@ func TestDataIntegrity(t *testing.T) {
 				OrganizationID: newOrgID,
 				ItemID:         testItemID,
 				TxnDate:        op.date,
-				Amount:         op.amount,
+				Amount:         models.Qty(int64(op.amount)),
 				Type:           op.type_,
 				ChangedBy:      "integrity_test",
 			}
@@ -676,15 +719,15 @@ func TestDataIntegrity(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Failed to get balance: %v", err)
 		}
-		if finalBalance != 100 {
-			t.Fatalf("Expected final balance 100, got %d", finalBalance)
+		if !finalBalance.Equal(models.Qty(100)) {
+			t.Fatalf("Expected final balance 100, got %s", finalBalance)
 		}
 
 		// Now update the third transaction
 		updateReq := services.UpdateTransactionRequest{
 			InventoryID: lastInventoryID,
 			TxnDate:     time.Date(2024, 7, 4, 12, 0, 0, 0, time.UTC),
-			Amount:      -40, // Changed from -30 to -40
+			Amount:      models.Qty(-40), // Changed from -30 to -40
 			ChangedBy:   "integrity_test",
 		}
 		err = testService.UpdateTransaction(updateReq)
@@ -697,8 +740,8 @@ func TestDataIntegrity(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Failed to get new balance: %v", err)
 		}
-		if newBalance != 90 {
-			t.Fatalf("Expected new balance 90, got %d", newBalance)
+		if !newBalance.Equal(models.Qty(90)) {
+			t.Fatalf("Expected new balance 90, got %s", newBalance)
 		}
 
 		// Verify all balances in database are consistent
@@ -708,17 +751,62 @@ func TestDataIntegrity(t *testing.T) {
 			Order("txn_date ASC, created_at ASC").
 			Find(&transactions)
 
-		runningBalance := 0
+		runningBalance := decimal.Zero
 		for i, tx := range transactions {
-			runningBalance += tx.Amount
-			if runningBalance != tx.Balance {
-				t.Fatalf("Transaction %d has inconsistent balance. Expected %d, got %d",
+			runningBalance = runningBalance.Add(tx.Amount)
+			if !runningBalance.Equal(tx.Balance) {
+				t.Fatalf("Transaction %d has inconsistent balance. Expected %s, got %s",
 					i+1, runningBalance, tx.Balance)
 			}
 		}
 	})
 }
 
+// ============ TEST SCENARIO 10: BATCH POSTING ============
+func TestBatchPosting(t *testing.T) {
+	batchOrgID := uuid.New()
+	testDB.Create(&models.Organization{ID: batchOrgID, Name: "Batch Org", Code: "ORG-BATCH"})
+
+	t.Run("SC23: Batch lines posted with single recalculation", func(t *testing.T) {
+		lines := []services.CreateTransactionRequest{
+			{OrganizationID: batchOrgID, ItemID: testItemID, Amount: models.Qty(40), Type: "penerimaan", ChangedBy: "batch_test",
+				TxnDate: time.Date(2024, 8, 3, 10, 0, 0, 0, time.UTC)},
+			{OrganizationID: batchOrgID, ItemID: testItemID, Amount: models.Qty(100), Type: "stok_awal", ChangedBy: "batch_test",
+				TxnDate: time.Date(2024, 8, 1, 10, 0, 0, 0, time.UTC)},
+			{OrganizationID: batchOrgID, ItemID: testItemID, Amount: models.Qty(-25), Type: "pemakaian", ChangedBy: "batch_test",
+				TxnDate: time.Date(2024, 8, 2, 10, 0, 0, 0, time.UTC)},
+		}
+
+		results, err := testService.CreateTransactionBatch(lines, "batch_test", nil)
+		assertNoError(t, err)
+		assertEqual(t, 3, len(results))
+
+		// Balance per baris mengikuti urutan tanggal, bukan urutan input
+		assertEqual(t, 115, *results[0].Balance)
+		assertEqual(t, 100, *results[1].Balance)
+		assertEqual(t, 75, *results[2].Balance)
+
+		current, _ := testService.GetCurrentBalance(batchOrgID, testItemID)
+		assertEqual(t, 115, current)
+	})
+
+	t.Run("SC24: Batch with invalid line posts nothing", func(t *testing.T) {
+		lines := []services.CreateTransactionRequest{
+			{OrganizationID: batchOrgID, ItemID: testItemID, Amount: models.Qty(10), Type: "penerimaan", ChangedBy: "batch_test",
+				TxnDate: time.Date(2024, 8, 4, 10, 0, 0, 0, time.UTC)},
+			{OrganizationID: batchOrgID, ItemID: testItemID, Amount: models.Qty(10), Type: "stok_awal", ChangedBy: "batch_test",
+				TxnDate: time.Date(2024, 8, 5, 10, 0, 0, 0, time.UTC)},
+		}
+
+		results, err := testService.CreateTransactionBatch(lines, "batch_test", nil)
+		assertError(t, err, services.ErrBatchRejected.Error())
+		assertEqual(t, "stok awal already exists for this item", results[1].Error)
+
+		current, _ := testService.GetCurrentBalance(batchOrgID, testItemID)
+		assertEqual(t, 115, current)
+	})
+}
+
 // Helper function
 func stringPtr(s string) *string {
 	return &s
@@ -0,0 +1,134 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/services"
+)
+
+// setupMutationOrgs - Dua org baru dengan stok awal masing-masing
+func setupMutationOrgs(t *testing.T, code string, base time.Time, stock int) (uuid.UUID, uuid.UUID) {
+	t.Helper()
+
+	orgA, orgB := uuid.New(), uuid.New()
+	testDB.Create(&models.Organization{ID: orgA, Name: "Mutation " + code + " A", Code: code + "-A"})
+	testDB.Create(&models.Organization{ID: orgB, Name: "Mutation " + code + " B", Code: code + "-B"})
+
+	for _, orgID := range []uuid.UUID{orgA, orgB} {
+		_, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID,
+			ItemID:         testItemID,
+			TxnDate:        base,
+			Amount:         models.Qty(int64(stock)),
+			Type:           "stok_awal",
+			ChangedBy:      "mutation_test",
+		})
+		assertNoError(t, err)
+	}
+	return orgA, orgB
+}
+
+// findMutationLeg - Leg mutasi aktif di org tertentu
+func findMutationLeg(t *testing.T, orgID uuid.UUID) models.Inventory {
+	t.Helper()
+
+	var leg models.Inventory
+	err := testDB.Where("organization_id = ? AND item_id = ? AND type = ? AND deleted_at IS NULL",
+		orgID, testItemID, models.InventoryTypeMutation).Take(&leg).Error
+	assertNoError(t, err)
+	return leg
+}
+
+// ============ TEST SCENARIO 12: MUTATION LEGS ============
+func TestMutationLegs(t *testing.T) {
+	t.Run("SC27: Deleting one mutation leg deletes both", func(t *testing.T) {
+		base := time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC)
+		orgA, orgB := setupMutationOrgs(t, "MUT-DEL", base, 100)
+
+		err := testService.CreateMutation(services.MutationRequest{
+			FromOrganizationID: orgA, ToOrganizationID: orgB, ItemID: testItemID,
+			Quantity: models.Qty(30), TxnDate: base.Add(time.Hour), ChangedBy: "mutation_test",
+		})
+		assertNoError(t, err)
+
+		// Hapus lewat leg masuk (org tujuan)
+		inLeg := findMutationLeg(t, orgB)
+		assertNoError(t, testService.DeleteTransaction(inLeg.ID, "mutation_test", stringPtr("wrong transfer")))
+
+		assertEqual(t, 100, assertBalanceChain(t, orgA, testItemID))
+		assertEqual(t, 100, assertBalanceChain(t, orgB, testItemID))
+
+		var histories int64
+		testDB.Model(&models.InventoryHistory{}).
+			Where("item_id = ? AND action = ? AND organization_id IN ?", testItemID, "DELETE_BEFORE", []uuid.UUID{orgA, orgB}).
+			Count(&histories)
+		assertEqual(t, int64(2), histories)
+	})
+
+	t.Run("SC28: Updating a mutation leg updates both", func(t *testing.T) {
+		base := time.Date(2024, 10, 5, 8, 0, 0, 0, time.UTC)
+		orgA, orgB := setupMutationOrgs(t, "MUT-UPD", base, 100)
+
+		err := testService.CreateMutation(services.MutationRequest{
+			FromOrganizationID: orgA, ToOrganizationID: orgB, ItemID: testItemID,
+			Quantity: models.Qty(30), TxnDate: base.Add(2 * time.Hour), ChangedBy: "mutation_test",
+		})
+		assertNoError(t, err)
+
+		outLeg := findMutationLeg(t, orgA)
+		err = testService.UpdateTransaction(services.UpdateTransactionRequest{
+			InventoryID: outLeg.ID,
+			TxnDate:     base.Add(time.Hour),
+			Amount:      models.Qty(-45),
+			ChangedBy:   "mutation_test",
+		})
+		assertNoError(t, err)
+
+		assertEqual(t, 55, assertBalanceChain(t, orgA, testItemID))
+		assertEqual(t, 145, assertBalanceChain(t, orgB, testItemID))
+
+		newOut, newIn := findMutationLeg(t, orgA), findMutationLeg(t, orgB)
+		assertEqual(t, *outLeg.RefID, *newIn.RefID)
+		assertEqual(t, true, newOut.TxnDate.Equal(newIn.TxnDate))
+
+		// Arah mutasi tidak boleh dibalik lewat update
+		err = testService.UpdateTransaction(services.UpdateTransactionRequest{
+			InventoryID: newOut.ID,
+			TxnDate:     base.Add(time.Hour),
+			Amount:      models.Qty(45),
+			ChangedBy:   "mutation_test",
+		})
+		assertError(t, err, "mutation amount cannot change direction")
+	})
+
+	t.Run("SC29: Rollback restores the counterpart leg", func(t *testing.T) {
+		base := time.Date(2024, 10, 10, 8, 0, 0, 0, time.UTC)
+		orgA, orgB := setupMutationOrgs(t, "MUT-RB", base, 100)
+
+		err := testService.CreateMutation(services.MutationRequest{
+			FromOrganizationID: orgA, ToOrganizationID: orgB, ItemID: testItemID,
+			Quantity: models.Qty(20), TxnDate: base.Add(time.Hour), ChangedBy: "mutation_test",
+		})
+		assertNoError(t, err)
+
+		outLeg := findMutationLeg(t, orgA)
+		assertNoError(t, testService.DeleteTransaction(outLeg.ID, "mutation_test", nil))
+		assertEqual(t, 100, assertBalanceChain(t, orgB, testItemID))
+
+		// Rollback history DELETE_BEFORE org asal → kedua leg kembali
+		var history models.InventoryHistory
+		err = testDB.Where("organization_id = ? AND item_id = ? AND action = ?", orgA, testItemID, "DELETE_BEFORE").
+			Take(&history).Error
+		assertNoError(t, err)
+
+		assertNoError(t, testService.RollbackTransaction(history.ID, "mutation_test", nil))
+
+		assertEqual(t, 80, assertBalanceChain(t, orgA, testItemID))
+		assertEqual(t, 120, assertBalanceChain(t, orgB, testItemID))
+		assertEqual(t, *outLeg.RefID, *findMutationLeg(t, orgB).RefID)
+	})
+}
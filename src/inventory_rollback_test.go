@@ -0,0 +1,72 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/services"
+)
+
+// ============ TEST SCENARIO 13: ROLLBACK PREVIEW ============
+func TestRollbackPreview(t *testing.T) {
+	orgID := uuid.New()
+	testDB.Create(&models.Organization{ID: orgID, Name: "Preview Org", Code: "ORG-PREVIEW"})
+
+	base := time.Date(2024, 11, 1, 8, 0, 0, 0, time.UTC)
+	post := func(offset time.Duration, amount int, txnType string) *models.Inventory {
+		inv, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID,
+			ItemID:         testItemID,
+			TxnDate:        base.Add(offset),
+			Amount:         models.Qty(int64(amount)),
+			Type:           txnType,
+			ChangedBy:      "preview_test",
+		})
+		assertNoError(t, err)
+		return inv
+	}
+
+	post(0, 100, "stok_awal")
+	receipt := post(24*time.Hour, 50, "penerimaan")
+
+	var history models.InventoryHistory
+	err := testDB.Where("trigger_inventory_id = ? AND action = ?", receipt.ID, "CREATE").Take(&history).Error
+	assertNoError(t, err)
+
+	// Dibuat setelah history → akan dibuang oleh rollback
+	usage := post(48*time.Hour, -30, "pemakaian")
+
+	t.Run("SC30: Preview reports effect without changing anything", func(t *testing.T) {
+		var historiesBefore int64
+		testDB.Model(&models.InventoryHistory{}).Where("organization_id = ?", orgID).Count(&historiesBefore)
+
+		preview, err := testService.PreviewRollback(history.ID, "preview_test")
+		assertNoError(t, err)
+
+		assertEqual(t, 2, len(preview.SoftDeleted))
+		assertEqual(t, 1, len(preview.Recreated))
+		assertEqual(t, 50, preview.Recreated[0].Amount)
+		assertEqual(t, 1, len(preview.Warnings))
+
+		assertEqual(t, 2, len(preview.Balances))
+		assertEqual(t, 150, preview.Balances[0].BalanceBefore)
+		assertEqual(t, 150, preview.Balances[0].BalanceAfter)
+		assertEqual(t, 120, preview.Balances[1].BalanceBefore)
+		assertEqual(t, 150, preview.Balances[1].BalanceAfter)
+
+		// Tidak ada yang tersimpan
+		current, err := testService.GetCurrentBalance(orgID, testItemID)
+		assertNoError(t, err)
+		assertEqual(t, 120, current)
+
+		var stillActive models.Inventory
+		assertNoError(t, testDB.First(&stillActive, "id = ?", usage.ID).Error)
+
+		var historiesAfter int64
+		testDB.Model(&models.InventoryHistory{}).Where("organization_id = ?", orgID).Count(&historiesAfter)
+		assertEqual(t, historiesBefore, historiesAfter)
+	})
+}
@@ -0,0 +1,124 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/services"
+)
+
+// ============ TEST SCENARIO 15: LEDGER HASH CHAIN ============
+func TestLedgerHashChain(t *testing.T) {
+	orgID := uuid.New()
+	testDB.Create(&models.Organization{ID: orgID, Name: "Chain Org", Code: "ORG-CHAIN"})
+
+	chainService := &services.ChainService{DB: testDB, Inventory: testService}
+
+	base := time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC)
+	post := func(offset time.Duration, amount int, txnType string) *models.Inventory {
+		inv, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID,
+			ItemID:         testItemID,
+			TxnDate:        base.Add(offset),
+			Amount:         models.Qty(int64(amount)),
+			Type:           txnType,
+			ChangedBy:      "chain_test",
+		})
+		assertNoError(t, err)
+		return inv
+	}
+
+	stokAwal := post(0, 100, "stok_awal")
+	receipt := post(24*time.Hour, 50, "penerimaan")
+	usage := post(48*time.Hour, -30, "pemakaian")
+
+	// Update → soft delete + RecalculateForward menulis ulang balance baris setelahnya
+	err := testService.UpdateTransaction(services.UpdateTransactionRequest{
+		InventoryID: receipt.ID,
+		TxnDate:     receipt.TxnDate,
+		Amount:      models.Qty(60),
+		ChangedBy:   "chain_test",
+	})
+	assertNoError(t, err)
+
+	t.Run("SC32: Chain stays valid across recalculation and soft delete", func(t *testing.T) {
+		var stored models.Inventory
+		assertNoError(t, testDB.First(&stored, "id = ?", usage.ID).Error)
+		assertEqual(t, 130, stored.Balance, "usage balance recalculated")
+		if stored.ChainSeq == nil || stored.RowHash == nil || stored.PrevHash == nil {
+			t.Fatalf("usage row is not chained: seq=%v", stored.ChainSeq)
+		}
+
+		result, err := chainService.Verify(orgID, testItemID)
+		assertNoError(t, err)
+		assertEqual(t, true, result.Valid, "chain valid")
+		assertEqual(t, 2, result.Chains, "inventory + history chain")
+		assertEqual(t, 0, result.Unsealed, "unsealed rows")
+	})
+
+	t.Run("SC33: Direct SQL edits are reported at the first broken link", func(t *testing.T) {
+		assertNoError(t, testDB.Exec("UPDATE inventories SET amount = amount - 5 WHERE id = ?", usage.ID).Error)
+
+		result, err := chainService.Verify(orgID, testItemID)
+		assertNoError(t, err)
+		assertEqual(t, false, result.Valid, "chain valid after tamper")
+		assertEqual(t, 1, len(result.Broken), "broken chains")
+		assertEqual(t, "inventories", result.Broken[0].Table, "broken table")
+		assertEqual(t, usage.ID, result.Broken[0].Break.ID, "broken row")
+
+		assertNoError(t, testDB.Exec("UPDATE inventories SET amount = amount + 5 WHERE id = ?", usage.ID).Error)
+
+		var history models.InventoryHistory
+		assertNoError(t, testDB.Where("trigger_inventory_id = ? AND action = ?", usage.ID, "CREATE").Take(&history).Error)
+		assertNoError(t, testDB.Exec("UPDATE inventory_histories SET changed_by = ? WHERE id = ?", "mallory", history.ID).Error)
+
+		result, err = chainService.Verify(orgID, testItemID)
+		assertNoError(t, err)
+		assertEqual(t, 1, len(result.Broken), "broken chains")
+		assertEqual(t, "inventory_histories", result.Broken[0].Table, "broken table")
+		assertEqual(t, history.ID, result.Broken[0].Break.ID, "broken row")
+
+		assertNoError(t, testDB.Exec("UPDATE inventory_histories SET changed_by = ? WHERE id = ?", history.ChangedBy, history.ID).Error)
+
+		result, err = chainService.Verify(orgID, testItemID)
+		assertNoError(t, err)
+		assertEqual(t, true, result.Valid, "chain valid after restore")
+	})
+	t.Run("SC68: Soft deletes must be backed by a chained history row", func(t *testing.T) {
+		// Delete & rollback lewat service tetap valid
+		assertNoError(t, testService.DeleteTransaction(usage.ID, "chain_test", nil))
+		var deleteHistory models.InventoryHistory
+		assertNoError(t, testDB.Where("trigger_inventory_id = ? AND action = ?", usage.ID, "DELETE_BEFORE").Take(&deleteHistory).Error)
+		assertNoError(t, testService.RollbackTransaction(deleteHistory.ID, "chain_test", nil))
+
+		result, err := chainService.Verify(orgID, testItemID)
+		assertNoError(t, err)
+		assertEqual(t, true, result.Valid, "chain valid after delete and rollback")
+
+		// Hapus langsung lewat SQL: saldo berubah tanpa history
+		assertNoError(t, testDB.Exec("UPDATE inventories SET deleted_at = now(), deleted_by = 'mallory' WHERE id = ?", stokAwal.ID).Error)
+		result, err = chainService.Verify(orgID, testItemID)
+		assertNoError(t, err)
+		assertEqual(t, false, result.Valid, "chain valid after SQL soft delete")
+		assertEqual(t, "inventories", result.Broken[0].Table, "broken table")
+		assertEqual(t, stokAwal.ID, result.Broken[0].Break.ID, "deleted row")
+		assertNoError(t, testDB.Exec("UPDATE inventories SET deleted_at = NULL, deleted_by = NULL WHERE id = ?", stokAwal.ID).Error)
+
+		// Menghidupkan lagi baris yang dihapus DELETE_BEFORE juga terdeteksi
+		var deleted models.Inventory
+		assertNoError(t, testDB.Unscoped().First(&deleted, "id = ?", usage.ID).Error)
+		assertNoError(t, testDB.Exec("UPDATE inventories SET deleted_at = NULL WHERE id = ?", usage.ID).Error)
+		result, err = chainService.Verify(orgID, testItemID)
+		assertNoError(t, err)
+		assertEqual(t, false, result.Valid, "chain valid after SQL undelete")
+		assertEqual(t, usage.ID, result.Broken[0].Break.ID, "undeleted row")
+		assertNoError(t, testDB.Exec("UPDATE inventories SET deleted_at = ? WHERE id = ?", deleted.DeletedAt.Time, usage.ID).Error)
+
+		result, err = chainService.Verify(orgID, testItemID)
+		assertNoError(t, err)
+		assertEqual(t, true, result.Valid, "chain valid after restore")
+	})
+}
@@ -0,0 +1,145 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+	"inventory-ledger/src/services"
+)
+
+// ============ TEST SCENARIO 27: BIN / LOCATION HIERARCHY ============
+func TestLocationHierarchy(t *testing.T) {
+	locationService := &services.LocationService{
+		DB:   testDB,
+		Repo: &repositories.LocationRepository{DB: testDB},
+	}
+
+	orgID := uuid.New()
+	otherOrgID := uuid.New()
+	testDB.Create(&models.Organization{ID: orgID, Name: "Location Org", Code: "ORG-LOC"})
+	testDB.Create(&models.Organization{ID: otherOrgID, Name: "Location Other", Code: "ORG-LOC-2"})
+
+	base := time.Date(2025, 10, 1, 8, 0, 0, 0, time.UTC)
+
+	zone, err := locationService.CreateLocation(orgID, services.LocationRequest{Code: "Z1", Name: "Zona 1"})
+	assertNoError(t, err)
+	binA, err := locationService.CreateLocation(orgID, services.LocationRequest{Code: "Z1-A", Name: "Bin A", ParentID: &zone.ID})
+	assertNoError(t, err)
+	binB, err := locationService.CreateLocation(orgID, services.LocationRequest{Code: "Z1-B", Name: "Bin B", ParentID: &zone.ID})
+	assertNoError(t, err)
+
+	// Saldo item di summary: roll-up satu baris per item, drill-down satu baris per item+lokasi
+	itemRows := func(scope repositories.LocationScope) []map[string]interface{} {
+		summary, err := testService.GetOrganizationSummary(orgID, scope)
+		assertNoError(t, err)
+		rows := make([]map[string]interface{}, 0)
+		for _, row := range summary {
+			if row["item_id"] == testItemID {
+				rows = append(rows, row)
+			}
+		}
+		return rows
+	}
+
+	t.Run("SC57: Moves between bins keep the org balance and roll up by location", func(t *testing.T) {
+		_, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: testItemID, TxnDate: base, Amount: models.Qty(100),
+			Type: "stok_awal", LocationID: &binA.ID, ChangedBy: "location_test",
+		})
+		assertNoError(t, err)
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: testItemID, TxnDate: base.Add(time.Hour), Amount: models.Qty(10),
+			Type: "penerimaan", ChangedBy: "location_test",
+		})
+		assertNoError(t, err)
+
+		assertNoError(t, testService.CreateLocationMove(services.LocationMoveRequest{
+			OrganizationID: orgID, ItemID: testItemID, FromLocationID: &binA.ID, ToLocationID: &binB.ID,
+			Quantity: models.Qty(40), TxnDate: base.Add(2 * time.Hour), ChangedBy: "location_test",
+		}))
+		assertEqual(t, 110, assertBalanceChain(t, orgID, testItemID), "org balance unchanged by move")
+
+		var legs []models.Inventory
+		testDB.Where("organization_id = ? AND type = ? AND deleted_at IS NULL", orgID, models.InventoryTypeLocationMove).
+			Order("amount").Find(&legs)
+		assertEqual(t, 2, len(legs), "move legs")
+		assertEqual(t, *legs[0].RefID, *legs[1].RefID, "legs share ref_id")
+		assertEqual(t, binA.ID, *legs[0].LocationID, "out leg at source bin")
+		assertEqual(t, binB.ID, *legs[1].LocationID, "in leg at destination bin")
+
+		rollup := itemRows(repositories.LocationScope{LocationID: &zone.ID})
+		assertEqual(t, 1, len(rollup), "roll-up rows")
+		assertEqual(t, 100, rollup[0]["current_stock"], "zone rolls up both bins")
+
+		drill := itemRows(repositories.LocationScope{DrillDown: true})
+		assertEqual(t, 3, len(drill), "drill-down rows incl. unassigned")
+		assertEqual(t, 60, drill[0]["current_stock"], "bin A")
+		assertEqual(t, 40, drill[1]["current_stock"], "bin B")
+		assertEqual(t, (*uuid.UUID)(nil), drill[2]["location_id"], "unassigned row last")
+		assertEqual(t, 10, drill[2]["current_stock"], "unassigned")
+
+		// Hapus satu leg menghapus kedua leg
+		assertNoError(t, testService.DeleteTransaction(legs[1].ID, "location_test", nil))
+		var remaining int64
+		testDB.Model(&models.Inventory{}).
+			Where("organization_id = ? AND type = ? AND deleted_at IS NULL", orgID, models.InventoryTypeLocationMove).
+			Count(&remaining)
+		assertEqual(t, int64(0), remaining, "both legs deleted")
+
+		binRows := itemRows(repositories.LocationScope{LocationID: &binA.ID, DrillDown: true})
+		assertEqual(t, 1, len(binRows), "bin A drill-down rows")
+		assertEqual(t, 100, binRows[0]["current_stock"], "bin A restored")
+		assertEqual(t, 110, assertBalanceChain(t, orgID, testItemID), "org balance after delete")
+	})
+
+	t.Run("SC58: Location rules reject bad trees, foreign bins and overdrawn moves", func(t *testing.T) {
+		move := func(from, to *uuid.UUID, quantity decimal.Decimal) error {
+			return testService.CreateLocationMove(services.LocationMoveRequest{
+				OrganizationID: orgID, ItemID: testItemID, FromLocationID: from, ToLocationID: to,
+				Quantity: quantity, TxnDate: base.Add(3 * time.Hour), ChangedBy: "location_test",
+			})
+		}
+
+		// Zona tidak boleh menjadi anak bin-nya sendiri
+		_, err := locationService.UpdateLocation(orgID, zone.ID, services.LocationRequest{Code: "Z1", Name: "Zona 1", ParentID: &binA.ID})
+		assertError(t, err, services.ErrInvalidLocationParent.Error())
+		_, err = locationService.CreateLocation(orgID, services.LocationRequest{Code: "Z1-A", Name: "Duplikat"})
+		assertError(t, err, services.ErrLocationCodeTaken.Error())
+
+		foreign, err := locationService.CreateLocation(otherOrgID, services.LocationRequest{Code: "Z1", Name: "Zona lain"})
+		assertNoError(t, err)
+		_, err = locationService.CreateLocation(orgID, services.LocationRequest{Code: "Z2", Name: "Zona 2", ParentID: &foreign.ID})
+		assertError(t, err, services.ErrInvalidLocationParent.Error())
+		assertError(t, move(&binA.ID, &foreign.ID, models.Qty(1)), services.ErrLocationNotFound.Error())
+		_, err = testService.GetOrganizationSummary(orgID, repositories.LocationScope{LocationID: &foreign.ID})
+		assertError(t, err, services.ErrLocationNotFound.Error())
+
+		assertError(t, move(&binA.ID, &binA.ID, models.Qty(1)), services.ErrInvalidLocationMove.Error())
+
+		// Policy block: bin B kosong, tidak bisa dipindah walaupun saldo org cukup
+		assertError(t, move(&binB.ID, &binA.ID, models.Qty(1)), services.ErrInsufficientLocationStock.Error())
+		// Stok yang belum ditempatkan bisa dipindah ke bin
+		assertNoError(t, move(nil, &binB.ID, models.Qty(10)))
+		assertError(t, move(nil, &binB.ID, models.Qty(1)), services.ErrInsufficientLocationStock.Error())
+
+		// Pemakaian backdated dari bin B sebelum stok ditempatkan ditolak di chokepoint
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: testItemID, TxnDate: base.Add(150 * time.Minute), Amount: models.Qty(-5),
+			Type: "pemakaian", LocationID: &binB.ID, ChangedBy: "location_test",
+		})
+		if !errors.Is(err, services.ErrInsufficientLocationStock) {
+			t.Fatalf("expected insufficient location stock, got %v", err)
+		}
+
+		_, err = locationService.SetLocationActive(orgID, binB.ID, false)
+		assertNoError(t, err)
+		assertError(t, move(&binA.ID, &binB.ID, models.Qty(1)), services.ErrLocationInactive.Error())
+		assertEqual(t, 110, assertBalanceChain(t, orgID, testItemID), "org balance after rejected postings")
+	})
+}
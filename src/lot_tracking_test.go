@@ -0,0 +1,245 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+	"inventory-ledger/src/services"
+)
+
+// ============ TEST SCENARIO 24: LOT & EXPIRY TRACKING ============
+func TestLotTracking(t *testing.T) {
+	itemService := &services.ItemService{
+		DB:        testDB,
+		Repo:      &repositories.ItemRepository{DB: testDB},
+		Inventory: testService,
+	}
+
+	orgID := uuid.New()
+	branchID := uuid.New()
+	testDB.Create(&models.Organization{ID: orgID, Name: "Lot Org", Code: "ORG-LOT"})
+	testDB.Create(&models.Organization{ID: branchID, Name: "Lot Branch", Code: "ORG-LOT-2"})
+
+	trackLots := true
+	base := time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)
+	today := time.Now().UTC()
+	lot := func(s string) *string { return &s }
+	expiry := func(days int) *time.Time {
+		d := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, days)
+		return &d
+	}
+
+	lotBalances := func(orgID uuid.UUID, itemID uint) map[string]decimal.Decimal {
+		lots, err := testService.GetLotBalances(orgID, itemID)
+		assertNoError(t, err)
+		result := make(map[string]decimal.Decimal, len(lots))
+		for _, l := range lots {
+			result[l.LotNumber] = l.Balance
+		}
+		return result
+	}
+
+	t.Run("SC51: Pemakaian allocates FEFO or explicit lots and never overdraws a lot", func(t *testing.T) {
+		item, err := itemService.CreateItem(services.ItemRequest{Code: "ITEM-LOT", Name: "Lot Item", Unit: "pcs", TrackLots: &trackLots})
+		assertNoError(t, err)
+
+		receipts := []struct {
+			lot    string
+			expiry *time.Time
+			qty    int
+		}{
+			{"LOT-LATE", expiry(90), 10},
+			{"LOT-EARLY", expiry(10), 5},
+			{"LOT-NOEXP", nil, 8},
+		}
+		for i, r := range receipts {
+			txnType := "penerimaan"
+			if i == 0 {
+				txnType = "stok_awal"
+			}
+			_, err := testService.CreateTransaction(services.CreateTransactionRequest{
+				OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, i), Amount: models.Qty(int64(r.qty)), Type: txnType,
+				LotNumber: lot(r.lot), ExpiryDate: r.expiry, ChangedBy: "lot_test",
+			})
+			assertNoError(t, err)
+		}
+
+		// Lot wajib untuk stok masuk, expiry lot yang sudah ada tidak boleh berbeda
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 3), Amount: models.Qty(1), Type: "penerimaan",
+			ChangedBy: "lot_test",
+		})
+		assertError(t, err, services.ErrLotRequired.Error())
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 3), Amount: models.Qty(1), Type: "penerimaan",
+			LotNumber: lot("LOT-EARLY"), ExpiryDate: expiry(11), ChangedBy: "lot_test",
+		})
+		assertError(t, err, services.ErrLotExpiryMismatch.Error())
+
+		// FEFO: 5 dari LOT-EARLY lalu 2 dari LOT-LATE, response = baris terakhir
+		issue, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 4), Amount: models.Qty(-7), Type: "pemakaian",
+			ChangedBy: "lot_test",
+		})
+		assertNoError(t, err)
+		assertEqual(t, "LOT-LATE", *issue.LotNumber, "last fefo row lot")
+		assertEqual(t, -2, issue.Amount, "last fefo row amount")
+		assertEqual(t, 16, issue.Balance, "balance after fefo issue")
+
+		balances := lotBalances(orgID, item.ID)
+		assertEqual(t, 0, balances["LOT-EARLY"], "early lot consumed")
+		assertEqual(t, 8, balances["LOT-LATE"], "late lot remaining")
+		assertEqual(t, 8, balances["LOT-NOEXP"], "lot without expiry untouched")
+
+		// Lot eksplisit
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 5), Amount: models.Qty(-3), Type: "pemakaian",
+			Lots: []services.LotQuantity{{LotNumber: "LOT-NOEXP", Quantity: models.Qty(3)}}, ChangedBy: "lot_test",
+		})
+		assertNoError(t, err)
+		assertEqual(t, 5, lotBalances(orgID, item.ID)["LOT-NOEXP"], "explicit lot issued")
+
+		// Lot yang diminta tidak cukup, walaupun total saldo item cukup
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 5), Amount: models.Qty(-6), Type: "pemakaian",
+			LotNumber: lot("LOT-NOEXP"), ChangedBy: "lot_test",
+		})
+		assertError(t, err, services.ErrInsufficientLotStock.Error()+" LOT-NOEXP")
+
+		// Total lot harus sama dengan qty keluar
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 5), Amount: models.Qty(-4), Type: "pemakaian",
+			Lots: []services.LotQuantity{{LotNumber: "LOT-LATE", Quantity: models.Qty(3)}}, ChangedBy: "lot_test",
+		})
+		assertError(t, err, services.ErrInvalidLotAllocation.Error())
+
+		// Opname tidak didukung untuk item ber-lot
+		_, err = testService.CreateOpname(services.OpnameRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 6), PhysicalQty: models.Qty(10), ChangedBy: "lot_test",
+		})
+		assertError(t, err, services.ErrLotOpnameNotSupported.Error())
+
+		// track_lots terkunci setelah ada posting
+		off := false
+		_, err = itemService.UpdateItem(item.ID, services.ItemRequest{Code: item.Code, Name: item.Name, Unit: item.Unit, TrackLots: &off})
+		assertError(t, err, services.ErrLotTrackingLocked.Error())
+
+		// Item tanpa lot menolak field lot
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: testItemID, TxnDate: base, Amount: models.Qty(1), Type: "penerimaan",
+			LotNumber: lot("LOT-X"), ChangedBy: "lot_test",
+		})
+		assertError(t, err, services.ErrLotNotTracked.Error())
+	})
+
+	t.Run("SC52: Mutations carry lots to the destination and expiring report lists them", func(t *testing.T) {
+		item, err := itemService.CreateItem(services.ItemRequest{Code: "ITEM-LOT-MUT", Name: "Lot Mutation Item", Unit: "pcs", TrackLots: &trackLots})
+		assertNoError(t, err)
+
+		for i, r := range []struct {
+			lot  string
+			days int
+			qty  int
+		}{{"MUT-A", 5, 4}, {"MUT-B", 60, 10}} {
+			txnType := "penerimaan"
+			if i == 0 {
+				txnType = "stok_awal"
+			}
+			_, err := testService.CreateTransaction(services.CreateTransactionRequest{
+				OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, i), Amount: models.Qty(int64(r.qty)), Type: txnType,
+				LotNumber: lot(r.lot), ExpiryDate: expiry(r.days), ChangedBy: "lot_test",
+			})
+			assertNoError(t, err)
+		}
+
+		// FEFO: 4 dari MUT-A + 2 dari MUT-B, satu pasang leg per lot
+		assertNoError(t, testService.CreateMutation(services.MutationRequest{
+			FromOrganizationID: orgID, ToOrganizationID: branchID, ItemID: item.ID,
+			Quantity: models.Qty(6), TxnDate: base.AddDate(0, 0, 2), ChangedBy: "lot_test",
+		}))
+
+		var legs []models.Inventory
+		assertNoError(t, testDB.Where("organization_id = ? AND item_id = ? AND type = ?", branchID, item.ID, models.InventoryTypeMutation).
+			Order("lot_number").Find(&legs).Error)
+		assertEqual(t, 2, len(legs), "incoming legs per lot")
+		assertEqual(t, "MUT-A", *legs[0].LotNumber, "first leg lot")
+		assertEqual(t, 4, legs[0].Amount, "first leg qty")
+		assertEqual(t, true, legs[0].ExpiryDate != nil && legs[0].ExpiryDate.Equal(*expiry(5)), "leg carries expiry")
+		assertEqual(t, true, *legs[0].RefID != *legs[1].RefID, "each lot pair has its own ref_id")
+
+		branch := lotBalances(branchID, item.ID)
+		assertEqual(t, 4, branch["MUT-A"], "branch lot A")
+		assertEqual(t, 2, branch["MUT-B"], "branch lot B")
+
+		// Mutasi lot eksplisit yang melebihi saldo lot di org asal ditolak
+		err = testService.CreateMutation(services.MutationRequest{
+			FromOrganizationID: orgID, ToOrganizationID: branchID, ItemID: item.ID,
+			Quantity: models.Qty(1), TxnDate: base.AddDate(0, 0, 3), Lots: []services.LotQuantity{{LotNumber: "MUT-A", Quantity: models.Qty(1)}},
+			ChangedBy: "lot_test",
+		})
+		assertError(t, err, services.ErrInsufficientLotStock.Error()+" MUT-A")
+
+		// Expiring dalam 30 hari: hanya MUT-A (di cabang), MUT-B masih 60 hari
+		expiring, total, err := testService.GetExpiringLots(services.ExpiringLotsRequest{
+			ItemID: item.ID, Days: 30, Page: 1, Limit: 50,
+		})
+		assertNoError(t, err)
+		assertEqual(t, int64(1), total, "expiring lots")
+		assertEqual(t, branchID, expiring[0].OrganizationID, "expiring lot org")
+		assertEqual(t, "MUT-A", expiring[0].LotNumber, "expiring lot number")
+		assertEqual(t, 5, expiring[0].DaysToExpiry, "days to expiry")
+		assertEqual(t, false, expiring[0].Expired, "not yet expired")
+
+		_, total, err = testService.GetExpiringLots(services.ExpiringLotsRequest{
+			ItemID: item.ID, Days: 90, Page: 1, Limit: 50,
+		})
+		assertNoError(t, err)
+		assertEqual(t, int64(3), total, "expiring lots within 90 days")
+	})
+
+	t.Run("SC66: Backdated issues cannot drain a lot that is emptied and refilled later", func(t *testing.T) {
+		item, err := itemService.CreateItem(services.ItemRequest{Code: "ITEM-LOT-DIP", Name: "Lot Dip Item", Unit: "pcs", TrackLots: &trackLots})
+		assertNoError(t, err)
+
+		post := func(day int, amount int64, txnType string) (*models.Inventory, error) {
+			return testService.CreateTransaction(services.CreateTransactionRequest{
+				OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, day), Amount: models.Qty(amount), Type: txnType,
+				LotNumber: lot("LOT-DIP"), ChangedBy: "lot_test",
+			})
+		}
+		opening, err := post(0, 10, "stok_awal")
+		assertNoError(t, err)
+		_, err = post(9, -10, "pemakaian")
+		assertNoError(t, err)
+		_, err = post(19, 10, "penerimaan")
+		assertNoError(t, err)
+
+		// Saldo akhir lot 10, tapi lot habis di hari ke-9: pemakaian hari ke-4 ditolak
+		_, err = post(4, -10, "pemakaian")
+		assertError(t, err, services.ErrInsufficientLotStock.Error()+" LOT-DIP")
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 4), Amount: models.Qty(-10), Type: "pemakaian",
+			ChangedBy: "lot_test",
+		})
+		if !errors.Is(err, services.ErrInsufficientLotStock) {
+			t.Fatalf("expected insufficient lot stock for fefo issue, got %v", err)
+		}
+
+		// Chokepoint: mengecilkan penerimaan awal membuat saldo berjalan lot minus di hari ke-9
+		err = testService.UpdateTransaction(services.UpdateTransactionRequest{
+			InventoryID: opening.ID, TxnDate: opening.TxnDate, Amount: models.Qty(5), ChangedBy: "lot_test",
+		})
+		assertError(t, err, services.ErrInsufficientLotStock.Error()+" LOT-DIP")
+
+		// Setelah diterima lagi, lot bisa dipakai
+		_, err = post(24, -10, "pemakaian")
+		assertNoError(t, err)
+		assertEqual(t, 0, lotBalances(orgID, item.ID)["LOT-DIP"], "lot balance")
+	})
+}
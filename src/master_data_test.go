@@ -0,0 +1,108 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+	"inventory-ledger/src/services"
+)
+
+// ============ TEST SCENARIO 11: MASTER DATA ============
+func TestMasterData(t *testing.T) {
+	orgService := &services.OrganizationService{
+		DB:   testDB,
+		Repo: &repositories.OrganizationRepository{DB: testDB},
+	}
+	itemService := &services.ItemService{
+		DB:   testDB,
+		Repo: &repositories.ItemRepository{DB: testDB},
+	}
+
+	t.Run("SC25: Organization and item codes stay unique", func(t *testing.T) {
+		org, err := orgService.CreateOrganization(services.OrganizationRequest{Name: "Master Org", Code: "ORG-MASTER"})
+		assertNoError(t, err)
+		assertEqual(t, true, org.IsActive)
+
+		_, err = orgService.CreateOrganization(services.OrganizationRequest{Name: "Duplicate", Code: "ORG-MASTER"})
+		assertError(t, err, "organization code already exists")
+
+		// Update ke kode milik organisasi lain ditolak, kode sendiri boleh
+		_, err = orgService.UpdateOrganization(org.ID, services.OrganizationRequest{Name: "Master Org", Code: "ORG001"})
+		assertError(t, err, "organization code already exists")
+		_, err = orgService.UpdateOrganization(org.ID, services.OrganizationRequest{Name: "Master Org Renamed", Code: "ORG-MASTER"})
+		assertNoError(t, err)
+
+		_, err = itemService.CreateItem(services.ItemRequest{Code: "ITEM001", Name: "Duplicate", Unit: "pcs"})
+		assertError(t, err, "item code already exists")
+
+		orgs, total, err := orgService.ListOrganizations(repositories.MasterDataFilter{Query: "renamed", Page: 1, Limit: 20})
+		assertNoError(t, err)
+		assertEqual(t, int64(1), total)
+		assertEqual(t, org.ID, orgs[0].ID)
+	})
+
+	t.Run("SC26: Postings against unknown or inactive master data are rejected", func(t *testing.T) {
+		org, err := orgService.CreateOrganization(services.OrganizationRequest{Name: "Inactive Org", Code: "ORG-INACTIVE"})
+		assertNoError(t, err)
+		item, err := itemService.CreateItem(services.ItemRequest{Code: "ITEM-INACTIVE", Name: "Inactive Item", Unit: "pcs"})
+		assertNoError(t, err)
+
+		txnDate := time.Date(2024, 9, 1, 10, 0, 0, 0, time.UTC)
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: org.ID, ItemID: item.ID, TxnDate: txnDate,
+			Amount: models.Qty(10), Type: "stok_awal", ChangedBy: "master_test",
+		})
+		assertNoError(t, err)
+
+		_, err = orgService.SetOrganizationActive(org.ID, false)
+		assertNoError(t, err)
+
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: org.ID, ItemID: item.ID, TxnDate: txnDate.Add(time.Hour),
+			Amount: models.Qty(5), Type: "penerimaan", ChangedBy: "master_test",
+		})
+		assertError(t, err, "organization is inactive")
+
+		_, err = testService.CreateOpname(services.OpnameRequest{
+			OrganizationID: org.ID, ItemID: item.ID, TxnDate: txnDate.Add(time.Hour),
+			PhysicalQty: models.Qty(8), ChangedBy: "master_test",
+		})
+		assertError(t, err, "organization is inactive")
+
+		// History & saldo tetap bisa dibaca
+		balance, err := testService.GetCurrentBalance(org.ID, item.ID)
+		assertNoError(t, err)
+		assertEqual(t, 10, balance)
+
+		_, err = orgService.SetOrganizationActive(org.ID, true)
+		assertNoError(t, err)
+		_, err = itemService.SetItemActive(item.ID, false)
+		assertNoError(t, err)
+
+		err = testService.CreateMutation(services.MutationRequest{
+			FromOrganizationID: org.ID, ToOrganizationID: testOrg1ID, ItemID: item.ID,
+			Quantity: models.Qty(1), TxnDate: txnDate.Add(time.Hour), ChangedBy: "master_test",
+		})
+		assertError(t, err, "item is inactive")
+
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: uuid.New(), ItemID: testItemID, TxnDate: txnDate,
+			Amount: models.Qty(5), Type: "penerimaan", ChangedBy: "master_test",
+		})
+		assertError(t, err, "organization not found")
+
+		results, err := testService.CreateTransactionBatch([]services.CreateTransactionRequest{
+			{OrganizationID: testOrg1ID, ItemID: 9999, TxnDate: txnDate, Amount: models.Qty(5), Type: "penerimaan", ChangedBy: "master_test"},
+		}, "master_test", nil)
+		assertError(t, err, "batch rejected, no lines were posted")
+		assertEqual(t, "item not found", results[0].Error)
+
+		var count int64
+		testDB.Model(&models.Inventory{}).Where("item_id = ?", 9999).Count(&count)
+		assertEqual(t, int64(0), count)
+	})
+}
@@ -0,0 +1,197 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+)
+
+const IdempotencyHeader = "Idempotency-Key"
+
+// responseRecorder - Tee response body supaya bisa disimpan untuk replay
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency - Honor header Idempotency-Key: replay response lama untuk request identik,
+// 409 untuk key yang sama dengan body berbeda atau yang masih diproses. Key pending yang lebih
+// tua dari pendingTTL dianggap bebas; body lebih dari maxBodyBytes ditolak 413.
+func Idempotency(repo *repositories.IdempotencyRepository, maxBodyBytes int64, pendingTTL time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(IdempotencyHeader))
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+
+		requestHash, err := hashRequest(c, maxBodyBytes)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+
+		// Presisi timestamptz: CreatedAt dibandingkan lagi saat Complete/Release
+		now := time.Now().Truncate(time.Microsecond)
+		record := &models.IdempotencyKey{
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        c.FullPath(),
+			RequestHash: requestHash,
+			Status:      models.IdempotencyStatusPending,
+			CreatedAt:   now,
+		}
+
+		reserved, existing, err := repo.Reserve(record, now.Add(-pendingTTL))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !reserved {
+			if existing.RequestHash != requestHash {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "idempotency key already used with a different request"})
+				return
+			}
+			if existing.Status != models.IdempotencyStatusCompleted {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "request with this idempotency key is still in progress"})
+				return
+			}
+
+			log.Printf("Idempotent replay: key=%s path=%s", key, existing.Path)
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(existing.ResponseCode, "application/json; charset=utf-8", existing.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// Error server dan panic handler (Recovery ada di luar middleware ini) tidak disimpan
+		// supaya client bisa retry dengan key yang sama
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := repo.Release(record); err != nil {
+				log.Printf("Failed to release idempotency key %s: %v", key, err)
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		var responseBody []byte
+		if recorder.body.Len() > 0 {
+			responseBody = recorder.body.Bytes()
+		}
+		completed = true
+		if err := repo.Complete(record, status, responseBody); err != nil {
+			log.Printf("Failed to store idempotent response for key %s: %v", key, err)
+		}
+	}
+}
+
+// hashRequest - Hash method + URI + body. Upload multipart (/import) di-hash dari field dan isi
+// file yang sudah di-parse, karena retry client memakai boundary baru.
+func hashRequest(c *gin.Context, maxBodyBytes int64) (string, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes)
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+
+	if c.ContentType() == binding.MIMEMultipartPOSTForm {
+		form, err := c.MultipartForm()
+		if err != nil {
+			return "", err
+		}
+		if err := hashMultipartForm(hash, form); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return "", err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashMultipartForm - Field dan file diurutkan per nama; setiap bagian diberi panjang supaya
+// batas antar field tidak ambigu
+func hashMultipartForm(hash io.Writer, form *multipart.Form) error {
+	names := make([]string, 0, len(form.Value))
+	for name := range form.Value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range form.Value[name] {
+			fmt.Fprintf(hash, "value %d:%s %d:%s\n", len(name), name, len(value), value)
+		}
+	}
+
+	names = names[:0]
+	for name := range form.File {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, fileHeader := range form.File[name] {
+			fmt.Fprintf(hash, "file %d:%s %d:%s %d\n", len(name), name, len(fileHeader.Filename), fileHeader.Filename, fileHeader.Size)
+			file, err := fileHeader.Open()
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(hash, file)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
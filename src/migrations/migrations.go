@@ -0,0 +1,264 @@
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockKey - Advisory lock supaya dua proses tidak menjalankan migrasi bersamaan
+const lockKey int64 = 0x6d6967726174 // "migrat"
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var ErrNoMigrationApplied = errors.New("no migration has been applied")
+
+// ============ TYPES ============
+
+// Migration - Satu versi skema (file NNNN_name.up.sql + NNNN_name.down.sql)
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus - Status migrasi untuk command status
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// SchemaMigration - Baris di tabel schema_migrations
+type SchemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// ============ MIGRATOR ============
+type Migrator struct {
+	DB *gorm.DB
+}
+
+// Load - Baca semua migrasi yang di-embed, urut berdasarkan versi
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := files.ReadFile(path.Join("sql", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up - Jalankan semua migrasi yang belum diterapkan, masing-masing dalam satu transaksi
+func (m *Migrator) Up() ([]Migration, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range migrations {
+		ran, err := m.apply(migration)
+		if err != nil {
+			return applied, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if ran {
+			applied = append(applied, migration)
+		}
+	}
+
+	return applied, nil
+}
+
+// Down - Rollback sejumlah migrasi terakhir (steps >= 1)
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]Migration, len(migrations))
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	var reverted []Migration
+	for i := 0; i < steps; i++ {
+		var last SchemaMigration
+		err := m.DB.Order("version DESC").Take(&last).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if i == 0 {
+				return nil, ErrNoMigrationApplied
+			}
+			break
+		}
+		if err != nil {
+			return reverted, err
+		}
+
+		migration, ok := byVersion[last.Version]
+		if !ok {
+			return reverted, fmt.Errorf("applied migration %d_%s has no embedded file", last.Version, last.Name)
+		}
+		if err := m.revert(migration); err != nil {
+			return reverted, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		reverted = append(reverted, migration)
+	}
+
+	return reverted, nil
+}
+
+// Status - Semua migrasi yang dikenal beserta waktu diterapkan (nil = pending)
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	var rows []SchemaMigration
+	if err := m.DB.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	appliedAt := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		appliedAt[row.Version] = row.AppliedAt
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if at, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// ============ INTERNAL ============
+
+func (m *Migrator) ensureTable() error {
+	return m.DB.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint       NOT NULL PRIMARY KEY,
+		name       varchar(255) NOT NULL,
+		applied_at timestamptz  NOT NULL
+	)`).Error
+}
+
+// apply - Jalankan up migration jika belum tercatat (dicek ulang setelah lock)
+func (m *Migrator) apply(migration Migration) (bool, error) {
+	ran := false
+
+	err := m.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&SchemaMigration{}).Where("version = ?", migration.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		log.Printf("MIGRATE UP: %d_%s", migration.Version, migration.Name)
+		if err := tx.Exec(migration.Up).Error; err != nil {
+			return err
+		}
+
+		ran = true
+		return tx.Create(&SchemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+
+	return ran, err
+}
+
+// revert - Jalankan down migration dan hapus catatannya
+func (m *Migrator) revert(migration Migration) error {
+	return m.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+			return err
+		}
+
+		log.Printf("MIGRATE DOWN: %d_%s", migration.Version, migration.Name)
+		if err := tx.Exec(migration.Down).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("version = ?", migration.Version).Delete(&SchemaMigration{}).Error
+	})
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS stock_balances;
+DROP TABLE IF EXISTS inventory_histories;
+DROP TABLE IF EXISTS inventories;
+DROP TABLE IF EXISTS items;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,107 @@
+-- Skema awal, setara dengan hasil AutoMigrate sebelumnya.
+-- Memakai IF NOT EXISTS supaya database lama (hasil AutoMigrate) bisa langsung diadopsi.
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id         uuid         NOT NULL DEFAULT gen_random_uuid(),
+    name       varchar(100) NOT NULL,
+    code       varchar(50)  NOT NULL,
+    is_active  boolean      NOT NULL DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT organizations_pkey PRIMARY KEY (id),
+    CONSTRAINT uni_organizations_code UNIQUE (code)
+);
+
+CREATE TABLE IF NOT EXISTS items (
+    id         bigserial    NOT NULL,
+    code       varchar(50)  NOT NULL,
+    name       varchar(200) NOT NULL,
+    unit       varchar(20)  NOT NULL,
+    is_active  boolean      NOT NULL DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT items_pkey PRIMARY KEY (id),
+    CONSTRAINT uni_items_code UNIQUE (code)
+);
+
+-- Kolom master data yang ditambahkan belakangan (database AutoMigrate lama mungkin belum punya)
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS is_active boolean NOT NULL DEFAULT true;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+ALTER TABLE items ADD COLUMN IF NOT EXISTS is_active boolean NOT NULL DEFAULT true;
+ALTER TABLE items ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS inventories (
+    id                   uuid         NOT NULL DEFAULT gen_random_uuid(),
+    organization_id      uuid         NOT NULL,
+    item_id              bigint       NOT NULL,
+    txn_date             timestamp    NOT NULL,
+    amount               bigint       NOT NULL,
+    balance              bigint       NOT NULL,
+    type                 varchar(20)  NOT NULL,
+    ref_id               uuid,
+    target_id            uuid,
+    source               varchar(20),
+    from_organization_id uuid,
+    to_organization_id   uuid,
+    physical_qty         integer,
+    system_qty           integer,
+    difference           integer,
+    created_by           varchar(100) NOT NULL,
+    updated_by           varchar(100),
+    deleted_by           varchar(100),
+    created_at           timestamptz,
+    updated_at           timestamptz,
+    deleted_at           timestamptz,
+    page_code            varchar(50),
+    notes                text,
+    CONSTRAINT inventories_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_org_item_date ON inventories (organization_id, item_id, txn_date);
+CREATE INDEX IF NOT EXISTS idx_inventories_ref_id ON inventories (ref_id);
+CREATE INDEX IF NOT EXISTS idx_inventories_target_id ON inventories (target_id);
+CREATE INDEX IF NOT EXISTS idx_inventories_from_organization_id ON inventories (from_organization_id);
+CREATE INDEX IF NOT EXISTS idx_inventories_to_organization_id ON inventories (to_organization_id);
+CREATE INDEX IF NOT EXISTS idx_inventories_deleted_at ON inventories (deleted_at);
+
+CREATE TABLE IF NOT EXISTS inventory_histories (
+    id                   uuid         NOT NULL DEFAULT gen_random_uuid(),
+    organization_id      uuid         NOT NULL,
+    item_id              bigint       NOT NULL,
+    trigger_inventory_id uuid,
+    data_before          jsonb,
+    data_after           jsonb,
+    snapshot_from_date   timestamptz  NOT NULL,
+    action               varchar(50)  NOT NULL,
+    changed_by           varchar(100) NOT NULL,
+    reason               text,
+    created_at           timestamptz,
+    CONSTRAINT inventory_histories_pkey PRIMARY KEY (id)
+);
+
+-- Nama index dibedakan dari idx_org_item_date milik inventories (nama index unik per schema)
+CREATE INDEX IF NOT EXISTS idx_history_org_item_date ON inventory_histories (organization_id, item_id, snapshot_from_date);
+CREATE INDEX IF NOT EXISTS idx_inventory_histories_trigger_inventory_id ON inventory_histories (trigger_inventory_id);
+
+CREATE TABLE IF NOT EXISTS stock_balances (
+    organization_id   uuid      NOT NULL,
+    item_id           bigint    NOT NULL,
+    balance           bigint    NOT NULL DEFAULT 0,
+    last_txn_date     timestamp,
+    last_inventory_id uuid,
+    updated_at        timestamptz,
+    CONSTRAINT stock_balances_pkey PRIMARY KEY (organization_id, item_id)
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key           varchar(255) NOT NULL,
+    method        varchar(10)  NOT NULL,
+    path          varchar(255) NOT NULL,
+    request_hash  varchar(64)  NOT NULL,
+    status        varchar(20)  NOT NULL,
+    response_code integer,
+    response_body jsonb,
+    created_at    timestamptz,
+    completed_at  timestamptz,
+    CONSTRAINT idempotency_keys_pkey PRIMARY KEY (key)
+);
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS idx_inventories_active_org_item_date;
+
+ALTER TABLE stock_balances
+    DROP CONSTRAINT IF EXISTS fk_stock_balances_item,
+    DROP CONSTRAINT IF EXISTS fk_stock_balances_organization;
+
+ALTER TABLE inventory_histories
+    DROP CONSTRAINT IF EXISTS fk_inventory_histories_trigger_inventory,
+    DROP CONSTRAINT IF EXISTS fk_inventory_histories_item,
+    DROP CONSTRAINT IF EXISTS fk_inventory_histories_organization;
+
+ALTER TABLE inventories
+    DROP CONSTRAINT IF EXISTS fk_inventories_to_organization,
+    DROP CONSTRAINT IF EXISTS fk_inventories_from_organization,
+    DROP CONSTRAINT IF EXISTS fk_inventories_item,
+    DROP CONSTRAINT IF EXISTS fk_inventories_organization;
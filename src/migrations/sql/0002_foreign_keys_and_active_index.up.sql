@@ -0,0 +1,23 @@
+-- Foreign key ke master data. Ledger tidak pernah di-hard-delete (soft delete via deleted_at),
+-- organisasi/item dinonaktifkan lewat is_active, jadi RESTRICT aman.
+-- NOT VALID: database lama bisa punya baris yatim (dulu tanpa FK). Baris baru langsung dicek,
+-- baris lama dilaporkan `integrity` (orphan_reference) dan divalidasi di migrasi 0017.
+ALTER TABLE inventories
+    ADD CONSTRAINT fk_inventories_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE RESTRICT NOT VALID,
+    ADD CONSTRAINT fk_inventories_item FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE RESTRICT NOT VALID,
+    ADD CONSTRAINT fk_inventories_from_organization FOREIGN KEY (from_organization_id) REFERENCES organizations (id) ON DELETE RESTRICT NOT VALID,
+    ADD CONSTRAINT fk_inventories_to_organization FOREIGN KEY (to_organization_id) REFERENCES organizations (id) ON DELETE RESTRICT NOT VALID;
+
+ALTER TABLE inventory_histories
+    ADD CONSTRAINT fk_inventory_histories_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE RESTRICT NOT VALID,
+    ADD CONSTRAINT fk_inventory_histories_item FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE RESTRICT NOT VALID,
+    ADD CONSTRAINT fk_inventory_histories_trigger_inventory FOREIGN KEY (trigger_inventory_id) REFERENCES inventories (id) ON DELETE RESTRICT NOT VALID;
+
+ALTER TABLE stock_balances
+    ADD CONSTRAINT fk_stock_balances_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE RESTRICT NOT VALID,
+    ADD CONSTRAINT fk_stock_balances_item FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE RESTRICT NOT VALID;
+
+-- Hampir semua query ledger memfilter deleted_at IS NULL dan urut txn_date, created_at
+CREATE INDEX IF NOT EXISTS idx_inventories_active_org_item_date
+    ON inventories (organization_id, item_id, txn_date, created_at)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_inventory_histories_paired_history_id;
+
+ALTER TABLE inventory_histories
+    DROP CONSTRAINT IF EXISTS fk_inventory_histories_paired_history,
+    DROP COLUMN IF EXISTS paired_history_id;
@@ -0,0 +1,8 @@
+-- Pasangan UPDATE_BEFORE <-> UPDATE_AFTER untuk audit diff
+ALTER TABLE inventory_histories
+    ADD COLUMN IF NOT EXISTS paired_history_id uuid;
+
+ALTER TABLE inventory_histories
+    ADD CONSTRAINT fk_inventory_histories_paired_history FOREIGN KEY (paired_history_id) REFERENCES inventory_histories (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_inventory_histories_paired_history_id ON inventory_histories (paired_history_id);
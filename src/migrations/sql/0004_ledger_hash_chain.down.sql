@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_inventory_histories_chain;
+DROP INDEX IF EXISTS idx_inventories_chain;
+
+ALTER TABLE inventory_histories
+    DROP COLUMN IF EXISTS row_hash,
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS chain_seq;
+
+ALTER TABLE inventories
+    DROP COLUMN IF EXISTS row_hash,
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS chain_seq;
@@ -0,0 +1,14 @@
+-- Hash chain per org+item (tamper-evident) untuk inventories & inventory_histories.
+-- Baris lama tetap NULL sampai di-seal lewat `go run . chain seal`.
+ALTER TABLE inventories
+    ADD COLUMN IF NOT EXISTS chain_seq bigint,
+    ADD COLUMN IF NOT EXISTS prev_hash varchar(64),
+    ADD COLUMN IF NOT EXISTS row_hash  varchar(64);
+
+ALTER TABLE inventory_histories
+    ADD COLUMN IF NOT EXISTS chain_seq bigint,
+    ADD COLUMN IF NOT EXISTS prev_hash varchar(64),
+    ADD COLUMN IF NOT EXISTS row_hash  varchar(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_inventories_chain ON inventories (organization_id, item_id, chain_seq);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_inventory_histories_chain ON inventory_histories (organization_id, item_id, chain_seq);
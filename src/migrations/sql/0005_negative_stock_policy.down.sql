@@ -0,0 +1,5 @@
+ALTER TABLE organizations
+    DROP CONSTRAINT IF EXISTS chk_organizations_negative_stock_policy;
+
+ALTER TABLE organizations
+    DROP COLUMN IF EXISTS negative_stock_policy;
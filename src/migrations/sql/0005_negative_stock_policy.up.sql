@@ -0,0 +1,9 @@
+-- Policy saldo negatif per organisasi: allow / warn / block.
+-- Semua organisasi (termasuk yang sudah ada) default 'block': sebelumnya mutasi sudah menolak stok
+-- sumber yang kurang, jadi backfill 'allow' akan diam-diam membuang cek itu. Organisasi yang memang
+-- boleh minus harus opt-in allow/warn secara eksplisit.
+ALTER TABLE organizations
+    ADD COLUMN IF NOT EXISTS negative_stock_policy varchar(10) NOT NULL DEFAULT 'block';
+
+ALTER TABLE organizations
+    ADD CONSTRAINT chk_organizations_negative_stock_policy CHECK (negative_stock_policy IN ('allow', 'warn', 'block'));
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS period_closing_balances;
+DROP TABLE IF EXISTS period_closings;
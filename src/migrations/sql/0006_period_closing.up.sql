@@ -0,0 +1,33 @@
+-- Tutup buku per organisasi. Closing aktif (reopened_at IS NULL) mengunci semua posting
+-- dengan txn_date <= period_end; reopen dicatat di baris yang sama, tidak pernah dihapus.
+CREATE TABLE IF NOT EXISTS period_closings (
+    id              uuid         NOT NULL DEFAULT gen_random_uuid(),
+    organization_id uuid         NOT NULL,
+    period_end      date         NOT NULL,
+    item_count      integer      NOT NULL DEFAULT 0,
+    closed_by       varchar(100) NOT NULL,
+    reason          text,
+    closed_at       timestamptz  NOT NULL DEFAULT now(),
+    reopened_by     varchar(100),
+    reopen_reason   text,
+    reopened_at     timestamptz,
+    CONSTRAINT period_closings_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_period_closings_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE RESTRICT
+);
+
+-- Satu closing aktif per org+period_end, sekaligus index untuk lookup lock date
+CREATE UNIQUE INDEX IF NOT EXISTS idx_period_closings_active
+    ON period_closings (organization_id, period_end)
+    WHERE reopened_at IS NULL;
+
+-- Saldo akhir setiap item per closing (snapshot, tidak ikut berubah saat ledger di-recalculate)
+CREATE TABLE IF NOT EXISTS period_closing_balances (
+    closing_id        uuid      NOT NULL,
+    item_id           bigint    NOT NULL,
+    balance           bigint    NOT NULL DEFAULT 0,
+    last_txn_date     timestamp,
+    last_inventory_id uuid,
+    CONSTRAINT period_closing_balances_pkey PRIMARY KEY (closing_id, item_id),
+    CONSTRAINT fk_period_closing_balances_closing FOREIGN KEY (closing_id) REFERENCES period_closings (id) ON DELETE CASCADE,
+    CONSTRAINT fk_period_closing_balances_item FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE RESTRICT
+);
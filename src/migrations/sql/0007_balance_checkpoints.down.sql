@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_inventories_active_org_item_date;
+CREATE INDEX IF NOT EXISTS idx_inventories_active_org_item_date
+    ON inventories (organization_id, item_id, txn_date, created_at)
+    WHERE deleted_at IS NULL;
+
+DROP TABLE IF EXISTS balance_checkpoints;
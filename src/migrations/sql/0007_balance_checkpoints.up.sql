@@ -0,0 +1,28 @@
+-- Checkpoint saldo akhir bulan per org+item (tabelnya di-drop lagi oleh 0018; index di bawah tetap dipakai).
+-- period_start = tanggal 1 bulan (jam dinding txn_date).
+CREATE TABLE IF NOT EXISTS balance_checkpoints (
+    organization_id   uuid        NOT NULL,
+    item_id           bigint      NOT NULL,
+    period_start      date        NOT NULL,
+    balance           bigint      NOT NULL DEFAULT 0,
+    last_txn_date     timestamp   NOT NULL,
+    last_inventory_id uuid        NOT NULL,
+    updated_at        timestamptz,
+    CONSTRAINT balance_checkpoints_pkey PRIMARY KEY (organization_id, item_id, period_start),
+    CONSTRAINT fk_balance_checkpoints_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE RESTRICT,
+    CONSTRAINT fk_balance_checkpoints_item FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE RESTRICT
+);
+
+INSERT INTO balance_checkpoints (organization_id, item_id, period_start, balance, last_txn_date, last_inventory_id, updated_at)
+SELECT DISTINCT ON (organization_id, item_id, date_trunc('month', txn_date))
+    organization_id, item_id, date_trunc('month', txn_date)::date, balance, txn_date, id, NOW()
+FROM inventories
+WHERE deleted_at IS NULL
+ORDER BY organization_id, item_id, date_trunc('month', txn_date), txn_date DESC, created_at DESC, id DESC
+ON CONFLICT DO NOTHING;
+
+-- Keyset stream recalculation: (txn_date, created_at, id) harus bisa dibaca langsung dari index
+DROP INDEX IF EXISTS idx_inventories_active_org_item_date;
+CREATE INDEX IF NOT EXISTS idx_inventories_active_org_item_date
+    ON inventories (organization_id, item_id, txn_date, created_at, id)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,27 @@
+-- Outbox domain event, ditulis di transaksi yang sama dengan perubahan ledger.
+-- id (bigserial) = urutan event; dispatcher mengirim status pending berurutan id.
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              bigserial    NOT NULL,
+    event_type      varchar(50)  NOT NULL,
+    organization_id uuid         NOT NULL,
+    item_id         bigint       NOT NULL,
+    payload         jsonb        NOT NULL,
+    status          varchar(20)  NOT NULL DEFAULT 'pending',
+    attempts        integer      NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz  NOT NULL DEFAULT NOW(),
+    last_error      text,
+    created_at      timestamptz  NOT NULL DEFAULT NOW(),
+    delivered_at    timestamptz,
+    CONSTRAINT outbox_events_pkey PRIMARY KEY (id),
+    CONSTRAINT chk_outbox_events_status CHECK (status IN ('pending', 'delivered', 'dead'))
+);
+
+-- Polling dispatcher: hanya event pending yang sudah jatuh tempo
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending
+    ON outbox_events (next_attempt_at, id)
+    WHERE status = 'pending';
+
+-- Urutan per org+item: event ditahan selama masih ada event pending yang lebih awal
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending_org_item
+    ON outbox_events (organization_id, item_id, id)
+    WHERE status = 'pending';
@@ -0,0 +1,47 @@
+-- Webhook keluar. event_types / organization_ids kosong = semua.
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id               uuid         NOT NULL DEFAULT gen_random_uuid(),
+    name             varchar(100) NOT NULL,
+    url              text         NOT NULL,
+    secret           varchar(255) NOT NULL,
+    event_types      jsonb        NOT NULL DEFAULT '[]',
+    organization_ids jsonb        NOT NULL DEFAULT '[]',
+    is_active        boolean      NOT NULL DEFAULT true,
+    created_at       timestamptz,
+    updated_at       timestamptz,
+    CONSTRAINT webhook_subscriptions_pkey PRIMARY KEY (id)
+);
+
+-- Delivery log: satu baris per subscription per event outbox (+ baris redelivery manual)
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               uuid         NOT NULL DEFAULT gen_random_uuid(),
+    subscription_id  uuid         NOT NULL,
+    event_id         bigint       NOT NULL,
+    event_type       varchar(50)  NOT NULL,
+    payload          jsonb        NOT NULL,
+    redelivery_of    uuid,
+    status           varchar(20)  NOT NULL DEFAULT 'pending',
+    attempts         integer      NOT NULL DEFAULT 0,
+    next_attempt_at  timestamptz  NOT NULL DEFAULT NOW(),
+    last_status_code integer,
+    last_error       text,
+    last_response    text,
+    created_at       timestamptz  NOT NULL DEFAULT NOW(),
+    delivered_at     timestamptz,
+    CONSTRAINT webhook_deliveries_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_webhook_deliveries_subscription FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    CONSTRAINT fk_webhook_deliveries_redelivery FOREIGN KEY (redelivery_of) REFERENCES webhook_deliveries (id) ON DELETE SET NULL,
+    CONSTRAINT chk_webhook_deliveries_status CHECK (status IN ('pending', 'delivered', 'failed'))
+);
+
+-- Fan-out outbox at-least-once: event yang sama tidak membuat delivery ganda
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event
+    ON webhook_deliveries (subscription_id, event_id)
+    WHERE redelivery_of IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending
+    ON webhook_deliveries (next_attempt_at)
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription
+    ON webhook_deliveries (subscription_id, created_at DESC);
@@ -0,0 +1,43 @@
+-- Level stok per org+item (semua opsional). Dievaluasi setiap kali recalculation selesai.
+CREATE TABLE IF NOT EXISTS stock_levels (
+    organization_id uuid    NOT NULL,
+    item_id         bigint  NOT NULL,
+    min_qty         bigint,
+    max_qty         bigint,
+    reorder_point   bigint,
+    updated_by      varchar(100),
+    updated_at      timestamptz,
+    CONSTRAINT stock_levels_pkey PRIMARY KEY (organization_id, item_id),
+    CONSTRAINT fk_stock_levels_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE RESTRICT,
+    CONSTRAINT fk_stock_levels_item FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE RESTRICT
+);
+
+-- Alert level stok: open -> acknowledged -> resolved (resolved otomatis saat saldo kembali normal)
+CREATE TABLE IF NOT EXISTS stock_alerts (
+    id               uuid         NOT NULL DEFAULT gen_random_uuid(),
+    organization_id  uuid         NOT NULL,
+    item_id          bigint       NOT NULL,
+    alert_type       varchar(20)  NOT NULL,
+    threshold        bigint       NOT NULL,
+    balance          bigint       NOT NULL,
+    state            varchar(20)  NOT NULL DEFAULT 'open',
+    raised_at        timestamptz  NOT NULL DEFAULT NOW(),
+    acknowledged_by  varchar(100),
+    acknowledge_note text,
+    acknowledged_at  timestamptz,
+    resolved_balance bigint,
+    resolved_at      timestamptz,
+    CONSTRAINT stock_alerts_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_stock_alerts_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE RESTRICT,
+    CONSTRAINT fk_stock_alerts_item FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE RESTRICT,
+    CONSTRAINT chk_stock_alerts_type CHECK (alert_type IN ('below_min', 'reorder', 'above_max')),
+    CONSTRAINT chk_stock_alerts_state CHECK (state IN ('open', 'acknowledged', 'resolved'))
+);
+
+-- Paling banyak satu alert aktif per org+item+type
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_alerts_active
+    ON stock_alerts (organization_id, item_id, alert_type)
+    WHERE state <> 'resolved';
+
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_state_raised
+    ON stock_alerts (state, raised_at DESC);
@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS cost_layers;
+
+ALTER TABLE stock_balances
+    DROP COLUMN IF EXISTS balance_value;
+
+ALTER TABLE inventories
+    DROP CONSTRAINT IF EXISTS chk_inventories_unit_cost;
+
+ALTER TABLE inventories
+    DROP COLUMN IF EXISTS balance_value,
+    DROP COLUMN IF EXISTS cost_amount,
+    DROP COLUMN IF EXISTS unit_cost;
+
+ALTER TABLE items
+    DROP CONSTRAINT IF EXISTS chk_items_costing_method;
+
+ALTER TABLE items
+    DROP COLUMN IF EXISTS costing_method;
@@ -0,0 +1,40 @@
+-- Metode costing per item: fifo atau average (weighted moving average, default).
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS costing_method varchar(10) NOT NULL DEFAULT 'average';
+
+ALTER TABLE items
+    ADD CONSTRAINT chk_items_costing_method CHECK (costing_method IN ('fifo', 'average'));
+
+-- unit_cost = input harga per unit (hanya stok_awal/penerimaan masuk, NULL = dinilai dengan cost berjalan).
+-- cost_amount (nilai mutasi baris, bertanda) & balance_value (nilai persediaan setelah baris)
+-- dihitung ulang oleh valuation setiap kali recalculation, seperti balance.
+ALTER TABLE inventories
+    ADD COLUMN IF NOT EXISTS unit_cost     numeric(20, 6),
+    ADD COLUMN IF NOT EXISTS cost_amount   numeric(20, 6),
+    ADD COLUMN IF NOT EXISTS balance_value numeric(20, 6);
+
+ALTER TABLE inventories
+    ADD CONSTRAINT chk_inventories_unit_cost CHECK (unit_cost IS NULL OR unit_cost >= 0);
+
+ALTER TABLE stock_balances
+    ADD COLUMN IF NOT EXISTS balance_value numeric(20, 6) NOT NULL DEFAULT 0;
+
+-- Layer FIFO yang masih terbuka per org+item (projection, ditulis ulang oleh valuation).
+-- Item average tidak punya layer: nilainya ada di balance_value.
+CREATE TABLE IF NOT EXISTS cost_layers (
+    id              bigserial      NOT NULL,
+    organization_id uuid           NOT NULL,
+    item_id         bigint         NOT NULL,
+    inventory_id    uuid           NOT NULL,
+    txn_date        timestamp      NOT NULL,
+    quantity        bigint         NOT NULL,
+    remaining_qty   bigint         NOT NULL,
+    unit_cost       numeric(20, 6) NOT NULL,
+    CONSTRAINT cost_layers_pkey PRIMARY KEY (id),
+    CONSTRAINT uni_cost_layers_inventory UNIQUE (inventory_id),
+    CONSTRAINT fk_cost_layers_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE RESTRICT,
+    CONSTRAINT fk_cost_layers_item FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE RESTRICT,
+    CONSTRAINT fk_cost_layers_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_layers_org_item ON cost_layers (organization_id, item_id, id);
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS lot_balances;
+
+DROP INDEX IF EXISTS idx_inventories_org_item_lot;
+
+ALTER TABLE inventories
+    DROP COLUMN IF EXISTS expiry_date,
+    DROP COLUMN IF EXISTS lot_number;
+
+ALTER TABLE items
+    DROP COLUMN IF EXISTS track_lots;
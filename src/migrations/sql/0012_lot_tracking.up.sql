@@ -0,0 +1,28 @@
+-- Item dengan track_lots wajib mencatat lot di setiap posting (default: tidak, item lama tetap sama).
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS track_lots boolean NOT NULL DEFAULT false;
+
+-- lot_number & expiry_date ikut disalin ke baris keluar (pemakaian, mutasi) dari lot yang dipakai.
+ALTER TABLE inventories
+    ADD COLUMN IF NOT EXISTS lot_number  varchar(50),
+    ADD COLUMN IF NOT EXISTS expiry_date date;
+
+CREATE INDEX IF NOT EXISTS idx_inventories_org_item_lot
+    ON inventories (organization_id, item_id, lot_number)
+    WHERE lot_number IS NOT NULL AND deleted_at IS NULL;
+
+-- Saldo per org+item+lot (projection, ditulis ulang setiap recalculation item ber-lot).
+-- Lot yang sudah habis tidak disimpan.
+CREATE TABLE IF NOT EXISTS lot_balances (
+    organization_id uuid        NOT NULL,
+    item_id         bigint      NOT NULL,
+    lot_number      varchar(50) NOT NULL,
+    expiry_date     date,
+    balance         bigint      NOT NULL,
+    updated_at      timestamptz,
+    CONSTRAINT lot_balances_pkey PRIMARY KEY (organization_id, item_id, lot_number),
+    CONSTRAINT fk_lot_balances_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE RESTRICT,
+    CONSTRAINT fk_lot_balances_item FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_lot_balances_expiry ON lot_balances (expiry_date) WHERE balance > 0;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS serial_numbers;
+
+DROP INDEX IF EXISTS idx_inventories_serial_numbers;
+
+ALTER TABLE inventories
+    DROP COLUMN IF EXISTS serial_numbers;
+
+ALTER TABLE items
+    DROP COLUMN IF EXISTS serialized;
@@ -0,0 +1,29 @@
+-- Item serialized dicatat per unit: setiap posting menyebut nomor seri yang bergerak (default: tidak).
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS serialized boolean NOT NULL DEFAULT false;
+
+-- Daftar nomor seri per baris ledger (jsonb array), jumlahnya sama dengan |amount|.
+ALTER TABLE inventories
+    ADD COLUMN IF NOT EXISTS serial_numbers jsonb;
+
+CREATE INDEX IF NOT EXISTS idx_inventories_serial_numbers
+    ON inventories USING gin (serial_numbers jsonb_path_ops)
+    WHERE serial_numbers IS NOT NULL;
+
+-- Registry nomor seri (projection, ditulis ulang setiap recalculation item serialized).
+-- organization_id = organisasi tempat unit berada, NULL jika unit sudah keluar.
+CREATE TABLE IF NOT EXISTS serial_numbers (
+    item_id           bigint       NOT NULL,
+    serial_number     varchar(100) NOT NULL,
+    organization_id   uuid,
+    status            varchar(20)  NOT NULL,
+    last_inventory_id uuid,
+    last_txn_date     timestamp,
+    updated_at        timestamptz,
+    CONSTRAINT serial_numbers_pkey PRIMARY KEY (item_id, serial_number),
+    CONSTRAINT fk_serial_numbers_item FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE RESTRICT,
+    CONSTRAINT fk_serial_numbers_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_serial_numbers_serial ON serial_numbers (serial_number);
+CREATE INDEX IF NOT EXISTS idx_serial_numbers_org_item ON serial_numbers (organization_id, item_id) WHERE organization_id IS NOT NULL;
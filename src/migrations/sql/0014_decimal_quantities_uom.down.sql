@@ -0,0 +1,35 @@
+DROP TABLE IF EXISTS item_units;
+
+-- Kuantitas pecahan dibulatkan kembali ke integer
+ALTER TABLE lot_balances
+    ALTER COLUMN balance TYPE bigint USING round(balance);
+
+ALTER TABLE cost_layers
+    ALTER COLUMN quantity      TYPE bigint USING round(quantity),
+    ALTER COLUMN remaining_qty TYPE bigint USING round(remaining_qty);
+
+ALTER TABLE stock_alerts
+    ALTER COLUMN threshold        TYPE bigint USING round(threshold),
+    ALTER COLUMN balance          TYPE bigint USING round(balance),
+    ALTER COLUMN resolved_balance TYPE bigint USING round(resolved_balance);
+
+ALTER TABLE stock_levels
+    ALTER COLUMN min_qty       TYPE bigint USING round(min_qty),
+    ALTER COLUMN max_qty       TYPE bigint USING round(max_qty),
+    ALTER COLUMN reorder_point TYPE bigint USING round(reorder_point);
+
+ALTER TABLE period_closing_balances
+    ALTER COLUMN balance TYPE bigint USING round(balance);
+
+ALTER TABLE balance_checkpoints
+    ALTER COLUMN balance TYPE bigint USING round(balance);
+
+ALTER TABLE stock_balances
+    ALTER COLUMN balance TYPE bigint USING round(balance);
+
+ALTER TABLE inventories
+    ALTER COLUMN amount       TYPE bigint USING round(amount),
+    ALTER COLUMN balance      TYPE bigint USING round(balance),
+    ALTER COLUMN physical_qty TYPE integer USING round(physical_qty),
+    ALTER COLUMN system_qty   TYPE integer USING round(system_qty),
+    ALTER COLUMN difference   TYPE integer USING round(difference);
@@ -0,0 +1,47 @@
+-- Kuantitas pecahan: semua kolom qty pindah ke numeric(20,6) (sama dengan kolom nilai).
+-- Nilai integer lama tetap sama persis, hash chain lama tetap valid.
+ALTER TABLE inventories
+    ALTER COLUMN amount       TYPE numeric(20,6),
+    ALTER COLUMN balance      TYPE numeric(20,6),
+    ALTER COLUMN physical_qty TYPE numeric(20,6),
+    ALTER COLUMN system_qty   TYPE numeric(20,6),
+    ALTER COLUMN difference   TYPE numeric(20,6);
+
+ALTER TABLE stock_balances
+    ALTER COLUMN balance TYPE numeric(20,6);
+
+ALTER TABLE balance_checkpoints
+    ALTER COLUMN balance TYPE numeric(20,6);
+
+ALTER TABLE period_closing_balances
+    ALTER COLUMN balance TYPE numeric(20,6);
+
+ALTER TABLE stock_levels
+    ALTER COLUMN min_qty       TYPE numeric(20,6),
+    ALTER COLUMN max_qty       TYPE numeric(20,6),
+    ALTER COLUMN reorder_point TYPE numeric(20,6);
+
+ALTER TABLE stock_alerts
+    ALTER COLUMN threshold        TYPE numeric(20,6),
+    ALTER COLUMN balance          TYPE numeric(20,6),
+    ALTER COLUMN resolved_balance TYPE numeric(20,6);
+
+ALTER TABLE cost_layers
+    ALTER COLUMN quantity      TYPE numeric(20,6),
+    ALTER COLUMN remaining_qty TYPE numeric(20,6);
+
+ALTER TABLE lot_balances
+    ALTER COLUMN balance TYPE numeric(20,6);
+
+-- Satuan alternatif per item: 1 unit = factor x satuan dasar (items.unit).
+-- Posting boleh memakai satuan ini, ledger selalu dicatat dalam satuan dasar.
+CREATE TABLE IF NOT EXISTS item_units (
+    item_id    bigint        NOT NULL,
+    unit       varchar(20)   NOT NULL,
+    factor     numeric(20,6) NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT item_units_pkey PRIMARY KEY (item_id, unit),
+    CONSTRAINT fk_item_units_item FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE CASCADE,
+    CONSTRAINT chk_item_units_factor CHECK (factor > 0)
+);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS location_balances;
+
+DROP INDEX IF EXISTS idx_inventories_org_item_location;
+
+ALTER TABLE inventories
+    DROP CONSTRAINT IF EXISTS fk_inventories_location,
+    DROP COLUMN IF EXISTS location_id;
+
+DROP TABLE IF EXISTS locations;
@@ -0,0 +1,42 @@
+-- Pohon lokasi (zona / rak / bin) di dalam organisasi. Kode unik per organisasi,
+-- parent harus di organisasi yang sama (dicek aplikasi).
+CREATE TABLE IF NOT EXISTS locations (
+    id              uuid         NOT NULL DEFAULT gen_random_uuid(),
+    organization_id uuid         NOT NULL,
+    parent_id       uuid,
+    code            varchar(50)  NOT NULL,
+    name            varchar(100) NOT NULL,
+    is_active       boolean      NOT NULL DEFAULT true,
+    created_at      timestamptz,
+    updated_at      timestamptz,
+    CONSTRAINT locations_pkey PRIMARY KEY (id),
+    CONSTRAINT uq_locations_org_code UNIQUE (organization_id, code),
+    CONSTRAINT fk_locations_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE RESTRICT,
+    CONSTRAINT fk_locations_parent FOREIGN KEY (parent_id) REFERENCES locations (id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_locations_parent ON locations (parent_id);
+
+-- Lokasi opsional per baris ledger (NULL = belum ditempatkan). pindah_lokasi = dua baris
+-- (keluar dari lokasi asal, masuk ke lokasi tujuan) dengan ref_id yang sama di org yang sama.
+ALTER TABLE inventories
+    ADD COLUMN IF NOT EXISTS location_id uuid,
+    ADD CONSTRAINT fk_inventories_location FOREIGN KEY (location_id) REFERENCES locations (id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_inventories_org_item_location
+    ON inventories (organization_id, item_id, location_id)
+    WHERE location_id IS NOT NULL AND deleted_at IS NULL;
+
+-- Saldo per org+lokasi+item (projection, ditulis ulang setiap recalculation).
+-- Saldo nol tidak disimpan.
+CREATE TABLE IF NOT EXISTS location_balances (
+    organization_id uuid          NOT NULL,
+    location_id     uuid          NOT NULL,
+    item_id         bigint        NOT NULL,
+    balance         numeric(20,6) NOT NULL,
+    updated_at      timestamptz,
+    CONSTRAINT location_balances_pkey PRIMARY KEY (organization_id, location_id, item_id),
+    CONSTRAINT fk_location_balances_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE RESTRICT,
+    CONSTRAINT fk_location_balances_location FOREIGN KEY (location_id) REFERENCES locations (id) ON DELETE RESTRICT,
+    CONSTRAINT fk_location_balances_item FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE RESTRICT
+);
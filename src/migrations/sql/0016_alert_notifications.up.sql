@@ -0,0 +1,25 @@
+-- Antrian notifikasi alert stok: satu baris per notifier per event outbox. Dikirim dan di-retry
+-- sendiri (seperti webhook_deliveries), jadi notifier yang lambat/gagal tidak menahan outbox.
+CREATE TABLE IF NOT EXISTS alert_notifications (
+    id              uuid         NOT NULL DEFAULT gen_random_uuid(),
+    notifier        varchar(20)  NOT NULL,
+    event_id        bigint       NOT NULL,
+    event_type      varchar(50)  NOT NULL,
+    payload         jsonb        NOT NULL,
+    status          varchar(20)  NOT NULL DEFAULT 'pending',
+    attempts        integer      NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz  NOT NULL DEFAULT NOW(),
+    last_error      text,
+    created_at      timestamptz  NOT NULL DEFAULT NOW(),
+    delivered_at    timestamptz,
+    CONSTRAINT alert_notifications_pkey PRIMARY KEY (id),
+    CONSTRAINT chk_alert_notifications_status CHECK (status IN ('pending', 'delivered', 'failed'))
+);
+
+-- Fan-out outbox at-least-once: event yang sama tidak membuat notifikasi ganda
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alert_notifications_event
+    ON alert_notifications (notifier, event_id);
+
+CREATE INDEX IF NOT EXISTS idx_alert_notifications_pending
+    ON alert_notifications (next_attempt_at)
+    WHERE status = 'pending';
@@ -0,0 +1,2 @@
+-- Constraint yang sudah divalidasi tidak bisa dikembalikan ke NOT VALID; down tidak mengubah apa pun.
+SELECT 1;
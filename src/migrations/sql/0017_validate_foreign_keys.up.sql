@@ -0,0 +1,13 @@
+-- Validasi foreign key 0002 (ditambah NOT VALID). Gagal jika masih ada baris yatim:
+-- jalankan `go run . integrity` (orphan_reference), bersihkan barisnya, lalu `migrate up` lagi.
+ALTER TABLE inventories VALIDATE CONSTRAINT fk_inventories_organization;
+ALTER TABLE inventories VALIDATE CONSTRAINT fk_inventories_item;
+ALTER TABLE inventories VALIDATE CONSTRAINT fk_inventories_from_organization;
+ALTER TABLE inventories VALIDATE CONSTRAINT fk_inventories_to_organization;
+
+ALTER TABLE inventory_histories VALIDATE CONSTRAINT fk_inventory_histories_organization;
+ALTER TABLE inventory_histories VALIDATE CONSTRAINT fk_inventory_histories_item;
+ALTER TABLE inventory_histories VALIDATE CONSTRAINT fk_inventory_histories_trigger_inventory;
+
+ALTER TABLE stock_balances VALIDATE CONSTRAINT fk_stock_balances_organization;
+ALTER TABLE stock_balances VALIDATE CONSTRAINT fk_stock_balances_item;
@@ -0,0 +1,14 @@
+-- Tabel dibuat ulang kosong (skema sesudah 0014) supaya down 0014/0007 tetap jalan;
+-- isinya tidak dikembalikan.
+CREATE TABLE IF NOT EXISTS balance_checkpoints (
+    organization_id   uuid          NOT NULL,
+    item_id           bigint        NOT NULL,
+    period_start      date          NOT NULL,
+    balance           numeric(20,6) NOT NULL DEFAULT 0,
+    last_txn_date     timestamp     NOT NULL,
+    last_inventory_id uuid          NOT NULL,
+    updated_at        timestamptz,
+    CONSTRAINT balance_checkpoints_pkey PRIMARY KEY (organization_id, item_id, period_start),
+    CONSTRAINT fk_balance_checkpoints_organization FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE RESTRICT,
+    CONSTRAINT fk_balance_checkpoints_item FOREIGN KEY (item_id) REFERENCES items (id) ON DELETE RESTRICT
+);
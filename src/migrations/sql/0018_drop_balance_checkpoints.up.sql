@@ -0,0 +1,5 @@
+-- balance_checkpoints (0007) tidak pernah dibaca recalculation maupun laporan dan hanya snapshot
+-- saat rebuild-balances, jadi isinya basi setelah posting berikutnya. Saldo per tanggal diambil
+-- dari baris ledger terakhir sebelum tanggal itu (index 0007), saldo tutup buku dari
+-- period_closing_balances.
+DROP TABLE IF EXISTS balance_checkpoints;
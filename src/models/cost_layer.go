@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ============ COST LAYER (FIFO) ============
+// CostLayer - Layer FIFO yang masih terbuka per org+item. Projection dari ledger:
+// ditulis ulang oleh valuation setiap kali recalculation, sumbernya baris masuk
+// (stok_awal, penerimaan, mutasi masuk, opname plus).
+type CostLayer struct {
+	ID             uint64    `gorm:"primaryKey;autoIncrement"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null"`
+	ItemID         uint      `gorm:"not null"`
+	InventoryID    uuid.UUID `gorm:"type:uuid;not null;unique"`
+	TxnDate        time.Time `gorm:"type:timestamp;not null"`
+
+	Quantity     decimal.Decimal `gorm:"type:numeric(20,6);not null"`
+	RemainingQty decimal.Decimal `gorm:"type:numeric(20,6);not null"`
+	UnitCost     decimal.Decimal `gorm:"type:numeric(20,6);not null"`
+}
+
+func (CostLayer) TableName() string {
+	return "cost_layers"
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ============ IDEMPOTENCY ============
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusPending   IdempotencyStatus = "pending"
+	IdempotencyStatusCompleted IdempotencyStatus = "completed"
+)
+
+// IdempotencyKey menyimpan hasil request POST berdasarkan header Idempotency-Key,
+// supaya retry dari scanner/ERP mengembalikan response awal tanpa double posting.
+type IdempotencyKey struct {
+	Key         string            `gorm:"type:varchar(255);primaryKey"`
+	Method      string            `gorm:"type:varchar(10);not null"`
+	Path        string            `gorm:"type:varchar(255);not null"`
+	RequestHash string            `gorm:"type:varchar(64);not null"`
+	Status      IdempotencyStatus `gorm:"type:varchar(20);not null"`
+
+	ResponseCode int             `gorm:"type:integer"`
+	ResponseBody json.RawMessage `gorm:"type:jsonb"`
+
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
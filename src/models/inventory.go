@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -17,6 +18,9 @@ const (
 	InventoryTypePemakaian  InventoryType = "pemakaian"
 	InventoryTypeMutation   InventoryType = "mutation"
 	InventoryTypeOpname     InventoryType = "opname"
+
+	// Pindah antar lokasi di dalam satu organisasi (saldo org & nilai tidak berubah)
+	InventoryTypeLocationMove InventoryType = "pindah_lokasi"
 )
 
 type TransactionSource string
@@ -28,6 +32,23 @@ const (
 	SourceReturn   TransactionSource = "return"
 )
 
+// NegativeStockPolicy - Perlakuan saldo negatif per organisasi
+type NegativeStockPolicy string
+
+const (
+	NegativeStockAllow NegativeStockPolicy = "allow"
+	NegativeStockWarn  NegativeStockPolicy = "warn"
+	NegativeStockBlock NegativeStockPolicy = "block"
+)
+
+// CostingMethod - Metode valuation per item
+type CostingMethod string
+
+const (
+	CostingFIFO    CostingMethod = "fifo"
+	CostingAverage CostingMethod = "average" // weighted moving average
+)
+
 // ============ MAIN INVENTORY MODEL ============
 type Inventory struct {
 	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
@@ -39,10 +60,10 @@ type Inventory struct {
 	ItemID uint `gorm:"not null;index:idx_org_item_date"`
 
 	// Transaction data
-	TxnDate time.Time     `gorm:"type:timestamp;not null;index:idx_org_item_date"`
-	Amount  int           `gorm:"not null"`
-	Balance int           `gorm:"not null"`
-	Type    InventoryType `gorm:"type:varchar(20);not null"`
+	TxnDate time.Time       `gorm:"type:timestamp;not null;index:idx_org_item_date"`
+	Amount  decimal.Decimal `gorm:"type:numeric(20,6);not null"` // satuan dasar item
+	Balance decimal.Decimal `gorm:"type:numeric(20,6);not null"`
+	Type    InventoryType   `gorm:"type:varchar(20);not null"`
 
 	// Reference tracking
 	RefID    *uuid.UUID         `gorm:"type:uuid;index"`
@@ -54,9 +75,24 @@ type Inventory struct {
 	ToOrganizationID   *uuid.UUID `gorm:"type:uuid;index"`
 
 	// Opname data
-	PhysicalQty *int `gorm:"type:integer"`
-	SystemQty   *int `gorm:"type:integer"`
-	Difference  *int `gorm:"type:integer"`
+	PhysicalQty *decimal.Decimal `gorm:"type:numeric(20,6)"`
+	SystemQty   *decimal.Decimal `gorm:"type:numeric(20,6)"`
+	Difference  *decimal.Decimal `gorm:"type:numeric(20,6)"`
+
+	// Lot/batch (hanya item TrackLots): baris keluar menyalin lot & expiry dari lot yang dipakai
+	LotNumber  *string    `gorm:"type:varchar(50)"`
+	ExpiryDate *time.Time `gorm:"type:date"`
+
+	// Lokasi / bin di dalam organisasi (nil = belum ditempatkan)
+	LocationID *uuid.UUID `gorm:"type:uuid;index"`
+
+	// Nomor seri unit yang bergerak (hanya item Serialized), jumlahnya = |Amount|
+	SerialNumbers []string `gorm:"type:jsonb;serializer:json"`
+
+	// Valuation: UnitCost = input (stok_awal/penerimaan masuk), sisanya dihitung ulang seperti Balance
+	UnitCost     *decimal.Decimal `gorm:"type:numeric(20,6)"`
+	CostAmount   *decimal.Decimal `gorm:"type:numeric(20,6)"`
+	BalanceValue *decimal.Decimal `gorm:"type:numeric(20,6)"`
 
 	// Audit trail
 	CreatedBy string  `gorm:"type:varchar(100);not null"`
@@ -71,6 +107,11 @@ type Inventory struct {
 	// Metadata
 	PageCode string  `gorm:"type:varchar(50)"`
 	Notes    *string `gorm:"type:text"`
+
+	// Hash chain per org+item (lihat ledger_chain.go)
+	ChainSeq *int64  `gorm:"uniqueIndex:idx_inventories_chain"`
+	PrevHash *string `gorm:"type:varchar(64)"`
+	RowHash  *string `gorm:"type:varchar(64)"`
 }
 
 func (Inventory) TableName() string {
@@ -82,18 +123,21 @@ type InventoryHistory struct {
 	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 
 	// SPESIFIK org dan item
-	OrganizationID uuid.UUID `gorm:"type:uuid;not null;index:idx_org_item_date"`
-	ItemID         uint      `gorm:"not null;index:idx_org_item_date"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null;index:idx_history_org_item_date"`
+	ItemID         uint      `gorm:"not null;index:idx_history_org_item_date"`
 
 	// Reference ke transaksi yang trigger history
 	TriggerInventoryID *uuid.UUID `gorm:"type:uuid;index"`
 
+	// Pasangan UPDATE_BEFORE <-> UPDATE_AFTER dari satu update
+	PairedHistoryID *uuid.UUID `gorm:"type:uuid;index"`
+
 	// Snapshot data
 	DataBefore json.RawMessage `gorm:"type:jsonb"`
 	DataAfter  json.RawMessage `gorm:"type:jsonb"`
 
 	// Scope of snapshot
-	SnapshotFromDate time.Time `gorm:"not null;index:idx_org_item_date"`
+	SnapshotFromDate time.Time `gorm:"not null;index:idx_history_org_item_date"`
 
 	// Context
 	Action    string  `gorm:"type:varchar(50);not null"`
@@ -101,6 +145,11 @@ type InventoryHistory struct {
 	Reason    *string `gorm:"type:text"`
 
 	CreatedAt time.Time
+
+	// Hash chain per org+item (lihat ledger_chain.go)
+	ChainSeq *int64  `gorm:"uniqueIndex:idx_inventory_histories_chain"`
+	PrevHash *string `gorm:"type:varchar(64)"`
+	RowHash  *string `gorm:"type:varchar(64)"`
 }
 
 func (InventoryHistory) TableName() string {
@@ -109,20 +158,35 @@ func (InventoryHistory) TableName() string {
 
 // SnapshotItem untuk history data
 type SnapshotItem struct {
-	InventoryID uuid.UUID `json:"inventory_id"`
-	TxnDate     time.Time `json:"txn_date"`
-	Amount      int       `json:"amount"`
-	Balance     int       `json:"balance"`
-	Type        string    `json:"type"`
-	RefID       *string   `json:"ref_id,omitempty"`
+	InventoryID uuid.UUID       `json:"inventory_id"`
+	TxnDate     time.Time       `json:"txn_date"`
+	Amount      decimal.Decimal `json:"amount"`
+	Balance     decimal.Decimal `json:"balance"`
+	Type        string          `json:"type"`
+	RefID       *string         `json:"ref_id,omitempty"`
+
+	UnitCost     *decimal.Decimal `json:"unit_cost,omitempty"`
+	BalanceValue *decimal.Decimal `json:"balance_value,omitempty"`
+
+	LotNumber  *string    `json:"lot_number,omitempty"`
+	ExpiryDate *time.Time `json:"expiry_date,omitempty"`
+
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+
+	LocationID *uuid.UUID `json:"location_id,omitempty"`
 }
 
 // ============ SUPPORTING MODELS ============
 type Organization struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	Name      string    `gorm:"type:varchar(100);not null"`
-	Code      string    `gorm:"type:varchar(50);unique;not null"`
+	ID       uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name     string    `gorm:"type:varchar(100);not null"`
+	Code     string    `gorm:"type:varchar(50);unique;not null"`
+	IsActive bool      `gorm:"not null;default:true"`
+
+	NegativeStockPolicy NegativeStockPolicy `gorm:"type:varchar(10);not null;default:block"`
+
 	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 func (Organization) TableName() string {
@@ -130,11 +194,18 @@ func (Organization) TableName() string {
 }
 
 type Item struct {
-	ID        uint   `gorm:"primaryKey;autoIncrement"`
-	Code      string `gorm:"type:varchar(50);unique;not null"`
-	Name      string `gorm:"type:varchar(200);not null"`
-	Unit      string `gorm:"type:varchar(20);not null"`
+	ID       uint   `gorm:"primaryKey;autoIncrement"`
+	Code     string `gorm:"type:varchar(50);unique;not null"`
+	Name     string `gorm:"type:varchar(200);not null"`
+	Unit     string `gorm:"type:varchar(20);not null"` // satuan dasar ledger
+	IsActive bool   `gorm:"not null;default:true"`
+
+	CostingMethod CostingMethod `gorm:"type:varchar(10);not null;default:average"`
+	TrackLots     bool          `gorm:"not null;default:false"`
+	Serialized    bool          `gorm:"not null;default:false"`
+
 	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 func (Item) TableName() string {
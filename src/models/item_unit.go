@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ============ UNIT OF MEASURE ============
+// ItemUnit - Satuan alternatif item: 1 Unit = Factor x satuan dasar (Item.Unit).
+// Posting dalam satuan ini dinormalkan ke satuan dasar sebelum masuk ledger.
+type ItemUnit struct {
+	ItemID uint            `gorm:"primaryKey" json:"item_id"`
+	Unit   string          `gorm:"type:varchar(20);primaryKey" json:"unit"`
+	Factor decimal.Decimal `gorm:"type:numeric(20,6);not null" json:"factor"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (ItemUnit) TableName() string {
+	return "item_units"
+}
@@ -0,0 +1,241 @@
+package models
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ============ LEDGER HASH CHAIN ============
+// Setiap baris inventories & inventory_histories menyimpan hash dari isi kanoniknya
+// ditambah hash baris sebelumnya di chain org+item yang sama. Edit SQL langsung
+// ke kolom yang di-hash membuat hash tidak cocok; hapus baris membuat chain_seq bolong.
+//
+// Yang di-hash hanya fakta yang tidak pernah berubah setelah insert. Kolom yang
+// memang di-update aplikasi TIDAK di-hash:
+//   - balance (dan amount/system_qty/difference untuk opname): turunan dari
+//     urutan amount/physical_qty, ditulis ulang oleh RecalculateForward
+//   - deleted_at/deleted_by/updated_*: soft delete, tercatat di history DELETE_BEFORE/UPDATE_BEFORE
+//     atau ROLLBACK yang ikut di-chain; verify mencocokkan setiap baris terhapus dengan history itu
+//   - paired_history_id: link yang diisi setelah pasangan UPDATE_AFTER dibuat
+
+// ChainPayloadVersion - Naikkan jika format payload kanonik berubah
+const ChainPayloadVersion = 1
+
+// chainTimestamp - Kolom timestamptz: instant UTC, presisi mikrodetik (sesuai Postgres)
+func chainTimestamp(t time.Time) string {
+	return t.UTC().Truncate(time.Microsecond).Format("2006-01-02T15:04:05.000000Z")
+}
+
+// chainWallClock - Kolom timestamp tanpa zona: jam dinding apa adanya (zona dibuang driver)
+func chainWallClock(t time.Time) string {
+	return t.Truncate(time.Microsecond).Format("2006-01-02T15:04:05.000000")
+}
+
+// canonicalJSON - jsonb menormalkan spasi & urutan key, jadi decode lalu encode ulang (key terurut)
+func canonicalJSON(raw json.RawMessage) (json.RawMessage, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return json.RawMessage("null"), nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// ChainHash - sha256(prev_hash + "\n" + payload) dalam hex
+func ChainHash(prevHash string, payload []byte) string {
+	sum := sha256.New()
+	sum.Write([]byte(prevHash))
+	sum.Write([]byte("\n"))
+	sum.Write(payload)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// ChainPrevHash - prev_hash baris pertama di chain adalah string kosong
+func ChainPrevHash(prevHash *string) string {
+	if prevHash == nil {
+		return ""
+	}
+	return *prevHash
+}
+
+// ChainPayload - Isi kanonik baris inventory yang di-hash
+func (inv *Inventory) ChainPayload() ([]byte, error) {
+	payload := struct {
+		Version            int                `json:"v"`
+		ID                 uuid.UUID          `json:"id"`
+		OrganizationID     uuid.UUID          `json:"organization_id"`
+		ItemID             uint               `json:"item_id"`
+		TxnDate            string             `json:"txn_date"`
+		Type               InventoryType      `json:"type"`
+		Amount             *json.Number       `json:"amount"` // integer lama → angka yang sama (hash lama tetap valid)
+		PhysicalQty        *json.Number       `json:"physical_qty"`
+		UnitCost           *string            `json:"unit_cost,omitempty"` // tidak ada di baris tanpa harga (hash lama tetap valid)
+		LotNumber          *string            `json:"lot_number,omitempty"`
+		ExpiryDate         *string            `json:"expiry_date,omitempty"`
+		SerialNumbers      []string           `json:"serial_numbers,omitempty"`
+		LocationID         *uuid.UUID         `json:"location_id,omitempty"` // baris tanpa lokasi: hash lama tetap valid
+		RefID              *uuid.UUID         `json:"ref_id"`
+		TargetID           *uuid.UUID         `json:"target_id"`
+		Source             *TransactionSource `json:"source"`
+		FromOrganizationID *uuid.UUID         `json:"from_organization_id"`
+		ToOrganizationID   *uuid.UUID         `json:"to_organization_id"`
+		PageCode           string             `json:"page_code"`
+		Notes              *string            `json:"notes"`
+		CreatedBy          string             `json:"created_by"`
+		CreatedAt          string             `json:"created_at"`
+	}{
+		Version:            ChainPayloadVersion,
+		ID:                 inv.ID,
+		OrganizationID:     inv.OrganizationID,
+		ItemID:             inv.ItemID,
+		TxnDate:            chainWallClock(inv.TxnDate),
+		Type:               inv.Type,
+		RefID:              inv.RefID,
+		TargetID:           inv.TargetID,
+		Source:             inv.Source,
+		FromOrganizationID: inv.FromOrganizationID,
+		ToOrganizationID:   inv.ToOrganizationID,
+		PageCode:           inv.PageCode,
+		Notes:              inv.Notes,
+		CreatedBy:          inv.CreatedBy,
+		CreatedAt:          chainTimestamp(inv.CreatedAt),
+		LotNumber:          inv.LotNumber,
+		SerialNumbers:      inv.SerialNumbers,
+		LocationID:         inv.LocationID,
+	}
+
+	if inv.ExpiryDate != nil {
+		expiryDate := inv.ExpiryDate.Format("2006-01-02")
+		payload.ExpiryDate = &expiryDate
+	}
+	if inv.UnitCost != nil {
+		unitCost := inv.UnitCost.String()
+		payload.UnitCost = &unitCost
+	}
+
+	// Opname: yang dicatat adalah physical_qty, amount = selisih yang dihitung ulang
+	if inv.Type == InventoryTypeOpname {
+		if inv.PhysicalQty != nil {
+			physicalQty := json.Number(inv.PhysicalQty.String())
+			payload.PhysicalQty = &physicalQty
+		}
+	} else {
+		amount := json.Number(inv.Amount.String())
+		payload.Amount = &amount
+	}
+
+	return json.Marshal(payload)
+}
+
+// ChainPayload - Isi kanonik baris history yang di-hash
+func (h *InventoryHistory) ChainPayload() ([]byte, error) {
+	dataBefore, err := canonicalJSON(h.DataBefore)
+	if err != nil {
+		return nil, err
+	}
+	dataAfter, err := canonicalJSON(h.DataAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(struct {
+		Version            int             `json:"v"`
+		ID                 uuid.UUID       `json:"id"`
+		OrganizationID     uuid.UUID       `json:"organization_id"`
+		ItemID             uint            `json:"item_id"`
+		TriggerInventoryID *uuid.UUID      `json:"trigger_inventory_id"`
+		DataBefore         json.RawMessage `json:"data_before"`
+		DataAfter          json.RawMessage `json:"data_after"`
+		SnapshotFromDate   string          `json:"snapshot_from_date"`
+		Action             string          `json:"action"`
+		ChangedBy          string          `json:"changed_by"`
+		Reason             *string         `json:"reason"`
+		CreatedAt          string          `json:"created_at"`
+	}{
+		Version:            ChainPayloadVersion,
+		ID:                 h.ID,
+		OrganizationID:     h.OrganizationID,
+		ItemID:             h.ItemID,
+		TriggerInventoryID: h.TriggerInventoryID,
+		DataBefore:         dataBefore,
+		DataAfter:          dataAfter,
+		SnapshotFromDate:   chainTimestamp(h.SnapshotFromDate),
+		Action:             h.Action,
+		ChangedBy:          h.ChangedBy,
+		Reason:             h.Reason,
+		CreatedAt:          chainTimestamp(h.CreatedAt),
+	})
+}
+
+// AfterCreate - Sambungkan baris inventory baru ke chain org+item
+func (inv *Inventory) AfterCreate(tx *gorm.DB) error {
+	return AppendToChain(tx, inv.TableName(), inv.OrganizationID, inv.ItemID, inv.ID, inv.ChainPayload,
+		&inv.ChainSeq, &inv.PrevHash, &inv.RowHash)
+}
+
+// AfterCreate - Sambungkan baris history baru ke chain org+item
+func (h *InventoryHistory) AfterCreate(tx *gorm.DB) error {
+	return AppendToChain(tx, h.TableName(), h.OrganizationID, h.ItemID, h.ID, h.ChainPayload,
+		&h.ChainSeq, &h.PrevHash, &h.RowHash)
+}
+
+// AppendToChain - Ambil ujung chain org+item, hitung hash baris lalu simpan seq/prev_hash/row_hash.
+// Dijalankan per baris setelah INSERT (juga untuk CreateInBatches) sehingga baris
+// sebelumnya di batch yang sama sudah jadi ujung chain. Penulis ledger sudah memegang
+// advisory lock org+item; unique index (org, item, chain_seq) menjaga sisanya.
+func AppendToChain(tx *gorm.DB, table string, orgID uuid.UUID, itemID uint, id uuid.UUID,
+	payloadFn func() ([]byte, error), seq **int64, prevHash, rowHash **string) error {
+
+	db := tx.Session(&gorm.Session{NewDB: true})
+
+	var tip struct {
+		ChainSeq int64
+		RowHash  *string
+	}
+	if err := db.Table(table).
+		Select("chain_seq, row_hash").
+		Where("organization_id = ? AND item_id = ? AND chain_seq IS NOT NULL", orgID, itemID).
+		Order("chain_seq DESC").
+		Limit(1).
+		Scan(&tip).Error; err != nil {
+		return err
+	}
+
+	payload, err := payloadFn()
+	if err != nil {
+		return err
+	}
+
+	var prev *string
+	if tip.ChainSeq > 0 {
+		prev = new(string)
+		if tip.RowHash != nil {
+			*prev = *tip.RowHash
+		}
+	}
+
+	nextSeq := tip.ChainSeq + 1
+	hash := ChainHash(ChainPrevHash(prev), payload)
+
+	if err := db.Table(table).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"chain_seq": nextSeq,
+		"prev_hash": prev,
+		"row_hash":  hash,
+	}).Error; err != nil {
+		return err
+	}
+
+	*seq, *prevHash, *rowHash = &nextSeq, prev, &hash
+	return nil
+}
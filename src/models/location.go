@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ============ LOCATION (BIN) HIERARCHY ============
+// Location - Satu node pohon lokasi di dalam organisasi (zona > rak > bin, kedalaman bebas).
+// Posting boleh menyebut lokasi; tanpa lokasi stok tercatat sebagai "belum ditempatkan".
+type Location struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	OrganizationID uuid.UUID  `gorm:"type:uuid;not null" json:"organization_id"`
+	ParentID       *uuid.UUID `gorm:"type:uuid" json:"parent_id"`
+	Code           string     `gorm:"type:varchar(50);not null" json:"code"` // unik per organisasi
+	Name           string     `gorm:"type:varchar(100);not null" json:"name"`
+	IsActive       bool       `gorm:"not null;default:true" json:"is_active"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Location) TableName() string {
+	return "locations"
+}
+
+// ============ LOCATION BALANCE PROJECTION ============
+// LocationBalance - Saldo per org+lokasi+item. Ditulis ulang dari ledger setiap recalculation
+// (seperti LotBalance); baris tanpa lokasi tidak disimpan, saldonya = stock_balances - total lokasi.
+type LocationBalance struct {
+	OrganizationID uuid.UUID `gorm:"type:uuid;primaryKey" json:"organization_id"`
+	LocationID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"location_id"`
+	ItemID         uint      `gorm:"primaryKey" json:"item_id"`
+
+	Balance decimal.Decimal `gorm:"type:numeric(20,6);not null" json:"balance"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (LocationBalance) TableName() string {
+	return "location_balances"
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ============ LOT BALANCE PROJECTION ============
+// LotBalance - Saldo per org+item+lot untuk item TrackLots. Ditulis ulang dari ledger
+// setiap recalculation (seperti StockBalance); lot yang sudah habis dihapus.
+type LotBalance struct {
+	OrganizationID uuid.UUID `gorm:"type:uuid;primaryKey" json:"organization_id"`
+	ItemID         uint      `gorm:"primaryKey" json:"item_id"`
+	LotNumber      string    `gorm:"type:varchar(50);primaryKey" json:"lot_number"`
+
+	ExpiryDate *time.Time      `gorm:"type:date" json:"expiry_date"`
+	Balance    decimal.Decimal `gorm:"type:numeric(20,6);not null" json:"balance"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (LotBalance) TableName() string {
+	return "lot_balances"
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ============ OUTBOX ============
+type OutboxStatus string
+
+const (
+	OutboxStatusPending   OutboxStatus = "pending"
+	OutboxStatusDelivered OutboxStatus = "delivered"
+	OutboxStatusDead      OutboxStatus = "dead" // melewati batas attempts, perlu ditangani manual
+)
+
+// OutboxEvent - Domain event yang ditulis di transaksi yang sama dengan perubahan ledger,
+// lalu dikirim ke sink oleh dispatcher (at-least-once, consumer dedup berdasarkan ID).
+type OutboxEvent struct {
+	ID             uint64          `gorm:"primaryKey;autoIncrement"`
+	EventType      string          `gorm:"type:varchar(50);not null"`
+	OrganizationID uuid.UUID       `gorm:"type:uuid;not null"`
+	ItemID         uint            `gorm:"not null"`
+	Payload        json.RawMessage `gorm:"type:jsonb;not null"`
+
+	Status        OutboxStatus `gorm:"type:varchar(20);not null;default:pending"`
+	Attempts      int          `gorm:"not null;default:0"`
+	NextAttemptAt time.Time    `gorm:"not null"`
+	LastError     *string      `gorm:"type:text"`
+
+	CreatedAt   time.Time
+	DeliveredAt *time.Time
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// OutboxEnvelope - Bentuk JSON event yang diterima sink/consumer
+type OutboxEnvelope struct {
+	ID             uint64          `json:"id"`
+	Type           string          `json:"type"`
+	OrganizationID uuid.UUID       `json:"organization_id"`
+	ItemID         uint            `json:"item_id"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+	Data           json.RawMessage `json:"data"`
+}
+
+// Envelope - Bungkus payload dengan metadata event
+func (e *OutboxEvent) Envelope() OutboxEnvelope {
+	return OutboxEnvelope{
+		ID:             e.ID,
+		Type:           e.EventType,
+		OrganizationID: e.OrganizationID,
+		ItemID:         e.ItemID,
+		OccurredAt:     e.CreatedAt,
+		Data:           e.Payload,
+	}
+}
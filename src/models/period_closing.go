@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ============ PERIOD CLOSING (TUTUP BUKU) ============
+// PeriodClosing mengunci ledger satu organisasi sampai PeriodEnd (inklusif).
+// Closing aktif selama ReopenedAt nil; reopen dicatat di baris yang sama supaya
+// jejak tutup/buka buku tidak pernah hilang.
+type PeriodClosing struct {
+	ID             uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null;index"`
+	PeriodEnd      time.Time `gorm:"type:date;not null"`
+	ItemCount      int       `gorm:"not null;default:0"`
+
+	ClosedBy string  `gorm:"type:varchar(100);not null"`
+	Reason   *string `gorm:"type:text"`
+	ClosedAt time.Time
+
+	// Reopen eksplisit (membuka kembali posting di periode ini)
+	ReopenedBy   *string    `gorm:"type:varchar(100)"`
+	ReopenReason *string    `gorm:"type:text"`
+	ReopenedAt   *time.Time `gorm:"type:timestamptz"`
+
+	Balances []PeriodClosingBalance `gorm:"foreignKey:ClosingID"`
+}
+
+func (PeriodClosing) TableName() string {
+	return "period_closings"
+}
+
+// IsActive - Closing belum di-reopen
+func (p *PeriodClosing) IsActive() bool {
+	return p.ReopenedAt == nil
+}
+
+// PeriodClosingBalance - Saldo akhir item pada PeriodEnd saat periode ditutup
+type PeriodClosingBalance struct {
+	ClosingID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ItemID    uint      `gorm:"primaryKey"`
+
+	Balance         decimal.Decimal `gorm:"type:numeric(20,6);not null;default:0"`
+	LastTxnDate     *time.Time      `gorm:"type:timestamp"`
+	LastInventoryID *uuid.UUID      `gorm:"type:uuid"`
+}
+
+func (PeriodClosingBalance) TableName() string {
+	return "period_closing_balances"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// ============ QUANTITY ============
+// Semua kuantitas (amount, balance, physical_qty, level stok, layer, lot) disimpan
+// sebagai numeric(20,6) dalam satuan dasar item (Item.Unit).
+
+// QuantityScale - Jumlah digit desimal kuantitas (sesuai kolom numeric(20,6))
+const QuantityScale int32 = 6
+
+func init() {
+	// Kuantitas & nilai tetap berupa angka JSON (bukan string) di response, snapshot history
+	// dan payload webhook, jadi client lama yang membaca integer tidak berubah
+	decimal.MarshalJSONWithoutQuotes = true
+}
+
+// Qty - Helper kuantitas integer (seed, test, konstanta)
+func Qty(n int64) decimal.Decimal {
+	return decimal.NewFromInt(n)
+}
+
+// QtyPtr - Pointer ke salinan kuantitas (kolom opname, level stok)
+func QtyPtr(q decimal.Decimal) *decimal.Decimal {
+	return &q
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SerialStatus - Posisi unit serialized
+type SerialStatus string
+
+const (
+	SerialInStock SerialStatus = "in_stock" // ada di OrganizationID
+	SerialOut     SerialStatus = "out"      // sudah dipakai / keluar lewat opname
+)
+
+// ============ SERIAL REGISTRY PROJECTION ============
+// SerialNumber - Posisi terakhir satu unit item Serialized. Ditulis ulang dari ledger
+// setiap recalculation (seperti LotBalance); riwayat lengkap ada di baris inventories.
+type SerialNumber struct {
+	ItemID       uint   `gorm:"primaryKey" json:"item_id"`
+	SerialNumber string `gorm:"type:varchar(100);primaryKey" json:"serial_number"`
+
+	OrganizationID  *uuid.UUID   `gorm:"type:uuid" json:"organization_id"`
+	Status          SerialStatus `gorm:"type:varchar(20);not null" json:"status"`
+	LastInventoryID *uuid.UUID   `gorm:"type:uuid" json:"last_inventory_id"`
+	LastTxnDate     *time.Time   `gorm:"type:timestamp" json:"last_txn_date"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (SerialNumber) TableName() string {
+	return "serial_numbers"
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ============ STOCK LEVELS ============
+// StockLevel - Batas stok org+item. Nil = tidak dipakai.
+type StockLevel struct {
+	OrganizationID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ItemID         uint      `gorm:"primaryKey"`
+
+	MinQty       *decimal.Decimal `gorm:"type:numeric(20,6)"`
+	MaxQty       *decimal.Decimal `gorm:"type:numeric(20,6)"`
+	ReorderPoint *decimal.Decimal `gorm:"type:numeric(20,6)"`
+
+	UpdatedBy *string `gorm:"type:varchar(100)"`
+	UpdatedAt time.Time
+}
+
+func (StockLevel) TableName() string {
+	return "stock_levels"
+}
+
+// ============ STOCK ALERTS ============
+type StockAlertType string
+
+const (
+	StockAlertBelowMin StockAlertType = "below_min" // saldo < min_qty
+	StockAlertReorder  StockAlertType = "reorder"   // saldo <= reorder_point
+	StockAlertAboveMax StockAlertType = "above_max" // saldo > max_qty
+)
+
+type StockAlertState string
+
+const (
+	StockAlertOpen         StockAlertState = "open"
+	StockAlertAcknowledged StockAlertState = "acknowledged"
+	StockAlertResolved     StockAlertState = "resolved"
+)
+
+// StockAlert - Satu kejadian saldo melewati level stok. Selama belum resolved,
+// tidak ada alert baru dengan type yang sama untuk org+item tersebut.
+type StockAlert struct {
+	ID             uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID uuid.UUID       `gorm:"type:uuid;not null"`
+	ItemID         uint            `gorm:"not null"`
+	AlertType      StockAlertType  `gorm:"type:varchar(20);not null"`
+	Threshold      decimal.Decimal `gorm:"type:numeric(20,6);not null"`
+	Balance        decimal.Decimal `gorm:"type:numeric(20,6);not null"` // saldo saat alert dibuat
+
+	State    StockAlertState `gorm:"type:varchar(20);not null;default:open"`
+	RaisedAt time.Time
+
+	AcknowledgedBy  *string `gorm:"type:varchar(100)"`
+	AcknowledgeNote *string `gorm:"type:text"`
+	AcknowledgedAt  *time.Time
+
+	ResolvedBalance *decimal.Decimal `gorm:"type:numeric(20,6)"`
+	ResolvedAt      *time.Time
+}
+
+func (StockAlert) TableName() string {
+	return "stock_alerts"
+}
+
+// IsActive - Alert belum resolved
+func (a *StockAlert) IsActive() bool {
+	return a.State != StockAlertResolved
+}
+
+// ============ ALERT NOTIFICATIONS ============
+type AlertNotificationStatus string
+
+const (
+	AlertNotificationPending   AlertNotificationStatus = "pending"
+	AlertNotificationDelivered AlertNotificationStatus = "delivered"
+	AlertNotificationFailed    AlertNotificationStatus = "failed" // melewati batas attempts
+)
+
+// AlertNotification - Satu event alert untuk satu notifier (log/smtp/webhook), dikirim dan
+// di-retry terpisah dari dispatcher outbox. Payload = payload StockAlertEvent dari outbox.
+type AlertNotification struct {
+	ID        uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Notifier  string          `gorm:"type:varchar(20);not null"`
+	EventID   uint64          `gorm:"not null"`
+	EventType string          `gorm:"type:varchar(50);not null"`
+	Payload   json.RawMessage `gorm:"type:jsonb;not null"`
+
+	Status        AlertNotificationStatus `gorm:"type:varchar(20);not null;default:pending"`
+	Attempts      int                     `gorm:"not null;default:0"`
+	NextAttemptAt time.Time               `gorm:"not null"`
+	LastError     *string                 `gorm:"type:text"`
+
+	CreatedAt   time.Time
+	DeliveredAt *time.Time
+}
+
+func (AlertNotification) TableName() string {
+	return "alert_notifications"
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ============ STOCK BALANCE PROJECTION ============
+// StockBalance menyimpan saldo terakhir per org+item supaya pembacaan saldo
+// dan summary tidak perlu scan ledger. Selalu di-refresh di transaksi yang
+// sama dengan perubahan inventories (lihat RecalculateForward).
+type StockBalance struct {
+	OrganizationID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ItemID         uint      `gorm:"primaryKey"`
+
+	Balance         decimal.Decimal `gorm:"type:numeric(20,6);not null;default:0"`
+	BalanceValue    decimal.Decimal `gorm:"type:numeric(20,6);not null;default:0"`
+	LastTxnDate     *time.Time      `gorm:"type:timestamp"`
+	LastInventoryID *uuid.UUID      `gorm:"type:uuid"`
+
+	UpdatedAt time.Time
+}
+
+func (StockBalance) TableName() string {
+	return "stock_balances"
+}
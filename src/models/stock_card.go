@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ============ STOCK CARD (KARTU STOK) ============
+// StockCardLine - Satu pergerakan di kartu stok (kolom masuk/keluar + saldo berjalan)
+type StockCardLine struct {
+	InventoryID uuid.UUID       `json:"inventory_id"`
+	TxnDate     time.Time       `json:"txn_date"`
+	Type        string          `json:"type"`
+	RefID       *uuid.UUID      `json:"ref_id,omitempty"`
+	Notes       *string         `json:"notes,omitempty"`
+	LotNumber   *string         `json:"lot_number,omitempty"`
+	Serials     []string        `json:"serial_numbers,omitempty"`
+	LocationID  *uuid.UUID      `json:"location_id,omitempty"`
+	In          decimal.Decimal `json:"in"`
+	Out         decimal.Decimal `json:"out"`
+	Balance     decimal.Decimal `json:"balance"`
+
+	CostAmount   *decimal.Decimal `json:"cost_amount,omitempty"`
+	BalanceValue *decimal.Decimal `json:"balance_value,omitempty"`
+}
+
+// StockCard - Kartu stok per org+item untuk rentang tanggal
+type StockCard struct {
+	OrganizationID   uuid.UUID `json:"organization_id"`
+	OrganizationCode string    `json:"organization_code"`
+	OrganizationName string    `json:"organization_name"`
+	ItemID           uint      `json:"item_id"`
+	ItemCode         string    `json:"item_code"`
+	ItemName         string    `json:"item_name"`
+	Unit             string    `json:"unit"`
+
+	FromDate time.Time `json:"from_date"`
+	ToDate   time.Time `json:"to_date"`
+
+	OpeningBalance decimal.Decimal `json:"opening_balance"`
+	TotalIn        decimal.Decimal `json:"total_in"`
+	TotalOut       decimal.Decimal `json:"total_out"`
+	ClosingBalance decimal.Decimal `json:"closing_balance"`
+	Lines          []StockCardLine `json:"lines"`
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ============ WEBHOOK ============
+// WebhookSubscription - Endpoint penerima event outbox. EventTypes / OrganizationIDs kosong = semua.
+// Payload ditandatangani HMAC-SHA256 dengan Secret (tidak pernah dikembalikan lewat API).
+type WebhookSubscription struct {
+	ID              uuid.UUID   `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name            string      `gorm:"type:varchar(100);not null"`
+	URL             string      `gorm:"type:text;not null"`
+	Secret          string      `gorm:"type:varchar(255);not null" json:"-"`
+	EventTypes      []string    `gorm:"type:jsonb;serializer:json;not null"`
+	OrganizationIDs []uuid.UUID `gorm:"type:jsonb;serializer:json;not null"`
+	IsActive        bool        `gorm:"not null;default:true"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Matches - Event lolos filter event type & organisasi subscription
+func (w *WebhookSubscription) Matches(eventType string, orgID uuid.UUID) bool {
+	if !w.IsActive {
+		return false
+	}
+	if len(w.EventTypes) > 0 && !containsString(w.EventTypes, eventType) {
+		return false
+	}
+	if len(w.OrganizationIDs) == 0 {
+		return true
+	}
+	for _, id := range w.OrganizationIDs {
+		if id == orgID {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed" // melewati batas attempts, bisa di-redeliver manual
+)
+
+// WebhookDelivery - Log pengiriman satu event ke satu subscription.
+// Redelivery manual membuat baris baru (RedeliveryOf) supaya log lama tetap utuh.
+type WebhookDelivery struct {
+	ID             uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	SubscriptionID uuid.UUID       `gorm:"type:uuid;not null"`
+	EventID        uint64          `gorm:"not null"`
+	EventType      string          `gorm:"type:varchar(50);not null"`
+	Payload        json.RawMessage `gorm:"type:jsonb;not null"`
+	RedeliveryOf   *uuid.UUID      `gorm:"type:uuid"`
+
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(20);not null;default:pending"`
+	Attempts       int                   `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `gorm:"not null"`
+	LastStatusCode *int
+	LastError      *string `gorm:"type:text"`
+	LastResponse   *string `gorm:"type:text"`
+
+	CreatedAt   time.Time
+	DeliveredAt *time.Time
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,206 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/services"
+)
+
+// recordingSink - Sink test: gagal sebanyak failures kali pertama, lalu catat event yang diterima
+type recordingSink struct {
+	failures  int
+	delivered []uint64
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Deliver(ctx context.Context, event *models.OutboxEvent) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("receiver unavailable")
+	}
+	s.delivered = append(s.delivered, event.ID)
+	return nil
+}
+
+// blockingSink - Sink test yang menahan Deliver sampai release ditutup
+type blockingSink struct {
+	started chan uint64
+	release chan struct{}
+}
+
+func (s *blockingSink) Name() string { return "blocking" }
+
+func (s *blockingSink) Deliver(ctx context.Context, event *models.OutboxEvent) error {
+	s.started <- event.ID
+	<-s.release
+	return nil
+}
+
+// ============ TEST SCENARIO 20: TRANSACTIONAL OUTBOX ============
+func TestOutboxEvents(t *testing.T) {
+	assertNoError(t, testDB.Exec("DELETE FROM outbox_events").Error)
+
+	orgID := uuid.New()
+	otherOrgID := uuid.New()
+	testDB.Create(&models.Organization{ID: orgID, Name: "Outbox Org", Code: "ORG-OUTBOX"})
+	testDB.Create(&models.Organization{ID: otherOrgID, Name: "Outbox Branch", Code: "ORG-OUTBOX-2"})
+
+	base := time.Date(2025, 4, 1, 8, 0, 0, 0, time.UTC)
+	eventTypes := func(org uuid.UUID) string {
+		var types []string
+		assertNoError(t, testDB.Model(&models.OutboxEvent{}).
+			Where("organization_id = ?", org).Order("id").Pluck("event_type", &types).Error)
+		return strings.Join(types, ",")
+	}
+
+	t.Run("SC43: Write paths emit events in the same transaction", func(t *testing.T) {
+		created, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: testItemID, TxnDate: base, Amount: models.Qty(50), Type: "stok_awal", ChangedBy: "outbox_test",
+		})
+		assertNoError(t, err)
+		assertNoError(t, testService.CreateMutation(services.MutationRequest{
+			FromOrganizationID: orgID, ToOrganizationID: otherOrgID, ItemID: testItemID,
+			Quantity: models.Qty(10), TxnDate: base.Add(time.Hour), ChangedBy: "outbox_test",
+		}))
+		_, err = testService.CreateOpname(services.OpnameRequest{
+			OrganizationID: orgID, ItemID: testItemID, PhysicalQty: models.Qty(40), TxnDate: base.Add(2 * time.Hour), ChangedBy: "outbox_test",
+		})
+		assertNoError(t, err)
+		assertNoError(t, testService.UpdateTransaction(services.UpdateTransactionRequest{
+			InventoryID: created.ID, TxnDate: base, Amount: models.Qty(60), ChangedBy: "outbox_test",
+		}))
+
+		// Opname menahan saldo akhir di 40: update stok awal tidak mengubah saldo terakhir
+		assertEqual(t, "BalanceChanged,TransactionCreated,BalanceChanged,MutationPosted,OpnameAdjusted,TransactionUpdated",
+			eventTypes(orgID), "source org events")
+		assertEqual(t, "BalanceChanged,MutationPosted", eventTypes(otherOrgID), "destination org events")
+
+		var updated models.OutboxEvent
+		assertNoError(t, testDB.Where("organization_id = ? AND event_type = ?", orgID, services.EventTransactionUpdated).Take(&updated).Error)
+		var payload services.TransactionUpdatedEvent
+		assertNoError(t, json.Unmarshal(updated.Payload, &payload))
+		assertEqual(t, 50, payload.Previous.Amount, "previous amount")
+		assertEqual(t, 60, payload.Current.Amount, "current amount")
+		assertEqual(t, 60, payload.Current.Balance, "current balance")
+
+		// Write yang gagal tidak meninggalkan event
+		before := eventTypes(otherOrgID)
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: otherOrgID, ItemID: testItemID, TxnDate: base.Add(3 * time.Hour), Amount: models.Qty(-100), Type: "pemakaian", ChangedBy: "outbox_test",
+		})
+		assertEqual(t, true, errors.Is(err, services.ErrNegativeStock), "negative stock rejected")
+		assertEqual(t, before, eventTypes(otherOrgID), "no events from rejected posting")
+	})
+
+	t.Run("SC44: Dispatcher retries with backoff, keeps per-item order and marks dead events", func(t *testing.T) {
+		sink := &recordingSink{failures: 1}
+		dispatcher := &services.OutboxDispatcher{
+			DB: testDB, Sinks: []services.EventSink{sink},
+			MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond,
+		}
+
+		// Event pertama gagal: event lain untuk org+item yang sama ditahan
+		processed, err := dispatcher.DispatchOnce(context.Background())
+		assertNoError(t, err)
+		assertEqual(t, 2, processed, "first event of each org+item")
+		assertEqual(t, 1, len(sink.delivered), "other org delivered")
+
+		var first models.OutboxEvent
+		assertNoError(t, testDB.Where("organization_id = ?", orgID).Order("id").First(&first).Error)
+		assertEqual(t, models.OutboxStatusPending, first.Status, "failed event stays pending")
+		assertEqual(t, 1, first.Attempts, "attempts after failure")
+
+		time.Sleep(5 * time.Millisecond)
+		for i := 0; i < 10; i++ {
+			if processed, err = dispatcher.DispatchOnce(context.Background()); err != nil || processed == 0 {
+				break
+			}
+		}
+		assertNoError(t, err)
+
+		var pending int64
+		testDB.Model(&models.OutboxEvent{}).Where("status = ?", models.OutboxStatusPending).Count(&pending)
+		assertEqual(t, int64(0), pending, "all events delivered")
+
+		// Urutan id per org+item tetap terjaga meskipun event pertama sempat gagal
+		var delivered []models.OutboxEvent
+		assertNoError(t, testDB.Where("id IN ?", sink.delivered).Find(&delivered).Error)
+		orgOf := make(map[uint64]uuid.UUID, len(delivered))
+		for _, event := range delivered {
+			orgOf[event.ID] = event.OrganizationID
+		}
+		lastID := map[uuid.UUID]uint64{}
+		for _, id := range sink.delivered {
+			if id < lastID[orgOf[id]] {
+				t.Fatalf("events delivered out of order: %v", sink.delivered)
+			}
+			lastID[orgOf[id]] = id
+		}
+
+		// Sink yang selalu gagal: dead setelah MaxAttempts
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: testItemID, TxnDate: base.Add(4 * time.Hour), Amount: models.Qty(5), Type: "penerimaan", ChangedBy: "outbox_test",
+		})
+		assertNoError(t, err)
+		sink.failures = 100
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond)
+			_, err = dispatcher.DispatchOnce(context.Background())
+			assertNoError(t, err)
+		}
+
+		var dead models.OutboxEvent
+		assertNoError(t, testDB.Where("status = ?", models.OutboxStatusDead).Order("id").First(&dead).Error)
+		assertEqual(t, 3, dead.Attempts, "attempts before dead")
+		assertEqual(t, "recording: receiver unavailable", *dead.LastError, "last error")
+	})
+
+	t.Run("SC64: Claimed events are leased and delivered outside the claiming transaction", func(t *testing.T) {
+		leaseOrgID := uuid.New()
+		testDB.Create(&models.Organization{ID: leaseOrgID, Name: "Outbox Lease", Code: "ORG-OUTBOX-3"})
+		assertNoError(t, testDB.Model(&models.OutboxEvent{}).Where("status = ?", models.OutboxStatusPending).
+			Update("status", models.OutboxStatusDead).Error)
+		_, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: leaseOrgID, ItemID: testItemID, TxnDate: base, Amount: models.Qty(5), Type: "stok_awal", ChangedBy: "outbox_test",
+		})
+		assertNoError(t, err)
+
+		sink := &blockingSink{started: make(chan uint64, 1), release: make(chan struct{})}
+		dispatcher := &services.OutboxDispatcher{DB: testDB, Sinks: []services.EventSink{sink}}
+		done := make(chan int)
+		go func() {
+			processed, err := dispatcher.DispatchOnce(context.Background())
+			if err != nil {
+				t.Errorf("dispatch: %v", err)
+			}
+			done <- processed
+		}()
+		claimedID := <-sink.started
+
+		// Selama sink berjalan: tidak ada row lock, dan dispatcher lain tidak mengambil event yang sama
+		var claimed models.OutboxEvent
+		assertNoError(t, testDB.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
+			Where("id = ?", claimedID).Take(&claimed).Error)
+		assertEqual(t, models.OutboxStatusPending, claimed.Status, "claimed event stays pending")
+		assertEqual(t, true, claimed.NextAttemptAt.After(time.Now()), "claimed event leased")
+		other, err := (&services.OutboxDispatcher{DB: testDB, Sinks: []services.EventSink{&recordingSink{}}}).
+			DispatchOnce(context.Background())
+		assertNoError(t, err)
+		assertEqual(t, 0, other, "leased event and its successors are not claimed again")
+
+		close(sink.release)
+		assertEqual(t, 1, <-done, "first event of the org+item")
+		assertNoError(t, testDB.Where("id = ?", claimedID).Take(&claimed).Error)
+		assertEqual(t, models.OutboxStatusDelivered, claimed.Status, "claimed event delivered")
+	})
+}
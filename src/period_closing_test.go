@@ -0,0 +1,177 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/services"
+)
+
+// ============ TEST SCENARIO 18: PERIOD CLOSING & POSTING LOCK ============
+func TestPeriodClosing(t *testing.T) {
+	orgID := uuid.New()
+	testDB.Create(&models.Organization{ID: orgID, Name: "Period Closing Org", Code: "ORG-PERIOD"})
+
+	periodService := &services.PeriodService{DB: testDB, Inventory: testService}
+
+	january := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)
+
+	post := func(txnDate time.Time, amount int, txnType string) (*models.Inventory, error) {
+		return testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID,
+			ItemID:         testItemID,
+			TxnDate:        txnDate,
+			Amount:         models.Qty(int64(amount)),
+			Type:           txnType,
+			ChangedBy:      "period_test",
+		})
+	}
+	assertClosed := func(err error, msg string) {
+		t.Helper()
+		if !errors.Is(err, services.ErrPeriodClosed) {
+			t.Fatalf("%s: expected ErrPeriodClosed, got %v", msg, err)
+		}
+	}
+
+	_, err := post(time.Date(2025, 1, 2, 8, 0, 0, 0, time.UTC), 100, "stok_awal")
+	assertNoError(t, err)
+	lastJanuary, err := post(time.Date(2025, 1, 31, 17, 0, 0, 0, time.UTC), -30, "pemakaian")
+	assertNoError(t, err)
+	february10, err := post(time.Date(2025, 2, 10, 8, 0, 0, 0, time.UTC), 20, "penerimaan")
+	assertNoError(t, err)
+
+	var closing *models.PeriodClosing
+
+	t.Run("SC39: Closing snapshots period-end balances and locks every write path", func(t *testing.T) {
+		closing, err = periodService.ClosePeriod(services.ClosePeriodRequest{
+			OrganizationID: orgID,
+			PeriodEnd:      january,
+			ClosedBy:       "period_test",
+		})
+		assertNoError(t, err)
+		assertEqual(t, 1, closing.ItemCount, "closed items")
+		assertEqual(t, 70, closing.Balances[0].Balance, "january closing balance")
+		assertEqual(t, lastJanuary.ID, *closing.Balances[0].LastInventoryID, "last january row")
+
+		// Backdated create, update & delete di Januari ditolak
+		_, err = post(time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC), 5, "penerimaan")
+		assertClosed(err, "backdated create")
+
+		err = testService.UpdateTransaction(services.UpdateTransactionRequest{
+			InventoryID: lastJanuary.ID, TxnDate: lastJanuary.TxnDate, Amount: models.Qty(-10), ChangedBy: "period_test",
+		})
+		assertClosed(err, "update in closed period")
+
+		err = testService.DeleteTransaction(lastJanuary.ID, "period_test", nil)
+		assertClosed(err, "delete in closed period")
+
+		// Memindahkan transaksi Februari ke Januari juga ditolak (earliest date)
+		err = testService.UpdateTransaction(services.UpdateTransactionRequest{
+			InventoryID: february10.ID, TxnDate: time.Date(2025, 1, 20, 8, 0, 0, 0, time.UTC),
+			Amount: models.Qty(20), ChangedBy: "period_test",
+		})
+		assertClosed(err, "move into closed period")
+
+		var history models.InventoryHistory
+		assertNoError(t, testDB.Where("trigger_inventory_id = ? AND action = ?", lastJanuary.ID, "CREATE").Take(&history).Error)
+		err = testService.RollbackTransaction(history.ID, "period_test", nil)
+		assertClosed(err, "rollback into closed period")
+
+		// Periode terbuka tetap bisa diposting
+		_, err = post(time.Date(2025, 2, 1, 8, 0, 0, 0, time.UTC), 10, "penerimaan")
+		assertNoError(t, err)
+		assertEqual(t, 100, assertBalanceChain(t, orgID, testItemID), "balance after open-period posting")
+	})
+
+	t.Run("SC40: Closings must move forward and only the latest can be reopened", func(t *testing.T) {
+		_, err := periodService.ClosePeriod(services.ClosePeriodRequest{
+			OrganizationID: orgID, PeriodEnd: january.AddDate(0, 0, -7), ClosedBy: "period_test",
+		})
+		assertEqual(t, services.ErrPeriodAlreadyClosed, err, "closing before latest closing")
+
+		_, err = periodService.ClosePeriod(services.ClosePeriodRequest{
+			OrganizationID: orgID, PeriodEnd: time.Now(), ClosedBy: "period_test",
+		})
+		assertEqual(t, services.ErrPeriodNotEnded, err, "closing today")
+
+		febClosing, err := periodService.ClosePeriod(services.ClosePeriodRequest{
+			OrganizationID: orgID, PeriodEnd: february, ClosedBy: "period_test",
+		})
+		assertNoError(t, err)
+		assertEqual(t, 100, febClosing.Balances[0].Balance, "february closing balance")
+
+		_, err = periodService.ReopenPeriod(services.ReopenPeriodRequest{
+			OrganizationID: orgID, ClosingID: closing.ID, ReopenedBy: "period_test", Reason: "audit adjustment",
+		})
+		assertEqual(t, services.ErrClosingNotLatest, err, "reopen older closing")
+
+		_, err = periodService.ReopenPeriod(services.ReopenPeriodRequest{
+			OrganizationID: orgID, ClosingID: febClosing.ID, ReopenedBy: "period_test",
+		})
+		assertEqual(t, services.ErrReopenReasonRequired, err, "reopen without reason")
+
+		reopened, err := periodService.ReopenPeriod(services.ReopenPeriodRequest{
+			OrganizationID: orgID, ClosingID: febClosing.ID, ReopenedBy: "period_test", Reason: "late invoice",
+		})
+		assertNoError(t, err)
+		assertEqual(t, false, reopened.IsActive(), "february closing reopened")
+
+		// Februari terbuka lagi, Januari tetap terkunci oleh closing sebelumnya
+		_, err = post(time.Date(2025, 2, 20, 8, 0, 0, 0, time.UTC), 5, "penerimaan")
+		assertNoError(t, err)
+		_, err = post(time.Date(2025, 1, 20, 8, 0, 0, 0, time.UTC), 5, "penerimaan")
+		assertClosed(err, "january still closed")
+
+		closings, err := periodService.ListClosings(orgID)
+		assertNoError(t, err)
+		assertEqual(t, 2, len(closings), "closing records kept after reopen")
+	})
+	t.Run("SC70: Offline repair and revaluation leave closed periods untouched", func(t *testing.T) {
+		var before models.Inventory
+		assertNoError(t, testDB.First(&before, "id = ?", lastJanuary.ID).Error)
+		var februaryBefore models.Inventory
+		assertNoError(t, testDB.First(&februaryBefore, "id = ?", february10.ID).Error)
+		costOf := func(inv models.Inventory) string {
+			if inv.CostAmount == nil {
+				return "<nil>"
+			}
+			return inv.CostAmount.String()
+		}
+
+		// Saldo rusak di Januari (tertutup): repair ditolak, baris tidak ditulis ulang
+		assertNoError(t, testDB.Exec("UPDATE inventories SET balance = 999 WHERE id = ?", lastJanuary.ID).Error)
+		integrityService := &services.IntegrityService{DB: testDB, Inventory: testService}
+		report, err := integrityService.Check(orgID, testItemID, true)
+		assertNoError(t, err)
+		assertEqual(t, 0, len(report.Repaired), "repaired sequences")
+		assertEqual(t, 1, len(report.Rejected), "rejected sequences")
+		if report.Unresolved == 0 {
+			t.Fatal("balance break in closed period should stay unresolved")
+		}
+		var corrupted models.Inventory
+		assertNoError(t, testDB.First(&corrupted, "id = ?", lastJanuary.ID).Error)
+		assertEqual(t, 999, corrupted.Balance, "closed row after rejected repair")
+		assertNoError(t, testDB.Exec("UPDATE inventories SET balance = ? WHERE id = ?", before.Balance, lastJanuary.ID).Error)
+
+		// revalue menilai ulang Februari (terbuka) saja
+		assertNoError(t, testDB.Exec("UPDATE inventories SET cost_amount = 12345 WHERE id IN ?",
+			[]uuid.UUID{lastJanuary.ID, february10.ID}).Error)
+		revalued, err := testService.RevalueAll()
+		assertNoError(t, err)
+		if revalued.Revalued == 0 {
+			t.Fatal("expected items to be revalued")
+		}
+
+		var january, february models.Inventory
+		assertNoError(t, testDB.First(&january, "id = ?", lastJanuary.ID).Error)
+		assertNoError(t, testDB.First(&february, "id = ?", february10.ID).Error)
+		assertEqual(t, "12345", costOf(january), "closed row cost_amount")
+		assertEqual(t, costOf(februaryBefore), costOf(february), "open row cost_amount")
+		assertNoError(t, testDB.Exec("UPDATE inventories SET cost_amount = ? WHERE id = ?", before.CostAmount, lastJanuary.ID).Error)
+	})
+}
@@ -0,0 +1,211 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+	"inventory-ledger/src/services"
+)
+
+// ============ TEST SCENARIO 19: STREAMING RECALCULATION & OFFLINE REBUILD ============
+func TestRecalculateStreaming(t *testing.T) {
+	orgID := uuid.New()
+	testDB.Create(&models.Organization{ID: orgID, Name: "Recalc Org", Code: "ORG-RECALC"})
+
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2025, month, d, 8, 0, 0, 0, time.UTC)
+	}
+	post := func(txnDate time.Time, amount int, txnType string) *models.Inventory {
+		inv, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID,
+			ItemID:         testItemID,
+			TxnDate:        txnDate,
+			Amount:         models.Qty(int64(amount)),
+			Type:           txnType,
+			ChangedBy:      "recalc_test",
+		})
+		assertNoError(t, err)
+		return inv
+	}
+
+	post(day(time.January, 5), 100, "stok_awal")
+	post(day(time.January, 20), -10, "pemakaian")
+	opname, err := testService.CreateOpname(services.OpnameRequest{
+		OrganizationID: orgID, ItemID: testItemID, PhysicalQty: models.Qty(80), TxnDate: day(time.February, 10), ChangedBy: "recalc_test",
+	})
+	assertNoError(t, err)
+	post(day(time.February, 15), 30, "penerimaan")
+	march := post(day(time.March, 3), -20, "pemakaian")
+
+	t.Run("SC41: Backdated posting is recalculated up to the next opname", func(t *testing.T) {
+		post(day(time.January, 10), 5, "penerimaan")
+
+		var stored models.Inventory
+		assertNoError(t, testDB.First(&stored, "id = ?", opname.ID).Error)
+		assertEqual(t, 95, *stored.SystemQty, "opname system_qty")
+		assertEqual(t, -15, *stored.Difference, "opname difference")
+		assertEqual(t, 90, assertBalanceChain(t, orgID, testItemID), "final balance")
+
+		// Ledger sudah konsisten: berhenti di baris pertama setelah changedThrough
+		stats, err := testService.Repo.RecalculateChanged(testDB, orgID, testItemID, day(time.January, 10), day(time.January, 10))
+		assertNoError(t, err)
+		assertEqual(t, true, stats.ShortCircuited, "short circuited")
+		assertEqual(t, 1, stats.Scanned, "rows scanned")
+		assertEqual(t, 0, stats.Updated, "rows updated")
+	})
+
+	t.Run("SC42: Stock balances are rebuilt offline from the ledger", func(t *testing.T) {
+		assertNoError(t, testService.DeleteTransaction(march.ID, "recalc_test", nil))
+		assertNoError(t, testDB.Exec("DELETE FROM stock_balances WHERE organization_id = ?", orgID).Error)
+		_, err := testService.RebuildStockBalances()
+		assertNoError(t, err)
+
+		var balance models.StockBalance
+		assertNoError(t, testDB.First(&balance, "organization_id = ? AND item_id = ?", orgID, testItemID).Error)
+		assertEqual(t, 110, balance.Balance, "rebuilt balance")
+
+		// Snapshot checkpoint bulanan sudah di-drop (migrasi 0018)
+		assertEqual(t, false, testDB.Migrator().HasTable("balance_checkpoints"), "balance_checkpoints table")
+
+		integrityService := &services.IntegrityService{DB: testDB, Inventory: testService}
+		report, err := integrityService.Check(orgID, testItemID, false)
+		assertNoError(t, err)
+		assertEqual(t, 0, len(report.Issues), "integrity issues")
+	})
+}
+
+// BenchmarkRecalculate1M - Recalculation pada satu org+item dengan 1M baris.
+// go test ./src -run '^$' -bench Recalculate1M -benchtime 5x
+func BenchmarkRecalculate1M(b *testing.B) {
+	if testing.Short() {
+		b.Skip("seeds 1M ledger rows")
+	}
+
+	const rows = 1_000_000
+	db := testDB.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+	repo := &repositories.InventoryRepository{DB: db}
+
+	orgID := uuid.New()
+	if err := db.Create(&models.Organization{ID: orgID, Name: "Recalc Bench Org", Code: "ORG-RECALC-BENCH"}).Error; err != nil {
+		b.Fatal(err)
+	}
+
+	// 1M penerimaan @1, satu per menit mulai 2020 (sekitar 2 tahun)
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	err := db.Exec(`
+		INSERT INTO inventories (organization_id, item_id, txn_date, amount, balance, type, created_by, created_at)
+		SELECT ?, ?, ?::timestamp + (n * interval '1 minute'), 1, n, 'penerimaan', 'recalc_bench', NOW()
+		FROM generate_series(1, ?) AS n`, orgID, testItemID, start, rows).Error
+	if err != nil {
+		b.Fatal(err)
+	}
+	backdated := start.Add(30 * time.Second)
+	errDiscard := errors.New("discard benchmark iteration")
+	run := func(b *testing.B, amounts []int) {
+		for i := 0; i < b.N; i++ {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				for _, amount := range amounts {
+					inv := models.Inventory{
+						OrganizationID: orgID, ItemID: testItemID, TxnDate: backdated,
+						Amount: models.Qty(int64(amount)), Type: models.InventoryTypePenerimaan, CreatedBy: "recalc_bench",
+					}
+					if err := tx.Create(&inv).Error; err != nil {
+						return err
+					}
+				}
+				stats, err := repo.RecalculateChanged(tx, orgID, testItemID, backdated, backdated)
+				if err != nil {
+					return err
+				}
+				b.ReportMetric(float64(stats.Updated), "rows_updated/op")
+				return errDiscard
+			})
+			if !errors.Is(err, errDiscard) {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	// Semua 1M saldo bergeser +1: stream penuh + bulk UPDATE
+	b.Run("shifted", func(b *testing.B) { run(b, []int{1}) })
+
+	// +1 lalu -1 di tanggal yang sama: saldo kembali cocok, short-circuit setelah baris pertama
+	b.Run("converged", func(b *testing.B) { run(b, []int{1, -1}) })
+
+	b.StopTimer()
+	db.Exec("DELETE FROM stock_balances WHERE organization_id = ?", orgID)
+	db.Exec("DELETE FROM inventories WHERE organization_id = ?", orgID)
+}
+
+// BenchmarkCreateTransaction1M - Write path lengkap (recalculation, policy, valuation, event)
+// lewat InventoryService.CreateTransaction pada satu org+item dengan 1M baris bernilai.
+// go test ./src -run '^$' -bench CreateTransaction1M -benchtime 20x
+func BenchmarkCreateTransaction1M(b *testing.B) {
+	if testing.Short() {
+		b.Skip("seeds 1M ledger rows")
+	}
+
+	const rows = 1_000_000
+	db := testDB.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+	service := &services.InventoryService{DB: db, Repo: &repositories.InventoryRepository{DB: db}}
+
+	orgID := uuid.New()
+	org := models.Organization{ID: orgID, Name: "Posting Bench Org", Code: "ORG-POST-BENCH", NegativeStockPolicy: models.NegativeStockAllow}
+	if err := db.Create(&org).Error; err != nil {
+		b.Fatal(err)
+	}
+
+	// 1M penerimaan @1 (sudah dinilai), satu per menit mulai 2020, dengan opname di menit ke-1000
+	// yang menyerap posting backdated sebelumnya
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	err := db.Exec(`
+		INSERT INTO inventories (organization_id, item_id, txn_date, amount, balance, type, unit_cost, cost_amount,
+			balance_value, created_by, created_at)
+		SELECT ?, ?, ?::timestamp + (n * interval '1 minute'), 1, n, 'penerimaan', 1, 1, n, 'posting_bench', NOW()
+		FROM generate_series(1, ?) AS n`, orgID, testItemID, start, rows).Error
+	if err != nil {
+		b.Fatal(err)
+	}
+	opnameDate := start.Add(1000*time.Minute + 30*time.Second)
+	err = db.Exec(`
+		INSERT INTO inventories (organization_id, item_id, txn_date, amount, balance, type, physical_qty, system_qty,
+			difference, cost_amount, balance_value, created_by, created_at)
+		VALUES (?, ?, ?, 0, 1000, 'opname', 1000, 1000, 0, 0, 1000, 'posting_bench', NOW())`,
+		orgID, testItemID, opnameDate).Error
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := service.Repo.RefreshStockBalance(db, orgID, testItemID); err != nil {
+		b.Fatal(err)
+	}
+
+	run := func(b *testing.B, txnDate time.Time) {
+		for i := 0; i < b.N; i++ {
+			_, err := service.CreateTransaction(services.CreateTransactionRequest{
+				OrganizationID: orgID, ItemID: testItemID, TxnDate: txnDate, Amount: models.Qty(-1),
+				Type: "pemakaian", ChangedBy: "posting_bench",
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	// Posting terbaru (kasus umum): recalculation & valuation hanya baris baru
+	b.Run("latest", func(b *testing.B) { run(b, start.Add((rows+1)*time.Minute)) })
+
+	// Backdated sebelum opname: saldo & nilai cocok lagi setelah opname, valuation berhenti di sana
+	b.Run("before_opname", func(b *testing.B) { run(b, opnameDate.Add(-500*time.Minute)) })
+
+	b.StopTimer()
+	for _, table := range []string{"outbox_events", "inventory_histories", "cost_layers", "stock_balances", "inventories"} {
+		db.Exec("DELETE FROM "+table+" WHERE organization_id = ?", orgID)
+	}
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"inventory-ledger/src/models"
+)
+
+type IdempotencyRepository struct {
+	DB *gorm.DB
+}
+
+// Reserve - Klaim key sebagai pending. Key pending yang dibuat sebelum staleBefore (request mati
+// di tengah jalan) diambil alih. Return false + record lama jika key masih dipakai.
+// CreatedAt record menjadi penanda pemilik untuk Complete/Release.
+func (r *IdempotencyRepository) Reserve(key *models.IdempotencyKey, staleBefore time.Time) (bool, *models.IdempotencyKey, error) {
+	result := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		return false, nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil, nil
+	}
+
+	takeover := r.DB.Model(&models.IdempotencyKey{}).
+		Where("key = ? AND status = ? AND created_at < ?", key.Key, models.IdempotencyStatusPending, staleBefore).
+		Updates(map[string]interface{}{
+			"method":       key.Method,
+			"path":         key.Path,
+			"request_hash": key.RequestHash,
+			"created_at":   key.CreatedAt,
+		})
+	if takeover.Error != nil {
+		return false, nil, takeover.Error
+	}
+	if takeover.RowsAffected == 1 {
+		return true, nil, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := r.DB.Where("key = ?", key.Key).Take(&existing).Error; err != nil {
+		return false, nil, err
+	}
+	return false, &existing, nil
+}
+
+// Complete - Simpan response final untuk replay berikutnya (hanya jika key masih milik request ini)
+func (r *IdempotencyRepository) Complete(key *models.IdempotencyKey, statusCode int, body json.RawMessage) error {
+	now := time.Now()
+	return r.DB.Model(&models.IdempotencyKey{}).
+		Where("key = ? AND status = ? AND created_at = ?", key.Key, models.IdempotencyStatusPending, key.CreatedAt).
+		Updates(map[string]interface{}{
+			"status":        models.IdempotencyStatusCompleted,
+			"response_code": statusCode,
+			"response_body": body,
+			"completed_at":  now,
+		}).Error
+}
+
+// Release - Hapus key pending (misal server error) supaya client boleh retry
+func (r *IdempotencyRepository) Release(key *models.IdempotencyKey) error {
+	return r.DB.
+		Where("key = ? AND status = ? AND created_at = ?", key.Key, models.IdempotencyStatusPending, key.CreatedAt).
+		Delete(&models.IdempotencyKey{}).Error
+}
@@ -1,11 +1,14 @@
 package repositories
 
 import (
-	"log"
+	"encoding/binary"
+	"hash/fnv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"inventory-ledger/src/models"
 )
@@ -14,27 +17,83 @@ type InventoryRepository struct {
 	DB *gorm.DB
 }
 
-// GetCurrentBalance - Get current balance for org+item
-func (r *InventoryRepository) GetCurrentBalance(orgID uuid.UUID, itemID uint) (int, error) {
-	var inventory models.Inventory
+// WithTx - Repository yang membaca/menulis lewat transaksi aktif
+func (r *InventoryRepository) WithTx(tx *gorm.DB) *InventoryRepository {
+	return &InventoryRepository{DB: tx}
+}
+
+// AdvisoryLock - Advisory lock transaction-scoped, dilepas otomatis saat commit/rollback
+func (r *InventoryRepository) AdvisoryLock(tx *gorm.DB, key int64) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", key).Error
+}
+
+// TryAdvisoryLock - Seperti AdvisoryLock tanpa menunggu: false jika lock dipegang transaksi lain
+func (r *InventoryRepository) TryAdvisoryLock(tx *gorm.DB, key int64) (bool, error) {
+	var locked bool
+	err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", key).Scan(&locked).Error
+	return locked, err
+}
+
+// OrgItemLockKey - Key advisory lock (int64) yang stabil untuk pasangan org+item
+func OrgItemLockKey(orgID uuid.UUID, itemID uint) int64 {
+	h := fnv.New64a()
+	h.Write(orgID[:])
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(itemID))
+	h.Write(buf[:])
+
+	return int64(h.Sum64())
+}
+
+// AdvisoryLockShared - Versi shared dari AdvisoryLock (banyak writer, satu pemegang exclusive)
+func (r *InventoryRepository) AdvisoryLockShared(tx *gorm.DB, key int64) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock_shared(?)", key).Error
+}
+
+// OrgPeriodLockKey - Key advisory lock tutup buku per org (prefix beda dengan OrgItemLockKey)
+func OrgPeriodLockKey(orgID uuid.UUID) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("period:"))
+	h.Write(orgID[:])
+
+	return int64(h.Sum64())
+}
+
+// GetCurrentBalance - Get current balance for org+item (dari projection stock_balances)
+func (r *InventoryRepository) GetCurrentBalance(orgID uuid.UUID, itemID uint) (decimal.Decimal, error) {
+	var stock models.StockBalance
 	err := r.DB.
+		Where("organization_id = ? AND item_id = ?", orgID, itemID).
+		Take(&stock).Error
+
+	if err == nil {
+		return stock.Balance, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return decimal.Zero, err
+	}
+
+	// Belum ada di projection (misal data lama sebelum rebuild), fallback ke ledger
+	var inventory models.Inventory
+	err = r.DB.
 		Where("organization_id = ? AND item_id = ? AND deleted_at IS NULL", orgID, itemID).
 		Order("txn_date DESC, created_at DESC").
 		First(&inventory).Error
 
 	if err == gorm.ErrRecordNotFound {
-		return 0, nil
+		return decimal.Zero, nil
 	}
 
 	if err != nil {
-		return 0, err
+		return decimal.Zero, err
 	}
 
 	return inventory.Balance, nil
 }
 
 // GetBalanceAt - Get balance at specific date
-func (r *InventoryRepository) GetBalanceAt(orgID uuid.UUID, itemID uint, at time.Time) (int, error) {
+func (r *InventoryRepository) GetBalanceAt(orgID uuid.UUID, itemID uint, at time.Time) (decimal.Decimal, error) {
 	var inventory models.Inventory
 	err := r.DB.
 		Where("organization_id = ? AND item_id = ? AND txn_date <= ? AND deleted_at IS NULL",
@@ -43,11 +102,11 @@ func (r *InventoryRepository) GetBalanceAt(orgID uuid.UUID, itemID uint, at time
 		First(&inventory).Error
 
 	if err == gorm.ErrRecordNotFound {
-		return 0, nil
+		return decimal.Zero, nil
 	}
 
 	if err != nil {
-		return 0, err
+		return decimal.Zero, err
 	}
 
 	return inventory.Balance, nil
@@ -88,36 +147,154 @@ func (r *InventoryRepository) GetTransactions(orgID uuid.UUID, itemID uint,
 	return transactions, total, nil
 }
 
-// GetOrganizationSummary - Get summary for all items in org (NO VIEW)
-func (r *InventoryRepository) GetOrganizationSummary(orgID uuid.UUID) ([]map[string]interface{}, error) {
-	var items []models.Item
-	if err := r.DB.Order("code").Find(&items).Error; err != nil {
-		return nil, err
+// GetOrganizationSummary - Get summary for all items in org (single query ke stock_balances).
+// Scope lokasi: roll-up subtree per item, atau drill-down satu baris per item+lokasi.
+func (r *InventoryRepository) GetOrganizationSummary(orgID uuid.UUID, scope LocationScope) ([]map[string]interface{}, error) {
+	if scope.LocationID != nil || scope.DrillDown {
+		return r.getLocationSummary(orgID, scope)
 	}
 
-	result := make([]map[string]interface{}, 0)
+	var rows []struct {
+		ItemID        uint
+		ItemCode      string
+		ItemName      string
+		Unit          string
+		CostingMethod string
+		Balance       decimal.Decimal
+		BalanceValue  decimal.Decimal
+		LastTxnDate   *time.Time
+	}
+
+	err := r.DB.Table("items").
+		Select("items.id AS item_id, items.code AS item_code, items.name AS item_name, items.unit, "+
+			"items.costing_method, COALESCE(sb.balance, 0) AS balance, "+
+			"COALESCE(sb.balance_value, 0) AS balance_value, sb.last_txn_date").
+		Joins("LEFT JOIN stock_balances sb ON sb.item_id = items.id AND sb.organization_id = ?", orgID).
+		Order("items.code").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
 
-	for _, item := range items {
-		var latestInv models.Inventory
-		err := r.DB.
-			Where("organization_id = ? AND item_id = ? AND deleted_at IS NULL", orgID, item.ID).
-			Order("txn_date DESC, created_at DESC").
-			First(&latestInv).Error
+	result := make([]map[string]interface{}, 0, len(rows))
 
-		currentStock := 0
+	for _, row := range rows {
 		lastTransaction := time.Time{}
+		if row.LastTxnDate != nil {
+			lastTransaction = *row.LastTxnDate
+		}
+
+		summary := map[string]interface{}{
+			"item_id":          row.ItemID,
+			"item_code":        row.ItemCode,
+			"item_name":        row.ItemName,
+			"unit":             row.Unit,
+			"costing_method":   row.CostingMethod,
+			"current_stock":    row.Balance,
+			"stock_value":      row.BalanceValue,
+			"average_cost":     averageCost(row.BalanceValue, row.Balance),
+			"last_transaction": lastTransaction,
+		}
+
+		result = append(result, summary)
+	}
 
-		if err == nil {
-			currentStock = latestInv.Balance
-			lastTransaction = latestInv.TxnDate
+	return result, nil
+}
+
+// getLocationSummary - Ringkasan org per lokasi dari location_balances. Nilai lokasi = qty x
+// average cost org (valuation tetap per org+item). Roll-up: semua item, qty = total subtree
+// scope.LocationID. Drill-down: baris item+lokasi bersaldo, ditambah sisa yang belum ditempatkan
+// (saldo org - total lokasi) jika tanpa filter lokasi.
+func (r *InventoryRepository) getLocationSummary(orgID uuid.UUID, scope LocationScope) ([]map[string]interface{}, error) {
+	var rows []struct {
+		ItemID        uint
+		ItemCode      string
+		ItemName      string
+		Unit          string
+		CostingMethod string
+		LocationID    *uuid.UUID
+		LocationCode  *string
+		LocationName  *string
+		Balance       decimal.Decimal
+		OrgBalance    decimal.Decimal
+		OrgValue      decimal.Decimal
+		LastTxnDate   *time.Time
+	}
+
+	const itemColumns = "i.id AS item_id, i.code AS item_code, i.name AS item_name, i.unit, i.costing_method, " +
+		"COALESCE(sb.balance, 0) AS org_balance, COALESCE(sb.balance_value, 0) AS org_value, sb.last_txn_date"
+
+	var err error
+	switch {
+	case !scope.DrillDown:
+		err = r.DB.Raw(`SELECT `+itemColumns+`, l.id AS location_id, l.code AS location_code, l.name AS location_name,
+				COALESCE(lb.balance, 0) AS balance
+			FROM items i
+			JOIN locations l ON l.id = ?
+			LEFT JOIN (
+				SELECT item_id, SUM(balance) AS balance FROM location_balances
+				WHERE organization_id = ? AND location_id IN (`+locationSubtree+`)
+				GROUP BY item_id
+			) lb ON lb.item_id = i.id
+			LEFT JOIN stock_balances sb ON sb.item_id = i.id AND sb.organization_id = ?
+			ORDER BY i.code`, *scope.LocationID, orgID, *scope.LocationID, orgID).Scan(&rows).Error
+
+	case scope.LocationID != nil:
+		err = r.DB.Raw(`SELECT `+itemColumns+`, l.id AS location_id, l.code AS location_code, l.name AS location_name,
+				lb.balance
+			FROM location_balances lb
+			JOIN items i ON i.id = lb.item_id
+			JOIN locations l ON l.id = lb.location_id
+			LEFT JOIN stock_balances sb ON sb.item_id = lb.item_id AND sb.organization_id = lb.organization_id
+			WHERE lb.organization_id = ? AND lb.location_id IN (`+locationSubtree+`)
+			ORDER BY i.code, l.code`, orgID, *scope.LocationID).Scan(&rows).Error
+
+	default:
+		err = r.DB.Raw(`SELECT * FROM (
+				SELECT `+itemColumns+`, l.id AS location_id, l.code AS location_code, l.name AS location_name,
+					lb.balance
+				FROM location_balances lb
+				JOIN items i ON i.id = lb.item_id
+				JOIN locations l ON l.id = lb.location_id
+				LEFT JOIN stock_balances sb ON sb.item_id = lb.item_id AND sb.organization_id = lb.organization_id
+				WHERE lb.organization_id = ?
+				UNION ALL
+				SELECT `+itemColumns+`, NULL, NULL, NULL,
+					sb.balance - COALESCE((SELECT SUM(balance) FROM location_balances lb
+						WHERE lb.organization_id = sb.organization_id AND lb.item_id = sb.item_id), 0)
+				FROM stock_balances sb
+				JOIN items i ON i.id = sb.item_id
+				WHERE sb.organization_id = ?
+			) summary
+			WHERE location_id IS NOT NULL OR balance <> 0
+			ORDER BY item_code, location_code NULLS LAST`, orgID, orgID).Scan(&rows).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(rows))
+
+	for _, row := range rows {
+		lastTransaction := time.Time{}
+		if row.LastTxnDate != nil {
+			lastTransaction = *row.LastTxnDate
 		}
+		cost := averageCost(row.OrgValue, row.OrgBalance)
 
 		summary := map[string]interface{}{
-			"item_id":          item.ID,
-			"item_code":        item.Code,
-			"item_name":        item.Name,
-			"unit":             item.Unit,
-			"current_stock":    currentStock,
+			"item_id":          row.ItemID,
+			"item_code":        row.ItemCode,
+			"item_name":        row.ItemName,
+			"unit":             row.Unit,
+			"costing_method":   row.CostingMethod,
+			"location_id":      row.LocationID,
+			"location_code":    row.LocationCode,
+			"location_name":    row.LocationName,
+			"current_stock":    row.Balance,
+			"stock_value":      row.Balance.Mul(cost).Round(CostScale),
+			"average_cost":     cost,
 			"last_transaction": lastTransaction,
 		}
 
@@ -127,35 +304,43 @@ func (r *InventoryRepository) GetOrganizationSummary(orgID uuid.UUID) ([]map[str
 	return result, nil
 }
 
-// GetItemSummary - Get summary for specific item across all orgs
+// GetItemSummary - Get summary for specific item across all orgs (single query ke stock_balances)
 func (r *InventoryRepository) GetItemSummary(itemID uint) ([]map[string]interface{}, error) {
-	var orgs []models.Organization
-	if err := r.DB.Order("code").Find(&orgs).Error; err != nil {
-		return nil, err
+	var rows []struct {
+		OrganizationID   uuid.UUID
+		OrganizationName string
+		OrganizationCode string
+		Balance          decimal.Decimal
+		BalanceValue     decimal.Decimal
+		LastTxnDate      *time.Time
 	}
 
-	result := make([]map[string]interface{}, 0)
+	err := r.DB.Table("organizations").
+		Select("organizations.id AS organization_id, organizations.name AS organization_name, "+
+			"organizations.code AS organization_code, COALESCE(sb.balance, 0) AS balance, "+
+			"COALESCE(sb.balance_value, 0) AS balance_value, sb.last_txn_date").
+		Joins("LEFT JOIN stock_balances sb ON sb.organization_id = organizations.id AND sb.item_id = ?", itemID).
+		Order("organizations.code").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
 
-	for _, org := range orgs {
-		var latestInv models.Inventory
-		err := r.DB.
-			Where("organization_id = ? AND item_id = ? AND deleted_at IS NULL", org.ID, itemID).
-			Order("txn_date DESC, created_at DESC").
-			First(&latestInv).Error
+	result := make([]map[string]interface{}, 0, len(rows))
 
-		currentStock := 0
+	for _, row := range rows {
 		lastTransaction := time.Time{}
-
-		if err == nil {
-			currentStock = latestInv.Balance
-			lastTransaction = latestInv.TxnDate
+		if row.LastTxnDate != nil {
+			lastTransaction = *row.LastTxnDate
 		}
 
 		summary := map[string]interface{}{
-			"organization_id":   org.ID,
-			"organization_name": org.Name,
-			"organization_code": org.Code,
-			"current_stock":     currentStock,
+			"organization_id":   row.OrganizationID,
+			"organization_name": row.OrganizationName,
+			"organization_code": row.OrganizationCode,
+			"current_stock":     row.Balance,
+			"stock_value":       row.BalanceValue,
+			"average_cost":      averageCost(row.BalanceValue, row.Balance),
 			"last_transaction":  lastTransaction,
 		}
 
@@ -165,94 +350,62 @@ func (r *InventoryRepository) GetItemSummary(itemID uint) ([]map[string]interfac
 	return result, nil
 }
 
-// RecalculateForward - Recalculate balances from specific date
-func (r *InventoryRepository) RecalculateForward(tx *gorm.DB, orgID uuid.UUID, itemID uint, fromDate time.Time) error {
-	var startBalance int
-	err := tx.Model(&models.Inventory{}).
-		Select("balance").
-		Where("organization_id = ? AND item_id = ? AND txn_date < ? AND deleted_at IS NULL",
-			orgID, itemID, fromDate).
-		Order("txn_date DESC, created_at DESC").
-		Limit(1).
-		Scan(&startBalance).Error
-
-	if err == gorm.ErrRecordNotFound {
-		startBalance = 0
-	} else if err != nil {
-		return err
+// averageCost - Nilai per unit stok di tangan (0 jika saldo tidak positif)
+func averageCost(value decimal.Decimal, balance decimal.Decimal) decimal.Decimal {
+	if !balance.IsPositive() {
+		return decimal.Zero
 	}
+	return value.DivRound(balance, CostScale)
+}
 
-	log.Printf("RECALC DEBUG: Start balance before %v = %d", fromDate, startBalance)
+// RefreshStockBalance - Sync projection stock_balances dari transaksi terakhir org+item
+func (r *InventoryRepository) RefreshStockBalance(tx *gorm.DB, orgID uuid.UUID, itemID uint) error {
+	stock := models.StockBalance{
+		OrganizationID: orgID,
+		ItemID:         itemID,
+		UpdatedAt:      time.Now(),
+	}
 
-	var transactions []models.Inventory
-	err = tx.
-		Where("organization_id = ? AND item_id = ? AND txn_date >= ? AND deleted_at IS NULL",
-			orgID, itemID, fromDate).
-		Order("txn_date ASC, created_at ASC").
-		Find(&transactions).Error
+	var latest models.Inventory
+	err := tx.
+		Where("organization_id = ? AND item_id = ? AND deleted_at IS NULL", orgID, itemID).
+		Order("txn_date DESC, created_at DESC").
+		First(&latest).Error
 
-	if err != nil {
+	if err == nil {
+		stock.Balance = latest.Balance
+		if latest.BalanceValue != nil {
+			stock.BalanceValue = *latest.BalanceValue
+		}
+		stock.LastTxnDate = &latest.TxnDate
+		stock.LastInventoryID = &latest.ID
+	} else if err != gorm.ErrRecordNotFound {
 		return err
 	}
 
-	log.Printf("RECALC DEBUG: Found %d transactions to recalculate", len(transactions))
-
-	currentBalance := startBalance
-	batchUpdates := make([]models.Inventory, 0)
-
-	for i, inv := range transactions {
-		oldBalance := inv.Balance
-		needsUpdate := false
-
-		if inv.Type == models.InventoryTypeOpname {
-			systemQty := currentBalance
-			var physicalQty int
-			if inv.PhysicalQty != nil {
-				physicalQty = *inv.PhysicalQty
-			} else {
-				physicalQty = inv.Balance
-			}
-			difference := physicalQty - systemQty
-			transactions[i].Balance = physicalQty
-			transactions[i].Amount = difference
-			transactions[i].SystemQty = &systemQty
-			transactions[i].PhysicalQty = &physicalQty
-			transactions[i].Difference = &difference
-
-			currentBalance = physicalQty
-
-			log.Printf("Opname[%d]: System=%d, Physical=%d, Diff=%d, Balance=%d",
-				i+1, systemQty, physicalQty, difference, currentBalance)
-
-			needsUpdate = true
-		} else {
-			currentBalance += inv.Amount
-			transactions[i].Balance = currentBalance
-
-			log.Printf("Normal[%d]: %s %d → New balance = %d",
-				i+1, inv.Type, inv.Amount, currentBalance)
-
-			if oldBalance != currentBalance {
-				needsUpdate = true
-			}
-		}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "item_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"balance", "balance_value", "last_txn_date", "last_inventory_id", "updated_at"}),
+	}).Create(&stock).Error
+}
 
-		if needsUpdate {
-			log.Printf("🔄 Updating transaction %d: balance from %d to %d",
-				i+1, oldBalance, currentBalance)
-			batchUpdates = append(batchUpdates, transactions[i])
-		}
+// RebuildStockBalances - Regenerate seluruh stock_balances dari inventories
+func (r *InventoryRepository) RebuildStockBalances(tx *gorm.DB) (int64, error) {
+	// Tahan posting lain yang mau refresh projection selama rebuild
+	if err := tx.Exec("LOCK TABLE stock_balances IN EXCLUSIVE MODE").Error; err != nil {
+		return 0, err
 	}
-
-	if len(batchUpdates) > 0 {
-		log.Printf("Saving %d updated transactions", len(batchUpdates))
-		for _, inv := range batchUpdates {
-			if err := tx.Save(&inv).Error; err != nil {
-				return err
-			}
-		}
+	if err := tx.Exec("DELETE FROM stock_balances").Error; err != nil {
+		return 0, err
 	}
 
-	log.Printf("RECALC COMPLETE: Final balance = %d", currentBalance)
-	return nil
+	result := tx.Exec(`
+		INSERT INTO stock_balances (organization_id, item_id, balance, balance_value, last_txn_date, last_inventory_id, updated_at)
+		SELECT DISTINCT ON (organization_id, item_id)
+			organization_id, item_id, balance, COALESCE(balance_value, 0), txn_date, id, NOW()
+		FROM inventories
+		WHERE deleted_at IS NULL
+		ORDER BY organization_id, item_id, txn_date DESC, created_at DESC`)
+
+	return result.RowsAffected, result.Error
 }
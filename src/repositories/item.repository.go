@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+)
+
+type ItemRepository struct {
+	DB *gorm.DB
+}
+
+// WithTx - Repository yang membaca/menulis lewat transaksi aktif
+func (r *ItemRepository) WithTx(tx *gorm.DB) *ItemRepository {
+	return &ItemRepository{DB: tx}
+}
+
+// FindByID - Ambil item berdasarkan ID (gorm.ErrRecordNotFound jika tidak ada)
+func (r *ItemRepository) FindByID(id uint) (*models.Item, error) {
+	var item models.Item
+	if err := r.DB.Where("id = ?", id).Take(&item).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// CodeExists - Cek kode sudah dipakai item lain
+func (r *ItemRepository) CodeExists(code string, excludeID uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.Item{}).
+		Where("code = ? AND id <> ?", code, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// List - List item dengan pencarian + pagination
+func (r *ItemRepository) List(filter MasterDataFilter) ([]models.Item, int64, error) {
+	var items []models.Item
+	var total int64
+
+	query := filter.apply(r.DB.Model(&models.Item{}))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.
+		Order("code ASC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&items).Error
+
+	return items, total, err
+}
+
+// Create - Insert item baru
+func (r *ItemRepository) Create(item *models.Item) error {
+	return r.DB.Create(item).Error
+}
+
+// Save - Update code/name/unit/costing_method/track_lots/serialized item
+func (r *ItemRepository) Save(item *models.Item) error {
+	return r.DB.Model(item).Select("code", "name", "unit", "costing_method", "track_lots", "serialized", "updated_at").Updates(item).Error
+}
+
+// SetActive - Aktifkan / nonaktifkan item
+func (r *ItemRepository) SetActive(item *models.Item, active bool) error {
+	return r.DB.Model(item).Update("is_active", active).Error
+}
+
+// FindUnits - Satuan alternatif item, urut nama
+func (r *ItemRepository) FindUnits(itemID uint) ([]models.ItemUnit, error) {
+	var units []models.ItemUnit
+	err := r.DB.Where("item_id = ?", itemID).Order("unit").Find(&units).Error
+	return units, err
+}
+
+// SaveUnit - Upsert satuan alternatif (PK item_id+unit)
+func (r *ItemRepository) SaveUnit(unit *models.ItemUnit) error {
+	return r.DB.Save(unit).Error
+}
+
+// DeleteUnit - Hapus satuan alternatif
+func (r *ItemRepository) DeleteUnit(itemID uint, unit string) error {
+	return r.DB.Where("item_id = ? AND unit = ?", itemID, unit).Delete(&models.ItemUnit{}).Error
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+)
+
+// locationSubtree - ID lokasi beserta semua turunannya (parameter: ID root)
+const locationSubtree = `WITH RECURSIVE subtree AS (
+		SELECT id FROM locations WHERE id = ?
+		UNION ALL
+		SELECT l.id FROM locations l JOIN subtree s ON l.parent_id = s.id
+	) SELECT id FROM subtree`
+
+// LocationScope - Cakupan ringkasan organisasi per lokasi.
+// LocationID nil = seluruh organisasi; DrillDown = satu baris per item+lokasi (bukan roll-up per item)
+type LocationScope struct {
+	LocationID *uuid.UUID
+	DrillDown  bool
+}
+
+// LocationStock - Saldo item di satu lokasi (atau yang belum ditempatkan) per tanggal posting dan saat ini
+type LocationStock struct {
+	BalanceAt decimal.Decimal // saldo lokasi pada tanggal posting
+	Balance   decimal.Decimal // saldo lokasi sekarang (termasuk posting setelah tanggal itu)
+}
+
+// Available - Qty yang boleh dipindah di tanggal posting tanpa membuat lokasi minus sesudahnya
+func (l LocationStock) Available() decimal.Decimal {
+	return decimal.Max(decimal.Min(l.BalanceAt, l.Balance), decimal.Zero)
+}
+
+// LocationViolation - Saldo berjalan pertama yang minus di satu lokasi (nil = belum ditempatkan)
+type LocationViolation struct {
+	LocationID *uuid.UUID
+	TxnDate    time.Time
+	Balance    decimal.Decimal
+}
+
+// ============ LOCATION MASTER DATA ============
+
+type LocationRepository struct {
+	DB *gorm.DB
+}
+
+// WithTx - Repository yang membaca/menulis lewat transaksi aktif
+func (r *LocationRepository) WithTx(tx *gorm.DB) *LocationRepository {
+	return &LocationRepository{DB: tx}
+}
+
+// FindByID - Ambil lokasi berdasarkan ID (gorm.ErrRecordNotFound jika tidak ada)
+func (r *LocationRepository) FindByID(id uuid.UUID) (*models.Location, error) {
+	var location models.Location
+	if err := r.DB.Where("id = ?", id).Take(&location).Error; err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
+
+// CodeExists - Cek kode sudah dipakai lokasi lain di organisasi yang sama
+func (r *LocationRepository) CodeExists(orgID uuid.UUID, code string, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.Location{}).
+		Where("organization_id = ? AND code = ? AND id <> ?", orgID, code, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// InSubtree - Cek candidateID adalah rootID atau turunannya (cegah siklus parent)
+func (r *LocationRepository) InSubtree(rootID, candidateID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.DB.Raw(`SELECT COUNT(*) FROM (`+locationSubtree+`) t WHERE id = ?`, rootID, candidateID).
+		Scan(&count).Error
+	return count > 0, err
+}
+
+// List - List lokasi organisasi dengan pencarian + pagination
+func (r *LocationRepository) List(orgID uuid.UUID, filter MasterDataFilter) ([]models.Location, int64, error) {
+	var locations []models.Location
+	var total int64
+
+	query := filter.apply(r.DB.Model(&models.Location{}).Where("organization_id = ?", orgID))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.
+		Order("code ASC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&locations).Error
+
+	return locations, total, err
+}
+
+// Create - Insert lokasi baru
+func (r *LocationRepository) Create(location *models.Location) error {
+	return r.DB.Create(location).Error
+}
+
+// Save - Update name/code/parent lokasi
+func (r *LocationRepository) Save(location *models.Location) error {
+	return r.DB.Model(location).Select("name", "code", "parent_id", "updated_at").Updates(location).Error
+}
+
+// SetActive - Aktifkan / nonaktifkan lokasi
+func (r *LocationRepository) SetActive(location *models.Location, active bool) error {
+	return r.DB.Model(location).Update("is_active", active).Error
+}
+
+// ============ LOCATION BALANCES ============
+
+// GetLocationStock - Saldo item di satu lokasi org (locationID nil = belum ditempatkan)
+func (r *InventoryRepository) GetLocationStock(orgID uuid.UUID, itemID uint, locationID *uuid.UUID, at time.Time) (LocationStock, error) {
+	query := r.DB.Model(&models.Inventory{}).
+		Select("COALESCE(SUM(amount) FILTER (WHERE txn_date <= ?), 0) AS balance_at, COALESCE(SUM(amount), 0) AS balance", at).
+		Where("organization_id = ? AND item_id = ? AND deleted_at IS NULL", orgID, itemID)
+	if locationID != nil {
+		query = query.Where("location_id = ?", *locationID)
+	} else {
+		query = query.Where("location_id IS NULL")
+	}
+
+	var stock LocationStock
+	err := query.Scan(&stock).Error
+	return stock, err
+}
+
+// HasLocationPostings - Org+item punya baris ledger aktif yang menyebut lokasi
+func (r *InventoryRepository) HasLocationPostings(tx *gorm.DB, orgID uuid.UUID, itemID uint) (bool, error) {
+	var ids []uuid.UUID
+	err := tx.Model(&models.Inventory{}).
+		Where("organization_id = ? AND item_id = ? AND location_id IS NOT NULL AND deleted_at IS NULL", orgID, itemID).
+		Limit(1).
+		Pluck("id", &ids).Error
+	return len(ids) > 0, err
+}
+
+// RefreshLocationBalances - Tulis ulang projection location_balances org+item dari ledger.
+// Lokasi minus tetap ditulis (policy warn/allow), saldo nol tidak disimpan.
+func (r *InventoryRepository) RefreshLocationBalances(tx *gorm.DB, orgID uuid.UUID, itemID uint) error {
+	if err := tx.Where("organization_id = ? AND item_id = ?", orgID, itemID).Delete(&models.LocationBalance{}).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`INSERT INTO location_balances (organization_id, location_id, item_id, balance, updated_at)
+		SELECT organization_id, location_id, item_id, SUM(amount), NOW()
+		FROM inventories
+		WHERE organization_id = ? AND item_id = ? AND location_id IS NOT NULL AND deleted_at IS NULL
+		GROUP BY organization_id, location_id, item_id
+		HAVING SUM(amount) <> 0`, orgID, itemID).Error
+}
+
+// FindLocationViolation - Saldo berjalan per lokasi (termasuk yang belum ditempatkan) yang pertama
+// menjadi minus mulai fromDate, urutan sama dengan RecalculateForward
+func (r *InventoryRepository) FindLocationViolation(tx *gorm.DB, orgID uuid.UUID, itemID uint, fromDate time.Time) (*LocationViolation, error) {
+	var violations []LocationViolation
+	err := tx.Raw(`SELECT location_id, txn_date, balance FROM (
+			SELECT location_id, txn_date,
+				SUM(amount) OVER (PARTITION BY location_id ORDER BY txn_date, created_at, id) AS balance
+			FROM inventories
+			WHERE organization_id = ? AND item_id = ? AND deleted_at IS NULL
+		) running
+		WHERE balance < 0 AND txn_date >= ?
+		ORDER BY txn_date
+		LIMIT 1`, orgID, itemID, fromDate).Scan(&violations).Error
+	if err != nil || len(violations) == 0 {
+		return nil, err
+	}
+	return &violations[0], nil
+}
@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+)
+
+// LotStock - Saldo satu lot org+item per tanggal posting dan saldo berjalan sesudahnya
+type LotStock struct {
+	LotNumber       string
+	ExpiryDate      *time.Time
+	BalanceAt       decimal.Decimal  // saldo lot pada tanggal posting
+	MinBalanceAfter *decimal.Decimal // saldo berjalan terendah setelah tanggal posting (nil = tidak ada posting sesudahnya)
+}
+
+// Available - Qty yang boleh diambil di tanggal posting tanpa membuat lot minus di titik mana pun
+// sesudahnya (bukan hanya saldo akhir: lot bisa habis lalu diterima lagi)
+func (l LotStock) Available() decimal.Decimal {
+	available := l.BalanceAt
+	if l.MinBalanceAfter != nil {
+		available = decimal.Min(available, *l.MinBalanceAfter)
+	}
+	return decimal.Max(available, decimal.Zero)
+}
+
+// LotViolation - Titik pertama saldo berjalan satu lot menjadi minus
+type LotViolation struct {
+	LotNumber string
+	TxnDate   time.Time
+	Balance   decimal.Decimal
+}
+
+// ExpiringLotFilter - Filter laporan lot yang akan/sudah kedaluwarsa
+type ExpiringLotFilter struct {
+	OrganizationID uuid.UUID
+	ItemID         uint
+	Before         time.Time // expiry_date <= Before
+	Page           int
+	Limit          int
+}
+
+// ExpiringLot - Satu baris laporan expiring
+type ExpiringLot struct {
+	OrganizationID   uuid.UUID       `json:"organization_id"`
+	OrganizationCode string          `json:"organization_code"`
+	ItemID           uint            `json:"item_id"`
+	ItemCode         string          `json:"item_code"`
+	ItemName         string          `json:"item_name"`
+	LotNumber        string          `json:"lot_number"`
+	ExpiryDate       time.Time       `json:"expiry_date"`
+	Balance          decimal.Decimal `json:"balance"`
+}
+
+// GetLotStocks - Lot yang masih punya saldo, urut FEFO: expiry terdekat dulu,
+// lot tanpa expiry paling akhir, lalu lot yang diterima lebih dulu
+func (r *InventoryRepository) GetLotStocks(orgID uuid.UUID, itemID uint, at time.Time) ([]LotStock, error) {
+	var lots []LotStock
+	err := r.DB.Raw(`SELECT lot_number, MIN(expiry_date) AS expiry_date,
+			COALESCE(SUM(amount) FILTER (WHERE txn_date <= ?), 0) AS balance_at,
+			MIN(running) FILTER (WHERE txn_date > ?) AS min_balance_after
+		FROM (
+			SELECT lot_number, expiry_date, txn_date, amount,
+				SUM(amount) OVER (PARTITION BY lot_number ORDER BY txn_date, created_at, id) AS running
+			FROM inventories
+			WHERE organization_id = ? AND item_id = ? AND lot_number IS NOT NULL AND deleted_at IS NULL
+		) lots
+		GROUP BY lot_number
+		HAVING SUM(amount) > 0
+		ORDER BY MIN(expiry_date) ASC NULLS LAST, MIN(txn_date) ASC, lot_number ASC`,
+		at, at, orgID, itemID).Scan(&lots).Error
+	return lots, err
+}
+
+// FindLotViolation - Saldo berjalan per lot yang pertama menjadi minus mulai fromDate,
+// urutan sama dengan RecalculateForward
+func (r *InventoryRepository) FindLotViolation(tx *gorm.DB, orgID uuid.UUID, itemID uint, fromDate time.Time) (*LotViolation, error) {
+	var violations []LotViolation
+	err := tx.Raw(`SELECT lot_number, txn_date, balance FROM (
+			SELECT lot_number, txn_date,
+				SUM(amount) OVER (PARTITION BY lot_number ORDER BY txn_date, created_at, id) AS balance
+			FROM inventories
+			WHERE organization_id = ? AND item_id = ? AND lot_number IS NOT NULL AND deleted_at IS NULL
+		) running
+		WHERE balance < 0 AND txn_date >= ?
+		ORDER BY txn_date, lot_number
+		LIMIT 1`, orgID, itemID, fromDate).Scan(&violations).Error
+	if err != nil || len(violations) == 0 {
+		return nil, err
+	}
+	return &violations[0], nil
+}
+
+// GetLotExpiry - Expiry lot item dari baris mana pun (lot yang sama punya satu expiry di semua org)
+func (r *InventoryRepository) GetLotExpiry(itemID uint, lotNumber string) (*time.Time, bool, error) {
+	var rows []models.Inventory
+	err := r.DB.Select("expiry_date").
+		Where("item_id = ? AND lot_number = ? AND deleted_at IS NULL", itemID, lotNumber).
+		Limit(1).
+		Find(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, false, err
+	}
+	return rows[0].ExpiryDate, true, nil
+}
+
+// RefreshLotBalances - Tulis ulang projection lot_balances org+item dari ledger.
+// Lot minus tetap ditulis supaya bisa ditolak oleh service.
+func (r *InventoryRepository) RefreshLotBalances(tx *gorm.DB, orgID uuid.UUID, itemID uint) error {
+	if err := tx.Where("organization_id = ? AND item_id = ?", orgID, itemID).Delete(&models.LotBalance{}).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`INSERT INTO lot_balances (organization_id, item_id, lot_number, expiry_date, balance, updated_at)
+		SELECT organization_id, item_id, lot_number, MIN(expiry_date), SUM(amount), NOW()
+		FROM inventories
+		WHERE organization_id = ? AND item_id = ? AND lot_number IS NOT NULL AND deleted_at IS NULL
+		GROUP BY organization_id, item_id, lot_number
+		HAVING SUM(amount) <> 0`, orgID, itemID).Error
+}
+
+// GetLotBalances - Saldo per lot org+item (urut FEFO)
+func (r *InventoryRepository) GetLotBalances(orgID uuid.UUID, itemID uint) ([]models.LotBalance, error) {
+	var lots []models.LotBalance
+	err := r.DB.
+		Where("organization_id = ? AND item_id = ?", orgID, itemID).
+		Order("expiry_date ASC NULLS LAST, lot_number ASC").
+		Find(&lots).Error
+	return lots, err
+}
+
+// GetExpiringLots - Lot bersaldo positif dengan expiry_date <= filter.Before (termasuk yang sudah lewat)
+func (r *InventoryRepository) GetExpiringLots(filter ExpiringLotFilter) ([]ExpiringLot, int64, error) {
+	query := r.DB.Table("lot_balances lb").
+		Joins("JOIN organizations o ON o.id = lb.organization_id").
+		Joins("JOIN items i ON i.id = lb.item_id").
+		Where("lb.balance > 0 AND lb.expiry_date IS NOT NULL AND lb.expiry_date <= ?", filter.Before)
+	if filter.OrganizationID != uuid.Nil {
+		query = query.Where("lb.organization_id = ?", filter.OrganizationID)
+	}
+	if filter.ItemID != 0 {
+		query = query.Where("lb.item_id = ?", filter.ItemID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var lots []ExpiringLot
+	err := query.
+		Select("lb.organization_id, o.code AS organization_code, lb.item_id, i.code AS item_code, " +
+			"i.name AS item_name, lb.lot_number, lb.expiry_date, lb.balance").
+		Order("lb.expiry_date ASC, o.code ASC, i.code ASC, lb.lot_number ASC").
+		Limit(filter.Limit).
+		Offset((filter.Page - 1) * filter.Limit).
+		Scan(&lots).Error
+
+	return lots, total, err
+}
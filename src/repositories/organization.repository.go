@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+)
+
+// MasterDataFilter - Filter list master data (organization/item)
+type MasterDataFilter struct {
+	Query  string // cari di code / name (case-insensitive)
+	Active *bool  // nil = semua
+	Page   int
+	Limit  int
+}
+
+// apply - Terapkan pencarian + filter status ke query master data
+func (f MasterDataFilter) apply(query *gorm.DB) *gorm.DB {
+	if q := strings.TrimSpace(f.Query); q != "" {
+		pattern := "%" + strings.ToLower(q) + "%"
+		query = query.Where("(LOWER(code) LIKE ? OR LOWER(name) LIKE ?)", pattern, pattern)
+	}
+	if f.Active != nil {
+		query = query.Where("is_active = ?", *f.Active)
+	}
+	return query
+}
+
+type OrganizationRepository struct {
+	DB *gorm.DB
+}
+
+// WithTx - Repository yang membaca/menulis lewat transaksi aktif
+func (r *OrganizationRepository) WithTx(tx *gorm.DB) *OrganizationRepository {
+	return &OrganizationRepository{DB: tx}
+}
+
+// FindByID - Ambil organisasi berdasarkan ID (gorm.ErrRecordNotFound jika tidak ada)
+func (r *OrganizationRepository) FindByID(id uuid.UUID) (*models.Organization, error) {
+	var org models.Organization
+	if err := r.DB.Where("id = ?", id).Take(&org).Error; err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// CodeExists - Cek kode sudah dipakai organisasi lain
+func (r *OrganizationRepository) CodeExists(code string, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.Organization{}).
+		Where("code = ? AND id <> ?", code, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// List - List organisasi dengan pencarian + pagination
+func (r *OrganizationRepository) List(filter MasterDataFilter) ([]models.Organization, int64, error) {
+	var orgs []models.Organization
+	var total int64
+
+	query := filter.apply(r.DB.Model(&models.Organization{}))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.
+		Order("code ASC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&orgs).Error
+
+	return orgs, total, err
+}
+
+// Create - Insert organisasi baru
+func (r *OrganizationRepository) Create(org *models.Organization) error {
+	return r.DB.Create(org).Error
+}
+
+// Save - Update name/code/policy stok negatif organisasi
+func (r *OrganizationRepository) Save(org *models.Organization) error {
+	return r.DB.Model(org).Select("name", "code", "negative_stock_policy", "updated_at").Updates(org).Error
+}
+
+// SetActive - Aktifkan / nonaktifkan organisasi
+func (r *OrganizationRepository) SetActive(org *models.Organization, active bool) error {
+	return r.DB.Model(org).Update("is_active", active).Error
+}
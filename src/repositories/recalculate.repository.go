@@ -0,0 +1,214 @@
+package repositories
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+)
+
+// RecalcBatchSize - Baris per batch keyset stream (sekaligus ukuran satu bulk UPDATE)
+const RecalcBatchSize = 1000
+
+// RecalcStats - Ringkasan satu kali recalculation
+type RecalcStats struct {
+	Scanned        int
+	Updated        int
+	ShortCircuited bool
+
+	// SettledAt - Baris short-circuit: mulai baris ini saldo, amount dan keberadaan baris sama
+	// seperti sebelum write path (nil = recalculation sampai baris terakhir)
+	SettledAt *LedgerPosition
+}
+
+// LedgerPosition - Posisi baris di urutan stream (txn_date, created_at, id)
+type LedgerPosition struct {
+	TxnDate   time.Time
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// reachedBy - Baris (txnDate, createdAt, id) berada di posisi p atau sesudahnya.
+// Posisi dengan CreatedAt/ID kosong berarti awal tanggal TxnDate.
+func (p *LedgerPosition) reachedBy(txnDate, createdAt time.Time, id uuid.UUID) bool {
+	if !txnDate.Equal(p.TxnDate) {
+		return txnDate.After(p.TxnDate)
+	}
+	if !createdAt.Equal(p.CreatedAt) {
+		return createdAt.After(p.CreatedAt)
+	}
+	return bytes.Compare(id[:], p.ID[:]) >= 0
+}
+
+// recalcRow - Kolom yang dibaca/ditulis recalculation (bukan seluruh baris inventory)
+type recalcRow struct {
+	ID          uuid.UUID
+	TxnDate     time.Time
+	CreatedAt   time.Time
+	Type        models.InventoryType
+	Amount      decimal.Decimal
+	Balance     decimal.Decimal
+	PhysicalQty *decimal.Decimal
+	SystemQty   *decimal.Decimal
+	Difference  *decimal.Decimal
+}
+
+const recalcColumns = "id, txn_date, created_at, type, amount, balance, physical_qty, system_qty, difference"
+
+// ============ RECALCULATE ============
+
+// RecalculateForward - Hitung ulang semua saldo mulai fromDate sampai baris terakhir (tanpa short-circuit).
+// Dipakai rollback & repair integrity, di mana semua baris setelah fromDate dianggap berubah.
+func (r *InventoryRepository) RecalculateForward(tx *gorm.DB, orgID uuid.UUID, itemID uint, fromDate time.Time) error {
+	_, err := r.recalculate(tx, orgID, itemID, fromDate, nil)
+	return err
+}
+
+// RecalculateChanged - Seperti RecalculateForward, tetapi berhenti di baris pertama setelah
+// changedThrough (tanggal baris terakhir yang diubah write path) yang saldonya sudah cocok.
+// Baris sesudahnya konsisten dengan baris itu, jadi tidak perlu dibaca.
+func (r *InventoryRepository) RecalculateChanged(tx *gorm.DB, orgID uuid.UUID, itemID uint,
+	fromDate, changedThrough time.Time) (*RecalcStats, error) {
+	return r.recalculate(tx, orgID, itemID, fromDate, &changedThrough)
+}
+
+// recalculate - Stream baris aktif per RecalcBatchSize (keyset txn_date, created_at, id) dan
+// tulis baris yang berubah dengan satu bulk UPDATE per batch. Saldo awal cukup satu baris
+// sebelum fromDate (index lookup), tanpa snapshot saldo per periode.
+func (r *InventoryRepository) recalculate(tx *gorm.DB, orgID uuid.UUID, itemID uint,
+	fromDate time.Time, changedThrough *time.Time) (*RecalcStats, error) {
+
+	stats := &RecalcStats{}
+
+	var starts []recalcRow
+	err := tx.Model(&models.Inventory{}).
+		Select(recalcColumns).
+		Where("organization_id = ? AND item_id = ? AND txn_date < ? AND deleted_at IS NULL",
+			orgID, itemID, fromDate).
+		Order("txn_date DESC, created_at DESC, id DESC").
+		Limit(1).
+		Find(&starts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	currentBalance := decimal.Zero
+	if len(starts) > 0 {
+		currentBalance = starts[0].Balance
+	}
+
+	log.Printf("RECALC: org=%v item=%d from %v, start balance = %s", orgID, itemID, fromDate, currentBalance)
+
+	var last *recalcRow
+	for !stats.ShortCircuited {
+		query := tx.Model(&models.Inventory{}).
+			Select(recalcColumns).
+			Where("organization_id = ? AND item_id = ? AND deleted_at IS NULL", orgID, itemID)
+		if last == nil {
+			query = query.Where("txn_date >= ?", fromDate)
+		} else {
+			query = query.Where("(txn_date, created_at, id) > (?, ?, ?)", last.TxnDate, last.CreatedAt, last.ID)
+		}
+
+		var batch []recalcRow
+		if err := query.Order("txn_date ASC, created_at ASC, id ASC").Limit(RecalcBatchSize).Find(&batch).Error; err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		updates := make([]recalcRow, 0, len(batch))
+		for i := range batch {
+			row := &batch[i]
+			changed := recomputeRow(row, currentBalance)
+
+			if !changed && changedThrough != nil && row.TxnDate.After(*changedThrough) {
+				stats.ShortCircuited = true
+				stats.SettledAt = &LedgerPosition{TxnDate: row.TxnDate, CreatedAt: row.CreatedAt, ID: row.ID}
+				break
+			}
+
+			stats.Scanned++
+			currentBalance = row.Balance
+			if changed {
+				updates = append(updates, *row)
+			}
+		}
+
+		if err := r.bulkUpdateBalances(tx, updates); err != nil {
+			return nil, err
+		}
+		stats.Updated += len(updates)
+
+		if len(batch) < RecalcBatchSize {
+			break
+		}
+		last = &batch[len(batch)-1]
+	}
+
+	log.Printf("RECALC COMPLETE: scanned=%d updated=%d short_circuited=%v",
+		stats.Scanned, stats.Updated, stats.ShortCircuited)
+
+	return stats, r.RefreshStockBalance(tx, orgID, itemID)
+}
+
+// recomputeRow - Terapkan saldo berjalan ke baris; true jika ada kolom yang berubah.
+// Opname: balance = physical_qty, system_qty = saldo berjalan, amount = difference.
+func recomputeRow(row *recalcRow, runningBalance decimal.Decimal) bool {
+	if row.Type != models.InventoryTypeOpname {
+		newBalance := runningBalance.Add(row.Amount)
+		changed := !row.Balance.Equal(newBalance)
+		row.Balance = newBalance
+		return changed
+	}
+
+	physicalQty := row.Balance
+	if row.PhysicalQty != nil {
+		physicalQty = *row.PhysicalQty
+	}
+	systemQty := runningBalance
+	difference := physicalQty.Sub(systemQty)
+
+	changed := row.PhysicalQty == nil || row.SystemQty == nil || row.Difference == nil ||
+		!row.SystemQty.Equal(systemQty) || !row.Difference.Equal(difference) ||
+		!row.Amount.Equal(difference) || !row.Balance.Equal(physicalQty)
+
+	row.Balance = physicalQty
+	row.Amount = difference
+	row.PhysicalQty = &physicalQty
+	row.SystemQty = &systemQty
+	row.Difference = &difference
+	return changed
+}
+
+// bulkUpdateBalances - Satu UPDATE ... FROM (VALUES ...) untuk semua baris berubah di batch
+func (r *InventoryRepository) bulkUpdateBalances(tx *gorm.DB, rows []recalcRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var sql strings.Builder
+	args := make([]interface{}, 0, len(rows)*6)
+
+	sql.WriteString(`UPDATE inventories AS i
+		SET balance = v.balance, amount = v.amount, physical_qty = v.physical_qty,
+			system_qty = v.system_qty, difference = v.difference, updated_at = NOW()
+		FROM (VALUES `)
+	for i, row := range rows {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteString("(?::uuid, ?::numeric, ?::numeric, ?::numeric, ?::numeric, ?::numeric)")
+		args = append(args, row.ID, row.Balance, row.Amount, row.PhysicalQty, row.SystemQty, row.Difference)
+	}
+	sql.WriteString(") AS v(id, balance, amount, physical_qty, system_qty, difference) WHERE i.id = v.id")
+
+	return tx.Exec(sql.String(), args...).Error
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+)
+
+// serialRows - Satu baris per (baris ledger, nomor seri)
+const serialRows = "inventories i CROSS JOIN LATERAL jsonb_array_elements_text(i.serial_numbers) AS s(serial)"
+
+// SerialStock - Keberadaan satu nomor seri di org+item: +1 per baris masuk, -1 per baris keluar
+type SerialStock struct {
+	SerialNumber string
+	PresenceAt   int // pada tanggal posting
+	Presence     int // sekarang (termasuk posting setelah tanggal itu)
+}
+
+// SerialPosition - Keberadaan nomor seri per organisasi (semua org) + pergerakan terakhirnya
+type SerialPosition struct {
+	SerialNumber    string
+	OrganizationID  uuid.UUID
+	Presence        int
+	LastInventoryID uuid.UUID
+	LastTxnDate     time.Time
+}
+
+// SerialViolation - Nomor seri yang saldo berjalannya di satu org keluar dari 0..1
+type SerialViolation struct {
+	SerialNumber string
+	TxnDate      time.Time
+	Presence     int
+}
+
+// SerialMovement - Satu baris jejak pergerakan unit
+type SerialMovement struct {
+	InventoryID        uuid.UUID            `json:"inventory_id"`
+	ItemID             uint                 `json:"-"`
+	OrganizationID     uuid.UUID            `json:"organization_id"`
+	OrganizationCode   string               `json:"organization_code"`
+	TxnDate            time.Time            `json:"txn_date"`
+	Type               models.InventoryType `json:"type"`
+	Amount             decimal.Decimal      `json:"-"`
+	RefID              *uuid.UUID           `json:"ref_id,omitempty"`
+	FromOrganizationID *uuid.UUID           `json:"from_organization_id,omitempty"`
+	ToOrganizationID   *uuid.UUID           `json:"to_organization_id,omitempty"`
+	Notes              *string              `json:"notes,omitempty"`
+	CreatedBy          string               `json:"created_by"`
+	CreatedAt          time.Time            `json:"created_at"`
+}
+
+// GetSerialStocks - Semua nomor seri yang pernah ada di org+item beserta keberadaannya
+func (r *InventoryRepository) GetSerialStocks(orgID uuid.UUID, itemID uint, at time.Time) ([]SerialStock, error) {
+	var stocks []SerialStock
+	err := r.DB.Table(serialRows).
+		Select("s.serial AS serial_number, "+
+			"COALESCE(SUM(SIGN(i.amount)) FILTER (WHERE i.txn_date <= ?), 0)::int AS presence_at, SUM(SIGN(i.amount))::int AS presence", at).
+		Where("i.organization_id = ? AND i.item_id = ? AND i.serial_numbers IS NOT NULL AND i.deleted_at IS NULL", orgID, itemID).
+		Group("s.serial").
+		Order("s.serial").
+		Scan(&stocks).Error
+	return stocks, err
+}
+
+// GetSerialPositions - Nomor seri yang pernah menyentuh org+item (termasuk baris terhapus, supaya
+// unit yang hilang dari ledger ikut dibersihkan) dan keberadaannya di semua organisasi
+func (r *InventoryRepository) GetSerialPositions(tx *gorm.DB, orgID uuid.UUID, itemID uint) ([]string, []SerialPosition, error) {
+	var touched []string
+	if err := tx.Table(serialRows).
+		Where("i.organization_id = ? AND i.item_id = ? AND i.serial_numbers IS NOT NULL", orgID, itemID).
+		Distinct("s.serial").
+		Order("s.serial").
+		Pluck("s.serial", &touched).Error; err != nil || len(touched) == 0 {
+		return nil, nil, err
+	}
+
+	var positions []SerialPosition
+	err := tx.Table(serialRows).
+		Select("s.serial AS serial_number, i.organization_id, SUM(SIGN(i.amount))::int AS presence, "+
+			"(ARRAY_AGG(i.id ORDER BY i.txn_date DESC, i.created_at DESC, i.id DESC))[1] AS last_inventory_id, "+
+			"MAX(i.txn_date) AS last_txn_date").
+		Where("i.item_id = ? AND i.serial_numbers IS NOT NULL AND i.deleted_at IS NULL AND s.serial IN ?", itemID, touched).
+		Group("s.serial, i.organization_id").
+		Order("s.serial, i.organization_id").
+		Scan(&positions).Error
+	return touched, positions, err
+}
+
+// ReplaceSerialNumbers - Tulis ulang registry untuk nomor seri touched
+func (r *InventoryRepository) ReplaceSerialNumbers(tx *gorm.DB, itemID uint, touched []string, serials []models.SerialNumber) error {
+	if len(touched) == 0 {
+		return nil
+	}
+	if err := tx.Where("item_id = ? AND serial_number IN ?", itemID, touched).Delete(&models.SerialNumber{}).Error; err != nil {
+		return err
+	}
+	if len(serials) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(serials, 500).Error
+}
+
+// FindSerialViolation - Nomor seri pertama (urut tanggal) yang saldo berjalannya di org+item
+// menjadi minus (keluar sebelum masuk) atau lebih dari satu (masuk dua kali)
+func (r *InventoryRepository) FindSerialViolation(tx *gorm.DB, orgID uuid.UUID, itemID uint) (*SerialViolation, error) {
+	var violations []SerialViolation
+	err := tx.Raw(`SELECT serial_number, txn_date, presence FROM (
+			SELECT s.serial AS serial_number, i.txn_date,
+				(SUM(SIGN(i.amount)) OVER (PARTITION BY s.serial ORDER BY i.txn_date, i.created_at, i.id))::int AS presence
+			FROM `+serialRows+`
+			WHERE i.organization_id = ? AND i.item_id = ? AND i.serial_numbers IS NOT NULL AND i.deleted_at IS NULL
+		) running
+		WHERE presence < 0 OR presence > 1
+		ORDER BY txn_date, serial_number
+		LIMIT 1`, orgID, itemID).Scan(&violations).Error
+	if err != nil || len(violations) == 0 {
+		return nil, err
+	}
+	return &violations[0], nil
+}
+
+// FindSerialCountMismatch - Baris item serialized yang jumlah nomor serinya tidak sama dengan |amount|
+// (misal opname yang selisihnya berubah karena posting backdated)
+func (r *InventoryRepository) FindSerialCountMismatch(tx *gorm.DB, orgID uuid.UUID, itemID uint) (*models.Inventory, error) {
+	var rows []models.Inventory
+	err := tx.Select("id", "txn_date", "type", "amount").
+		Where("organization_id = ? AND item_id = ?", orgID, itemID).
+		Where("COALESCE(jsonb_array_length(serial_numbers), 0) <> ABS(amount)").
+		Order("txn_date, created_at").
+		Limit(1).
+		Find(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return &rows[0], nil
+}
+
+// FindSerialNumbers - Registry nomor seri (semua item, atau satu item jika itemID != 0)
+func (r *InventoryRepository) FindSerialNumbers(serial string, itemID uint) ([]models.SerialNumber, error) {
+	query := r.DB.Where("serial_number = ?", serial)
+	if itemID != 0 {
+		query = query.Where("item_id = ?", itemID)
+	}
+
+	var serials []models.SerialNumber
+	err := query.Order("item_id").Find(&serials).Error
+	return serials, err
+}
+
+// GetSerialTrail - Semua baris ledger yang menyebut nomor seri, urut item lalu tanggal
+func (r *InventoryRepository) GetSerialTrail(serial string, itemID uint) ([]SerialMovement, error) {
+	contains, err := json.Marshal([]string{serial})
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.DB.Table("inventories i").
+		Joins("JOIN organizations o ON o.id = i.organization_id").
+		Where("i.serial_numbers @> ?::jsonb AND i.deleted_at IS NULL", string(contains))
+	if itemID != 0 {
+		query = query.Where("i.item_id = ?", itemID)
+	}
+
+	var movements []SerialMovement
+	err = query.
+		Select("i.id AS inventory_id, i.item_id, i.organization_id, o.code AS organization_code, i.txn_date, " +
+			"i.type, i.amount, i.ref_id, i.from_organization_id, i.to_organization_id, i.notes, i.created_by, i.created_at").
+		Order("i.item_id, i.txn_date, i.created_at, i.id").
+		Scan(&movements).Error
+	return movements, err
+}
@@ -0,0 +1,512 @@
+package repositories
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+)
+
+// CostScale - Jumlah desimal cost_amount/balance_value (kolom numeric(20,6))
+const CostScale = 6
+
+// RevalueStats - Ringkasan satu kali valuation
+type RevalueStats struct {
+	Scanned        int
+	Updated        int
+	OpenLayers     int
+	ShortCircuited bool
+
+	// ChangedTransfers - Leg mutasi keluar yang nilainya berubah: leg masuk di org tujuan
+	// memakai cost leg keluar, jadi org tujuan harus dinilai ulang mulai tanggal itu
+	ChangedTransfers []TransferCostChange
+}
+
+type TransferCostChange struct {
+	ToOrganizationID uuid.UUID
+	TxnDate          time.Time
+}
+
+// valuationRow - Kolom yang dibaca/ditulis valuation
+type valuationRow struct {
+	ID               uuid.UUID
+	TxnDate          time.Time
+	CreatedAt        time.Time
+	Type             models.InventoryType
+	Amount           decimal.Decimal
+	Balance          decimal.Decimal
+	RefID            *uuid.UUID
+	ToOrganizationID *uuid.UUID
+	UnitCost         *decimal.Decimal
+	CostAmount       *decimal.Decimal
+	BalanceValue     *decimal.Decimal
+}
+
+const valuationColumns = "id, txn_date, created_at, type, amount, balance, ref_id, " +
+	"to_organization_id, unit_cost, cost_amount, balance_value"
+
+// movementUnitCost - |cost_amount / amount| (nil jika baris belum pernah dinilai atau pindah lokasi)
+func (row *valuationRow) movementUnitCost() *decimal.Decimal {
+	if row.CostAmount == nil || row.Amount.IsZero() || row.Type == models.InventoryTypeLocationMove {
+		return nil
+	}
+	unitCost := row.CostAmount.Abs().DivRound(row.Amount.Abs(), CostScale)
+	return &unitCost
+}
+
+// isTransferOut - Leg mutasi keluar (org tujuan membaca cost leg ini)
+func (row *valuationRow) isTransferOut(orgID uuid.UUID) bool {
+	return row.Type == models.InventoryTypeMutation && row.Amount.IsNegative() &&
+		row.ToOrganizationID != nil && *row.ToOrganizationID != orgID
+}
+
+// ============ REVALUE ============
+
+// Revalue - Hitung ulang cost_amount & balance_value mulai fromDate sampai baris terakhir,
+// dengan urutan yang sama seperti RecalculateForward (saldo harus sudah final), lalu tulis
+// ulang layer FIFO yang masih terbuka dan nilai di stock_balances.
+//
+// Baris masuk (amount > 0) dinilai dengan unit_cost input (stok_awal/penerimaan), cost leg
+// keluar pasangannya (mutasi masuk), atau cost berjalan. Baris keluar (pemakaian, mutasi keluar,
+// opname minus) mengambil cost dari layer tertua (FIFO) atau rata-rata berjalan (average).
+//
+// settled = posisi mulai baris ledger sama dengan yang dinilai valuation sebelumnya
+// (RecalcStats.SettledAt; nil = nilai sampai baris terakhir). Mulai posisi itu valuation berhenti
+// di baris pertama yang cost_amount & balance_value-nya tidak berubah, saldonya positif, dan
+// semua layer FIFO terbukanya dibuka baris sesudah settled yang cost-nya tidak berubah: posisi
+// persediaan di baris itu sama dengan valuation sebelumnya, jadi baris sesudahnya dan layer
+// tersimpan tetap benar.
+func (r *InventoryRepository) Revalue(tx *gorm.DB, orgID uuid.UUID, itemID uint,
+	fromDate time.Time, settled *LedgerPosition, method models.CostingMethod) (*RevalueStats, error) {
+
+	stats := &RevalueStats{}
+	state := &valuationState{method: method}
+
+	var starts []valuationRow
+	err := tx.Model(&models.Inventory{}).
+		Select(valuationColumns).
+		Where("organization_id = ? AND item_id = ? AND txn_date < ? AND deleted_at IS NULL",
+			orgID, itemID, fromDate).
+		Order("txn_date DESC, created_at DESC, id DESC").
+		Limit(1).
+		Find(&starts).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(starts) > 0 {
+		state.start(&starts[0])
+	}
+	if method == models.CostingFIFO {
+		if err := r.restoreOpenLayers(tx, orgID, itemID, fromDate, state); err != nil {
+			return nil, err
+		}
+	}
+
+	var last *valuationRow
+	for !stats.ShortCircuited {
+		query := tx.Model(&models.Inventory{}).
+			Select(valuationColumns).
+			Where("organization_id = ? AND item_id = ? AND deleted_at IS NULL", orgID, itemID)
+		if last == nil {
+			query = query.Where("txn_date >= ?", fromDate)
+		} else {
+			query = query.Where("(txn_date, created_at, id) > (?, ?, ?)", last.TxnDate, last.CreatedAt, last.ID)
+		}
+
+		var batch []valuationRow
+		if err := query.Order("txn_date ASC, created_at ASC, id ASC").Limit(RecalcBatchSize).Find(&batch).Error; err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		transferCosts, err := r.transferInCosts(tx, orgID, itemID, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		updates := make([]valuationRow, 0, len(batch))
+		for i := range batch {
+			row := &batch[i]
+			previousCost := row.CostAmount
+			previousValue := row.BalanceValue
+
+			var transferCost *decimal.Decimal
+			if row.RefID != nil && row.Type == models.InventoryTypeMutation && row.Amount.IsPositive() {
+				if cost, ok := transferCosts[*row.RefID]; ok {
+					transferCost = &cost
+				}
+			}
+			state.apply(row, transferCost)
+
+			costChanged := !decimalPtrEqual(previousCost, row.CostAmount)
+			valueChanged := !decimalPtrEqual(previousValue, row.BalanceValue)
+			matched := settled != nil && !costChanged && settled.reachedBy(row.TxnDate, row.CreatedAt, row.ID)
+			if !matched {
+				state.markUnsettled(row)
+			}
+			if matched && !valueChanged && state.settled() {
+				stats.ShortCircuited = true
+				break
+			}
+
+			stats.Scanned++
+			if costChanged || valueChanged {
+				updates = append(updates, *row)
+			}
+			if costChanged && row.isTransferOut(orgID) {
+				stats.ChangedTransfers = append(stats.ChangedTransfers, TransferCostChange{
+					ToOrganizationID: *row.ToOrganizationID,
+					TxnDate:          row.TxnDate,
+				})
+			}
+		}
+
+		if err := r.bulkUpdateValues(tx, updates); err != nil {
+			return nil, err
+		}
+		stats.Updated += len(updates)
+
+		if len(batch) < RecalcBatchSize {
+			break
+		}
+		last = &batch[len(batch)-1]
+	}
+
+	// Short-circuit: layer tersimpan = posisi akhir valuation sebelumnya, tetap berlaku
+	if !stats.ShortCircuited {
+		if err := r.replaceCostLayers(tx, orgID, itemID, state.layers); err != nil {
+			return nil, err
+		}
+		stats.OpenLayers = len(state.layers)
+	}
+
+	log.Printf("REVALUE: org=%v item=%d method=%s from %v scanned=%d updated=%d open_layers=%d short_circuited=%v",
+		orgID, itemID, method, fromDate, stats.Scanned, stats.Updated, stats.OpenLayers, stats.ShortCircuited)
+
+	return stats, r.RefreshStockBalance(tx, orgID, itemID)
+}
+
+// restoreOpenLayers - Layer FIFO yang terbuka tepat sebelum fromDate. Pada FIFO stok di tangan
+// selalu berasal dari baris masuk terbaru sebanyak saldo, jadi cukup telusuri baris masuk
+// mundur sampai saldo awal tertutup (tidak perlu menyimpan riwayat pemakaian layer).
+func (r *InventoryRepository) restoreOpenLayers(tx *gorm.DB, orgID uuid.UUID, itemID uint,
+	fromDate time.Time, state *valuationState) error {
+
+	left := state.qty
+	var newestFirst []*models.CostLayer
+	var last *valuationRow
+	for left.IsPositive() {
+		query := tx.Model(&models.Inventory{}).
+			Select(valuationColumns).
+			Where("organization_id = ? AND item_id = ? AND deleted_at IS NULL AND amount > 0 AND type <> ?",
+				orgID, itemID, models.InventoryTypeLocationMove)
+		if last == nil {
+			query = query.Where("txn_date < ?", fromDate)
+		} else {
+			query = query.Where("(txn_date, created_at, id) < (?, ?, ?)", last.TxnDate, last.CreatedAt, last.ID)
+		}
+
+		var batch []valuationRow
+		if err := query.Order("txn_date DESC, created_at DESC, id DESC").Limit(RecalcBatchSize).Find(&batch).Error; err != nil {
+			return err
+		}
+		for i := 0; i < len(batch) && left.IsPositive(); i++ {
+			row := &batch[i]
+			unitCost := state.lastCost
+			if cost := row.movementUnitCost(); cost != nil {
+				unitCost = *cost
+			}
+			remaining := decimal.Min(row.Amount, left)
+			newestFirst = append(newestFirst, &models.CostLayer{
+				OrganizationID: orgID,
+				ItemID:         itemID,
+				InventoryID:    row.ID,
+				TxnDate:        row.TxnDate,
+				Quantity:       row.Amount,
+				RemainingQty:   remaining,
+				UnitCost:       unitCost,
+			})
+			left = left.Sub(remaining)
+		}
+		if len(batch) < RecalcBatchSize {
+			break
+		}
+		last = &batch[len(batch)-1]
+	}
+
+	state.layers = make([]*models.CostLayer, 0, len(newestFirst))
+	state.value = decimal.Zero
+	for i := len(newestFirst) - 1; i >= 0; i-- {
+		layer := newestFirst[i]
+		state.layers = append(state.layers, layer)
+		state.value = state.value.Add(layer.UnitCost.Mul(layer.RemainingQty))
+	}
+	// Layer hasil restore berasal dari sebelum fromDate, tidak pernah dianggap cocok untuk short-circuit
+	if len(newestFirst) > 0 {
+		state.unsettled = newestFirst[0]
+	}
+	// Saldo awal tanpa baris masuk yang cukup (data lama / stok negatif): sisanya dengan cost terakhir
+	if !left.IsZero() {
+		state.value = state.value.Add(state.lastCost.Mul(left))
+	}
+	return nil
+}
+
+// transferInCosts - Unit cost leg keluar (org asal) untuk setiap leg mutasi masuk di batch, per ref_id
+func (r *InventoryRepository) transferInCosts(tx *gorm.DB, orgID uuid.UUID, itemID uint,
+	batch []valuationRow) (map[uuid.UUID]decimal.Decimal, error) {
+
+	var refIDs []uuid.UUID
+	for _, row := range batch {
+		if row.Type == models.InventoryTypeMutation && row.Amount.IsPositive() && row.RefID != nil {
+			refIDs = append(refIDs, *row.RefID)
+		}
+	}
+	if len(refIDs) == 0 {
+		return nil, nil
+	}
+
+	var legs []valuationRow
+	err := tx.Model(&models.Inventory{}).
+		Select(valuationColumns).
+		Where("item_id = ? AND ref_id IN ? AND type = ? AND amount < 0 AND organization_id <> ? AND deleted_at IS NULL",
+			itemID, refIDs, models.InventoryTypeMutation, orgID).
+		Find(&legs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	costs := make(map[uuid.UUID]decimal.Decimal, len(legs))
+	for i := range legs {
+		if cost := legs[i].movementUnitCost(); cost != nil {
+			costs[*legs[i].RefID] = *cost
+		}
+	}
+	return costs, nil
+}
+
+// bulkUpdateValues - Satu UPDATE ... FROM (VALUES ...) untuk semua baris yang nilainya berubah
+func (r *InventoryRepository) bulkUpdateValues(tx *gorm.DB, rows []valuationRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var sql strings.Builder
+	args := make([]interface{}, 0, len(rows)*3)
+
+	sql.WriteString(`UPDATE inventories AS i
+		SET cost_amount = v.cost_amount, balance_value = v.balance_value
+		FROM (VALUES `)
+	for i, row := range rows {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteString("(?::uuid, ?::numeric, ?::numeric)")
+		args = append(args, row.ID, row.CostAmount, row.BalanceValue)
+	}
+	sql.WriteString(") AS v(id, cost_amount, balance_value) WHERE i.id = v.id")
+
+	return tx.Exec(sql.String(), args...).Error
+}
+
+// replaceCostLayers - Tulis ulang layer terbuka org+item (item average: kosong)
+func (r *InventoryRepository) replaceCostLayers(tx *gorm.DB, orgID uuid.UUID, itemID uint, layers []*models.CostLayer) error {
+	if err := tx.Where("organization_id = ? AND item_id = ?", orgID, itemID).Delete(&models.CostLayer{}).Error; err != nil {
+		return err
+	}
+	if len(layers) == 0 {
+		return nil
+	}
+	for _, layer := range layers {
+		layer.ID = 0
+		layer.OrganizationID = orgID
+		layer.ItemID = itemID
+	}
+	return tx.CreateInBatches(layers, RecalcBatchSize).Error
+}
+
+// GetCostLayers - Layer FIFO terbuka org+item, tertua dulu
+func (r *InventoryRepository) GetCostLayers(orgID uuid.UUID, itemID uint) ([]models.CostLayer, error) {
+	var layers []models.CostLayer
+	err := r.DB.
+		Where("organization_id = ? AND item_id = ?", orgID, itemID).
+		Order("id").
+		Find(&layers).Error
+	return layers, err
+}
+
+// ============ VALUATION STATE ============
+
+// valuationState - Posisi persediaan berjalan selama stream valuation.
+// Stok negatif dinilai dengan cost terakhir; baris masuk yang menutupnya menilai ulang
+// sisa stok dengan cost baris itu (selisihnya terserap di balance_value baris tersebut).
+type valuationState struct {
+	method   models.CostingMethod
+	qty      decimal.Decimal
+	value    decimal.Decimal
+	lastCost decimal.Decimal
+	layers   []*models.CostLayer // FIFO, tertua dulu
+
+	// unsettled - Layer terbaru yang dibuka baris yang tidak cocok dengan valuation sebelumnya.
+	// Layer habis dari yang tertua, jadi semua layer terbuka cocok begitu layer ini habis.
+	unsettled *models.CostLayer
+}
+
+// markUnsettled - Layer yang baru dibuka row (jika ada) tidak bisa dipakai untuk short-circuit
+func (s *valuationState) markUnsettled(row *valuationRow) {
+	if n := len(s.layers); n > 0 && s.layers[n-1].InventoryID == row.ID {
+		s.unsettled = s.layers[n-1]
+	}
+}
+
+// settled - Saldo positif dan semua layer terbuka dibuka baris yang cocok dengan valuation sebelumnya
+// (item average: cukup saldo positif, nilai sudah dicek per baris)
+func (s *valuationState) settled() bool {
+	return s.qty.IsPositive() && (s.unsettled == nil || s.unsettled.RemainingQty.IsZero())
+}
+
+// start - Posisi dari baris terakhir sebelum fromDate
+func (s *valuationState) start(row *valuationRow) {
+	s.qty = row.Balance
+	if row.BalanceValue != nil {
+		s.value = *row.BalanceValue
+	}
+	if cost := row.movementUnitCost(); cost != nil {
+		s.lastCost = *cost
+	} else if s.qty.IsPositive() {
+		s.lastCost = s.value.DivRound(s.qty, CostScale)
+	}
+}
+
+// currentCost - Cost untuk baris masuk tanpa harga: rata-rata (average) atau layer terbaru (FIFO)
+func (s *valuationState) currentCost() decimal.Decimal {
+	if s.qty.IsPositive() {
+		if s.method == models.CostingFIFO && len(s.layers) > 0 {
+			return s.layers[len(s.layers)-1].UnitCost
+		}
+		if s.method != models.CostingFIFO {
+			return s.value.DivRound(s.qty, CostScale)
+		}
+	}
+	return s.lastCost
+}
+
+// apply - Nilai satu baris (saldo sudah final) dan majukan posisi
+func (s *valuationState) apply(row *valuationRow, transferCost *decimal.Decimal) {
+	// Pindah lokasi: leg keluar & masuk di org yang sama, nilai dan layer FIFO tidak berubah
+	// (saldo yang turun sementara di leg keluar tidak boleh me-reset nilai)
+	if row.Type == models.InventoryTypeLocationMove {
+		s.qty = row.Balance
+		cost := decimal.Zero
+		value := s.value.Round(CostScale)
+		row.CostAmount = &cost
+		row.BalanceValue = &value
+		return
+	}
+
+	var cost decimal.Decimal
+	switch {
+	case row.Amount.IsPositive():
+		unitCost := s.currentCost()
+		if row.UnitCost != nil && (row.Type == models.InventoryTypeStokAwal || row.Type == models.InventoryTypePenerimaan) {
+			unitCost = *row.UnitCost
+		} else if transferCost != nil {
+			unitCost = *transferCost
+		}
+		cost = s.receive(row, unitCost)
+	case row.Amount.IsNegative():
+		cost = s.issue(row.Amount.Neg()).Neg()
+	default:
+		cost = decimal.Zero
+	}
+
+	// Saldo ledger adalah sumber kebenaran (opname menimpa saldo berjalan)
+	s.qty = row.Balance
+	if s.qty.IsZero() {
+		s.value = decimal.Zero
+	}
+
+	cost = cost.Round(CostScale)
+	value := s.value.Round(CostScale)
+	row.CostAmount = &cost
+	row.BalanceValue = &value
+}
+
+// receive - Baris masuk: layer baru (FIFO) / tambah pool (average). Return nilai baris.
+func (s *valuationState) receive(row *valuationRow, unitCost decimal.Decimal) decimal.Decimal {
+	cost := unitCost.Mul(row.Amount)
+	previousQty := s.qty
+	s.qty = s.qty.Add(row.Amount)
+	s.lastCost = unitCost
+
+	if s.method == models.CostingFIFO && s.qty.IsPositive() {
+		s.layers = append(s.layers, &models.CostLayer{
+			InventoryID:  row.ID,
+			TxnDate:      row.TxnDate,
+			Quantity:     row.Amount,
+			RemainingQty: decimal.Min(row.Amount, s.qty), // stok negatif ditutup lebih dulu
+			UnitCost:     unitCost,
+		})
+	}
+
+	if previousQty.IsNegative() {
+		s.value = unitCost.Mul(s.qty)
+	} else {
+		s.value = s.value.Add(cost)
+	}
+	return cost
+}
+
+// issue - Baris keluar sebanyak qty: ambil dari layer tertua (FIFO) / rata-rata (average).
+// Return nilai barang keluar (positif).
+func (s *valuationState) issue(qty decimal.Decimal) decimal.Decimal {
+	cost := decimal.Zero
+	left := qty
+
+	if s.method == models.CostingFIFO {
+		for left.IsPositive() && len(s.layers) > 0 {
+			layer := s.layers[0]
+			take := decimal.Min(left, layer.RemainingQty)
+			cost = cost.Add(layer.UnitCost.Mul(take))
+			s.lastCost = layer.UnitCost
+			layer.RemainingQty = layer.RemainingQty.Sub(take)
+			left = left.Sub(take)
+			if layer.RemainingQty.IsZero() {
+				s.layers = s.layers[1:]
+			}
+		}
+	} else if s.qty.IsPositive() {
+		average := s.value.Div(s.qty)
+		s.lastCost = average.Round(CostScale)
+		if left.GreaterThanOrEqual(s.qty) {
+			cost = s.value
+			left = left.Sub(s.qty)
+		} else {
+			cost = average.Mul(left).Round(CostScale)
+			left = decimal.Zero
+		}
+	}
+
+	// Keluar melebihi stok: stok negatif dinilai dengan cost terakhir
+	if left.IsPositive() {
+		cost = cost.Add(s.lastCost.Mul(left))
+	}
+
+	s.qty = s.qty.Sub(qty)
+	s.value = s.value.Sub(cost)
+	return cost
+}
+
+func decimalPtrEqual(a, b *decimal.Decimal) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
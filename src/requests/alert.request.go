@@ -0,0 +1,18 @@
+package requests
+
+import "github.com/shopspring/decimal"
+
+// ============ STOCK ALERT ============
+
+// StockLevelRequest - Level kosong (null) = tidak dipantau
+type StockLevelRequest struct {
+	MinQty       *decimal.Decimal `json:"min_qty"`
+	MaxQty       *decimal.Decimal `json:"max_qty"`
+	ReorderPoint *decimal.Decimal `json:"reorder_point"`
+	UpdatedBy    string           `json:"updated_by" binding:"required,max=100"`
+}
+
+type AcknowledgeAlertRequest struct {
+	AcknowledgedBy string  `json:"acknowledged_by" binding:"required,max=100"`
+	Note           *string `json:"note" binding:"omitempty,max=500"`
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 // ============ BASE REQUEST ============
@@ -16,11 +17,12 @@ type BaseInventoryRequest struct {
 type InventoryRequest struct {
 	BaseInventoryRequest
 
-	OrganizationID uuid.UUID `json:"organization_id" binding:"required"`
-	ItemID         uint      `json:"item_id" binding:"required"`
-	TxnDate        time.Time `json:"txn_date" binding:"required"`
-	Amount         int       `json:"amount" binding:"required"`
-	Type           string    `json:"type" binding:"required,oneof=penerimaan pemakaian stok_awal"`
+	OrganizationID uuid.UUID       `json:"organization_id" binding:"required"`
+	ItemID         uint            `json:"item_id" binding:"required"`
+	TxnDate        time.Time       `json:"txn_date" binding:"required"`
+	Amount         decimal.Decimal `json:"amount"`
+	Unit           string          `json:"unit,omitempty" binding:"omitempty,max=20"`
+	Type           string          `json:"type" binding:"required,oneof=penerimaan pemakaian stok_awal"`
 
 	// Optional fields
 	RefID    *uuid.UUID `json:"ref_id,omitempty"`
@@ -34,11 +36,12 @@ type InventoryRequest struct {
 type MutationRequest struct {
 	BaseInventoryRequest
 
-	FromOrganizationID uuid.UUID `json:"from_organization_id" binding:"required"`
-	ToOrganizationID   uuid.UUID `json:"to_organization_id" binding:"required"`
-	ItemID             uint      `json:"item_id" binding:"required"`
-	Quantity           int       `json:"quantity" binding:"required,min=1"`
-	TxnDate            time.Time `json:"txn_date" binding:"required"`
+	FromOrganizationID uuid.UUID       `json:"from_organization_id" binding:"required"`
+	ToOrganizationID   uuid.UUID       `json:"to_organization_id" binding:"required"`
+	ItemID             uint            `json:"item_id" binding:"required"`
+	Quantity           decimal.Decimal `json:"quantity"`
+	Unit               string          `json:"unit,omitempty" binding:"omitempty,max=20"`
+	TxnDate            time.Time       `json:"txn_date" binding:"required"`
 
 	RefID *uuid.UUID `json:"ref_id,omitempty"`
 	Notes *string    `json:"notes,omitempty"`
@@ -48,10 +51,11 @@ type MutationRequest struct {
 type OpnameRequest struct {
 	BaseInventoryRequest
 
-	OrganizationID uuid.UUID `json:"organization_id" binding:"required"`
-	ItemID         uint      `json:"item_id" binding:"required"`
-	PhysicalQty    int       `json:"physical_qty" binding:"required"`
-	TxnDate        time.Time `json:"txn_date" binding:"required"`
+	OrganizationID uuid.UUID       `json:"organization_id" binding:"required"`
+	ItemID         uint            `json:"item_id" binding:"required"`
+	PhysicalQty    decimal.Decimal `json:"physical_qty"`
+	Unit           string          `json:"unit,omitempty" binding:"omitempty,max=20"`
+	TxnDate        time.Time       `json:"txn_date" binding:"required"`
 
 	RefID *uuid.UUID `json:"ref_id,omitempty"`
 	Notes *string    `json:"notes,omitempty"`
@@ -61,9 +65,10 @@ type OpnameRequest struct {
 type UpdateInventoryRequest struct {
 	BaseInventoryRequest
 
-	InventoryID uuid.UUID `json:"inventory_id" binding:"required"`
-	TxnDate     time.Time `json:"txn_date" binding:"required"`
-	Amount      int       `json:"amount" binding:"required"`
+	InventoryID uuid.UUID       `json:"inventory_id" binding:"required"`
+	TxnDate     time.Time       `json:"txn_date" binding:"required"`
+	Amount      decimal.Decimal `json:"amount"`
+	Unit        string          `json:"unit,omitempty" binding:"omitempty,max=20"`
 
 	// Optional updates
 	TargetID *uuid.UUID `json:"target_id,omitempty"`
@@ -77,18 +82,51 @@ type RollbackRequest struct {
 	HistoryID uuid.UUID `json:"history_id" binding:"required"`
 }
 
+// RollbackPreviewRequest - Dry-run rollback, tidak ada yang disimpan
+type RollbackPreviewRequest struct {
+	HistoryID uuid.UUID `json:"history_id" binding:"required"`
+	ChangedBy string    `json:"changed_by,omitempty"`
+}
+
 // ============ REQUEST STRUCTS ============
 type CreateTransactionRequest struct {
-	OrganizationID uuid.UUID  `json:"organization_id" binding:"required"`
-	ItemID         uint       `json:"item_id" binding:"required"`
-	TxnDate        string     `json:"txn_date" binding:"required"`
-	Amount         int        `json:"amount" binding:"required"`
-	Type           string     `json:"type" binding:"required,oneof=stok_awal penerimaan pemakaian"`
-	ChangedBy      string     `json:"changed_by" binding:"required"`
-	Reason         *string    `json:"reason,omitempty"`
-	RefID          *uuid.UUID `json:"ref_id,omitempty"`
-	TargetID       *uuid.UUID `json:"target_id,omitempty"`
-	Source         *string    `json:"source,omitempty"`
-	PageCode       *string    `json:"page_code,omitempty"`
-	Notes          *string    `json:"notes,omitempty"`
+	OrganizationID uuid.UUID        `json:"organization_id" binding:"required"`
+	ItemID         uint             `json:"item_id" binding:"required"`
+	TxnDate        string           `json:"txn_date" binding:"required"`
+	Amount         decimal.Decimal  `json:"amount"`
+	Unit           string           `json:"unit,omitempty" binding:"omitempty,max=20"` // kosong = satuan dasar item
+	Type           string           `json:"type" binding:"required,oneof=stok_awal penerimaan pemakaian"`
+	UnitCost       *decimal.Decimal `json:"unit_cost,omitempty"`
+	ChangedBy      string           `json:"changed_by" binding:"required"`
+	Reason         *string          `json:"reason,omitempty"`
+	RefID          *uuid.UUID       `json:"ref_id,omitempty"`
+	TargetID       *uuid.UUID       `json:"target_id,omitempty"`
+	Source         *string          `json:"source,omitempty"`
+	PageCode       *string          `json:"page_code,omitempty"`
+	Notes          *string          `json:"notes,omitempty"`
+
+	// Item ber-lot: lot_number (+expiry_date YYYY-MM-DD) untuk stok masuk,
+	// lots untuk memilih lot pemakaian (kosong = FEFO)
+	LotNumber  *string              `json:"lot_number,omitempty" binding:"omitempty,max=50"`
+	ExpiryDate *string              `json:"expiry_date,omitempty"`
+	Lots       []LotQuantityRequest `json:"lots,omitempty" binding:"omitempty,dive"`
+
+	// Item serialized: satu nomor seri per unit
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+
+	// Lokasi / bin di organisasi (kosong = belum ditempatkan)
+	LocationID *uuid.UUID `json:"location_id,omitempty"`
+}
+
+// LotQuantityRequest - Qty yang diambil dari satu lot
+type LotQuantityRequest struct {
+	LotNumber string          `json:"lot_number" binding:"required,max=50"`
+	Quantity  decimal.Decimal `json:"quantity"`
+}
+
+// ============ BATCH ============
+type BatchTransactionRequest struct {
+	ChangedBy string                     `json:"changed_by" binding:"required"`
+	Reason    *string                    `json:"reason,omitempty"`
+	Lines     []CreateTransactionRequest `json:"lines" binding:"required,min=1,max=500"`
 }
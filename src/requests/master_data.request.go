@@ -0,0 +1,36 @@
+package requests
+
+import (
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ============ ORGANIZATION ============
+type OrganizationRequest struct {
+	Name                string `json:"name" binding:"required,max=100"`
+	Code                string `json:"code" binding:"required,max=50"`
+	NegativeStockPolicy string `json:"negative_stock_policy" binding:"omitempty,oneof=allow warn block"`
+}
+
+// ============ ITEM ============
+type ItemRequest struct {
+	Code string `json:"code" binding:"required,max=50"`
+	Name string `json:"name" binding:"required,max=200"`
+	Unit string `json:"unit" binding:"required,max=20"`
+
+	CostingMethod string `json:"costing_method" binding:"omitempty,oneof=fifo average"`
+	TrackLots     *bool  `json:"track_lots,omitempty"`
+	Serialized    *bool  `json:"serialized,omitempty"`
+}
+
+// ItemUnitRequest - 1 unit = factor x satuan dasar item
+type ItemUnitRequest struct {
+	Factor decimal.Decimal `json:"factor"`
+}
+
+// ============ LOCATION ============
+type LocationRequest struct {
+	Code     string     `json:"code" binding:"required,max=50"`
+	Name     string     `json:"name" binding:"required,max=100"`
+	ParentID *uuid.UUID `json:"parent_id,omitempty"` // kosong = lokasi root
+}
@@ -0,0 +1,13 @@
+package requests
+
+// ============ PERIOD CLOSING ============
+type ClosePeriodRequest struct {
+	PeriodEnd string  `json:"period_end" binding:"required"` // YYYY-MM-DD, inklusif
+	ClosedBy  string  `json:"closed_by" binding:"required,max=100"`
+	Reason    *string `json:"reason,omitempty"`
+}
+
+type ReopenPeriodRequest struct {
+	ReopenedBy string `json:"reopened_by" binding:"required,max=100"`
+	Reason     string `json:"reason" binding:"required"`
+}
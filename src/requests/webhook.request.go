@@ -0,0 +1,13 @@
+package requests
+
+import "github.com/google/uuid"
+
+// ============ WEBHOOK ============
+type WebhookSubscriptionRequest struct {
+	Name            string      `json:"name" binding:"required,max=100"`
+	URL             string      `json:"url" binding:"required,url"`
+	Secret          string      `json:"secret" binding:"omitempty,min=16,max=255"` // kosong = generate / tidak diubah
+	EventTypes      []string    `json:"event_types"`                               // kosong = semua event
+	OrganizationIDs []uuid.UUID `json:"organization_ids"`                          // kosong = semua organisasi
+	IsActive        *bool       `json:"is_active"`
+}
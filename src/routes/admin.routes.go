@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"inventory-ledger/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminRoutes(r *gin.RouterGroup, handler *handlers.AdminHandler) {
+	// Verifikasi hash chain ledger (read-only)
+	r.GET("/ledger/verify", handler.VerifyLedgerChain)
+
+	// Integrity check ledger (read-only, repair lewat CLI)
+	r.GET("/integrity", handler.CheckIntegrity)
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"inventory-ledger/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAlertRoutes(r *gin.RouterGroup, handler *handlers.AlertHandler) {
+	r.GET("", handler.ListAlerts)
+	r.GET("/:id", handler.GetAlert)
+	r.POST("/:id/acknowledge", handler.AcknowledgeAlert)
+}
+
+// RegisterStockLevelRoutes - Level min/max/reorder per organisasi+item, di-mount di group /organizations
+func RegisterStockLevelRoutes(r *gin.RouterGroup, handler *handlers.AlertHandler) {
+	r.GET("/:id/stock-levels", handler.ListStockLevels)
+	r.PUT("/:id/stock-levels/:item_id", handler.SetStockLevel)
+	r.DELETE("/:id/stock-levels/:item_id", handler.DeleteStockLevel)
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"inventory-ledger/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterImportRoutes(r *gin.RouterGroup, handler *handlers.ImportHandler, idempotency gin.HandlerFunc) {
+	// Upload CSV/XLSX (query dry_run=true untuk validasi saja)
+	r.POST("/import", idempotency, handler.ImportTransactions)
+}
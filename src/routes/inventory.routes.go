@@ -6,25 +6,32 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterInventoryRoutes(r *gin.RouterGroup, handler *handlers.InventoryHandler) {
+func RegisterInventoryRoutes(r *gin.RouterGroup, handler *handlers.InventoryHandler, idempotency gin.HandlerFunc) {
 	// GET endpoints
 	r.GET("/balance/current", handler.GetCurrentBalance)
 	r.GET("/balance/historical", handler.GetBalanceAt)
+	r.GET("/cost-layers", handler.GetCostLayers)
+	r.GET("/lots", handler.GetLotBalances)
+	r.GET("/lots/expiring", handler.GetExpiringLots)
 	r.GET("/transactions", handler.GetTransactions)
 	r.GET("/summary/org", handler.GetOrganizationSummary)
 	r.GET("/summary/item", handler.GetItemSummary)
 	r.GET("/history", handler.GetHistory)
+	r.GET("/history/:id/diff", handler.GetHistoryDiff)
 
-	// POST endpoints
-	r.POST("/transaction", handler.CreateTransaction)
-	r.POST("/mutation", handler.CreateMutation)
-	r.POST("/opname", handler.CreateOpname)
+	// POST endpoints (mendukung header Idempotency-Key)
+	r.POST("/transaction", idempotency, handler.CreateTransaction)
+	r.POST("/transactions/batch", idempotency, handler.CreateTransactionBatch)
+	r.POST("/mutation", idempotency, handler.CreateMutation)
+	r.POST("/location-move", idempotency, handler.CreateLocationMove)
+	r.POST("/opname", idempotency, handler.CreateOpname)
 
 	// PUT endpoint
 	r.PUT("/transaction", handler.UpdateTransaction)
 
 	// ROLLBACK endpoint (NEW!)
-	r.POST("/rollback", handler.RollbackTransaction)
+	r.POST("/rollback", idempotency, handler.RollbackTransaction)
+	r.POST("/rollback/preview", handler.PreviewRollback)
 
 	// DELETE endpoint
 	r.DELETE("/transaction", handler.DeleteTransaction)
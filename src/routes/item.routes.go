@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"inventory-ledger/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterItemRoutes(r *gin.RouterGroup, handler *handlers.ItemHandler) {
+	r.GET("", handler.ListItems)
+	r.GET("/:id", handler.GetItem)
+	r.POST("", handler.CreateItem)
+	r.PUT("/:id", handler.UpdateItem)
+
+	// Deactivate = soft delete, ledger & history tetap utuh
+	r.DELETE("/:id", handler.DeactivateItem)
+	r.POST("/:id/activate", handler.ActivateItem)
+
+	// Satuan alternatif: posting dalam unit ini dikonversi ke satuan dasar item
+	r.GET("/:id/units", handler.GetItemUnits)
+	r.PUT("/:id/units/:unit", handler.SetItemUnit)
+	r.DELETE("/:id/units/:unit", handler.DeleteItemUnit)
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"inventory-ledger/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterLocationRoutes - Pohon lokasi (zona / rak / bin) per organisasi, di-mount di group /organizations
+func RegisterLocationRoutes(r *gin.RouterGroup, handler *handlers.LocationHandler) {
+	r.GET("/:id/locations", handler.ListLocations)
+	r.GET("/:id/locations/:location_id", handler.GetLocation)
+	r.POST("/:id/locations", handler.CreateLocation)
+	r.PUT("/:id/locations/:location_id", handler.UpdateLocation)
+
+	// Deactivate = soft delete, ledger & saldo lokasi tetap utuh
+	r.DELETE("/:id/locations/:location_id", handler.DeactivateLocation)
+	r.POST("/:id/locations/:location_id/activate", handler.ActivateLocation)
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"inventory-ledger/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterOrganizationRoutes(r *gin.RouterGroup, handler *handlers.OrganizationHandler) {
+	r.GET("", handler.ListOrganizations)
+	r.GET("/:id", handler.GetOrganization)
+	r.POST("", handler.CreateOrganization)
+	r.PUT("/:id", handler.UpdateOrganization)
+
+	// Deactivate = soft delete, ledger & history tetap utuh
+	r.DELETE("/:id", handler.DeactivateOrganization)
+	r.POST("/:id/activate", handler.ActivateOrganization)
+}
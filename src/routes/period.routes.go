@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"inventory-ledger/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterPeriodRoutes - Tutup buku per organisasi, di-mount di group /organizations
+func RegisterPeriodRoutes(r *gin.RouterGroup, handler *handlers.PeriodHandler) {
+	r.GET("/:id/periods", handler.ListPeriodClosings)
+	r.GET("/:id/periods/:closing_id", handler.GetPeriodClosing)
+	r.POST("/:id/periods", handler.ClosePeriod)
+
+	// Reopen eksplisit, tercatat di closing (tidak menghapus snapshot)
+	r.POST("/:id/periods/:closing_id/reopen", handler.ReopenPeriod)
+}
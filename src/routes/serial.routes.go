@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"inventory-ledger/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSerialRoutes(r *gin.RouterGroup, handler *handlers.InventoryHandler) {
+	r.GET("/:sn", handler.GetSerialTrail)
+}
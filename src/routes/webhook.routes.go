@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"inventory-ledger/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWebhookRoutes(r *gin.RouterGroup, handler *handlers.WebhookHandler) {
+	r.GET("", handler.ListWebhooks)
+	r.GET("/:id", handler.GetWebhook)
+	r.POST("", handler.CreateWebhook)
+	r.PUT("/:id", handler.UpdateWebhook)
+	r.DELETE("/:id", handler.DeleteWebhook)
+
+	// Delivery log + redelivery manual (delivery baru, log lama tetap)
+	r.GET("/:id/deliveries", handler.ListWebhookDeliveries)
+	r.GET("/:id/deliveries/:delivery_id", handler.GetWebhookDelivery)
+	r.POST("/:id/deliveries/:delivery_id/redeliver", handler.RedeliverWebhook)
+}
@@ -0,0 +1,153 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+	"inventory-ledger/src/services"
+)
+
+// ============ TEST SCENARIO 25: SERIAL NUMBER TRACKING ============
+func TestSerialTracking(t *testing.T) {
+	itemService := &services.ItemService{
+		DB:        testDB,
+		Repo:      &repositories.ItemRepository{DB: testDB},
+		Inventory: testService,
+	}
+
+	orgID := uuid.New()
+	branchID := uuid.New()
+	testDB.Create(&models.Organization{ID: orgID, Name: "Serial Org", Code: "ORG-SN"})
+	testDB.Create(&models.Organization{ID: branchID, Name: "Serial Branch", Code: "ORG-SN-2"})
+
+	serialized := true
+	base := time.Date(2025, 8, 1, 8, 0, 0, 0, time.UTC)
+
+	registry := func(itemID uint, serial string) models.SerialNumber {
+		var sn models.SerialNumber
+		assertNoError(t, testDB.Where("item_id = ? AND serial_number = ?", itemID, serial).Take(&sn).Error)
+		return sn
+	}
+
+	t.Run("SC53: Receipts, usage and mutations name the serials that move", func(t *testing.T) {
+		item, err := itemService.CreateItem(services.ItemRequest{Code: "ITEM-SN", Name: "Laptop Dell XPS 13", Unit: "unit", Serialized: &serialized})
+		assertNoError(t, err)
+
+		// Jumlah nomor seri harus sama dengan amount
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base, Amount: models.Qty(3), Type: "stok_awal",
+			SerialNumbers: []string{"SN-1", "SN-2"}, ChangedBy: "serial_test",
+		})
+		assertError(t, err, services.ErrSerialCountMismatch.Error())
+
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base, Amount: models.Qty(3), Type: "stok_awal",
+			SerialNumbers: []string{"SN-3", " SN-1", "SN-2"}, ChangedBy: "serial_test",
+		})
+		assertNoError(t, err)
+
+		// Nomor seri yang masih ada tidak bisa diterima lagi
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 1), Amount: models.Qty(1), Type: "penerimaan",
+			SerialNumbers: []string{"SN-2"}, ChangedBy: "serial_test",
+		})
+		assertError(t, err, services.ErrSerialAlreadyInStock.Error()+" SN-2")
+
+		issue, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 2), Amount: models.Qty(-1), Type: "pemakaian",
+			SerialNumbers: []string{"SN-1"}, ChangedBy: "serial_test",
+		})
+		assertNoError(t, err)
+		assertEqual(t, 2, issue.Balance, "balance after usage")
+		assertEqual(t, models.SerialOut, registry(item.ID, "SN-1").Status, "used serial is out")
+
+		// Unit yang sudah keluar tidak bisa dipakai lagi
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 3), Amount: models.Qty(-1), Type: "pemakaian",
+			SerialNumbers: []string{"SN-1"}, ChangedBy: "serial_test",
+		})
+		assertError(t, err, services.ErrSerialNotInStock.Error()+" SN-1")
+
+		// Pemakaian tanpa nomor seri ditolak
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 3), Amount: models.Qty(-1), Type: "pemakaian",
+			ChangedBy: "serial_test",
+		})
+		assertError(t, err, services.ErrSerialCountMismatch.Error())
+
+		assertNoError(t, testService.CreateMutation(services.MutationRequest{
+			FromOrganizationID: orgID, ToOrganizationID: branchID, ItemID: item.ID,
+			Quantity: models.Qty(1), TxnDate: base.AddDate(0, 0, 4), SerialNumbers: []string{"SN-2"}, ChangedBy: "serial_test",
+		}))
+		sn2 := registry(item.ID, "SN-2")
+		assertEqual(t, models.SerialInStock, sn2.Status, "moved serial in stock")
+		assertEqual(t, branchID, *sn2.OrganizationID, "moved serial at branch")
+
+		units, err := testService.GetSerialTrail("SN-2", 0)
+		assertNoError(t, err)
+		assertEqual(t, 1, len(units), "one unit")
+		assertEqual(t, "ORG-SN-2", *units[0].OrganizationCode, "current organization code")
+		assertEqual(t, 3, len(units[0].Movements), "receipt + mutation out + mutation in")
+		assertEqual(t, "in", units[0].Movements[0].Direction, "first movement")
+		assertEqual(t, models.InventoryTypeStokAwal, units[0].Movements[0].Type, "first movement type")
+		assertEqual(t, orgID, units[0].Movements[1].OrganizationID, "mutation out at source")
+
+		_, err = testService.GetSerialTrail("SN-UNKNOWN", 0)
+		assertError(t, err, services.ErrSerialNotFound.Error())
+
+		// Flag serialized terkunci dan item tanpa nomor seri menolak serial_numbers
+		off := false
+		_, err = itemService.UpdateItem(item.ID, services.ItemRequest{Code: item.Code, Name: item.Name, Unit: item.Unit, Serialized: &off})
+		assertError(t, err, services.ErrSerializedLocked.Error())
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: testItemID, TxnDate: base, Amount: models.Qty(1), Type: "penerimaan",
+			SerialNumbers: []string{"SN-X"}, ChangedBy: "serial_test",
+		})
+		assertError(t, err, services.ErrSerialNotTracked.Error())
+	})
+
+	t.Run("SC54: Opname counts serials and deleting a receipt with moved units is rejected", func(t *testing.T) {
+		item, err := itemService.CreateItem(services.ItemRequest{Code: "ITEM-SN-OPN", Name: "Scanner", Unit: "unit", Serialized: &serialized})
+		assertNoError(t, err)
+
+		receipt, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base, Amount: models.Qty(3), Type: "stok_awal",
+			SerialNumbers: []string{"OPN-1", "OPN-2", "OPN-3"}, ChangedBy: "serial_test",
+		})
+		assertNoError(t, err)
+
+		// Dihitung: OPN-1, OPN-3, OPN-9 → OPN-2 hilang (minus), OPN-9 tak tercatat (plus)
+		adjustment, err := testService.CreateOpname(services.OpnameRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 1), PhysicalQty: models.Qty(3),
+			SerialNumbers: []string{"OPN-1", "OPN-3", "OPN-9"}, ChangedBy: "serial_test",
+		})
+		assertNoError(t, err)
+		assertEqual(t, 1, adjustment.Amount, "last opname row adds the uncounted unit")
+		assertEqual(t, 3, adjustment.Balance, "balance after opname")
+
+		var rows []models.Inventory
+		assertNoError(t, testDB.Where("organization_id = ? AND item_id = ? AND type = ?", orgID, item.ID, models.InventoryTypeOpname).
+			Order("created_at").Find(&rows).Error)
+		assertEqual(t, 2, len(rows), "minus and plus opname rows")
+		assertEqual(t, -1, rows[0].Amount, "missing unit row")
+		assertEqual(t, "OPN-2", rows[0].SerialNumbers[0], "missing serial")
+		assertEqual(t, models.SerialOut, registry(item.ID, "OPN-2").Status, "missing serial is out")
+		assertEqual(t, models.SerialInStock, registry(item.ID, "OPN-9").Status, "found serial in stock")
+
+		_, err = testService.CreateOpname(services.OpnameRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 2), PhysicalQty: models.Qty(2),
+			SerialNumbers: []string{"OPN-1"}, ChangedBy: "serial_test",
+		})
+		assertError(t, err, services.ErrSerialCountMismatch.Error())
+
+		// Hapus stok awal: OPN-2 keluar (opname) sebelum pernah masuk, selisih opname ikut berubah
+		err = testService.DeleteTransaction(receipt.ID, "serial_test", nil)
+		if err == nil {
+			t.Fatalf("expected deleting the receipt to be rejected")
+		}
+	})
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"inventory-ledger/src/models"
+)
+
+var (
+	ErrAlertNotFound      = errors.New("stock alert not found")
+	ErrAlertNotOpen       = errors.New("only open alerts can be acknowledged")
+	ErrStockLevelNotFound = errors.New("stock level not found")
+	ErrInvalidStockLevels = errors.New("stock levels must be non-negative with min_qty <= reorder_point <= max_qty")
+)
+
+// ============ REQUEST STRUCTS ============
+type StockLevelRequest struct {
+	OrganizationID uuid.UUID
+	ItemID         uint
+	MinQty         *decimal.Decimal
+	MaxQty         *decimal.Decimal
+	ReorderPoint   *decimal.Decimal
+	UpdatedBy      string
+}
+
+type AcknowledgeAlertRequest struct {
+	AlertID        uuid.UUID
+	AcknowledgedBy string
+	Note           *string
+}
+
+// AlertFilter - Filter list alert (zero value = semua)
+type AlertFilter struct {
+	OrganizationID uuid.UUID
+	ItemID         uint
+	State          models.StockAlertState
+	AlertType      models.StockAlertType
+	Page           int
+	Limit          int
+}
+
+// StockAlertEvent - Payload StockAlertRaised / StockAlertResolved (dipakai juga oleh notifier)
+type StockAlertEvent struct {
+	AlertID         uuid.UUID              `json:"alert_id"`
+	OrganizationID  uuid.UUID              `json:"organization_id"`
+	ItemID          uint                   `json:"item_id"`
+	AlertType       models.StockAlertType  `json:"alert_type"`
+	State           models.StockAlertState `json:"state"`
+	Threshold       decimal.Decimal        `json:"threshold"`
+	Balance         decimal.Decimal        `json:"balance"`
+	RaisedAt        time.Time              `json:"raised_at"`
+	ResolvedBalance *decimal.Decimal       `json:"resolved_balance,omitempty"`
+	ResolvedAt      *time.Time             `json:"resolved_at,omitempty"`
+}
+
+func newStockAlertEvent(alert *models.StockAlert) StockAlertEvent {
+	return StockAlertEvent{
+		AlertID:         alert.ID,
+		OrganizationID:  alert.OrganizationID,
+		ItemID:          alert.ItemID,
+		AlertType:       alert.AlertType,
+		State:           alert.State,
+		Threshold:       alert.Threshold,
+		Balance:         alert.Balance,
+		RaisedAt:        alert.RaisedAt,
+		ResolvedBalance: alert.ResolvedBalance,
+		ResolvedAt:      alert.ResolvedAt,
+	}
+}
+
+// ============ ALERT SERVICE ============
+type AlertService struct {
+	DB        *gorm.DB
+	Inventory *InventoryService
+}
+
+// ListStockLevels - Level stok semua item di organisasi
+func (s *AlertService) ListStockLevels(orgID uuid.UUID) ([]models.StockLevel, error) {
+	var levels []models.StockLevel
+	err := s.DB.Where("organization_id = ?", orgID).Order("item_id").Find(&levels).Error
+	return levels, err
+}
+
+// SetStockLevel - Upsert level stok org+item lalu langsung evaluasi terhadap saldo sekarang
+func (s *AlertService) SetStockLevel(req StockLevelRequest) (*models.StockLevel, error) {
+	if !validStockLevels(req.MinQty, req.ReorderPoint, req.MaxQty) {
+		return nil, ErrInvalidStockLevels
+	}
+
+	level := &models.StockLevel{
+		OrganizationID: req.OrganizationID,
+		ItemID:         req.ItemID,
+		MinQty:         req.MinQty,
+		MaxQty:         req.MaxQty,
+		ReorderPoint:   req.ReorderPoint,
+		UpdatedBy:      &req.UpdatedBy,
+		UpdatedAt:      time.Now(),
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.ensureOrgItemExists(tx, req.OrganizationID, req.ItemID); err != nil {
+			return err
+		}
+		if err := s.Inventory.lockOrgItems(tx, orgItemKey{req.OrganizationID, req.ItemID}); err != nil {
+			return err
+		}
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "organization_id"}, {Name: "item_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"min_qty", "max_qty", "reorder_point", "updated_by", "updated_at"}),
+		}).Create(level).Error
+		if err != nil {
+			return err
+		}
+		return s.Inventory.applyStockLevel(tx, level)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return level, nil
+}
+
+// DeleteStockLevel - Hapus level stok; alert aktif org+item ikut resolved
+func (s *AlertService) DeleteStockLevel(orgID uuid.UUID, itemID uint) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.Inventory.lockOrgItems(tx, orgItemKey{orgID, itemID}); err != nil {
+			return err
+		}
+		result := tx.Where("organization_id = ? AND item_id = ?", orgID, itemID).Delete(&models.StockLevel{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStockLevelNotFound
+		}
+		return s.Inventory.applyStockLevel(tx, &models.StockLevel{OrganizationID: orgID, ItemID: itemID})
+	})
+}
+
+// ListAlerts - List alert dengan filter, terbaru dulu
+func (s *AlertService) ListAlerts(filter AlertFilter) ([]models.StockAlert, int64, error) {
+	var alerts []models.StockAlert
+	var total int64
+
+	query := s.DB.Model(&models.StockAlert{})
+	if filter.OrganizationID != uuid.Nil {
+		query = query.Where("organization_id = ?", filter.OrganizationID)
+	}
+	if filter.ItemID != 0 {
+		query = query.Where("item_id = ?", filter.ItemID)
+	}
+	if filter.State != "" {
+		query = query.Where("state = ?", filter.State)
+	}
+	if filter.AlertType != "" {
+		query = query.Where("alert_type = ?", filter.AlertType)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order("raised_at DESC").
+		Limit(filter.Limit).
+		Offset((filter.Page - 1) * filter.Limit).
+		Find(&alerts).Error
+
+	return alerts, total, err
+}
+
+// GetAlert - Detail alert
+func (s *AlertService) GetAlert(id uuid.UUID) (*models.StockAlert, error) {
+	var alert models.StockAlert
+	err := s.DB.Where("id = ?", id).Take(&alert).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAlertNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// AcknowledgeAlert - open -> acknowledged (alert tetap aktif sampai saldo kembali normal)
+func (s *AlertService) AcknowledgeAlert(req AcknowledgeAlertRequest) (*models.StockAlert, error) {
+	var alert models.StockAlert
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", req.AlertID).Take(&alert).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAlertNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if alert.State != models.StockAlertOpen {
+			return ErrAlertNotOpen
+		}
+
+		now := time.Now()
+		alert.State = models.StockAlertAcknowledged
+		alert.AcknowledgedBy = &req.AcknowledgedBy
+		alert.AcknowledgeNote = req.Note
+		alert.AcknowledgedAt = &now
+		return tx.Save(&alert).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("ALERT ACKNOWLEDGED: %v by %s", alert.ID, req.AcknowledgedBy)
+	return &alert, nil
+}
+
+// ensureOrgItemExists - Level stok boleh diatur untuk org/item nonaktif, tapi keduanya harus ada
+func (s *AlertService) ensureOrgItemExists(tx *gorm.DB, orgID uuid.UUID, itemID uint) error {
+	var count int64
+	if err := tx.Model(&models.Organization{}).Where("id = ?", orgID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrOrganizationNotFound
+	}
+	if err := tx.Model(&models.Item{}).Where("id = ?", itemID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrItemNotFound
+	}
+	return nil
+}
+
+// validStockLevels - Non-negatif dan min <= reorder <= max untuk level yang diisi
+func validStockLevels(levels ...*decimal.Decimal) bool {
+	var previous *decimal.Decimal
+	for _, level := range levels {
+		if level == nil {
+			continue
+		}
+		if level.IsNegative() || !validQuantity(*level) || (previous != nil && level.LessThan(*previous)) {
+			return false
+		}
+		previous = level
+	}
+	return true
+}
+
+// ============ EVALUATION (di dalam transaksi write path) ============
+
+// evaluateStockLevels - Dipanggil setelah recalculation: org+item tanpa level stok dilewati
+func (s *InventoryService) evaluateStockLevels(tx *gorm.DB, orgID uuid.UUID, itemID uint) error {
+	var level models.StockLevel
+	err := tx.Where("organization_id = ? AND item_id = ?", orgID, itemID).Take(&level).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.applyStockLevel(tx, &level)
+}
+
+// applyStockLevel - Bandingkan saldo projection dengan setiap level: raise alert baru saat
+// level terlewati, resolve alert aktif saat saldo kembali normal (atau levelnya dihapus)
+func (s *InventoryService) applyStockLevel(tx *gorm.DB, level *models.StockLevel) error {
+	balance, err := s.projectedBalance(tx, level.OrganizationID, level.ItemID)
+	if err != nil {
+		return err
+	}
+
+	var active []models.StockAlert
+	if err := tx.Where("organization_id = ? AND item_id = ? AND state <> ?",
+		level.OrganizationID, level.ItemID, models.StockAlertResolved).Find(&active).Error; err != nil {
+		return err
+	}
+	activeByType := make(map[models.StockAlertType]*models.StockAlert, len(active))
+	for i := range active {
+		activeByType[active[i].AlertType] = &active[i]
+	}
+
+	checks := []struct {
+		alertType models.StockAlertType
+		threshold *decimal.Decimal
+		breached  func(balance, threshold decimal.Decimal) bool
+	}{
+		{models.StockAlertBelowMin, level.MinQty, decimal.Decimal.LessThan},
+		{models.StockAlertReorder, level.ReorderPoint, decimal.Decimal.LessThanOrEqual},
+		{models.StockAlertAboveMax, level.MaxQty, decimal.Decimal.GreaterThan},
+	}
+
+	now := time.Now()
+	for _, check := range checks {
+		breached := check.threshold != nil && check.breached(balance, *check.threshold)
+		alert := activeByType[check.alertType]
+
+		switch {
+		case breached && alert == nil:
+			alert = &models.StockAlert{
+				OrganizationID: level.OrganizationID,
+				ItemID:         level.ItemID,
+				AlertType:      check.alertType,
+				Threshold:      *check.threshold,
+				Balance:        balance,
+				State:          models.StockAlertOpen,
+				RaisedAt:       now,
+			}
+			if err := tx.Create(alert).Error; err != nil {
+				return err
+			}
+			log.Printf("🔔 ALERT RAISED: %s org=%v item=%d balance=%s threshold=%s",
+				alert.AlertType, alert.OrganizationID, alert.ItemID, balance, alert.Threshold)
+			if err := emitEvent(tx, EventStockAlertRaised, level.OrganizationID, level.ItemID, newStockAlertEvent(alert)); err != nil {
+				return err
+			}
+
+		case !breached && alert != nil:
+			alert.State = models.StockAlertResolved
+			alert.ResolvedBalance = &balance
+			alert.ResolvedAt = &now
+			if err := tx.Save(alert).Error; err != nil {
+				return err
+			}
+			log.Printf("✅ ALERT RESOLVED: %s org=%v item=%d balance=%s",
+				alert.AlertType, alert.OrganizationID, alert.ItemID, balance)
+			if err := emitEvent(tx, EventStockAlertResolved, level.OrganizationID, level.ItemID, newStockAlertEvent(alert)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+)
+
+// MaxBatchLines - Batas jumlah baris per batch posting
+const MaxBatchLines = 500
+
+var (
+	ErrBatchEmpty      = errors.New("batch must contain at least one line")
+	ErrBatchTooLarge   = errors.New("batch exceeds maximum number of lines")
+	ErrBatchValidation = errors.New("batch validation failed")
+	ErrBatchRejected   = errors.New("batch rejected, no lines were posted")
+)
+
+// BatchLineResult - Hasil per baris batch posting
+type BatchLineResult struct {
+	Line        int              `json:"line"`
+	InventoryID *uuid.UUID       `json:"inventory_id,omitempty"`
+	Balance     *decimal.Decimal `json:"balance,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// ValidateTransactionBatch - Validasi semua baris tanpa menyentuh database.
+// Return false jika ada baris yang error (detail ada di results).
+func ValidateTransactionBatch(reqs []CreateTransactionRequest) ([]BatchLineResult, bool) {
+	results := make([]BatchLineResult, len(reqs))
+	valid := true
+
+	for i, req := range reqs {
+		results[i].Line = i + 1
+		if err := validateTransactionRequest(req); err != nil {
+			results[i].Error = err.Error()
+			valid = false
+		}
+	}
+
+	return results, valid
+}
+
+// CreateTransactionBatch - Posting banyak transaksi dalam satu DB transaction (all-or-nothing).
+// RecalculateForward hanya dijalankan sekali per org+item dari tanggal paling awal.
+func (s *InventoryService) CreateTransactionBatch(reqs []CreateTransactionRequest, changedBy string, reason *string) ([]BatchLineResult, error) {
+	if len(reqs) == 0 {
+		return nil, ErrBatchEmpty
+	}
+	if len(reqs) > MaxBatchLines {
+		return nil, ErrBatchTooLarge
+	}
+
+	return s.postTransactionBatch(reqs, changedBy, reason)
+}
+
+// postTransactionBatch - Inti batch posting tanpa batas jumlah baris (dipakai juga oleh import)
+func (s *InventoryService) postTransactionBatch(reqs []CreateTransactionRequest, changedBy string, reason *string) ([]BatchLineResult, error) {
+	if len(reqs) == 0 {
+		return nil, ErrBatchEmpty
+	}
+
+	// Qty dalam satuan alternatif dinormalkan ke satuan dasar sebelum validasi
+	reqs = append([]CreateTransactionRequest(nil), reqs...)
+	unitErrors := make(map[int]error)
+	for i := range reqs {
+		amount, lots, err := s.toBaseUnit(s.DB, reqs[i].ItemID, reqs[i].Unit, reqs[i].Amount, reqs[i].Lots)
+		if err != nil {
+			if !isUnitError(err) && !isPostableError(err) {
+				return nil, err
+			}
+			unitErrors[i] = err
+			continue
+		}
+		reqs[i].Amount, reqs[i].Lots, reqs[i].Unit = amount, lots, ""
+	}
+
+	results, valid := ValidateTransactionBatch(reqs)
+	for i, err := range unitErrors {
+		results[i].Error = err.Error()
+		valid = false
+	}
+	if !valid {
+		return results, ErrBatchValidation
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		// Group baris per org+item
+		groups := make(map[orgItemKey][]int)
+		keys := make([]orgItemKey, 0)
+		for i, req := range reqs {
+			key := orgItemKey{req.OrganizationID, req.ItemID}
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], i)
+		}
+
+		if err := s.lockOrgItems(tx, keys...); err != nil {
+			return err
+		}
+
+		rejected := false
+		for _, key := range keys {
+			if err := s.ensurePostable(tx, key.OrganizationID, key.ItemID); err != nil {
+				if !isPostableError(err) {
+					return err
+				}
+				for _, i := range groups[key] {
+					results[i].Error = err.Error()
+				}
+				rejected = true
+				continue
+			}
+
+			firstStockLine := -1
+			for _, i := range groups[key] {
+				if reqs[i].Type != "stok_awal" {
+					continue
+				}
+				if firstStockLine >= 0 {
+					results[i].Error = "duplicate stok_awal in batch for this item"
+					rejected = true
+					continue
+				}
+				firstStockLine = i
+
+				exists, err := s.checkFirstStockExists(tx, key.OrganizationID, key.ItemID)
+				if err != nil {
+					return err
+				}
+				if exists {
+					results[i].Error = ErrStokAwalExists.Error()
+					rejected = true
+				}
+			}
+		}
+		if rejected {
+			return ErrBatchRejected
+		}
+
+		// Pecah baris per lot (item ber-lot) dalam urutan tanggal; penerimaan lot / nomor seri di batch
+		// yang sama ikut bisa dipakai baris sesudahnya. Balance sementara 0, dihitung ulang oleh
+		// RecalculateForward per org+item.
+		lineRows := make([][]*models.Inventory, len(reqs))
+		for _, key := range keys {
+			lines := groups[key]
+			sort.SliceStable(lines, func(a, b int) bool {
+				return reqs[lines[a]].TxnDate.Before(reqs[lines[b]].TxnDate)
+			})
+
+			tracked, err := s.itemTracksLots(tx, key.ItemID)
+			if err != nil {
+				return err
+			}
+			var allocator *lotAllocator
+			if tracked {
+				allocator, err = s.newLotAllocator(tx, key.OrganizationID, key.ItemID, reqs[lines[0]].TxnDate)
+				if err != nil {
+					return err
+				}
+			}
+			serialized, err := s.itemSerialized(tx, key.ItemID)
+			if err != nil {
+				return err
+			}
+			var tracker *serialTracker
+			if serialized {
+				tracker, err = s.newSerialTracker(tx, key.OrganizationID, key.ItemID, reqs[lines[0]].TxnDate)
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, i := range lines {
+				req := reqs[i]
+				if req.ChangedBy == "" {
+					req.ChangedBy = changedBy
+				}
+				err := s.ensureLocation(tx, req.OrganizationID, req.LocationID)
+				var parts []lotPart
+				if err == nil {
+					parts, err = s.transactionLots(tx, req, allocator)
+				}
+				if err == nil {
+					req.SerialNumbers, err = s.transactionSerials(tx, req.OrganizationID, req.ItemID, req.TxnDate,
+						req.Amount, req.SerialNumbers, tracker)
+				}
+				if err != nil {
+					if !isLotError(err) && !isSerialError(err) && !isLocationError(err) {
+						return err
+					}
+					results[i].Error = err.Error()
+					rejected = true
+					continue
+				}
+				lineRows[i] = newTransactionInventories(req, parts, decimal.Zero)
+			}
+		}
+		if rejected {
+			return ErrBatchRejected
+		}
+
+		inventories := make([]*models.Inventory, 0, len(reqs))
+		for _, rows := range lineRows {
+			inventories = append(inventories, rows...)
+		}
+		if err := tx.CreateInBatches(inventories, 100).Error; err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			lines := groups[key]
+			earliest := lineRows[lines[0]][0]
+			latestRows := lineRows[lines[len(lines)-1]]
+			latest := latestRows[len(latestRows)-1]
+
+			log.Printf("BATCH: recalculating org=%v item=%d from %v (%d lines)",
+				key.OrganizationID, key.ItemID, earliest.TxnDate, len(lines))
+
+			if err := s.recalculate(tx, key.OrganizationID, key.ItemID, earliest.TxnDate, latest.TxnDate); err != nil {
+				return err
+			}
+			if err := s.createHistory(tx, earliest, "BATCH_CREATE", changedBy, reason); err != nil {
+				return err
+			}
+		}
+
+		ids := make([]uuid.UUID, len(inventories))
+		for i, inv := range inventories {
+			ids[i] = inv.ID
+		}
+
+		var posted []models.Inventory
+		if err := tx.Where("id IN ?", ids).Find(&posted).Error; err != nil {
+			return err
+		}
+		postedByID := make(map[uuid.UUID]*models.Inventory, len(posted))
+		for i := range posted {
+			postedByID[posted[i].ID] = &posted[i]
+		}
+
+		for i, rows := range lineRows {
+			// Baris yang dipecah ke beberapa lot: hasil memakai baris terakhir (saldo akhir posting)
+			id := rows[len(rows)-1].ID
+			balance := postedByID[id].Balance
+			results[i].InventoryID = &id
+			results[i].Balance = &balance
+
+			lineChangedBy := reqs[i].ChangedBy
+			if lineChangedBy == "" {
+				lineChangedBy = changedBy
+			}
+			for _, inv := range rows {
+				event := newInventoryEvent(postedByID[inv.ID], lineChangedBy, reason)
+				if err := emitEvent(tx, EventTransactionCreated, inv.OrganizationID, inv.ItemID, event); err != nil {
+					return err
+				}
+			}
+		}
+
+		log.Printf("BATCH COMPLETE: %d lines (%d rows) across %d org+item pairs", len(reqs), len(inventories), len(keys))
+		return nil
+	})
+
+	return results, err
+}
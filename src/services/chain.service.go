@@ -0,0 +1,324 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+)
+
+// ChainService - Verifikasi & seal hash chain ledger (lihat models/ledger_chain.go)
+type ChainService struct {
+	DB        *gorm.DB
+	Inventory *InventoryService
+}
+
+// ============ CHAIN TYPES ============
+
+// ChainBreak - Link pertama yang rusak di satu chain
+type ChainBreak struct {
+	Seq    int64     `json:"seq"`
+	ID     uuid.UUID `json:"id"`
+	Reason string    `json:"reason"`
+}
+
+// ChainReport - Hasil verifikasi satu chain (tabel + org + item)
+type ChainReport struct {
+	Table          string      `json:"table"`
+	OrganizationID uuid.UUID   `json:"organization_id"`
+	ItemID         uint        `json:"item_id"`
+	Rows           int         `json:"rows"`
+	Unsealed       int         `json:"unsealed"`
+	HeadSeq        int64       `json:"head_seq"`
+	HeadHash       string      `json:"head_hash"`
+	Break          *ChainBreak `json:"break,omitempty"`
+}
+
+// ChainVerification - Ringkasan verifikasi semua chain dalam scope
+type ChainVerification struct {
+	Valid    bool          `json:"valid"`
+	Chains   int           `json:"chains"`
+	Rows     int           `json:"rows"`
+	Unsealed int           `json:"unsealed"`
+	Broken   []ChainReport `json:"broken"`
+}
+
+// chainLink - Kolom chain satu baris + payload kanonik untuk dihitung ulang
+type chainLink struct {
+	ID       uuid.UUID
+	Seq      *int64
+	PrevHash *string
+	RowHash  *string
+	Payload  func() ([]byte, error)
+}
+
+// chainTables - Tabel yang di-chain
+var chainTables = []string{
+	models.Inventory{}.TableName(),
+	models.InventoryHistory{}.TableName(),
+}
+
+// ============ VERIFY ============
+
+// Verify - Telusuri chain per org+item (uuid.Nil / 0 = semua) dan laporkan link pertama yang rusak
+func (s *ChainService) Verify(orgID uuid.UUID, itemID uint) (*ChainVerification, error) {
+	result := &ChainVerification{Valid: true, Broken: []ChainReport{}}
+
+	for _, table := range chainTables {
+		keys, err := s.chainKeys(table, orgID, itemID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			report, err := s.verifyChain(table, key)
+			if err != nil {
+				return nil, err
+			}
+
+			result.Chains++
+			result.Rows += report.Rows
+			result.Unsealed += report.Unsealed
+			if report.Break != nil {
+				result.Valid = false
+				result.Broken = append(result.Broken, *report)
+			}
+		}
+	}
+
+	log.Printf("Chain verify: chains=%d rows=%d unsealed=%d broken=%d",
+		result.Chains, result.Rows, result.Unsealed, len(result.Broken))
+
+	return result, nil
+}
+
+// chainKeys - Daftar org+item yang punya baris di tabel
+func (s *ChainService) chainKeys(table string, orgID uuid.UUID, itemID uint) ([]orgItemKey, error) {
+	query := s.DB.Table(table).Distinct("organization_id", "item_id")
+	if orgID != uuid.Nil {
+		query = query.Where("organization_id = ?", orgID)
+	}
+	if itemID != 0 {
+		query = query.Where("item_id = ?", itemID)
+	}
+
+	var keys []orgItemKey
+	if err := query.Order("organization_id, item_id").Scan(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// verifyChain - Stream baris chain urut chain_seq; baris yang belum di-seal ada di akhir
+func (s *ChainService) verifyChain(table string, key orgItemKey) (*ChainReport, error) {
+	report := &ChainReport{Table: table, OrganizationID: key.OrganizationID, ItemID: key.ItemID}
+
+	rows, err := s.DB.Table(table).
+		Where("organization_id = ? AND item_id = ?", key.OrganizationID, key.ItemID).
+		Order("chain_seq ASC NULLS LAST, created_at ASC").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prevHash := ""
+	for rows.Next() {
+		link, err := s.scanChainLink(rows, table)
+		if err != nil {
+			return nil, err
+		}
+		report.Rows++
+
+		if link.Seq == nil {
+			report.Unsealed++
+			continue
+		}
+		if report.Break != nil {
+			continue
+		}
+
+		if reason := checkChainLink(link, report.HeadSeq+1, prevHash); reason != "" {
+			report.Break = &ChainBreak{Seq: *link.Seq, ID: link.ID, Reason: reason}
+			continue
+		}
+
+		report.HeadSeq = *link.Seq
+		report.HeadHash = *link.RowHash
+		prevHash = *link.RowHash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// deleted_at tidak di-hash, jadi setiap soft delete harus dibuktikan oleh history yang ter-chain
+	if table == (models.Inventory{}).TableName() && report.Break == nil {
+		chainBreak, err := s.checkDeletions(key)
+		if err != nil {
+			return nil, err
+		}
+		report.Break = chainBreak
+	}
+	return report, nil
+}
+
+// checkDeletions - Baris pertama (urut chain_seq) yang status hapusnya tidak cocok dengan history:
+// soft-deleted tanpa DELETE_BEFORE/UPDATE_BEFORE (trigger = baris itu, dibuat sebelum delete) atau
+// ROLLBACK (dibuat setelah delete, snapshot mencakup txn_date baris), atau masih aktif padahal
+// DELETE_BEFORE/UPDATE_BEFORE-nya ada
+func (s *ChainService) checkDeletions(key orgItemKey) (*ChainBreak, error) {
+	var row struct {
+		ID       uuid.UUID
+		ChainSeq *int64
+		Deleted  bool
+	}
+	err := s.DB.Raw(`
+		SELECT i.id, i.chain_seq, i.deleted_at IS NOT NULL AS deleted
+		FROM inventories i
+		WHERE i.organization_id = ? AND i.item_id = ?
+		  AND (
+		    (i.deleted_at IS NOT NULL AND NOT EXISTS (
+		      SELECT 1 FROM inventory_histories h
+		      WHERE h.organization_id = i.organization_id AND h.item_id = i.item_id AND h.chain_seq IS NOT NULL
+		        AND ((h.action IN ('DELETE_BEFORE', 'UPDATE_BEFORE') AND h.trigger_inventory_id = i.id
+		              AND h.created_at <= i.deleted_at)
+		          OR (h.action = 'ROLLBACK' AND h.snapshot_from_date <= i.txn_date
+		              AND h.created_at >= i.deleted_at AND i.deleted_at >= i.created_at))))
+		    OR (i.deleted_at IS NULL AND EXISTS (
+		      SELECT 1 FROM inventory_histories h
+		      WHERE h.trigger_inventory_id = i.id AND h.action IN ('DELETE_BEFORE', 'UPDATE_BEFORE')))
+		  )
+		ORDER BY i.chain_seq ASC NULLS LAST, i.created_at ASC
+		LIMIT 1`, key.OrganizationID, key.ItemID).Scan(&row).Error
+	if err != nil || row.ID == uuid.Nil {
+		return nil, err
+	}
+
+	chainBreak := &ChainBreak{ID: row.ID, Reason: "row is soft-deleted without a matching DELETE_BEFORE, UPDATE_BEFORE or ROLLBACK history"}
+	if !row.Deleted {
+		chainBreak.Reason = "row was deleted by a DELETE_BEFORE or UPDATE_BEFORE history but is active again"
+	}
+	if row.ChainSeq != nil {
+		chainBreak.Seq = *row.ChainSeq
+	}
+	return chainBreak, nil
+}
+
+// scanChainLink - Baca satu baris ke model sesuai tabel
+func (s *ChainService) scanChainLink(rows *sql.Rows, table string) (chainLink, error) {
+	switch table {
+	case models.Inventory{}.TableName():
+		var inv models.Inventory
+		if err := s.DB.ScanRows(rows, &inv); err != nil {
+			return chainLink{}, err
+		}
+		return chainLink{ID: inv.ID, Seq: inv.ChainSeq, PrevHash: inv.PrevHash, RowHash: inv.RowHash, Payload: inv.ChainPayload}, nil
+	default:
+		var history models.InventoryHistory
+		if err := s.DB.ScanRows(rows, &history); err != nil {
+			return chainLink{}, err
+		}
+		return chainLink{ID: history.ID, Seq: history.ChainSeq, PrevHash: history.PrevHash, RowHash: history.RowHash, Payload: history.ChainPayload}, nil
+	}
+}
+
+// checkChainLink - Alasan link rusak, kosong jika valid
+func checkChainLink(link chainLink, expectedSeq int64, prevHash string) string {
+	if *link.Seq != expectedSeq {
+		return fmt.Sprintf("expected chain_seq %d but found %d, a row was removed or renumbered", expectedSeq, *link.Seq)
+	}
+	if expectedSeq == 1 && link.PrevHash != nil {
+		return "first row of chain has a prev_hash"
+	}
+	if models.ChainPrevHash(link.PrevHash) != prevHash {
+		return "prev_hash does not match the previous row's row_hash"
+	}
+	if link.RowHash == nil {
+		return "row_hash is missing"
+	}
+
+	payload, err := link.Payload()
+	if err != nil {
+		return fmt.Sprintf("cannot build canonical payload: %v", err)
+	}
+	if models.ChainHash(prevHash, payload) != *link.RowHash {
+		return "row_hash does not match row content, the row was modified"
+	}
+	return ""
+}
+
+// ============ SEAL ============
+
+// Seal - Sambungkan baris lama (sebelum hash chain ada) ke ujung chain org+item masing-masing
+func (s *ChainService) Seal() (int, error) {
+	sealed := 0
+
+	for _, table := range chainTables {
+		var keys []orgItemKey
+		if err := s.DB.Table(table).
+			Distinct("organization_id", "item_id").
+			Where("chain_seq IS NULL").
+			Scan(&keys).Error; err != nil {
+			return sealed, err
+		}
+
+		for _, key := range keys {
+			var count int
+			err := s.DB.Transaction(func(tx *gorm.DB) error {
+				if err := s.Inventory.lockOrgItems(tx, key); err != nil {
+					return err
+				}
+				var err error
+				count, err = sealChain(tx, table, key)
+				return err
+			})
+			if err != nil {
+				return sealed, err
+			}
+			sealed += count
+		}
+	}
+
+	log.Printf("Chain seal: sealed %d rows", sealed)
+	return sealed, nil
+}
+
+// sealChain - Append baris yang belum di-seal urut created_at
+func sealChain(tx *gorm.DB, table string, key orgItemKey) (int, error) {
+	where := "organization_id = ? AND item_id = ? AND chain_seq IS NULL"
+
+	switch table {
+	case models.Inventory{}.TableName():
+		var inventories []models.Inventory
+		if err := tx.Unscoped().Where(where, key.OrganizationID, key.ItemID).
+			Order("created_at ASC, id ASC").Find(&inventories).Error; err != nil {
+			return 0, err
+		}
+		for i := range inventories {
+			inv := &inventories[i]
+			if err := models.AppendToChain(tx, table, inv.OrganizationID, inv.ItemID, inv.ID,
+				inv.ChainPayload, &inv.ChainSeq, &inv.PrevHash, &inv.RowHash); err != nil {
+				return 0, err
+			}
+		}
+		return len(inventories), nil
+	default:
+		var histories []models.InventoryHistory
+		if err := tx.Where(where, key.OrganizationID, key.ItemID).
+			Order("created_at ASC, id ASC").Find(&histories).Error; err != nil {
+			return 0, err
+		}
+		for i := range histories {
+			h := &histories[i]
+			if err := models.AppendToChain(tx, table, h.OrganizationID, h.ItemID, h.ID,
+				h.ChainPayload, &h.ChainSeq, &h.PrevHash, &h.RowHash); err != nil {
+				return 0, err
+			}
+		}
+		return len(histories), nil
+	}
+}
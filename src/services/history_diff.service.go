@@ -0,0 +1,312 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+)
+
+var (
+	ErrHistoryNotFound     = errors.New("history not found")
+	ErrHistoryNotDiffable  = errors.New("history action has no before/after snapshot to diff")
+	ErrHistoryPairNotFound = errors.New("paired update history not found")
+)
+
+// legacyPairingWindow - Jarak maksimum created_at UPDATE_BEFORE/UPDATE_AFTER lama (sebelum ada paired_history_id)
+const legacyPairingWindow = time.Minute
+
+// ============ HISTORY DIFF TYPES ============
+
+// SnapshotChange - Satu pergerakan yang ada di kedua sisi tapi field-nya berubah
+type SnapshotChange struct {
+	Before       models.SnapshotItem `json:"before"`
+	After        models.SnapshotItem `json:"after"`
+	MatchedBy    string              `json:"matched_by"`
+	Fields       []string            `json:"fields"`
+	AmountDelta  decimal.Decimal     `json:"amount_delta"`
+	BalanceDelta decimal.Decimal     `json:"balance_delta"`
+	TxnDateDelta string              `json:"txn_date_delta"`
+}
+
+// HistoryDiff - Perbandingan snapshot sebelum/sesudah untuk satu perubahan
+type HistoryDiff struct {
+	HistoryID       uuid.UUID             `json:"history_id"`
+	PairedHistoryID *uuid.UUID            `json:"paired_history_id,omitempty"`
+	PairedBy        string                `json:"paired_by,omitempty"`
+	Action          string                `json:"action"`
+	OrganizationID  uuid.UUID             `json:"organization_id"`
+	ItemID          uint                  `json:"item_id"`
+	WindowFrom      time.Time             `json:"window_from"`
+	Added           []models.SnapshotItem `json:"added"`
+	Removed         []models.SnapshotItem `json:"removed"`
+	Changed         []SnapshotChange      `json:"changed"`
+	UnchangedCount  int                   `json:"unchanged_count"`
+}
+
+// ============ HISTORY DIFF ============
+
+// GetHistoryDiff - Diff terstruktur untuk UPDATE_BEFORE/UPDATE_AFTER (dipasangkan) atau ROLLBACK
+func (s *InventoryService) GetHistoryDiff(historyID uuid.UUID) (*HistoryDiff, error) {
+	var history models.InventoryHistory
+	if err := s.DB.First(&history, "id = ?", historyID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHistoryNotFound
+		}
+		return nil, err
+	}
+
+	diff := &HistoryDiff{
+		HistoryID:      history.ID,
+		Action:         history.Action,
+		OrganizationID: history.OrganizationID,
+		ItemID:         history.ItemID,
+	}
+
+	var before, after *models.InventoryHistory
+	var beforeData, afterData json.RawMessage
+
+	switch history.Action {
+	case "UPDATE_BEFORE", "UPDATE_AFTER":
+		paired, pairedBy, err := s.findPairedHistory(&history)
+		if err != nil {
+			return nil, err
+		}
+		diff.PairedHistoryID = &paired.ID
+		diff.PairedBy = pairedBy
+
+		before, after = &history, paired
+		if history.Action == "UPDATE_AFTER" {
+			before, after = paired, &history
+		}
+		beforeData, afterData = before.DataBefore, after.DataAfter
+	case "ROLLBACK":
+		before, after = &history, &history
+		beforeData, afterData = history.DataBefore, history.DataAfter
+	default:
+		return nil, ErrHistoryNotDiffable
+	}
+
+	beforeItems, err := decodeSnapshot(beforeData)
+	if err != nil {
+		return nil, err
+	}
+	afterItems, err := decodeSnapshot(afterData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Snapshot before/after bisa mulai dari tanggal berbeda (tanggal transaksi diubah);
+	// bandingkan hanya rentang yang tercakup keduanya, kecuali baris trigger itu sendiri
+	diff.WindowFrom = before.SnapshotFromDate
+	if after.SnapshotFromDate.After(diff.WindowFrom) {
+		diff.WindowFrom = after.SnapshotFromDate
+	}
+	beforeItems = snapshotWindow(beforeItems, diff.WindowFrom, before.TriggerInventoryID)
+	afterItems = snapshotWindow(afterItems, diff.WindowFrom, after.TriggerInventoryID)
+
+	var beforeTrigger, afterTrigger *uuid.UUID
+	if history.Action != "ROLLBACK" {
+		beforeTrigger, afterTrigger = before.TriggerInventoryID, after.TriggerInventoryID
+	}
+
+	diff.Added, diff.Removed, diff.Changed, diff.UnchangedCount =
+		diffSnapshots(beforeItems, afterItems, beforeTrigger, afterTrigger)
+
+	log.Printf("History diff %v: added=%d removed=%d changed=%d unchanged=%d",
+		historyID, len(diff.Added), len(diff.Removed), len(diff.Changed), diff.UnchangedCount)
+
+	return diff, nil
+}
+
+// findPairedHistory - Pasangan UPDATE_BEFORE/UPDATE_AFTER lewat paired_history_id,
+// fallback ke history lama: org+item+changed_by sama dan created_at terdekat
+func (s *InventoryService) findPairedHistory(history *models.InventoryHistory) (*models.InventoryHistory, string, error) {
+	var paired models.InventoryHistory
+
+	if history.PairedHistoryID != nil {
+		if err := s.DB.First(&paired, "id = ?", *history.PairedHistoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, "", ErrHistoryPairNotFound
+			}
+			return nil, "", err
+		}
+		return &paired, "paired_history_id", nil
+	}
+
+	query := s.DB.Where("organization_id = ? AND item_id = ? AND changed_by = ? AND paired_history_id IS NULL",
+		history.OrganizationID, history.ItemID, history.ChangedBy)
+	if history.Action == "UPDATE_BEFORE" {
+		query = query.Where("action = ? AND created_at >= ? AND created_at <= ?",
+			"UPDATE_AFTER", history.CreatedAt, history.CreatedAt.Add(legacyPairingWindow)).
+			Order("created_at ASC")
+	} else {
+		query = query.Where("action = ? AND created_at <= ? AND created_at >= ?",
+			"UPDATE_BEFORE", history.CreatedAt, history.CreatedAt.Add(-legacyPairingWindow)).
+			Order("created_at DESC")
+	}
+
+	if err := query.Take(&paired).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrHistoryPairNotFound
+		}
+		return nil, "", err
+	}
+	return &paired, "heuristic", nil
+}
+
+// ============ DIFF HELPERS ============
+
+// decodeSnapshot - JSON snapshot ke slice (null/kosong = tanpa baris)
+func decodeSnapshot(data json.RawMessage) ([]models.SnapshotItem, error) {
+	items := []models.SnapshotItem{}
+	if len(data) == 0 || string(data) == "null" {
+		return items, nil
+	}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// snapshotWindow - Baris dengan txn_date >= from, ditambah baris trigger walau di luar rentang
+func snapshotWindow(items []models.SnapshotItem, from time.Time, triggerID *uuid.UUID) []models.SnapshotItem {
+	windowed := make([]models.SnapshotItem, 0, len(items))
+	for _, item := range items {
+		if !item.TxnDate.Before(from) || (triggerID != nil && item.InventoryID == *triggerID) {
+			windowed = append(windowed, item)
+		}
+	}
+	return windowed
+}
+
+// diffSnapshots - Cocokkan baris before/after: inventory_id, lalu pasangan trigger
+// (baris lama -> baris pengganti), lalu ref_id + type yang unik di kedua sisi
+func diffSnapshots(before, after []models.SnapshotItem, beforeTrigger, afterTrigger *uuid.UUID) (added, removed []models.SnapshotItem, changed []SnapshotChange, unchanged int) {
+	added = []models.SnapshotItem{}
+	removed = []models.SnapshotItem{}
+	changed = []SnapshotChange{}
+
+	matchedBefore := make([]bool, len(before))
+	matchedAfter := make([]bool, len(after))
+
+	match := func(i, j int, matchedBy string) {
+		matchedBefore[i], matchedAfter[j] = true, true
+		if change, ok := compareSnapshotItems(before[i], after[j], matchedBy); ok {
+			changed = append(changed, change)
+		} else {
+			unchanged++
+		}
+	}
+
+	afterByID := make(map[uuid.UUID]int, len(after))
+	for j, item := range after {
+		afterByID[item.InventoryID] = j
+	}
+	for i, item := range before {
+		if j, ok := afterByID[item.InventoryID]; ok {
+			match(i, j, "inventory_id")
+		}
+	}
+
+	if beforeTrigger != nil && afterTrigger != nil {
+		i, j := indexOfInventory(before, *beforeTrigger), indexOfInventory(after, *afterTrigger)
+		if i >= 0 && j >= 0 && !matchedBefore[i] && !matchedAfter[j] {
+			match(i, j, "trigger")
+		}
+	}
+
+	refKey := func(item models.SnapshotItem) string {
+		return item.Type + "|" + *item.RefID
+	}
+	uniqueRefs := func(items []models.SnapshotItem, matched []bool) map[string]int {
+		counts := make(map[string]int)
+		index := make(map[string]int)
+		for k, item := range items {
+			if matched[k] || item.RefID == nil {
+				continue
+			}
+			counts[refKey(item)]++
+			index[refKey(item)] = k
+		}
+		for key, count := range counts {
+			if count > 1 {
+				delete(index, key)
+			}
+		}
+		return index
+	}
+	beforeRefs, afterRefs := uniqueRefs(before, matchedBefore), uniqueRefs(after, matchedAfter)
+	for i, item := range before {
+		if matchedBefore[i] || item.RefID == nil {
+			continue
+		}
+		bi, okBefore := beforeRefs[refKey(item)]
+		j, okAfter := afterRefs[refKey(item)]
+		if okBefore && okAfter && bi == i {
+			match(i, j, "ref_id")
+		}
+	}
+
+	// Urut kronologis sesuai snapshot, bukan urutan pencocokan
+	sort.SliceStable(changed, func(a, b int) bool {
+		return changed[a].Before.TxnDate.Before(changed[b].Before.TxnDate)
+	})
+
+	for i, item := range before {
+		if !matchedBefore[i] {
+			removed = append(removed, item)
+		}
+	}
+	for j, item := range after {
+		if !matchedAfter[j] {
+			added = append(added, item)
+		}
+	}
+	return added, removed, changed, unchanged
+}
+
+// compareSnapshotItems - Field yang berubah antara dua baris yang sudah dicocokkan
+func compareSnapshotItems(before, after models.SnapshotItem, matchedBy string) (SnapshotChange, bool) {
+	change := SnapshotChange{
+		Before:       before,
+		After:        after,
+		MatchedBy:    matchedBy,
+		Fields:       []string{},
+		AmountDelta:  after.Amount.Sub(before.Amount),
+		BalanceDelta: after.Balance.Sub(before.Balance),
+	}
+
+	if !change.AmountDelta.IsZero() {
+		change.Fields = append(change.Fields, "amount")
+	}
+	if !change.BalanceDelta.IsZero() {
+		change.Fields = append(change.Fields, "balance")
+	}
+	if !after.TxnDate.Equal(before.TxnDate) {
+		change.Fields = append(change.Fields, "txn_date")
+		change.TxnDateDelta = after.TxnDate.Sub(before.TxnDate).String()
+	}
+	if after.Type != before.Type {
+		change.Fields = append(change.Fields, "type")
+	}
+
+	return change, len(change.Fields) > 0
+}
+
+// indexOfInventory - Posisi baris dengan inventory_id tertentu di snapshot, -1 jika tidak ada
+func indexOfInventory(items []models.SnapshotItem, inventoryID uuid.UUID) int {
+	for i, item := range items {
+		if item.InventoryID == inventoryID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,526 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+)
+
+var (
+	ErrImportUnsupportedFormat = errors.New("unsupported import file format, use .csv or .xlsx")
+	ErrImportMissingColumns    = errors.New("import file must have columns: organization_code, item_code, txn_date, amount, type")
+	ErrImportEmpty             = errors.New("import file has no data rows")
+	ErrImportInvalid           = errors.New("import validation failed, no rows were posted")
+)
+
+// importColumns - Kolom wajib di header file import
+var importColumns = []string{"organization_code", "item_code", "txn_date", "amount", "type"}
+
+// ============ IMPORT TYPES ============
+
+// ImportRow - Satu baris mentah dari file CSV/XLSX
+type ImportRow struct {
+	Line             int
+	OrganizationCode string
+	ItemCode         string
+	TxnDate          string
+	Amount           string
+	Unit             string // kosong = satuan dasar item
+	Type             string
+	UnitCost         string
+	LotNumber        string
+	ExpiryDate       string
+	SerialNumbers    string // dipisah ';'
+	LocationCode     string // kosong = belum ditempatkan
+	RefID            string
+	Notes            string
+}
+
+// ImportRowResult - Hasil validasi/posting per baris
+type ImportRowResult struct {
+	Line             int              `json:"line"`
+	OrganizationCode string           `json:"organization_code"`
+	ItemCode         string           `json:"item_code"`
+	Type             string           `json:"type"`
+	Amount           decimal.Decimal  `json:"amount"` // satuan dasar item
+	TxnDate          *time.Time       `json:"txn_date,omitempty"`
+	Errors           []string         `json:"errors,omitempty"`
+	InventoryID      *uuid.UUID       `json:"inventory_id,omitempty"`
+	Balance          *decimal.Decimal `json:"balance,omitempty"`
+}
+
+// ImportReport - Ringkasan import (dry-run atau posting)
+type ImportReport struct {
+	DryRun      bool              `json:"dry_run"`
+	TotalRows   int               `json:"total_rows"`
+	ValidRows   int               `json:"valid_rows"`
+	InvalidRows int               `json:"invalid_rows"`
+	PostedRows  int               `json:"posted_rows"`
+	Rows        []ImportRowResult `json:"rows"`
+}
+
+// ============ IMPORT SERVICE ============
+type ImportService struct {
+	DB        *gorm.DB
+	Inventory *InventoryService
+}
+
+// ParseImportFile - Parse CSV/XLSX berdasarkan ekstensi file
+func ParseImportFile(filename string, r io.Reader) ([]ImportRow, error) {
+	var records [][]string
+	var err error
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		reader := csv.NewReader(r)
+		reader.TrimLeadingSpace = true
+		reader.FieldsPerRecord = -1
+		records, err = reader.ReadAll()
+	case ".xlsx":
+		records, err = readXLSXRecords(r)
+	default:
+		return nil, ErrImportUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseImportRecords(records)
+}
+
+// readXLSXRecords - Baca sheet pertama XLSX sebagai raw value (tanggal tetap serial Excel)
+func readXLSXRecords(r io.Reader) ([][]string, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, ErrImportEmpty
+	}
+
+	return f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+}
+
+// parseImportRecords - Mapping kolom berdasarkan header (case-insensitive)
+func parseImportRecords(records [][]string) ([]ImportRow, error) {
+	if len(records) == 0 {
+		return nil, ErrImportEmpty
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, ErrImportMissingColumns
+		}
+	}
+
+	cell := func(record []string, name string) string {
+		idx, ok := columns[name]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	rows := make([]ImportRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		row := ImportRow{
+			Line:             i + 2, // baris 1 = header
+			OrganizationCode: cell(record, "organization_code"),
+			ItemCode:         cell(record, "item_code"),
+			TxnDate:          cell(record, "txn_date"),
+			Amount:           cell(record, "amount"),
+			Unit:             cell(record, "unit"),
+			Type:             strings.ToLower(cell(record, "type")),
+			UnitCost:         cell(record, "unit_cost"),
+			LotNumber:        cell(record, "lot_number"),
+			ExpiryDate:       cell(record, "expiry_date"),
+			SerialNumbers:    cell(record, "serial_numbers"),
+			LocationCode:     cell(record, "location_code"),
+			RefID:            cell(record, "ref_id"),
+			Notes:            cell(record, "notes"),
+		}
+
+		// Lewati baris kosong (umum di akhir file XLSX)
+		if row.OrganizationCode == "" && row.ItemCode == "" && row.Amount == "" && row.TxnDate == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrImportEmpty
+	}
+	return rows, nil
+}
+
+// Import - Validasi semua baris, lalu (jika bukan dry-run) posting sebagai satu batch
+func (s *ImportService) Import(rows []ImportRow, changedBy string, reason *string, dryRun bool) (*ImportReport, error) {
+	report, reqs, err := s.validate(rows, changedBy)
+	if err != nil {
+		return nil, err
+	}
+	report.DryRun = dryRun
+
+	if report.InvalidRows > 0 {
+		return report, ErrImportInvalid
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	results, err := s.Inventory.postTransactionBatch(reqs, changedBy, reason)
+	for i, result := range results {
+		if result.Error != "" {
+			report.Rows[i].Errors = append(report.Rows[i].Errors, result.Error)
+		}
+		report.Rows[i].InventoryID = result.InventoryID
+		report.Rows[i].Balance = result.Balance
+	}
+	if err != nil {
+		return report, err
+	}
+
+	report.PostedRows = len(results)
+	log.Printf("IMPORT COMPLETE: %d rows posted by %s", report.PostedRows, changedBy)
+	return report, nil
+}
+
+// validate - Resolve kode org/item dan cek aturan posting per baris
+func (s *ImportService) validate(rows []ImportRow, changedBy string) (*ImportReport, []CreateTransactionRequest, error) {
+	orgs, err := s.resolveOrganizations(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	items, err := s.resolveItems(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	locations, err := s.resolveLocations(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &ImportReport{
+		TotalRows: len(rows),
+		Rows:      make([]ImportRowResult, len(rows)),
+	}
+	reqs := make([]CreateTransactionRequest, len(rows))
+	firstStockLines := make(map[orgItemKey]int)
+
+	for i, row := range rows {
+		result := ImportRowResult{
+			Line:             row.Line,
+			OrganizationCode: row.OrganizationCode,
+			ItemCode:         row.ItemCode,
+			Type:             row.Type,
+		}
+
+		org, orgFound := orgs[row.OrganizationCode]
+		if !orgFound {
+			result.Errors = append(result.Errors, fmt.Sprintf("unknown organization code %q", row.OrganizationCode))
+		} else if !org.IsActive {
+			result.Errors = append(result.Errors, fmt.Sprintf("organization %q is inactive", row.OrganizationCode))
+		}
+		item, itemFound := items[row.ItemCode]
+		if !itemFound {
+			result.Errors = append(result.Errors, fmt.Sprintf("unknown item code %q", row.ItemCode))
+		} else if !item.IsActive {
+			result.Errors = append(result.Errors, fmt.Sprintf("item %q is inactive", row.ItemCode))
+		}
+		orgID, itemID := org.ID, item.ID
+
+		values, errs := parseImportValues(row)
+		result.Errors = append(result.Errors, errs...)
+		result.TxnDate = values.TxnDate
+
+		amount := values.Amount
+		if itemFound && amount.IsPositive() {
+			factor, err := s.Inventory.unitFactor(s.DB, itemID, row.Unit)
+			if err != nil && !isUnitError(err) {
+				return nil, nil, err
+			}
+			if err == nil {
+				amount = amount.Mul(factor)
+				if !validQuantity(amount) {
+					err = ErrInvalidQuantity
+				}
+			}
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s (unit %q)", err.Error(), row.Unit))
+			}
+		}
+		result.Amount = amount
+
+		var lotNumber *string
+		if row.LotNumber != "" {
+			lotNumber = &rows[i].LotNumber
+		}
+		if itemFound {
+			switch {
+			case item.TrackLots && lotNumber == nil:
+				result.Errors = append(result.Errors, ErrLotRequired.Error())
+			case !item.TrackLots && (lotNumber != nil || row.ExpiryDate != ""):
+				result.Errors = append(result.Errors, ErrLotNotTracked.Error())
+			}
+		}
+
+		serials := values.SerialNumbers
+		if itemFound {
+			switch {
+			case item.Serialized && !amount.Equal(decimal.NewFromInt(int64(len(serials)))):
+				result.Errors = append(result.Errors, ErrSerialCountMismatch.Error())
+			case !item.Serialized && len(serials) > 0:
+				result.Errors = append(result.Errors, ErrSerialNotTracked.Error())
+			}
+		}
+
+		var locationID *uuid.UUID
+		if row.LocationCode != "" && orgFound {
+			location, ok := locations[orgLocationKey{orgID, row.LocationCode}]
+			switch {
+			case !ok:
+				result.Errors = append(result.Errors, fmt.Sprintf("unknown location code %q", row.LocationCode))
+			case !location.IsActive:
+				result.Errors = append(result.Errors, fmt.Sprintf("location %q is inactive", row.LocationCode))
+			default:
+				locationID = &location.ID
+			}
+		}
+
+		// Tutup buku juga dicek saat dry-run supaya baris di periode tertutup ketahuan lebih awal
+		if orgFound && values.TxnDate != nil {
+			if err := s.Inventory.checkPeriodOpen(s.DB, orgID, *values.TxnDate); err != nil {
+				if !errors.Is(err, ErrPeriodClosed) {
+					return nil, nil, err
+				}
+				result.Errors = append(result.Errors, err.Error())
+			}
+		}
+
+		if orgFound && itemFound && row.Type == "stok_awal" {
+			key := orgItemKey{orgID, itemID}
+			if line, ok := firstStockLines[key]; ok {
+				result.Errors = append(result.Errors, fmt.Sprintf("duplicate stok_awal, already on line %d", line))
+			} else {
+				firstStockLines[key] = row.Line
+				exists, err := s.Inventory.checkFirstStockExists(s.DB, orgID, itemID)
+				if err != nil {
+					return nil, nil, err
+				}
+				if exists {
+					result.Errors = append(result.Errors, ErrStokAwalExists.Error())
+				}
+			}
+		}
+
+		if len(result.Errors) > 0 {
+			report.InvalidRows++
+		} else {
+			report.ValidRows++
+		}
+		report.Rows[i] = result
+
+		var notes *string
+		if row.Notes != "" {
+			notes = &rows[i].Notes
+		}
+		var txnDate time.Time
+		if values.TxnDate != nil {
+			txnDate = *values.TxnDate
+		}
+		reqs[i] = CreateTransactionRequest{
+			OrganizationID: orgID,
+			ItemID:         itemID,
+			TxnDate:        txnDate,
+			Amount:         amount,
+			Type:           row.Type,
+			UnitCost:       values.UnitCost,
+			LotNumber:      lotNumber,
+			ExpiryDate:     values.ExpiryDate,
+			SerialNumbers:  serials,
+			LocationID:     locationID,
+			ChangedBy:      changedBy,
+			RefID:          values.RefID,
+			Notes:          notes,
+		}
+	}
+
+	return report, reqs, nil
+}
+
+// importValues - Kolom baris import yang sudah di-parse (nil = kosong atau tidak valid)
+type importValues struct {
+	Amount        decimal.Decimal // satuan file, belum dikali faktor unit
+	TxnDate       *time.Time
+	UnitCost      *decimal.Decimal
+	ExpiryDate    *time.Time
+	SerialNumbers []string
+	RefID         *uuid.UUID
+}
+
+// parseImportValues - Parse kolom yang tidak butuh database; semua error baris dikumpulkan
+func parseImportValues(row ImportRow) (importValues, []string) {
+	var values importValues
+	var errs []string
+
+	if row.Type != "stok_awal" && row.Type != "penerimaan" {
+		errs = append(errs, "type must be stok_awal or penerimaan")
+	}
+
+	amount, err := decimal.NewFromString(row.Amount)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("invalid amount %q", row.Amount))
+	} else if !amount.IsPositive() {
+		errs = append(errs, "amount must be positive")
+	} else {
+		values.Amount = amount
+	}
+
+	txnDate, err := parseImportDate(row.TxnDate)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("invalid txn_date %q", row.TxnDate))
+	} else {
+		values.TxnDate = &txnDate
+	}
+
+	if row.UnitCost != "" {
+		parsed, err := decimal.NewFromString(row.UnitCost)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid unit_cost %q", row.UnitCost))
+		} else if parsed.IsNegative() {
+			errs = append(errs, ErrInvalidUnitCost.Error())
+		} else {
+			values.UnitCost = &parsed
+		}
+	}
+
+	if row.ExpiryDate != "" {
+		parsed, err := parseImportDate(row.ExpiryDate)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid expiry_date %q", row.ExpiryDate))
+		} else {
+			values.ExpiryDate = &parsed
+		}
+	}
+
+	if row.SerialNumbers != "" {
+		values.SerialNumbers = strings.Split(row.SerialNumbers, ";")
+		if _, err := normalizeSerials(values.SerialNumbers); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if row.RefID != "" {
+		parsed, err := uuid.Parse(row.RefID)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid ref_id %q", row.RefID))
+		} else {
+			values.RefID = &parsed
+		}
+	}
+
+	return values, errs
+}
+
+// resolveOrganizations - Map kode organisasi → organisasi (satu query)
+func (s *ImportService) resolveOrganizations(rows []ImportRow) (map[string]models.Organization, error) {
+	codes := make([]string, 0, len(rows))
+	for _, row := range rows {
+		codes = append(codes, row.OrganizationCode)
+	}
+
+	var orgs []models.Organization
+	if err := s.DB.Where("code IN ?", codes).Find(&orgs).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]models.Organization, len(orgs))
+	for _, org := range orgs {
+		result[org.Code] = org
+	}
+	return result, nil
+}
+
+// resolveItems - Map kode item → item (satu query)
+func (s *ImportService) resolveItems(rows []ImportRow) (map[string]models.Item, error) {
+	codes := make([]string, 0, len(rows))
+	for _, row := range rows {
+		codes = append(codes, row.ItemCode)
+	}
+
+	var items []models.Item
+	if err := s.DB.Where("code IN ?", codes).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]models.Item, len(items))
+	for _, item := range items {
+		result[item.Code] = item
+	}
+	return result, nil
+}
+
+// orgLocationKey - Kode lokasi unik per organisasi
+type orgLocationKey struct {
+	OrganizationID uuid.UUID
+	Code           string
+}
+
+// resolveLocations - Map org+kode lokasi → lokasi (satu query)
+func (s *ImportService) resolveLocations(rows []ImportRow) (map[orgLocationKey]models.Location, error) {
+	codes := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.LocationCode != "" {
+			codes = append(codes, row.LocationCode)
+		}
+	}
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	var locations []models.Location
+	if err := s.DB.Where("code IN ?", codes).Find(&locations).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[orgLocationKey]models.Location, len(locations))
+	for _, location := range locations {
+		result[orgLocationKey{location.OrganizationID, location.Code}] = location
+	}
+	return result, nil
+}
+
+// parseImportDate - RFC3339, YYYY-MM-DDTHH:MM:SS, YYYY-MM-DD, atau serial tanggal Excel
+func parseImportDate(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 0 {
+		return excelize.ExcelDateToTime(serial, false)
+	}
+
+	return time.Time{}, errors.New("invalid date")
+}
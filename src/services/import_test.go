@@ -0,0 +1,210 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+)
+
+// ============ PARSE IMPORT FILE ============
+
+func TestParseImportFileCSV(t *testing.T) {
+	// Header beda urutan & huruf besar, kolom opsional, baris pendek dan baris kosong
+	csv := "Item_Code, ORGANIZATION_CODE ,txn_date,amount,Type,notes,unit\n" +
+		"ITEM001,ORG001,2025-01-31,10,STOK_AWAL,  gudang utama ,box\n" +
+		",,,,\n" +
+		"ITEM002,ORG001,2025-02-01,5\n"
+
+	rows, err := ParseImportFile("stok.CSV", strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows (blank row skipped), got %d", len(rows))
+	}
+
+	first := rows[0]
+	if first.Line != 2 || first.OrganizationCode != "ORG001" || first.ItemCode != "ITEM001" ||
+		first.TxnDate != "2025-01-31" || first.Amount != "10" || first.Type != "stok_awal" ||
+		first.Notes != "gudang utama" || first.Unit != "box" {
+		t.Errorf("unexpected first row: %+v", first)
+	}
+
+	// Baris pendek: kolom yang tidak ada jadi kosong, nomor baris tetap menghitung baris kosong
+	short := rows[1]
+	if short.Line != 4 || short.ItemCode != "ITEM002" || short.Amount != "5" || short.Type != "" || short.Unit != "" {
+		t.Errorf("unexpected short row: %+v", short)
+	}
+}
+
+func TestParseImportFileXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+	records := [][]interface{}{
+		{"organization_code", "item_code", "TXN_DATE", "amount", "type", "lot_number"},
+		{"ORG001", "ITEM001", 45658, 12.5, "penerimaan", "LOT-1"},
+		{},
+		{"ORG001", "ITEM002", "2025-01-02T08:30:00", "3"},
+	}
+	for i, record := range records {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.SetSheetRow(sheet, cell, &record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ParseImportFile("stok.xlsx", bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("parse xlsx: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+
+	// Tanggal tetap serial Excel mentah, baru dikonversi parseImportDate
+	first := rows[0]
+	if first.Line != 2 || first.TxnDate != "45658" || first.Amount != "12.5" || first.LotNumber != "LOT-1" {
+		t.Errorf("unexpected first row: %+v", first)
+	}
+	if rows[1].Line != 4 || rows[1].TxnDate != "2025-01-02T08:30:00" || rows[1].Type != "" {
+		t.Errorf("unexpected short row: %+v", rows[1])
+	}
+}
+
+func TestParseImportFileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		want     error
+	}{
+		{"unsupported extension", "stok.xls", "organization_code", ErrImportUnsupportedFormat},
+		{"empty file", "stok.csv", "", ErrImportEmpty},
+		{"header only", "stok.csv", "organization_code,item_code,txn_date,amount,type\n", ErrImportEmpty},
+		{"only blank rows", "stok.csv", "organization_code,item_code,txn_date,amount,type\n,,,,\n , , , ,\n", ErrImportEmpty},
+		{"missing column", "stok.csv", "organization_code,item_code,txn_date,type\nORG001,ITEM001,2025-01-01,stok_awal\n", ErrImportMissingColumns},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseImportFile(tt.filename, strings.NewReader(tt.content))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// ============ PARSE IMPORT DATE ============
+
+func TestParseImportDate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		invalid bool
+	}{
+		{value: "2025-01-31", want: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{value: "2025-01-31T08:15:00", want: time.Date(2025, 1, 31, 8, 15, 0, 0, time.UTC)},
+		{value: "2025-01-31 08:15:00", want: time.Date(2025, 1, 31, 8, 15, 0, 0, time.UTC)},
+		{value: "2025-01-31T08:15:00+07:00", want: time.Date(2025, 1, 31, 1, 15, 0, 0, time.UTC)},
+		// Serial tanggal Excel (sistem 1900), pecahan = jam
+		{value: "45658", want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "45658.5", want: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)},
+		// dd/mm/yyyy ambigu dengan mm/dd/yyyy, jadi ditolak
+		{value: "31/01/2025", invalid: true},
+		{value: "01/02/2025", invalid: true},
+		{value: "0", invalid: true},
+		{value: "-45658", invalid: true},
+		{value: "", invalid: true},
+		{value: "kemarin", invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseImportDate(tt.value)
+			if tt.invalid {
+				if err == nil {
+					t.Errorf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// ============ PARSE IMPORT VALUES ============
+
+func TestParseImportValues(t *testing.T) {
+	valid := ImportRow{Line: 2, TxnDate: "2025-01-31", Amount: "10.5", Type: "penerimaan"}
+
+	tests := []struct {
+		name   string
+		modify func(row *ImportRow)
+		errors []string
+	}{
+		{name: "valid row", modify: func(row *ImportRow) {}},
+		{name: "bad amount", modify: func(row *ImportRow) { row.Amount = "10,5" }, errors: []string{`invalid amount "10,5"`}},
+		{name: "zero amount", modify: func(row *ImportRow) { row.Amount = "0" }, errors: []string{"amount must be positive"}},
+		{name: "bad type", modify: func(row *ImportRow) { row.Type = "pemakaian" }, errors: []string{"type must be stok_awal or penerimaan"}},
+		{name: "bad txn_date", modify: func(row *ImportRow) { row.TxnDate = "31/01/2025" }, errors: []string{`invalid txn_date "31/01/2025"`}},
+		{name: "negative unit_cost", modify: func(row *ImportRow) { row.UnitCost = "-1" }, errors: []string{ErrInvalidUnitCost.Error()}},
+		{name: "bad unit_cost", modify: func(row *ImportRow) { row.UnitCost = "abc" }, errors: []string{`invalid unit_cost "abc"`}},
+		{name: "bad expiry_date", modify: func(row *ImportRow) { row.ExpiryDate = "besok" }, errors: []string{`invalid expiry_date "besok"`}},
+		{name: "duplicate serial", modify: func(row *ImportRow) { row.SerialNumbers = "SN1;SN1" }, errors: []string{ErrDuplicateSerial.Error()}},
+		{name: "bad ref_id", modify: func(row *ImportRow) { row.RefID = "REF-1" }, errors: []string{`invalid ref_id "REF-1"`}},
+		{
+			// Semua error baris dilaporkan sekaligus, tidak berhenti di error pertama
+			name: "multiple errors",
+			modify: func(row *ImportRow) {
+				row.Type, row.Amount, row.TxnDate = "", "x", ""
+			},
+			errors: []string{"type must be stok_awal or penerimaan", `invalid amount "x"`, `invalid txn_date ""`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := valid
+			tt.modify(&row)
+			_, errs := parseImportValues(row)
+			if strings.Join(errs, "|") != strings.Join(tt.errors, "|") {
+				t.Errorf("expected errors %q, got %q", tt.errors, errs)
+			}
+		})
+	}
+
+	row := valid
+	row.UnitCost, row.ExpiryDate, row.SerialNumbers = "1250.50", "45688", "SN2;SN1"
+	values, errs := parseImportValues(row)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if !values.Amount.Equal(decimal.RequireFromString("10.5")) || !values.UnitCost.Equal(decimal.RequireFromString("1250.5")) {
+		t.Errorf("unexpected amounts: %+v", values)
+	}
+	if !values.TxnDate.Equal(time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)) ||
+		!values.ExpiryDate.Equal(time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected dates: %v %v", values.TxnDate, values.ExpiryDate)
+	}
+	if strings.Join(values.SerialNumbers, ";") != "SN2;SN1" {
+		t.Errorf("unexpected serial numbers: %v", values.SerialNumbers)
+	}
+}
@@ -0,0 +1,379 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+)
+
+// Jenis temuan integrity check
+const (
+	IssueBalanceChainBreak = "balance_chain_break"
+	IssueOpnameMismatch    = "opname_mismatch"
+	IssueOrphanMutationLeg = "orphan_mutation_leg"
+	IssueDuplicateStokAwal = "duplicate_stok_awal"
+	IssueNegativeBalance   = "negative_balance"
+	IssueOrphanReference   = "orphan_reference"
+)
+
+// IntegrityService - Scan ledger per org+item dan (opsional) perbaiki saldo yang rusak
+type IntegrityService struct {
+	DB        *gorm.DB
+	Inventory *InventoryService
+}
+
+// ============ INTEGRITY TYPES ============
+
+// IntegrityIssue - Satu temuan pada satu baris ledger
+type IntegrityIssue struct {
+	Kind           string           `json:"kind"`
+	OrganizationID uuid.UUID        `json:"organization_id"`
+	ItemID         uint             `json:"item_id"`
+	InventoryID    uuid.UUID        `json:"inventory_id"`
+	TxnDate        time.Time        `json:"txn_date"`
+	Expected       *decimal.Decimal `json:"expected,omitempty"`
+	Actual         *decimal.Decimal `json:"actual,omitempty"`
+	Message        string           `json:"message"`
+	Repairable     bool             `json:"repairable"`
+}
+
+// IntegrityRepair - Sequence yang di-recalculate oleh --repair (Reason: alasan repair ditolak)
+type IntegrityRepair struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+	ItemID         uint      `json:"item_id"`
+	FromDate       time.Time `json:"from_date"`
+	Reason         string    `json:"reason,omitempty"`
+}
+
+// IntegrityReport - Hasil scan (dan repair jika diminta)
+type IntegrityReport struct {
+	Sequences  int               `json:"sequences"`
+	Rows       int               `json:"rows"`
+	Counts     map[string]int    `json:"counts"`
+	Issues     []IntegrityIssue  `json:"issues"`
+	Repaired   []IntegrityRepair `json:"repaired"`
+	Rejected   []IntegrityRepair `json:"rejected"`
+	Unresolved int               `json:"unresolved"`
+}
+
+// integritySequence - State scan satu org+item
+type integritySequence struct {
+	key         orgItemKey
+	balance     decimal.Decimal
+	stokAwal    int
+	broken      bool
+	negative    bool
+	repairFrom  time.Time
+	needsRepair bool
+}
+
+// ============ CHECK ============
+
+// Check - Scan semua sequence org+item (uuid.Nil / 0 = semua). repair=true menjalankan
+// RecalculateForward dari baris rusak pertama untuk sequence dengan saldo/opname yang salah.
+func (s *IntegrityService) Check(orgID uuid.UUID, itemID uint, repair bool) (*IntegrityReport, error) {
+	report := &IntegrityReport{
+		Counts:   map[string]int{},
+		Issues:   []IntegrityIssue{},
+		Repaired: []IntegrityRepair{},
+		Rejected: []IntegrityRepair{},
+	}
+
+	sequences, err := s.scanSequences(orgID, itemID, report)
+	if err != nil {
+		return nil, err
+	}
+	report.Sequences = len(sequences)
+
+	if err := s.checkMutationLegs(orgID, itemID, report); err != nil {
+		return nil, err
+	}
+	if err := s.checkOrphanReferences(orgID, itemID, report); err != nil {
+		return nil, err
+	}
+
+	repaired := make(map[orgItemKey]bool)
+	if repair {
+		for _, seq := range sequences {
+			if !seq.needsRepair {
+				continue
+			}
+			result := IntegrityRepair{
+				OrganizationID: seq.key.OrganizationID,
+				ItemID:         seq.key.ItemID,
+				FromDate:       seq.repairFrom,
+			}
+			if err := s.repairSequence(seq); err != nil {
+				if !isRepairRejection(err) {
+					return report, err
+				}
+				// Ditolak chokepoint (periode tertutup, policy stok, lot/lokasi/seri): sequence tetap unresolved
+				result.Reason = err.Error()
+				report.Rejected = append(report.Rejected, result)
+				continue
+			}
+			repaired[seq.key] = true
+			report.Repaired = append(report.Repaired, result)
+		}
+	}
+
+	for _, issue := range report.Issues {
+		if !(issue.Repairable && repaired[orgItemKey{issue.OrganizationID, issue.ItemID}]) {
+			report.Unresolved++
+		}
+	}
+
+	log.Printf("Integrity check: sequences=%d rows=%d issues=%d repaired=%d rejected=%d unresolved=%d",
+		report.Sequences, report.Rows, len(report.Issues), len(report.Repaired), len(report.Rejected), report.Unresolved)
+
+	return report, nil
+}
+
+// scanSequences - Stream baris aktif urut org, item, txn_date, created_at, id (urutan RecalculateForward)
+func (s *IntegrityService) scanSequences(orgID uuid.UUID, itemID uint, report *IntegrityReport) ([]*integritySequence, error) {
+	query := s.DB.Model(&models.Inventory{}).Where("deleted_at IS NULL")
+	if orgID != uuid.Nil {
+		query = query.Where("organization_id = ?", orgID)
+	}
+	if itemID != 0 {
+		query = query.Where("item_id = ?", itemID)
+	}
+
+	rows, err := query.Order("organization_id, item_id, txn_date ASC, created_at ASC, id ASC").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sequences []*integritySequence
+	var current *integritySequence
+
+	for rows.Next() {
+		var inv models.Inventory
+		if err := s.DB.ScanRows(rows, &inv); err != nil {
+			return nil, err
+		}
+		report.Rows++
+
+		key := orgItemKey{inv.OrganizationID, inv.ItemID}
+		if current == nil || current.key != key {
+			current = &integritySequence{key: key}
+			sequences = append(sequences, current)
+		}
+
+		s.checkRow(current, &inv, report)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sequences, nil
+}
+
+// checkRow - Bandingkan baris dengan saldo berjalan yang dihitung dari amount/physical_qty
+func (s *IntegrityService) checkRow(seq *integritySequence, inv *models.Inventory, report *IntegrityReport) {
+	addIssue := func(kind, message string, expected, actual *decimal.Decimal, repairable bool) {
+		report.Counts[kind]++
+		report.Issues = append(report.Issues, IntegrityIssue{
+			Kind:           kind,
+			OrganizationID: inv.OrganizationID,
+			ItemID:         inv.ItemID,
+			InventoryID:    inv.ID,
+			TxnDate:        inv.TxnDate,
+			Expected:       expected,
+			Actual:         actual,
+			Message:        message,
+			Repairable:     repairable,
+		})
+		if repairable && !seq.needsRepair {
+			seq.needsRepair = true
+			seq.repairFrom = inv.TxnDate
+		}
+	}
+
+	systemQty := seq.balance
+	if inv.Type == models.InventoryTypeOpname {
+		physicalQty := inv.Balance
+		if inv.PhysicalQty != nil {
+			physicalQty = *inv.PhysicalQty
+		}
+
+		if inv.SystemQty == nil || inv.Difference == nil {
+			addIssue(IssueOpnameMismatch, "opname is missing system_qty or difference", nil, nil, true)
+		} else if actual := inv.SystemQty.Add(*inv.Difference); !actual.Equal(physicalQty) {
+			addIssue(IssueOpnameMismatch,
+				fmt.Sprintf("system_qty %s + difference %s != physical_qty %s", *inv.SystemQty, *inv.Difference, physicalQty),
+				&physicalQty, &actual, true)
+		} else if !inv.SystemQty.Equal(systemQty) && !seq.broken {
+			seq.broken = true
+			actual := *inv.SystemQty
+			addIssue(IssueBalanceChainBreak,
+				fmt.Sprintf("opname system_qty %s does not match running balance %s", actual, systemQty),
+				&systemQty, &actual, true)
+		}
+		seq.balance = physicalQty
+	} else {
+		seq.balance = seq.balance.Add(inv.Amount)
+	}
+
+	// Hanya link pertama yang dilaporkan, baris setelahnya ikut bergeser
+	if !inv.Balance.Equal(seq.balance) && !seq.broken {
+		seq.broken = true
+		expected, actual := seq.balance, inv.Balance
+		addIssue(IssueBalanceChainBreak,
+			fmt.Sprintf("balance %s does not equal running balance %s", actual, expected),
+			&expected, &actual, true)
+	}
+
+	// Dilaporkan saat saldo pertama kali turun di bawah nol
+	if seq.balance.IsNegative() && !seq.negative {
+		balance := seq.balance
+		addIssue(IssueNegativeBalance, fmt.Sprintf("running balance drops to %s", balance), nil, &balance, false)
+	}
+	seq.negative = seq.balance.IsNegative()
+
+	if inv.Type == models.InventoryTypeStokAwal {
+		seq.stokAwal++
+		if seq.stokAwal > 1 {
+			addIssue(IssueDuplicateStokAwal,
+				fmt.Sprintf("stok_awal #%d for this organization and item", seq.stokAwal), nil, nil, false)
+		}
+	}
+}
+
+// checkMutationLegs - Leg mutasi aktif tanpa leg pasangan (ref_id sama, org lawan, arah berlawanan)
+func (s *IntegrityService) checkMutationLegs(orgID uuid.UUID, itemID uint, report *IntegrityReport) error {
+	query := s.DB.Unscoped().Table("inventories AS a").
+		Where("a.type = ? AND a.deleted_at IS NULL", models.InventoryTypeMutation).
+		Where(`(a.ref_id IS NULL OR NOT EXISTS (
+			SELECT 1 FROM inventories b
+			WHERE b.ref_id = a.ref_id AND b.item_id = a.item_id AND b.type = a.type
+			  AND b.organization_id <> a.organization_id AND b.deleted_at IS NULL
+			  AND sign(b.amount) = -sign(a.amount)))`)
+	if orgID != uuid.Nil {
+		query = query.Where("a.organization_id = ?", orgID)
+	}
+	if itemID != 0 {
+		query = query.Where("a.item_id = ?", itemID)
+	}
+
+	var orphans []models.Inventory
+	if err := query.Select("a.*").Order("a.organization_id, a.item_id, a.txn_date").Find(&orphans).Error; err != nil {
+		return err
+	}
+
+	for _, leg := range orphans {
+		message := "mutation leg has no ref_id"
+		if leg.RefID != nil {
+			message = fmt.Sprintf("no active counterpart leg for ref_id %s", leg.RefID)
+		}
+		report.Counts[IssueOrphanMutationLeg]++
+		report.Issues = append(report.Issues, IntegrityIssue{
+			Kind:           IssueOrphanMutationLeg,
+			OrganizationID: leg.OrganizationID,
+			ItemID:         leg.ItemID,
+			InventoryID:    leg.ID,
+			TxnDate:        leg.TxnDate,
+			Message:        message,
+		})
+	}
+	return nil
+}
+
+// orphanReference - Foreign key 0002 (NOT VALID) yang dicek: baris lama yang menunjuk master
+// data / transaksi yang tidak ada harus dibersihkan sebelum migrasi 0017 memvalidasinya
+type orphanReference struct {
+	table  string
+	column string
+	target string
+}
+
+var orphanReferences = []orphanReference{
+	{"inventories", "organization_id", "organizations"},
+	{"inventories", "item_id", "items"},
+	{"inventories", "from_organization_id", "organizations"},
+	{"inventories", "to_organization_id", "organizations"},
+	{"inventory_histories", "organization_id", "organizations"},
+	{"inventory_histories", "item_id", "items"},
+	{"inventory_histories", "trigger_inventory_id", "inventories"},
+	{"stock_balances", "organization_id", "organizations"},
+	{"stock_balances", "item_id", "items"},
+}
+
+// checkOrphanReferences - Baris (termasuk yang soft-deleted) yang referensinya tidak ada
+func (s *IntegrityService) checkOrphanReferences(orgID uuid.UUID, itemID uint, report *IntegrityReport) error {
+	for _, ref := range orphanReferences {
+		// stock_balances tidak punya id sendiri, baris dikenali dari org/item
+		rowKey := "a.id::text"
+		if ref.table == "stock_balances" {
+			rowKey = "a.organization_id::text || '/' || a.item_id"
+		}
+
+		var orphans []struct {
+			RowKey         string
+			OrganizationID uuid.UUID
+			ItemID         uint
+			Reference      string
+		}
+		query := s.DB.Table(ref.table + " AS a").
+			Select(fmt.Sprintf("%s AS row_key, a.organization_id, a.item_id, a.%s::text AS reference", rowKey, ref.column)).
+			Where(fmt.Sprintf("a.%[1]s IS NOT NULL AND NOT EXISTS (SELECT 1 FROM %[2]s t WHERE t.id = a.%[1]s)",
+				ref.column, ref.target))
+		if orgID != uuid.Nil {
+			query = query.Where("a.organization_id = ?", orgID)
+		}
+		if itemID != 0 {
+			query = query.Where("a.item_id = ?", itemID)
+		}
+		if err := query.Order("a.organization_id, a.item_id").Scan(&orphans).Error; err != nil {
+			return err
+		}
+
+		for _, orphan := range orphans {
+			issue := IntegrityIssue{
+				Kind:           IssueOrphanReference,
+				OrganizationID: orphan.OrganizationID,
+				ItemID:         orphan.ItemID,
+				Message: fmt.Sprintf("%s %s: %s %s does not exist in %s",
+					ref.table, orphan.RowKey, ref.column, orphan.Reference, ref.target),
+			}
+			if ref.table == "inventories" {
+				issue.InventoryID, _ = uuid.Parse(orphan.RowKey)
+			}
+			report.Counts[IssueOrphanReference]++
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+	return nil
+}
+
+// ============ REPAIR ============
+
+// repairSequence - Recalculation dari baris rusak pertama lewat chokepoint yang sama dengan write path
+// (recalculateAll: policy stok negatif, stok lot/lokasi/seri, valuation, event BalanceChanged & alert),
+// di bawah advisory lock org+item
+func (s *IntegrityService) repairSequence(seq *integritySequence) error {
+	log.Printf("🔧 Repairing org=%v item=%d from %v", seq.key.OrganizationID, seq.key.ItemID, seq.repairFrom)
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.Inventory.lockOrgItems(tx, seq.key); err != nil {
+			return err
+		}
+		return s.Inventory.recalculateAll(tx, seq.key.OrganizationID, seq.key.ItemID, seq.repairFrom)
+	})
+}
+
+// isRepairRejection - Repair ditolak aturan ledger (bukan error database): periode tertutup tidak
+// ditulis ulang, sisanya perlu perbaikan manual dulu
+func isRepairRejection(err error) bool {
+	return errors.Is(err, ErrPeriodClosed) || errors.Is(err, ErrNegativeStock) || errors.Is(err, ErrInsufficientLocationStock) ||
+		errors.Is(err, ErrInsufficientLotStock) || errors.Is(err, ErrSerialNotInStock) ||
+		errors.Is(err, ErrSerialAlreadyInStock) || errors.Is(err, ErrSerialCountMismatch)
+}
@@ -4,22 +4,37 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 
 	"inventory-ledger/src/models"
 	"inventory-ledger/src/repositories"
 )
 
+var (
+	ErrInventoryNotFound        = errors.New("inventory transaction not found")
+	ErrStokAwalExists           = errors.New("stok awal already exists for this item")
+	ErrInsufficientSourceStock  = errors.New("insufficient stock in source organization")
+	ErrZeroAmount               = errors.New("amount cannot be zero")
+	ErrPemakaianAmountSign      = errors.New("pemakaian amount must be negative")
+	ErrPenerimaanAmountSign     = errors.New("penerimaan amount must be positive")
+	ErrInvalidTransactionType   = errors.New("invalid transaction type")
+	ErrNegativePhysicalQuantity = errors.New("physical_qty cannot be negative")
+)
+
 // ============ REQUEST STRUCTS ============
 type CreateTransactionRequest struct {
 	OrganizationID uuid.UUID
 	ItemID         uint
 	TxnDate        time.Time
-	Amount         int
+	Amount         decimal.Decimal
+	Unit           string // satuan Amount & Lots, kosong = satuan dasar item
 	Type           string
+	UnitCost       *decimal.Decimal // opsional, hanya stok_awal/penerimaan masuk
 	ChangedBy      string
 	Reason         *string
 	RefID          *uuid.UUID
@@ -27,39 +42,62 @@ type CreateTransactionRequest struct {
 	Source         *string
 	PageCode       *string
 	Notes          *string
+
+	// Item ber-lot: LotNumber (+ExpiryDate) untuk baris masuk; baris keluar memakai
+	// Lots / LotNumber, atau alokasi FEFO otomatis jika keduanya kosong
+	LotNumber  *string
+	ExpiryDate *time.Time
+	Lots       []LotQuantity
+
+	// Item serialized: satu nomor seri per unit (masuk maupun keluar)
+	SerialNumbers []string
+
+	// Lokasi / bin di organisasi (opsional, nil = belum ditempatkan)
+	LocationID *uuid.UUID
 }
 
 type MutationRequest struct {
 	FromOrganizationID uuid.UUID
 	ToOrganizationID   uuid.UUID
 	ItemID             uint
-	Quantity           int
+	Quantity           decimal.Decimal
+	Unit               string // kosong = satuan dasar item
 	TxnDate            time.Time
 	ChangedBy          string
 	Reason             *string
 	RefID              *uuid.UUID
 	Notes              *string
+	Lots               []LotQuantity // item ber-lot, kosong = FEFO
+	SerialNumbers      []string      // item serialized, wajib satu per unit
+	FromLocationID     *uuid.UUID    // lokasi di org asal, nil = belum ditempatkan
+	ToLocationID       *uuid.UUID    // lokasi di org tujuan, nil = belum ditempatkan
 }
 
 type OpnameRequest struct {
 	OrganizationID uuid.UUID
 	ItemID         uint
-	PhysicalQty    int
+	PhysicalQty    decimal.Decimal
+	Unit           string // kosong = satuan dasar item
 	TxnDate        time.Time
 	ChangedBy      string
 	Reason         *string
 	RefID          *uuid.UUID
 	Notes          *string
+	SerialNumbers  []string   // item serialized: nomor seri yang dihitung fisik (jumlah = PhysicalQty)
+	LocationID     *uuid.UUID // lokasi yang menampung selisih opname, nil = belum ditempatkan
 }
 
 type UpdateTransactionRequest struct {
-	InventoryID uuid.UUID
-	TxnDate     time.Time
-	Amount      int
-	ChangedBy   string
-	Reason      *string
-	TargetID    *uuid.UUID
-	Notes       *string
+	InventoryID   uuid.UUID
+	TxnDate       time.Time
+	Amount        decimal.Decimal
+	Unit          string           // kosong = satuan dasar item
+	UnitCost      *decimal.Decimal // nil = pakai unit_cost baris lama
+	ChangedBy     string
+	Reason        *string
+	TargetID      *uuid.UUID
+	Notes         *string
+	SerialNumbers []string // nil = pakai nomor seri baris lama
 }
 
 // ============ INVENTORY SERVICE ============
@@ -71,12 +109,12 @@ type InventoryService struct {
 // ============ PUBLIC METHODS ============
 
 // GetCurrentBalance - Get current balance
-func (s *InventoryService) GetCurrentBalance(orgID uuid.UUID, itemID uint) (int, error) {
+func (s *InventoryService) GetCurrentBalance(orgID uuid.UUID, itemID uint) (decimal.Decimal, error) {
 	return s.Repo.GetCurrentBalance(orgID, itemID)
 }
 
 // GetBalanceAt - Get historical balance
-func (s *InventoryService) GetBalanceAt(orgID uuid.UUID, itemID uint, at time.Time) (int, error) {
+func (s *InventoryService) GetBalanceAt(orgID uuid.UUID, itemID uint, at time.Time) (decimal.Decimal, error) {
 	return s.Repo.GetBalanceAt(orgID, itemID, at)
 }
 
@@ -86,9 +124,76 @@ func (s *InventoryService) GetTransactions(orgID uuid.UUID, itemID uint,
 	return s.Repo.GetTransactions(orgID, itemID, fromDate, toDate, page, limit)
 }
 
-// GetOrganizationSummary - Get org summary (handled in repo)
-func (s *InventoryService) GetOrganizationSummary(orgID uuid.UUID) ([]map[string]interface{}, error) {
-	return s.Repo.GetOrganizationSummary(orgID)
+// GetStockCard - Kartu stok: saldo awal, pergerakan masuk/keluar + saldo berjalan, saldo akhir
+func (s *InventoryService) GetStockCard(orgID uuid.UUID, itemID uint, fromDate, toDate time.Time) (*models.StockCard, error) {
+	var org models.Organization
+	if err := s.DB.First(&org, "id = ?", orgID).Error; err != nil {
+		return nil, err
+	}
+	var item models.Item
+	if err := s.DB.First(&item, itemID).Error; err != nil {
+		return nil, err
+	}
+
+	card := &models.StockCard{
+		OrganizationID:   org.ID,
+		OrganizationCode: org.Code,
+		OrganizationName: org.Name,
+		ItemID:           item.ID,
+		ItemCode:         item.Code,
+		ItemName:         item.Name,
+		Unit:             item.Unit,
+		FromDate:         fromDate,
+		ToDate:           toDate,
+	}
+
+	if !fromDate.IsZero() {
+		opening, err := s.Repo.GetBalanceAt(orgID, itemID, fromDate.Add(-time.Microsecond))
+		if err != nil {
+			return nil, err
+		}
+		card.OpeningBalance = opening
+	}
+
+	// Limit -1 = tanpa batas, kartu stok butuh seluruh pergerakan dalam rentang
+	transactions, _, err := s.Repo.GetTransactions(orgID, itemID, fromDate, toDate, 1, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	card.ClosingBalance = card.OpeningBalance
+	card.Lines = make([]models.StockCardLine, 0, len(transactions))
+
+	// GetTransactions urut terbaru dulu, kartu stok urut kronologis
+	for i := len(transactions) - 1; i >= 0; i-- {
+		inv := transactions[i]
+		line := models.StockCardLine{
+			InventoryID: inv.ID,
+			TxnDate:     inv.TxnDate,
+			Type:        string(inv.Type),
+			RefID:       inv.RefID,
+			Notes:       inv.Notes,
+			LotNumber:   inv.LotNumber,
+			Serials:     inv.SerialNumbers,
+			LocationID:  inv.LocationID,
+			Balance:     inv.Balance,
+
+			CostAmount:   inv.CostAmount,
+			BalanceValue: inv.BalanceValue,
+		}
+		if !inv.Amount.IsNegative() {
+			line.In = inv.Amount
+		} else {
+			line.Out = inv.Amount.Neg()
+		}
+
+		card.TotalIn = card.TotalIn.Add(line.In)
+		card.TotalOut = card.TotalOut.Add(line.Out)
+		card.ClosingBalance = inv.Balance
+		card.Lines = append(card.Lines, line)
+	}
+
+	return card, nil
 }
 
 // GetItemSummary - Get item summary across all orgs
@@ -100,19 +205,23 @@ func (s *InventoryService) GetItemSummary(itemID uint) ([]map[string]interface{}
 func (s *InventoryService) CreateTransaction(req CreateTransactionRequest) (*models.Inventory, error) {
 	var inventory *models.Inventory
 
-	if req.Amount == 0 {
-		return nil, errors.New("amount cannot be zero")
-	}
-	if req.Type == "pemakaian" && req.Amount > 0 {
-		return nil, errors.New("pemakaian amount must be negative")
+	var err error
+	if req.Amount, req.Lots, err = s.toBaseUnit(s.DB, req.ItemID, req.Unit, req.Amount, req.Lots); err != nil {
+		return nil, err
 	}
-	if req.Type == "penerimaan" && req.Amount < 0 {
-		return nil, errors.New("penerimaan amount must be positive")
+	if err := validateTransactionRequest(req); err != nil {
+		return nil, err
 	}
 
-	err := s.DB.Transaction(func(tx *gorm.DB) error {
-		if !isValidTransactionType(req.Type) {
-			return errors.New("invalid transaction type")
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.ensurePostable(tx, req.OrganizationID, req.ItemID); err != nil {
+			return err
+		}
+		if err := s.ensureLocation(tx, req.OrganizationID, req.LocationID); err != nil {
+			return err
+		}
+		if err := s.lockOrgItems(tx, orgItemKey{req.OrganizationID, req.ItemID}); err != nil {
+			return err
 		}
 		if req.Type == "stok_awal" {
 			exists, err := s.checkFirstStockExists(tx, req.OrganizationID, req.ItemID)
@@ -120,164 +229,285 @@ func (s *InventoryService) CreateTransaction(req CreateTransactionRequest) (*mod
 				return err
 			}
 			if exists {
-				return errors.New("stok awal already exists for this item")
+				return ErrStokAwalExists
 			}
 		}
-		prevBalance, err := s.Repo.GetBalanceAt(req.OrganizationID, req.ItemID, req.TxnDate)
+		prevBalance, err := s.Repo.WithTx(tx).GetBalanceAt(req.OrganizationID, req.ItemID, req.TxnDate)
 		if err != nil {
 			return err
 		}
-		newBalance := prevBalance + req.Amount
-		inventoryType := models.InventoryType(req.Type)
-		var source *models.TransactionSource
-		if req.Source != nil {
-			s := models.TransactionSource(*req.Source)
-			source = &s
-		}
-
-		pageCode := ""
-		if req.PageCode != nil {
-			pageCode = *req.PageCode
+		parts, err := s.transactionLots(tx, req, nil)
+		if err != nil {
+			return err
 		}
-		inventory = &models.Inventory{
-			OrganizationID: req.OrganizationID,
-			ItemID:         req.ItemID,
-			TxnDate:        req.TxnDate,
-			Amount:         req.Amount,
-			Balance:        newBalance,
-			Type:           inventoryType,
-			RefID:          req.RefID,
-			TargetID:       req.TargetID,
-			Source:         source,
-			PageCode:       pageCode,
-			Notes:          req.Notes,
-			CreatedBy:      req.ChangedBy,
-			CreatedAt:      time.Now(),
+		req.SerialNumbers, err = s.transactionSerials(tx, req.OrganizationID, req.ItemID, req.TxnDate, req.Amount, req.SerialNumbers, nil)
+		if err != nil {
+			return err
 		}
+		inventories := newTransactionInventories(req, parts, prevBalance)
 
-		if err := tx.Create(inventory).Error; err != nil {
-			return err
+		for _, inv := range inventories {
+			if err := tx.Create(inv).Error; err != nil {
+				return err
+			}
+			if err := s.createHistory(tx, inv, "CREATE", req.ChangedBy, req.Reason); err != nil {
+				return err
+			}
 		}
-		if err := s.createHistory(tx, inventory, "CREATE", req.ChangedBy, req.Reason); err != nil {
+		if err := s.recalculate(tx, req.OrganizationID, req.ItemID, req.TxnDate, req.TxnDate); err != nil {
 			return err
 		}
-		return s.Repo.RecalculateForward(tx, req.OrganizationID, req.ItemID, req.TxnDate)
+		for _, inv := range inventories {
+			if err := s.emitInventoryEvent(tx, EventTransactionCreated, inv.ID, req.ChangedBy, req.Reason); err != nil {
+				return err
+			}
+		}
+
+		// Pemakaian yang dipecah ke beberapa lot: baris terakhir membawa saldo akhir posting
+		inventory = inventories[len(inventories)-1]
+		return nil
 	})
 
 	return inventory, err
 }
 
-// CreateMutation - Create stock mutation
+// CreateMutation - Create stock mutation. Item ber-lot: satu pasang leg (RefID sendiri) per lot,
+// leg masuk membawa lot & expiry yang sama
 func (s *InventoryService) CreateMutation(req MutationRequest) error {
+	var err error
+	if req.Quantity, req.Lots, err = s.toBaseUnit(s.DB, req.ItemID, req.Unit, req.Quantity, req.Lots); err != nil {
+		return err
+	}
+	if !req.Quantity.IsPositive() {
+		return ErrInvalidMutationQuantity
+	}
+	if len(req.Lots) > 0 {
+		if err := validateLotQuantities(req.Lots, req.Quantity); err != nil {
+			return err
+		}
+	}
+	if len(req.SerialNumbers) > 0 {
+		if len(req.Lots) > 0 {
+			return ErrSerialLotConflict
+		}
+		if err := checkSerialCount(req.SerialNumbers, req.Quantity); err != nil {
+			return err
+		}
+	}
+
 	return s.DB.Transaction(func(tx *gorm.DB) error {
-		sourceBalance, err := s.Repo.GetBalanceAt(req.FromOrganizationID, req.ItemID, req.TxnDate)
+		for _, orgID := range []uuid.UUID{req.FromOrganizationID, req.ToOrganizationID} {
+			if err := s.ensurePostable(tx, orgID, req.ItemID); err != nil {
+				return err
+			}
+		}
+		if err := s.ensureLocation(tx, req.FromOrganizationID, req.FromLocationID); err != nil {
+			return err
+		}
+		if err := s.ensureLocation(tx, req.ToOrganizationID, req.ToLocationID); err != nil {
+			return err
+		}
+		if err := s.lockOrgItems(tx,
+			orgItemKey{req.FromOrganizationID, req.ItemID},
+			orgItemKey{req.ToOrganizationID, req.ItemID},
+		); err != nil {
+			return err
+		}
+
+		repo := s.Repo.WithTx(tx)
+		sourceBalance, err := repo.GetBalanceAt(req.FromOrganizationID, req.ItemID, req.TxnDate)
 		if err != nil {
 			return err
 		}
 
-		if sourceBalance < req.Quantity {
-			return errors.New("insufficient stock in source organization")
+		if sourceBalance.LessThan(req.Quantity) {
+			allowed, err := s.allowsInsufficientStock(tx, req.FromOrganizationID)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				return ErrInsufficientSourceStock
+			}
 		}
-		refID := uuid.New()
-		sourcePrevBalance, err := s.Repo.GetBalanceAt(req.FromOrganizationID, req.ItemID, req.TxnDate)
+		parts := []lotPart{{Amount: req.Quantity.Neg()}}
+		tracked, err := s.itemTracksLots(tx, req.ItemID)
 		if err != nil {
 			return err
 		}
-		sourceInv := &models.Inventory{
-			OrganizationID:     req.FromOrganizationID,
-			ItemID:             req.ItemID,
-			TxnDate:            req.TxnDate,
-			Amount:             -req.Quantity,
-			Balance:            sourcePrevBalance - req.Quantity,
-			Type:               models.InventoryTypeMutation,
-			RefID:              &refID,
-			FromOrganizationID: &req.FromOrganizationID,
-			ToOrganizationID:   &req.ToOrganizationID,
-			Notes:              req.Notes,
-			CreatedBy:          req.ChangedBy,
-			CreatedAt:          time.Now(),
-		}
-		destPrevBalance, err := s.Repo.GetBalanceAt(req.ToOrganizationID, req.ItemID, req.TxnDate)
+		if tracked {
+			allocator, err := s.newLotAllocator(tx, req.FromOrganizationID, req.ItemID, req.TxnDate)
+			if err != nil {
+				return err
+			}
+			if parts, err = allocator.allocate(req.Quantity, req.Lots); err != nil {
+				return err
+			}
+		} else if len(req.Lots) > 0 {
+			return ErrLotNotTracked
+		}
+
+		// Item serialized: nomor seri harus ada di org asal dan belum ada di org tujuan
+		serials, err := s.transactionSerials(tx, req.FromOrganizationID, req.ItemID, req.TxnDate, req.Quantity.Neg(), req.SerialNumbers, nil)
 		if err != nil {
 			return err
 		}
-		destInv := &models.Inventory{
-			OrganizationID:     req.ToOrganizationID,
-			ItemID:             req.ItemID,
-			TxnDate:            req.TxnDate,
-			Amount:             req.Quantity,
-			Balance:            destPrevBalance + req.Quantity,
-			Type:               models.InventoryTypeMutation,
-			RefID:              &refID,
-			FromOrganizationID: &req.FromOrganizationID,
-			ToOrganizationID:   &req.ToOrganizationID,
-			Notes:              req.Notes,
-			CreatedBy:          req.ChangedBy,
-			CreatedAt:          time.Now(),
-		}
-		if err := tx.Create(sourceInv).Error; err != nil {
+		if _, err := s.transactionSerials(tx, req.ToOrganizationID, req.ItemID, req.TxnDate, req.Quantity, serials, nil); err != nil {
 			return err
 		}
-		if err := tx.Create(destInv).Error; err != nil {
+
+		sourcePrevBalance, err := repo.GetBalanceAt(req.FromOrganizationID, req.ItemID, req.TxnDate)
+		if err != nil {
 			return err
 		}
-		if err := s.createHistory(tx, sourceInv, "MUTATION_OUT", req.ChangedBy, req.Reason); err != nil {
+		destPrevBalance, err := repo.GetBalanceAt(req.ToOrganizationID, req.ItemID, req.TxnDate)
+		if err != nil {
 			return err
 		}
-		if err := s.createHistory(tx, destInv, "MUTATION_IN", req.ChangedBy, req.Reason); err != nil {
+
+		var legs []*models.Inventory
+		for _, part := range parts {
+			refID := uuid.New()
+			sourcePrevBalance = sourcePrevBalance.Add(part.Amount)
+			destPrevBalance = destPrevBalance.Sub(part.Amount)
+
+			sourceInv := &models.Inventory{
+				OrganizationID:     req.FromOrganizationID,
+				ItemID:             req.ItemID,
+				TxnDate:            req.TxnDate,
+				Amount:             part.Amount,
+				Balance:            sourcePrevBalance,
+				Type:               models.InventoryTypeMutation,
+				RefID:              &refID,
+				FromOrganizationID: &req.FromOrganizationID,
+				ToOrganizationID:   &req.ToOrganizationID,
+				LocationID:         req.FromLocationID,
+				LotNumber:          part.LotNumber,
+				ExpiryDate:         part.ExpiryDate,
+				SerialNumbers:      serials,
+				Notes:              req.Notes,
+				CreatedBy:          req.ChangedBy,
+				CreatedAt:          time.Now(),
+			}
+			destInv := &models.Inventory{
+				OrganizationID:     req.ToOrganizationID,
+				ItemID:             req.ItemID,
+				TxnDate:            req.TxnDate,
+				Amount:             part.Amount.Neg(),
+				Balance:            destPrevBalance,
+				Type:               models.InventoryTypeMutation,
+				RefID:              &refID,
+				FromOrganizationID: &req.FromOrganizationID,
+				ToOrganizationID:   &req.ToOrganizationID,
+				LocationID:         req.ToLocationID,
+				LotNumber:          part.LotNumber,
+				ExpiryDate:         part.ExpiryDate,
+				SerialNumbers:      serials,
+				Notes:              req.Notes,
+				CreatedBy:          req.ChangedBy,
+				CreatedAt:          time.Now(),
+			}
+			if err := tx.Create(sourceInv).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(destInv).Error; err != nil {
+				return err
+			}
+			if err := s.createHistory(tx, sourceInv, "MUTATION_OUT", req.ChangedBy, req.Reason); err != nil {
+				return err
+			}
+			if err := s.createHistory(tx, destInv, "MUTATION_IN", req.ChangedBy, req.Reason); err != nil {
+				return err
+			}
+			legs = append(legs, sourceInv, destInv)
+		}
+
+		if err := s.recalculate(tx, req.FromOrganizationID, req.ItemID, req.TxnDate, req.TxnDate); err != nil {
 			return err
 		}
-		if err := s.Repo.RecalculateForward(tx, req.FromOrganizationID, req.ItemID, req.TxnDate); err != nil {
+		if err := s.recalculate(tx, req.ToOrganizationID, req.ItemID, req.TxnDate, req.TxnDate); err != nil {
 			return err
 		}
-		return s.Repo.RecalculateForward(tx, req.ToOrganizationID, req.ItemID, req.TxnDate)
+		for _, leg := range legs {
+			if err := s.emitInventoryEvent(tx, EventMutationPosted, leg.ID, req.ChangedBy, req.Reason); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
-// CreateOpname - Create stock opname
+// CreateOpname - Create stock opname. Item serialized: selisih dihitung dari nomor seri
+// (unit hilang dan unit tak tercatat masing-masing menjadi satu baris opname)
 func (s *InventoryService) CreateOpname(req OpnameRequest) (*models.Inventory, error) {
 	var inventory *models.Inventory
 
-	err := s.DB.Transaction(func(tx *gorm.DB) error {
-		systemBalance, err := s.Repo.GetBalanceAt(req.OrganizationID, req.ItemID, req.TxnDate)
+	var err error
+	if req.PhysicalQty, _, err = s.toBaseUnit(s.DB, req.ItemID, req.Unit, req.PhysicalQty, nil); err != nil {
+		return nil, err
+	}
+	if req.PhysicalQty.IsNegative() {
+		return nil, ErrNegativePhysicalQuantity
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.ensurePostable(tx, req.OrganizationID, req.ItemID); err != nil {
+			return err
+		}
+		if err := s.ensureLocation(tx, req.OrganizationID, req.LocationID); err != nil {
+			return err
+		}
+		if err := s.lockOrgItems(tx, orgItemKey{req.OrganizationID, req.ItemID}); err != nil {
+			return err
+		}
+		tracked, err := s.itemTracksLots(tx, req.ItemID)
 		if err != nil {
 			return err
 		}
+		if tracked {
+			return ErrLotOpnameNotSupported
+		}
 
-		difference := req.PhysicalQty - systemBalance
+		systemBalance, err := s.Repo.WithTx(tx).GetBalanceAt(req.OrganizationID, req.ItemID, req.TxnDate)
+		if err != nil {
+			return err
+		}
 
-		log.Printf("OPNAME DEBUG: System=%d, Physical=%d, Difference=%d",
-			systemBalance, req.PhysicalQty, difference)
+		inventories, err := s.serialOpnameInventories(tx, req, systemBalance)
+		if err != nil {
+			return err
+		}
+		if inventories == nil {
+			difference := req.PhysicalQty.Sub(systemBalance)
 
-		inventory = &models.Inventory{
-			OrganizationID: req.OrganizationID,
-			ItemID:         req.ItemID,
-			TxnDate:        req.TxnDate,
-			Amount:         difference,
-			Balance:        req.PhysicalQty,
-			Type:           models.InventoryTypeOpname,
-			RefID:          req.RefID,
-			PhysicalQty:    &req.PhysicalQty,
-			SystemQty:      &systemBalance,
-			Difference:     &difference,
-			Notes:          req.Notes,
-			CreatedBy:      req.ChangedBy,
-			CreatedAt:      time.Now(),
+			log.Printf("OPNAME DEBUG: System=%s, Physical=%s, Difference=%s",
+				systemBalance, req.PhysicalQty, difference)
+
+			inventories = []*models.Inventory{newOpnameInventory(req, systemBalance, req.PhysicalQty, nil)}
 		}
 
-		log.Printf("OPNAME INVENTORY: Amount=%d, Balance=%d",
-			inventory.Amount, inventory.Balance)
+		for _, inv := range inventories {
+			log.Printf("OPNAME INVENTORY: Amount=%s, Balance=%s", inv.Amount, inv.Balance)
 
-		if err := tx.Create(inventory).Error; err != nil {
-			return err
+			if err := tx.Create(inv).Error; err != nil {
+				return err
+			}
+			if err := s.createHistory(tx, inv, "OPNAME", req.ChangedBy, req.Reason); err != nil {
+				return err
+			}
 		}
 
-		if err := s.createHistory(tx, inventory, "OPNAME", req.ChangedBy, req.Reason); err != nil {
+		log.Println("Recalculating forward after opname...")
+		if err := s.recalculate(tx, req.OrganizationID, req.ItemID, req.TxnDate, req.TxnDate); err != nil {
 			return err
 		}
+		for _, inv := range inventories {
+			if err := s.emitInventoryEvent(tx, EventOpnameAdjusted, inv.ID, req.ChangedBy, req.Reason); err != nil {
+				return err
+			}
+		}
 
-		log.Println("Recalculating forward after opname...")
-		return s.Repo.RecalculateForward(tx, req.OrganizationID, req.ItemID, req.TxnDate)
+		inventory = inventories[len(inventories)-1]
+		return nil
 	})
 
 	return inventory, err
@@ -289,12 +519,32 @@ func (s *InventoryService) UpdateTransaction(req UpdateTransactionRequest) error
 
 	return s.DB.Transaction(func(tx *gorm.DB) error {
 		var existing models.Inventory
-		if err := tx.First(&existing, req.InventoryID).Error; err != nil {
+		counterpart, err := s.lockTransactionLegs(tx, &existing, req.InventoryID)
+		if err != nil {
 			return err
 		}
 
-		log.Printf("Existing: type=%s, amount=%d, balance=%d, date=%v",
+		log.Printf("Existing: type=%s, amount=%s, balance=%s, date=%v",
 			existing.Type, existing.Amount, existing.Balance, existing.TxnDate)
+
+		if req.Amount, _, err = s.toBaseUnit(tx, existing.ItemID, req.Unit, req.Amount, nil); err != nil {
+			return err
+		}
+		if req.Amount.IsZero() {
+			return ErrZeroAmount
+		}
+
+		if err := validateUnitCost(req.UnitCost, existing.Type, req.Amount); err != nil {
+			return err
+		}
+		if req.SerialNumbers, err = s.updateSerials(tx, existing, req); err != nil {
+			return err
+		}
+
+		// Mutasi: kedua leg diganti bersamaan
+		if counterpart != nil {
+			return s.updateMutation(tx, existing, *counterpart, req)
+		}
 		if err := s.createHistory(tx, &existing, "UPDATE_BEFORE", req.ChangedBy, req.Reason); err != nil {
 			return err
 		}
@@ -313,14 +563,28 @@ func (s *InventoryService) UpdateTransaction(req UpdateTransactionRequest) error
 			return err
 		}
 
-		log.Printf("Previous balance before %v = %d", req.TxnDate, prevBalance)
+		log.Printf("Previous balance before %v = %s", req.TxnDate, prevBalance)
+
+		// Harga lama ikut dibawa kecuali diganti; dibuang jika baris tidak lagi stok masuk
+		unitCost := existing.UnitCost
+		if req.UnitCost != nil {
+			unitCost = req.UnitCost
+		}
+		if !acceptsUnitCost(existing.Type, req.Amount) {
+			unitCost = nil
+		}
 		newInventory := models.Inventory{
 			OrganizationID: existing.OrganizationID,
 			ItemID:         existing.ItemID,
 			TxnDate:        req.TxnDate,
 			Amount:         req.Amount,
-			Balance:        prevBalance + req.Amount,
+			Balance:        prevBalance.Add(req.Amount),
 			Type:           existing.Type,
+			UnitCost:       unitCost,
+			LocationID:     existing.LocationID,
+			LotNumber:      existing.LotNumber,
+			ExpiryDate:     existing.ExpiryDate,
+			SerialNumbers:  req.SerialNumbers,
 			RefID:          existing.RefID,
 			TargetID:       req.TargetID,
 			Source:         existing.Source,
@@ -346,21 +610,29 @@ func (s *InventoryService) UpdateTransaction(req UpdateTransactionRequest) error
 			return err
 		}
 
-		log.Printf("Created new transaction: amount=%d, balance=%d",
+		log.Printf("Created new transaction: amount=%s, balance=%s",
 			newInventory.Amount, newInventory.Balance)
-		if err := s.createHistory(tx, &newInventory, "UPDATE_AFTER", req.ChangedBy, req.Reason); err != nil {
-			return err
-		}
 
-		earliestDate := existing.TxnDate
+		earliestDate, latestDate := existing.TxnDate, req.TxnDate
 		if req.TxnDate.Before(earliestDate) {
-			earliestDate = req.TxnDate
+			earliestDate, latestDate = req.TxnDate, existing.TxnDate
 		}
 
 		log.Printf("Recalculating from earliest date: %v", earliestDate)
 
-		return s.Repo.RecalculateForward(tx, existing.OrganizationID,
-			existing.ItemID, earliestDate)
+		if err := s.recalculate(tx, existing.OrganizationID,
+			existing.ItemID, earliestDate, latestDate); err != nil {
+			return err
+		}
+
+		// Snapshot UPDATE_AFTER diambil setelah recalculate supaya saldo di snapshot sudah final
+		if err := s.createHistory(tx, &newInventory, "UPDATE_AFTER", req.ChangedBy, req.Reason); err != nil {
+			return err
+		}
+		if err := s.pairUpdateHistories(tx, existing.ID, newInventory.ID); err != nil {
+			return err
+		}
+		return s.emitTransactionUpdated(tx, &existing, newInventory.ID, req.ChangedBy, req.Reason)
 	})
 }
 
@@ -376,7 +648,7 @@ func (s *InventoryService) handleOpnameUpdate(tx *gorm.DB, existing models.Inven
 
 	newDifference := req.Amount
 	newSystemQty := prevBalance
-	newPhysicalQty := prevBalance + req.Amount
+	newPhysicalQty := prevBalance.Add(req.Amount)
 
 	existing.DeletedBy = &req.ChangedBy
 	existing.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
@@ -392,6 +664,8 @@ func (s *InventoryService) handleOpnameUpdate(tx *gorm.DB, existing models.Inven
 		Balance:        newPhysicalQty,
 		Type:           models.InventoryTypeOpname,
 		RefID:          existing.RefID,
+		LocationID:     existing.LocationID,
+		SerialNumbers:  req.SerialNumbers,
 		PhysicalQty:    &newPhysicalQty,
 		SystemQty:      &newSystemQty,
 		Difference:     &newDifference,
@@ -404,11 +678,25 @@ func (s *InventoryService) handleOpnameUpdate(tx *gorm.DB, existing models.Inven
 		return err
 	}
 
-	log.Printf("📝 Created new opname: system_qty=%d, physical_qty=%d, diff=%d, balance=%d",
+	log.Printf("📝 Created new opname: system_qty=%s, physical_qty=%s, diff=%s, balance=%s",
 		newSystemQty, newPhysicalQty, newDifference, newPhysicalQty)
 
-	return s.Repo.RecalculateForward(tx, existing.OrganizationID,
-		existing.ItemID, req.TxnDate)
+	earliestDate, latestDate := existing.TxnDate, req.TxnDate
+	if req.TxnDate.Before(earliestDate) {
+		earliestDate, latestDate = req.TxnDate, existing.TxnDate
+	}
+	if err := s.recalculate(tx, existing.OrganizationID,
+		existing.ItemID, earliestDate, latestDate); err != nil {
+		return err
+	}
+
+	if err := s.createHistory(tx, &newOpname, "UPDATE_AFTER", req.ChangedBy, req.Reason); err != nil {
+		return err
+	}
+	if err := s.pairUpdateHistories(tx, existing.ID, newOpname.ID); err != nil {
+		return err
+	}
+	return s.emitTransactionUpdated(tx, &existing, newOpname.ID, req.ChangedBy, req.Reason)
 }
 
 // DeleteTransaction - Soft delete transaction
@@ -416,10 +704,16 @@ func (s *InventoryService) DeleteTransaction(inventoryID uuid.UUID, deletedBy st
 	return s.DB.Transaction(func(tx *gorm.DB) error {
 
 		var inventory models.Inventory
-		if err := tx.First(&inventory, inventoryID).Error; err != nil {
+		counterpart, err := s.lockTransactionLegs(tx, &inventory, inventoryID)
+		if err != nil {
 			return err
 		}
 
+		// Mutasi: kedua leg dihapus bersamaan
+		if counterpart != nil {
+			return s.deleteMutation(tx, []models.Inventory{inventory, *counterpart}, deletedBy, reason)
+		}
+
 		if err := s.createHistory(tx, &inventory, "DELETE_BEFORE", deletedBy, reason); err != nil {
 			return err
 		}
@@ -430,43 +724,64 @@ func (s *InventoryService) DeleteTransaction(inventoryID uuid.UUID, deletedBy st
 			return err
 		}
 
-		return s.Repo.RecalculateForward(tx, inventory.OrganizationID,
-			inventory.ItemID, inventory.TxnDate)
+		if err := s.recalculate(tx, inventory.OrganizationID,
+			inventory.ItemID, inventory.TxnDate, inventory.TxnDate); err != nil {
+			return err
+		}
+		return s.emitInventoryEvent(tx, EventTransactionDeleted, inventory.ID, deletedBy, reason)
+	})
+}
+
+// RebuildStockBalances - Regenerate projection stock_balances dari ledger inventories
+func (s *InventoryService) RebuildStockBalances() (int64, error) {
+	var rebuilt int64
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		count, err := s.Repo.RebuildStockBalances(tx)
+		rebuilt = count
+		return err
 	})
+
+	return rebuilt, err
 }
 
 // ============ PRIVATE HELPER METHODS ============
 
+// orgItemKey - Pasangan org+item yang diserialisasi saat write
+type orgItemKey struct {
+	OrganizationID uuid.UUID
+	ItemID         uint
+}
+
+// lockOrgItems - Ambil advisory lock org+item dengan urutan stabil (hindari deadlock)
+func (s *InventoryService) lockOrgItems(tx *gorm.DB, keys ...orgItemKey) error {
+	lockKeys := make([]int64, 0, len(keys))
+	seen := make(map[int64]bool)
+	for _, key := range keys {
+		lockKey := repositories.OrgItemLockKey(key.OrganizationID, key.ItemID)
+		if !seen[lockKey] {
+			seen[lockKey] = true
+			lockKeys = append(lockKeys, lockKey)
+		}
+	}
+	sort.Slice(lockKeys, func(i, j int) bool { return lockKeys[i] < lockKeys[j] })
+
+	for _, lockKey := range lockKeys {
+		if err := s.Repo.AdvisoryLock(tx, lockKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // createHistory - Create history snapshot for org+item
 func (s *InventoryService) createHistory(tx *gorm.DB, inventory *models.Inventory, action, changedBy string, reason *string) error {
 
-	var snapshots []models.Inventory
-	err := tx.
-		Where("organization_id = ? AND item_id = ? AND txn_date >= ? AND deleted_at IS NULL",
-			inventory.OrganizationID, inventory.ItemID, inventory.TxnDate).
-		Order("txn_date ASC, created_at ASC").
-		Find(&snapshots).Error
-
+	snapshotItems, err := s.takeSnapshot(tx, inventory.OrganizationID, inventory.ItemID, inventory.TxnDate)
 	if err != nil {
 		return err
 	}
 
-	var snapshotItems []models.SnapshotItem
-	for _, inv := range snapshots {
-		item := models.SnapshotItem{
-			InventoryID: inv.ID,
-			TxnDate:     inv.TxnDate,
-			Amount:      inv.Amount,
-			Balance:     inv.Balance,
-			Type:        string(inv.Type),
-		}
-		if inv.RefID != nil {
-			refStr := inv.RefID.String()
-			item.RefID = &refStr
-		}
-		snapshotItems = append(snapshotItems, item)
-	}
-
 	snapshotJSON, err := json.Marshal(snapshotItems)
 	if err != nil {
 		return err
@@ -492,6 +807,197 @@ func (s *InventoryService) createHistory(tx *gorm.DB, inventory *models.Inventor
 	return tx.Create(&history).Error
 }
 
+// pairUpdateHistories - Tautkan UPDATE_BEFORE (trigger = baris lama) dengan UPDATE_AFTER (trigger = baris baru)
+func (s *InventoryService) pairUpdateHistories(tx *gorm.DB, oldInventoryID, newInventoryID uuid.UUID) error {
+	var before, after models.InventoryHistory
+	if err := tx.Where("trigger_inventory_id = ? AND action = ?", oldInventoryID, "UPDATE_BEFORE").
+		Order("created_at DESC").Take(&before).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("trigger_inventory_id = ? AND action = ?", newInventoryID, "UPDATE_AFTER").
+		Order("created_at DESC").Take(&after).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&before).Update("paired_history_id", after.ID).Error; err != nil {
+		return err
+	}
+	return tx.Model(&after).Update("paired_history_id", before.ID).Error
+}
+
+// takeSnapshot - Snapshot transaksi aktif org+item mulai tanggal tertentu
+func (s *InventoryService) takeSnapshot(tx *gorm.DB, orgID uuid.UUID, itemID uint, fromDate time.Time) ([]models.SnapshotItem, error) {
+	var snapshots []models.Inventory
+	err := tx.
+		Where("organization_id = ? AND item_id = ? AND txn_date >= ? AND deleted_at IS NULL",
+			orgID, itemID, fromDate).
+		Order("txn_date ASC, created_at ASC").
+		Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return toSnapshotItems(snapshots), nil
+}
+
+// toSnapshotItems - Konversi baris inventory ke format snapshot history
+func toSnapshotItems(inventories []models.Inventory) []models.SnapshotItem {
+	var items []models.SnapshotItem
+	for _, inv := range inventories {
+		item := models.SnapshotItem{
+			InventoryID:  inv.ID,
+			TxnDate:      inv.TxnDate,
+			Amount:       inv.Amount,
+			Balance:      inv.Balance,
+			Type:         string(inv.Type),
+			UnitCost:     inv.UnitCost,
+			BalanceValue: inv.BalanceValue,
+			LotNumber:    inv.LotNumber,
+			ExpiryDate:   inv.ExpiryDate,
+
+			SerialNumbers: inv.SerialNumbers,
+			LocationID:    inv.LocationID,
+		}
+		if inv.RefID != nil {
+			refStr := inv.RefID.String()
+			item.RefID = &refStr
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// validateTransactionRequest - Validasi amount & type sebelum posting
+func validateTransactionRequest(req CreateTransactionRequest) error {
+	if req.Amount.IsZero() {
+		return ErrZeroAmount
+	}
+	if !validQuantity(req.Amount) {
+		return ErrInvalidQuantity
+	}
+	if req.Type == "pemakaian" && req.Amount.IsPositive() {
+		return ErrPemakaianAmountSign
+	}
+	if req.Type == "penerimaan" && req.Amount.IsNegative() {
+		return ErrPenerimaanAmountSign
+	}
+	if !isValidTransactionType(req.Type) {
+		return ErrInvalidTransactionType
+	}
+	if err := validateLotRequest(req); err != nil {
+		return err
+	}
+	if err := validateSerialRequest(req); err != nil {
+		return err
+	}
+	return validateUnitCost(req.UnitCost, models.InventoryType(req.Type), req.Amount)
+}
+
+// newTransactionInventory - Build row inventory dari request transaksi
+func newTransactionInventory(req CreateTransactionRequest, balance decimal.Decimal) *models.Inventory {
+	var source *models.TransactionSource
+	if req.Source != nil {
+		s := models.TransactionSource(*req.Source)
+		source = &s
+	}
+
+	pageCode := ""
+	if req.PageCode != nil {
+		pageCode = *req.PageCode
+	}
+
+	return &models.Inventory{
+		OrganizationID: req.OrganizationID,
+		ItemID:         req.ItemID,
+		TxnDate:        req.TxnDate,
+		Amount:         req.Amount,
+		Balance:        balance,
+		Type:           models.InventoryType(req.Type),
+		UnitCost:       req.UnitCost,
+		LocationID:     req.LocationID,
+		SerialNumbers:  req.SerialNumbers,
+		RefID:          req.RefID,
+		TargetID:       req.TargetID,
+		Source:         source,
+		PageCode:       pageCode,
+		Notes:          req.Notes,
+		CreatedBy:      req.ChangedBy,
+		CreatedAt:      time.Now(),
+	}
+}
+
+// newOpnameInventory - Baris opname: balance = physicalQty, amount = selisih terhadap systemQty
+func newOpnameInventory(req OpnameRequest, systemQty, physicalQty decimal.Decimal, serials []string) *models.Inventory {
+	difference := physicalQty.Sub(systemQty)
+	return &models.Inventory{
+		OrganizationID: req.OrganizationID,
+		ItemID:         req.ItemID,
+		TxnDate:        req.TxnDate,
+		Amount:         difference,
+		Balance:        physicalQty,
+		Type:           models.InventoryTypeOpname,
+		RefID:          req.RefID,
+		LocationID:     req.LocationID,
+		SerialNumbers:  serials,
+		PhysicalQty:    &physicalQty,
+		SystemQty:      &systemQty,
+		Difference:     &difference,
+		Notes:          req.Notes,
+		CreatedBy:      req.ChangedBy,
+		CreatedAt:      time.Now(),
+	}
+}
+
+// newTransactionInventories - Satu baris per lotPart (item tanpa lot: satu baris), saldo berjalan
+// dari prevBalance. CreatedAt dibuat berurutan supaya urutan baris dalam satu posting tetap.
+func newTransactionInventories(req CreateTransactionRequest, parts []lotPart, prevBalance decimal.Decimal) []*models.Inventory {
+	inventories := make([]*models.Inventory, len(parts))
+	balance := prevBalance
+	for i, part := range parts {
+		balance = balance.Add(part.Amount)
+		inv := newTransactionInventory(req, balance)
+		applyLotPart(inv, part)
+		inv.CreatedAt = inv.CreatedAt.Add(time.Duration(i) * time.Microsecond)
+		inventories[i] = inv
+	}
+	return inventories
+}
+
+// ensurePostable - Org & item harus ada dan aktif sebelum posting baru
+func (s *InventoryService) ensurePostable(tx *gorm.DB, orgID uuid.UUID, itemID uint) error {
+	var org models.Organization
+	err := tx.Select("id", "is_active").Where("id = ?", orgID).Take(&org).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrOrganizationNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !org.IsActive {
+		return ErrOrganizationInactive
+	}
+
+	var item models.Item
+	err = tx.Select("id", "is_active").Where("id = ?", itemID).Take(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrItemNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !item.IsActive {
+		return ErrItemInactive
+	}
+
+	return nil
+}
+
+// isPostableError - Error master data dari ensurePostable (bukan error database)
+func isPostableError(err error) bool {
+	return errors.Is(err, ErrOrganizationNotFound) || errors.Is(err, ErrOrganizationInactive) ||
+		errors.Is(err, ErrItemNotFound) || errors.Is(err, ErrItemInactive)
+}
+
 // checkFirstStockExists - Check if stok awal already exists
 func (s *InventoryService) checkFirstStockExists(tx *gorm.DB, orgID uuid.UUID, itemID uint) (bool, error) {
 	var count int64
@@ -504,9 +1010,9 @@ func (s *InventoryService) checkFirstStockExists(tx *gorm.DB, orgID uuid.UUID, i
 }
 
 // getBalanceBeforeDate - Get balance before specific date (excluding a record)
-func (s *InventoryService) getBalanceBeforeDate(tx *gorm.DB, orgID uuid.UUID, itemID uint, date time.Time, excludeID uuid.UUID) (int, error) {
+func (s *InventoryService) getBalanceBeforeDate(tx *gorm.DB, orgID uuid.UUID, itemID uint, date time.Time, excludeID uuid.UUID) (decimal.Decimal, error) {
 
-	var balance int
+	var balance decimal.Decimal
 	err := tx.Model(&models.Inventory{}).
 		Select("balance").
 		Where("organization_id = ? AND item_id = ? AND txn_date < ? AND id != ? AND deleted_at IS NULL",
@@ -516,205 +1022,11 @@ func (s *InventoryService) getBalanceBeforeDate(tx *gorm.DB, orgID uuid.UUID, it
 		Scan(&balance).Error
 
 	if err == gorm.ErrRecordNotFound {
-		return 0, nil
+		return decimal.Zero, nil
 	}
 	return balance, err
 }
 
-// RollbackTransaction
-func (s *InventoryService) RollbackTransaction(historyID uuid.UUID, changedBy string, reason *string) error {
-	log.Printf("Starting RollbackTransaction: history_id=%v", historyID)
-
-	return s.DB.Transaction(func(tx *gorm.DB) error {
-		var history models.InventoryHistory
-		if err := tx.First(&history, historyID).Error; err != nil {
-			return err
-		}
-
-		log.Printf("History found: action=%s, snapshot_from=%v",
-			history.Action, history.SnapshotFromDate)
-
-		var snapshotItems []models.SnapshotItem
-
-		var snapshotData json.RawMessage
-		switch history.Action {
-		case "CREATE", "MUTATION_IN", "MUTATION_OUT", "OPNAME":
-			snapshotData = history.DataAfter
-		case "UPDATE_BEFORE", "DELETE_BEFORE":
-			snapshotData = history.DataBefore
-		case "UPDATE_AFTER":
-			snapshotData = history.DataBefore
-		default:
-			return errors.New("unsupported history action for rollback")
-		}
-
-		if err := json.Unmarshal(snapshotData, &snapshotItems); err != nil {
-			return err
-		}
-
-		log.Printf("Snapshot contains %d transactions", len(snapshotItems))
-
-		now := time.Now()
-		if err := tx.Model(&models.Inventory{}).
-			Where(
-				"organization_id = ? AND item_id = ? AND txn_date >= ? AND deleted_at IS NULL",
-				history.OrganizationID, history.ItemID, history.SnapshotFromDate,
-			).
-			Updates(map[string]interface{}{
-				"deleted_at": now,
-				"deleted_by": changedBy + " (rollback_delete)",
-			}).Error; err != nil {
-			return err
-		}
-
-		log.Printf("🧹 Soft-deleted transactions from %v onward", history.SnapshotFromDate)
-
-		for _, item := range snapshotItems {
-			inventoryType := models.InventoryType(item.Type)
-
-			newID := uuid.New()
-
-			inventory := models.Inventory{
-				ID:             newID,
-				OrganizationID: history.OrganizationID,
-				ItemID:         history.ItemID,
-				TxnDate:        item.TxnDate,
-				Amount:         item.Amount,
-				Balance:        item.Balance,
-				Type:           inventoryType,
-				CreatedBy:      changedBy + " (rollback_restore)",
-				CreatedAt:      time.Now(),
-			}
-
-			if item.RefID != nil {
-				refID, err := uuid.Parse(*item.RefID)
-				if err == nil {
-					inventory.RefID = &refID
-				}
-			}
-
-			if inventoryType == models.InventoryTypeMutation {
-				var original models.Inventory
-				if err := tx.Unscoped().
-					Where("id = ?", item.InventoryID).
-					First(&original).Error; err == nil {
-					inventory.FromOrganizationID = original.FromOrganizationID
-					inventory.ToOrganizationID = original.ToOrganizationID
-				}
-			}
-
-			if inventoryType == models.InventoryTypeOpname {
-				var original models.Inventory
-				if err := tx.Unscoped().
-					Where("id = ?", item.InventoryID).
-					First(&original).Error; err == nil {
-					inventory.PhysicalQty = original.PhysicalQty
-					inventory.SystemQty = original.SystemQty
-					inventory.Difference = original.Difference
-				}
-			}
-
-			if err := tx.Create(&inventory).Error; err != nil {
-				log.Printf("Error creating restored transaction: %v", err)
-				return err
-			}
-
-			log.Printf("Recreated transaction: new_id=%v, date=%v, amount=%d, balance=%d",
-				newID, item.TxnDate, item.Amount, item.Balance)
-		}
-
-		log.Printf("Recalculating forward balances after rollback...")
-		if err := s.Repo.RecalculateForward(tx, history.OrganizationID,
-			history.ItemID, history.SnapshotFromDate); err != nil {
-			return err
-		}
-
-		rollbackHistory := models.InventoryHistory{
-			OrganizationID:     history.OrganizationID,
-			ItemID:             history.ItemID,
-			TriggerInventoryID: history.TriggerInventoryID,
-			SnapshotFromDate:   history.SnapshotFromDate,
-			Action:             "ROLLBACK",
-			ChangedBy:          changedBy,
-			Reason:             reason,
-			CreatedAt:          time.Now(),
-		}
-
-		var currentSnapshots []models.Inventory
-		if err := tx.Where(
-			"organization_id = ? AND item_id = ? AND txn_date >= ? AND deleted_at IS NULL",
-			history.OrganizationID, history.ItemID, history.SnapshotFromDate,
-		).Order("txn_date ASC, created_at ASC").Find(&currentSnapshots).Error; err != nil {
-			return err
-		}
-
-		var currentItems []models.SnapshotItem
-		for _, inv := range currentSnapshots {
-			item := models.SnapshotItem{
-				InventoryID: inv.ID,
-				TxnDate:     inv.TxnDate,
-				Amount:      inv.Amount,
-				Balance:     inv.Balance,
-				Type:        string(inv.Type),
-			}
-			if inv.RefID != nil {
-				refStr := inv.RefID.String()
-				item.RefID = &refStr
-			}
-			currentItems = append(currentItems, item)
-		}
-
-		currentJSON, err := json.Marshal(currentItems)
-		if err != nil {
-			return err
-		}
-
-		rollbackHistory.DataAfter = json.RawMessage(currentJSON)
-
-		var beforeSnapshots []models.Inventory
-		if err := tx.Unscoped().
-			Where(
-				"organization_id = ? AND item_id = ? AND txn_date >= ? AND deleted_at IS NOT NULL",
-				history.OrganizationID, history.ItemID, history.SnapshotFromDate,
-			).
-			Order("txn_date ASC, created_at ASC").
-			Find(&beforeSnapshots).Error; err != nil {
-			return err
-		}
-
-		var beforeItems []models.SnapshotItem
-		for _, inv := range beforeSnapshots {
-			item := models.SnapshotItem{
-				InventoryID: inv.ID,
-				TxnDate:     inv.TxnDate,
-				Amount:      inv.Amount,
-				Balance:     inv.Balance,
-				Type:        string(inv.Type),
-			}
-			if inv.RefID != nil {
-				refStr := inv.RefID.String()
-				item.RefID = &refStr
-			}
-			beforeItems = append(beforeItems, item)
-		}
-
-		beforeJSON, err := json.Marshal(beforeItems)
-		if err != nil {
-			return err
-		}
-
-		rollbackHistory.DataBefore = json.RawMessage(beforeJSON)
-
-		if err := tx.Create(&rollbackHistory).Error; err != nil {
-			return err
-		}
-
-		log.Printf("Rollback completed for history %v", historyID)
-
-		return nil
-	})
-}
-
 // GetHistory - Get inventory history for audit trail
 func (s *InventoryService) GetHistory(orgID uuid.UUID, itemID uint, action string, page, limit int) ([]models.InventoryHistory, int64, error) {
 	offset := (page - 1) * limit
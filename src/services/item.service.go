@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+)
+
+var (
+	ErrItemNotFound  = errors.New("item not found")
+	ErrItemInactive  = errors.New("item is inactive")
+	ErrItemCodeTaken = errors.New("item code already exists")
+)
+
+// ============ REQUEST STRUCTS ============
+type ItemRequest struct {
+	Code          string
+	Name          string
+	Unit          string
+	CostingMethod models.CostingMethod // kosong = average (create) / tidak berubah (update)
+	TrackLots     *bool                // nil = false (create) / tidak berubah (update)
+	Serialized    *bool                // nil = false (create) / tidak berubah (update)
+}
+
+// ============ ITEM SERVICE ============
+type ItemService struct {
+	DB   *gorm.DB
+	Repo *repositories.ItemRepository
+
+	// Inventory - Untuk menilai ulang ledger saat costing_method diganti
+	Inventory *InventoryService
+}
+
+// GetItem - Detail item
+func (s *ItemService) GetItem(id uint) (*models.Item, error) {
+	item, err := s.Repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrItemNotFound
+	}
+	return item, err
+}
+
+// ListItems - List + pencarian code/name
+func (s *ItemService) ListItems(filter repositories.MasterDataFilter) ([]models.Item, int64, error) {
+	return s.Repo.List(filter)
+}
+
+// CreateItem - Buat item baru (kode unik)
+func (s *ItemService) CreateItem(req ItemRequest) (*models.Item, error) {
+	item := &models.Item{
+		Code:     strings.TrimSpace(req.Code),
+		Name:     strings.TrimSpace(req.Name),
+		Unit:     strings.TrimSpace(req.Unit),
+		IsActive: true,
+
+		CostingMethod: models.CostingAverage,
+	}
+	if req.TrackLots != nil {
+		item.TrackLots = *req.TrackLots
+	}
+	if req.Serialized != nil {
+		item.Serialized = *req.Serialized
+	}
+	if item.TrackLots && item.Serialized {
+		return nil, ErrSerialLotConflict
+	}
+	if req.CostingMethod != "" {
+		if !isValidCostingMethod(req.CostingMethod) {
+			return nil, ErrInvalidCostingMethod
+		}
+		item.CostingMethod = req.CostingMethod
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		repo := s.Repo.WithTx(tx)
+		taken, err := repo.CodeExists(item.Code, 0)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrItemCodeTaken
+		}
+		return repo.Create(item)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("ITEM CREATED: %s (%d)", item.Code, item.ID)
+	return item, nil
+}
+
+// UpdateItem - Ubah code/name/unit (kode tetap unik). Ganti costing_method menilai ulang
+// seluruh ledger item dari awal, jadi ditolak jika ada organisasi dengan periode tertutup.
+// track_lots & serialized hanya bisa diubah selama item belum punya posting.
+func (s *ItemService) UpdateItem(id uint, req ItemRequest) (*models.Item, error) {
+	var item *models.Item
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		repo := s.Repo.WithTx(tx)
+
+		var err error
+		item, err = repo.FindByID(id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrItemNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		item.Code = strings.TrimSpace(req.Code)
+		item.Name = strings.TrimSpace(req.Name)
+
+		// Ledger tersimpan dalam satuan dasar: hanya ejaan yang boleh berubah setelah ada posting
+		unit := strings.TrimSpace(req.Unit)
+		if !strings.EqualFold(unit, item.Unit) {
+			postings, err := countItemPostings(tx, item.ID)
+			if err != nil {
+				return err
+			}
+			if postings > 0 {
+				return ErrBaseUnitLocked
+			}
+			units, err := repo.FindUnits(item.ID)
+			if err != nil {
+				return err
+			}
+			if findItemUnit(units, unit) != nil {
+				return ErrBaseUnitConversion
+			}
+		}
+		item.Unit = unit
+
+		methodChanged := false
+		if req.CostingMethod != "" {
+			if !isValidCostingMethod(req.CostingMethod) {
+				return ErrInvalidCostingMethod
+			}
+			methodChanged = req.CostingMethod != item.CostingMethod
+			item.CostingMethod = req.CostingMethod
+		}
+
+		lotsChanged := req.TrackLots != nil && *req.TrackLots != item.TrackLots
+		serialsChanged := req.Serialized != nil && *req.Serialized != item.Serialized
+		if lotsChanged || serialsChanged {
+			postings, err := countItemPostings(tx, item.ID)
+			if err != nil {
+				return err
+			}
+			switch {
+			case postings > 0 && lotsChanged:
+				return ErrLotTrackingLocked
+			case postings > 0:
+				return ErrSerializedLocked
+			}
+			if lotsChanged {
+				item.TrackLots = *req.TrackLots
+			}
+			if serialsChanged {
+				item.Serialized = *req.Serialized
+			}
+			if item.TrackLots && item.Serialized {
+				return ErrSerialLotConflict
+			}
+		}
+
+		taken, err := repo.CodeExists(item.Code, item.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrItemCodeTaken
+		}
+		if err := repo.Save(item); err != nil {
+			return err
+		}
+
+		if methodChanged && s.Inventory != nil {
+			log.Printf("ITEM %s: costing_method=%s, revaluing ledger", item.Code, item.CostingMethod)
+			return s.Inventory.revalueItem(tx, item.ID, false)
+		}
+		return nil
+	})
+
+	return item, err
+}
+
+// isValidCostingMethod - fifo / average
+func isValidCostingMethod(method models.CostingMethod) bool {
+	return method == models.CostingFIFO || method == models.CostingAverage
+}
+
+// SetItemActive - Nonaktifkan (soft) / aktifkan kembali item.
+// Item nonaktif tidak bisa diposting lagi, ledger & history tetap utuh.
+func (s *ItemService) SetItemActive(id uint, active bool) (*models.Item, error) {
+	item, err := s.GetItem(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.SetActive(item, active); err != nil {
+		return nil, err
+	}
+	item.IsActive = active
+
+	log.Printf("ITEM %s: active=%v", item.Code, active)
+	return item, nil
+}
+
+// countItemPostings - Jumlah baris ledger item (termasuk yang sudah dihapus)
+func countItemPostings(tx *gorm.DB, itemID uint) (int64, error) {
+	var postings int64
+	err := tx.Unscoped().Model(&models.Inventory{}).Where("item_id = ?", itemID).Count(&postings).Error
+	return postings, err
+}
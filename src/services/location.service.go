@@ -0,0 +1,459 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+)
+
+var (
+	ErrLocationNotFound          = errors.New("location not found")
+	ErrLocationInactive          = errors.New("location is inactive")
+	ErrLocationCodeTaken         = errors.New("location code already exists in this organization")
+	ErrInvalidLocationParent     = errors.New("parent_id must be another location of the same organization, outside this location's subtree")
+	ErrInvalidLocationMove       = errors.New("from_location_id and to_location_id must differ")
+	ErrInsufficientLocationStock = errors.New("insufficient stock at location")
+)
+
+// unassignedLocation - Label saldo yang belum ditempatkan di lokasi mana pun
+const unassignedLocation = "unassigned"
+
+// ============ REQUEST STRUCTS ============
+type LocationRequest struct {
+	Code     string
+	Name     string
+	ParentID *uuid.UUID // nil = lokasi root (misal zona)
+}
+
+// LocationMoveRequest - Pindah stok antar lokasi di dalam satu organisasi.
+// FromLocationID / ToLocationID nil = stok yang belum ditempatkan.
+type LocationMoveRequest struct {
+	OrganizationID uuid.UUID
+	ItemID         uint
+	FromLocationID *uuid.UUID
+	ToLocationID   *uuid.UUID
+	Quantity       decimal.Decimal
+	Unit           string // kosong = satuan dasar item
+	TxnDate        time.Time
+	ChangedBy      string
+	Reason         *string
+	Notes          *string
+	Lots           []LotQuantity // item ber-lot, kosong = FEFO
+	SerialNumbers  []string      // item serialized, wajib satu per unit
+}
+
+// ============ LOCATION SERVICE (MASTER DATA) ============
+type LocationService struct {
+	DB   *gorm.DB
+	Repo *repositories.LocationRepository
+}
+
+// GetLocation - Detail lokasi organisasi
+func (s *LocationService) GetLocation(orgID, id uuid.UUID) (*models.Location, error) {
+	location, err := s.Repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && location.OrganizationID != orgID) {
+		return nil, ErrLocationNotFound
+	}
+	return location, err
+}
+
+// ListLocations - List + pencarian code/name (parent_id membentuk pohon)
+func (s *LocationService) ListLocations(orgID uuid.UUID, filter repositories.MasterDataFilter) ([]models.Location, int64, error) {
+	return s.Repo.List(orgID, filter)
+}
+
+// CreateLocation - Buat lokasi baru (kode unik per organisasi, parent di organisasi yang sama)
+func (s *LocationService) CreateLocation(orgID uuid.UUID, req LocationRequest) (*models.Location, error) {
+	location := &models.Location{
+		OrganizationID: orgID,
+		ParentID:       req.ParentID,
+		Code:           strings.TrimSpace(req.Code),
+		Name:           strings.TrimSpace(req.Name),
+		IsActive:       true,
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var org models.Organization
+		err := tx.Select("id").Where("id = ?", orgID).Take(&org).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOrganizationNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		repo := s.Repo.WithTx(tx)
+		if err := checkLocationParent(repo, location); err != nil {
+			return err
+		}
+		taken, err := repo.CodeExists(orgID, location.Code, uuid.Nil)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrLocationCodeTaken
+		}
+		return repo.Create(location)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("LOCATION CREATED: %s (%s) org=%s", location.Code, location.ID, orgID)
+	return location, nil
+}
+
+// UpdateLocation - Ubah name/code/parent (pindah cabang pohon, tidak boleh ke subtree sendiri)
+func (s *LocationService) UpdateLocation(orgID, id uuid.UUID, req LocationRequest) (*models.Location, error) {
+	var location *models.Location
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		repo := s.Repo.WithTx(tx)
+
+		var err error
+		location, err = repo.FindByID(id)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && location.OrganizationID != orgID) {
+			return ErrLocationNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		location.Code = strings.TrimSpace(req.Code)
+		location.Name = strings.TrimSpace(req.Name)
+		location.ParentID = req.ParentID
+		if err := checkLocationParent(repo, location); err != nil {
+			return err
+		}
+
+		taken, err := repo.CodeExists(orgID, location.Code, location.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrLocationCodeTaken
+		}
+		return repo.Save(location)
+	})
+
+	return location, err
+}
+
+// SetLocationActive - Nonaktifkan / aktifkan kembali lokasi.
+// Lokasi nonaktif tidak bisa menerima posting baru, saldo & ledger tetap utuh.
+func (s *LocationService) SetLocationActive(orgID, id uuid.UUID, active bool) (*models.Location, error) {
+	location, err := s.GetLocation(orgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.SetActive(location, active); err != nil {
+		return nil, err
+	}
+	location.IsActive = active
+
+	log.Printf("LOCATION %s: active=%v", location.Code, active)
+	return location, nil
+}
+
+// checkLocationParent - Parent harus lokasi lain di organisasi yang sama dan bukan turunan lokasi ini
+func checkLocationParent(repo *repositories.LocationRepository, location *models.Location) error {
+	if location.ParentID == nil {
+		return nil
+	}
+	if *location.ParentID == location.ID {
+		return ErrInvalidLocationParent
+	}
+
+	parent, err := repo.FindByID(*location.ParentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidLocationParent
+	}
+	if err != nil {
+		return err
+	}
+	if parent.OrganizationID != location.OrganizationID {
+		return ErrInvalidLocationParent
+	}
+
+	if location.ID == uuid.Nil {
+		return nil
+	}
+	cycle, err := repo.InSubtree(location.ID, parent.ID)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return ErrInvalidLocationParent
+	}
+	return nil
+}
+
+// ============ LOCATION MOVE ============
+
+// CreateLocationMove - Pindah stok antar lokasi (bin) di satu organisasi. Dua baris pindah_lokasi
+// dengan RefID sama: keluar dari lokasi asal lalu masuk ke lokasi tujuan pada tanggal yang sama,
+// sehingga saldo & nilai organisasi tidak berubah. Item ber-lot: satu pasang baris per lot.
+func (s *InventoryService) CreateLocationMove(req LocationMoveRequest) error {
+	var err error
+	if req.Quantity, req.Lots, err = s.toBaseUnit(s.DB, req.ItemID, req.Unit, req.Quantity, req.Lots); err != nil {
+		return err
+	}
+	if !req.Quantity.IsPositive() {
+		return ErrInvalidMutationQuantity
+	}
+	if sameLocation(req.FromLocationID, req.ToLocationID) {
+		return ErrInvalidLocationMove
+	}
+	if len(req.Lots) > 0 {
+		if err := validateLotQuantities(req.Lots, req.Quantity); err != nil {
+			return err
+		}
+	}
+	if len(req.SerialNumbers) > 0 {
+		if len(req.Lots) > 0 {
+			return ErrSerialLotConflict
+		}
+		if err := checkSerialCount(req.SerialNumbers, req.Quantity); err != nil {
+			return err
+		}
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.ensurePostable(tx, req.OrganizationID, req.ItemID); err != nil {
+			return err
+		}
+		for _, locationID := range []*uuid.UUID{req.FromLocationID, req.ToLocationID} {
+			if err := s.ensureLocation(tx, req.OrganizationID, locationID); err != nil {
+				return err
+			}
+		}
+		if err := s.lockOrgItems(tx, orgItemKey{req.OrganizationID, req.ItemID}); err != nil {
+			return err
+		}
+
+		repo := s.Repo.WithTx(tx)
+		stock, err := repo.GetLocationStock(req.OrganizationID, req.ItemID, req.FromLocationID, req.TxnDate)
+		if err != nil {
+			return err
+		}
+		if stock.Available().LessThan(req.Quantity) {
+			allowed, err := s.allowsInsufficientStock(tx, req.OrganizationID)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				return ErrInsufficientLocationStock
+			}
+		}
+
+		parts := []lotPart{{Amount: req.Quantity.Neg()}}
+		tracked, err := s.itemTracksLots(tx, req.ItemID)
+		if err != nil {
+			return err
+		}
+		if tracked {
+			allocator, err := s.newLotAllocator(tx, req.OrganizationID, req.ItemID, req.TxnDate)
+			if err != nil {
+				return err
+			}
+			if parts, err = allocator.allocate(req.Quantity, req.Lots); err != nil {
+				return err
+			}
+		} else if len(req.Lots) > 0 {
+			return ErrLotNotTracked
+		}
+
+		// Item serialized: nomor seri harus ada di org; unit tetap di org yang sama setelah pindah
+		serials, err := s.transactionSerials(tx, req.OrganizationID, req.ItemID, req.TxnDate, req.Quantity.Neg(), req.SerialNumbers, nil)
+		if err != nil {
+			return err
+		}
+
+		balance, err := repo.GetBalanceAt(req.OrganizationID, req.ItemID, req.TxnDate)
+		if err != nil {
+			return err
+		}
+
+		var legs []*models.Inventory
+		createdAt := time.Now()
+		for _, part := range parts {
+			refID := uuid.New()
+			out := newLocationMoveLeg(req, refID, req.FromLocationID, part, serials)
+			out.Balance = balance.Add(part.Amount)
+			out.CreatedAt = createdAt
+			in := newLocationMoveLeg(req, refID, req.ToLocationID, part, serials)
+			in.Amount = part.Amount.Neg()
+			in.Balance = balance
+			// Leg masuk selalu sesudah leg keluar (urutan recalculation & saldo nomor seri)
+			in.CreatedAt = createdAt.Add(time.Microsecond)
+			createdAt = createdAt.Add(2 * time.Microsecond)
+
+			if err := tx.Create(out).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(in).Error; err != nil {
+				return err
+			}
+			if err := s.createHistory(tx, out, "LOCATION_MOVE_OUT", req.ChangedBy, req.Reason); err != nil {
+				return err
+			}
+			if err := s.createHistory(tx, in, "LOCATION_MOVE_IN", req.ChangedBy, req.Reason); err != nil {
+				return err
+			}
+			legs = append(legs, out, in)
+		}
+
+		log.Printf("LOCATION MOVE: org=%v item=%d qty=%s %s -> %s", req.OrganizationID, req.ItemID,
+			req.Quantity, locationLabel(req.FromLocationID), locationLabel(req.ToLocationID))
+
+		if err := s.recalculate(tx, req.OrganizationID, req.ItemID, req.TxnDate, req.TxnDate); err != nil {
+			return err
+		}
+		for _, leg := range legs {
+			if err := s.emitInventoryEvent(tx, EventLocationMoved, leg.ID, req.ChangedBy, req.Reason); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// newLocationMoveLeg - Satu baris pindah_lokasi (amount = part, dibalik untuk leg masuk)
+func newLocationMoveLeg(req LocationMoveRequest, refID uuid.UUID, locationID *uuid.UUID, part lotPart, serials []string) *models.Inventory {
+	return &models.Inventory{
+		OrganizationID: req.OrganizationID,
+		ItemID:         req.ItemID,
+		TxnDate:        req.TxnDate,
+		Amount:         part.Amount,
+		Type:           models.InventoryTypeLocationMove,
+		RefID:          &refID,
+		LocationID:     locationID,
+		LotNumber:      part.LotNumber,
+		ExpiryDate:     part.ExpiryDate,
+		SerialNumbers:  serials,
+		Notes:          req.Notes,
+		CreatedBy:      req.ChangedBy,
+	}
+}
+
+// isLocationMoveLeg - Baris pindah lokasi yang bisa dicari pasangannya (org yang sama)
+func isLocationMoveLeg(inv *models.Inventory) bool {
+	return inv.Type == models.InventoryTypeLocationMove && inv.RefID != nil
+}
+
+// sameLocation - Dua lokasi sama (nil = belum ditempatkan)
+func sameLocation(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// locationLabel - ID lokasi untuk log / error (nil = unassigned)
+func locationLabel(locationID *uuid.UUID) string {
+	if locationID == nil {
+		return unassignedLocation
+	}
+	return locationID.String()
+}
+
+// ============ VALIDATION (di dalam transaksi write path) ============
+
+// ensureLocation - Lokasi posting (opsional) harus ada, milik organisasi posting, dan aktif
+func (s *InventoryService) ensureLocation(tx *gorm.DB, orgID uuid.UUID, locationID *uuid.UUID) error {
+	if locationID == nil {
+		return nil
+	}
+
+	var location models.Location
+	err := tx.Select("id", "organization_id", "is_active").Where("id = ?", *locationID).Take(&location).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && location.OrganizationID != orgID) {
+		return ErrLocationNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !location.IsActive {
+		return ErrLocationInactive
+	}
+	return nil
+}
+
+// isLocationError - Error validasi lokasi posting (bukan error database)
+func isLocationError(err error) bool {
+	return errors.Is(err, ErrLocationNotFound) || errors.Is(err, ErrLocationInactive)
+}
+
+// ============ PROJECTION (di dalam transaksi write path) ============
+
+// enforceLocationStock - Dipanggil chokepoint recalculation: tulis ulang location_balances, lalu
+// saldo berjalan per lokasi (termasuk yang belum ditempatkan) diperlakukan seperti saldo org
+// terhadap policy stok negatif. Org+item tanpa lokasi dilewati (saldo = saldo org).
+func (s *InventoryService) enforceLocationStock(tx *gorm.DB, orgID uuid.UUID, itemID uint, fromDate time.Time) error {
+	repo := s.Repo.WithTx(tx)
+	if err := repo.RefreshLocationBalances(tx, orgID, itemID); err != nil {
+		return err
+	}
+	located, err := repo.HasLocationPostings(tx, orgID, itemID)
+	if err != nil || !located {
+		return err
+	}
+
+	policy, err := s.negativeStockPolicy(tx, orgID)
+	if err != nil {
+		return err
+	}
+	if policy == models.NegativeStockAllow {
+		return nil
+	}
+
+	violation, err := repo.FindLocationViolation(tx, orgID, itemID, fromDate)
+	if err != nil || violation == nil {
+		return err
+	}
+
+	label := unassignedLocation
+	if violation.LocationID != nil {
+		var location models.Location
+		if err := tx.Select("code").Where("id = ?", *violation.LocationID).Take(&location).Error; err != nil {
+			return err
+		}
+		label = location.Code
+	}
+	negative := fmt.Errorf("%w %s on %s (balance %s)", ErrInsufficientLocationStock, label,
+		violation.TxnDate.Format(time.RFC3339), violation.Balance)
+
+	if policy == models.NegativeStockWarn {
+		log.Printf("⚠️  NEGATIVE LOCATION STOCK (warn): org=%v item=%d: %v", orgID, itemID, negative)
+		return nil
+	}
+	return negative
+}
+
+// ============ SUMMARY ============
+
+// GetOrganizationSummary - Get org summary (handled in repo). Scope lokasi: roll-up subtree
+// atau drill-down per lokasi; lokasi harus milik organisasi.
+func (s *InventoryService) GetOrganizationSummary(orgID uuid.UUID, scope repositories.LocationScope) ([]map[string]interface{}, error) {
+	if scope.LocationID != nil {
+		var location models.Location
+		err := s.DB.Select("id", "organization_id").Where("id = ?", *scope.LocationID).Take(&location).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && location.OrganizationID != orgID) {
+			return nil, ErrLocationNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.Repo.GetOrganizationSummary(orgID, scope)
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+)
+
+var (
+	ErrLotNotTracked         = errors.New("item does not track lots")
+	ErrLotRequired           = errors.New("lot_number is required for incoming postings of lot-tracked items")
+	ErrLotExpiryMismatch     = errors.New("expiry_date does not match the existing lot")
+	ErrInvalidLotAllocation  = errors.New("lots must be unique, with positive quantities adding up to the outgoing quantity")
+	ErrInsufficientLotStock  = errors.New("insufficient stock in lot")
+	ErrLotOpnameNotSupported = errors.New("stock opname is not supported for lot-tracked items")
+	ErrLotTrackingLocked     = errors.New("track_lots cannot change once the item has postings")
+)
+
+// ============ REQUEST STRUCTS ============
+
+// LotQuantity - Qty yang diambil dari satu lot (baris keluar)
+type LotQuantity struct {
+	LotNumber string
+	Quantity  decimal.Decimal
+}
+
+// ExpiringLotsRequest - Filter laporan lot yang kedaluwarsa dalam Days hari (termasuk yang sudah lewat)
+type ExpiringLotsRequest struct {
+	OrganizationID uuid.UUID
+	ItemID         uint
+	Days           int
+	Page           int
+	Limit          int
+}
+
+// ExpiringLot - Baris laporan expiring + sisa hari
+type ExpiringLot struct {
+	repositories.ExpiringLot
+	DaysToExpiry int  `json:"days_to_expiry"`
+	Expired      bool `json:"expired"`
+}
+
+// ============ PUBLIC METHODS ============
+
+// GetLotBalances - Saldo per lot org+item (urut FEFO)
+func (s *InventoryService) GetLotBalances(orgID uuid.UUID, itemID uint) ([]models.LotBalance, error) {
+	return s.Repo.GetLotBalances(orgID, itemID)
+}
+
+// GetExpiringLots - Lot bersaldo yang expiry_date-nya dalam req.Days hari dari hari ini
+func (s *InventoryService) GetExpiringLots(req ExpiringLotsRequest) ([]ExpiringLot, int64, error) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	lots, total, err := s.Repo.GetExpiringLots(repositories.ExpiringLotFilter{
+		OrganizationID: req.OrganizationID,
+		ItemID:         req.ItemID,
+		Before:         today.AddDate(0, 0, req.Days),
+		Page:           req.Page,
+		Limit:          req.Limit,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]ExpiringLot, len(lots))
+	for i, lot := range lots {
+		days := int(lot.ExpiryDate.Sub(today).Hours() / 24)
+		result[i] = ExpiringLot{ExpiringLot: lot, DaysToExpiry: days, Expired: days < 0}
+	}
+	return result, total, nil
+}
+
+// ============ VALIDATION ============
+
+// validateLotRequest - Validasi field lot tanpa database (tracked/tidaknya item dicek saat posting)
+func validateLotRequest(req CreateTransactionRequest) error {
+	if req.ExpiryDate != nil && req.LotNumber == nil {
+		return ErrLotRequired
+	}
+	if len(req.Lots) == 0 {
+		return nil
+	}
+	if !req.Amount.IsNegative() || req.LotNumber != nil {
+		return ErrInvalidLotAllocation
+	}
+	return validateLotQuantities(req.Lots, req.Amount.Neg())
+}
+
+// validateLotQuantities - Lot unik, qty positif, total = qty keluar
+func validateLotQuantities(lots []LotQuantity, quantity decimal.Decimal) error {
+	seen := make(map[string]bool, len(lots))
+	total := decimal.Zero
+	for _, lot := range lots {
+		if lot.LotNumber == "" || !lot.Quantity.IsPositive() || !validQuantity(lot.Quantity) || seen[lot.LotNumber] {
+			return ErrInvalidLotAllocation
+		}
+		seen[lot.LotNumber] = true
+		total = total.Add(lot.Quantity)
+	}
+	if !total.Equal(quantity) {
+		return ErrInvalidLotAllocation
+	}
+	return nil
+}
+
+// ============ ALLOCATION (di dalam transaksi write path) ============
+
+// lotPart - Satu baris ledger hasil alokasi lot (item tanpa lot: satu part tanpa lot)
+type lotPart struct {
+	LotNumber  *string
+	ExpiryDate *time.Time
+	Amount     decimal.Decimal
+}
+
+// lotAllocator - Sisa qty per lot selama satu operasi, urut FEFO
+type lotAllocator struct {
+	lots  []*lotStock
+	byLot map[string]*lotStock
+}
+
+type lotStock struct {
+	lotNumber  string
+	expiryDate *time.Time
+	available  decimal.Decimal
+}
+
+// newLotAllocator - Lot org+item yang bisa dipakai pada tanggal posting
+func (s *InventoryService) newLotAllocator(tx *gorm.DB, orgID uuid.UUID, itemID uint, at time.Time) (*lotAllocator, error) {
+	stocks, err := s.Repo.WithTx(tx).GetLotStocks(orgID, itemID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	allocator := &lotAllocator{byLot: make(map[string]*lotStock, len(stocks))}
+	for _, stock := range stocks {
+		lot := &lotStock{lotNumber: stock.LotNumber, expiryDate: stock.ExpiryDate, available: stock.Available()}
+		allocator.lots = append(allocator.lots, lot)
+		allocator.byLot[lot.lotNumber] = lot
+	}
+	return allocator, nil
+}
+
+// receive - Lot masuk di operasi yang sama (batch) ikut bisa dialokasikan baris berikutnya
+func (a *lotAllocator) receive(lotNumber string, expiryDate *time.Time, quantity decimal.Decimal) {
+	if lot, ok := a.byLot[lotNumber]; ok {
+		lot.available = lot.available.Add(quantity)
+		return
+	}
+	lot := &lotStock{lotNumber: lotNumber, expiryDate: expiryDate, available: quantity}
+	a.lots = append(a.lots, lot)
+	a.byLot[lotNumber] = lot
+	sort.SliceStable(a.lots, func(i, j int) bool {
+		left, right := a.lots[i].expiryDate, a.lots[j].expiryDate
+		return left != nil && (right == nil || left.Before(*right))
+	})
+}
+
+// allocate - Ambil quantity dari lot yang diminta, atau FEFO jika requested kosong
+func (a *lotAllocator) allocate(quantity decimal.Decimal, requested []LotQuantity) ([]lotPart, error) {
+	var parts []lotPart
+	take := func(lot *lotStock, qty decimal.Decimal) {
+		lot.available = lot.available.Sub(qty)
+		lotNumber := lot.lotNumber
+		parts = append(parts, lotPart{LotNumber: &lotNumber, ExpiryDate: lot.expiryDate, Amount: qty.Neg()})
+	}
+
+	if len(requested) > 0 {
+		for _, req := range requested {
+			lot, ok := a.byLot[req.LotNumber]
+			if !ok || lot.available.LessThan(req.Quantity) {
+				return nil, fmt.Errorf("%w %s", ErrInsufficientLotStock, req.LotNumber)
+			}
+			take(lot, req.Quantity)
+		}
+		return parts, nil
+	}
+
+	left := quantity
+	for _, lot := range a.lots {
+		if left.IsZero() {
+			break
+		}
+		if !lot.available.IsPositive() {
+			continue
+		}
+		qty := decimal.Min(left, lot.available)
+		take(lot, qty)
+		left = left.Sub(qty)
+	}
+	if left.IsPositive() {
+		return nil, fmt.Errorf("%w: %s short", ErrInsufficientLotStock, left)
+	}
+	return parts, nil
+}
+
+// isLotError - Error lot dari input (bukan error database), dilaporkan per baris batch/import
+func isLotError(err error) bool {
+	for _, target := range []error{ErrLotNotTracked, ErrLotRequired, ErrLotExpiryMismatch, ErrInsufficientLotStock} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// itemTracksLots - Flag track_lots item (item tidak ditemukan = tidak)
+func (s *InventoryService) itemTracksLots(tx *gorm.DB, itemID uint) (bool, error) {
+	var items []models.Item
+	if err := tx.Select("track_lots").Where("id = ?", itemID).Limit(1).Find(&items).Error; err != nil {
+		return false, err
+	}
+	return len(items) > 0 && items[0].TrackLots, nil
+}
+
+// transactionLots - Pecah posting menjadi baris per lot. Masuk: satu lot (wajib untuk item ber-lot).
+// Keluar: lot yang diminta atau FEFO. allocator nil = dibuat dari saldo lot pada tanggal posting.
+func (s *InventoryService) transactionLots(tx *gorm.DB, req CreateTransactionRequest, allocator *lotAllocator) ([]lotPart, error) {
+	tracked, err := s.itemTracksLots(tx, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if !tracked {
+		if req.LotNumber != nil || req.ExpiryDate != nil || len(req.Lots) > 0 {
+			return nil, ErrLotNotTracked
+		}
+		return []lotPart{{Amount: req.Amount}}, nil
+	}
+
+	if req.Amount.IsPositive() {
+		if req.LotNumber == nil || *req.LotNumber == "" {
+			return nil, ErrLotRequired
+		}
+		expiryDate, err := s.resolveLotExpiry(tx, req.ItemID, *req.LotNumber, req.ExpiryDate)
+		if err != nil {
+			return nil, err
+		}
+		if allocator != nil {
+			allocator.receive(*req.LotNumber, expiryDate, req.Amount)
+		}
+		return []lotPart{{LotNumber: req.LotNumber, ExpiryDate: expiryDate, Amount: req.Amount}}, nil
+	}
+
+	if allocator == nil {
+		allocator, err = s.newLotAllocator(tx, req.OrganizationID, req.ItemID, req.TxnDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	requested := req.Lots
+	if len(requested) == 0 && req.LotNumber != nil {
+		requested = []LotQuantity{{LotNumber: *req.LotNumber, Quantity: req.Amount.Neg()}}
+	}
+	return allocator.allocate(req.Amount.Neg(), requested)
+}
+
+// resolveLotExpiry - Lot yang sudah ada memakai expiry yang sama (kosong = ikut expiry lot)
+func (s *InventoryService) resolveLotExpiry(tx *gorm.DB, itemID uint, lotNumber string, expiryDate *time.Time) (*time.Time, error) {
+	if expiryDate != nil {
+		date := time.Date(expiryDate.Year(), expiryDate.Month(), expiryDate.Day(), 0, 0, 0, 0, time.UTC)
+		expiryDate = &date
+	}
+
+	existing, found, err := s.Repo.WithTx(tx).GetLotExpiry(itemID, lotNumber)
+	if err != nil || !found {
+		return expiryDate, err
+	}
+	if expiryDate == nil {
+		return existing, nil
+	}
+	if existing == nil || !sameDate(*existing, *expiryDate) {
+		return nil, ErrLotExpiryMismatch
+	}
+	return existing, nil
+}
+
+func sameDate(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// applyLotPart - Salin lot hasil alokasi ke baris ledger
+func applyLotPart(inv *models.Inventory, part lotPart) {
+	inv.Amount = part.Amount
+	inv.LotNumber = part.LotNumber
+	inv.ExpiryDate = part.ExpiryDate
+}
+
+// ============ PROJECTION (di dalam transaksi write path) ============
+
+// refreshLotBalances - Tulis ulang lot_balances org+item (item tanpa lot dilewati)
+func (s *InventoryService) refreshLotBalances(tx *gorm.DB, orgID uuid.UUID, itemID uint) (bool, error) {
+	tracked, err := s.itemTracksLots(tx, itemID)
+	if err != nil || !tracked {
+		return false, err
+	}
+	return true, s.Repo.RefreshLotBalances(tx, orgID, itemID)
+}
+
+// enforceLotStock - Dipanggil chokepoint recalculation: saldo berjalan setiap lot mulai fromDate
+// tidak boleh minus (posting backdated bisa menghabiskan lot yang baru diterima lagi belakangan),
+// apa pun policy stok negatif organisasi
+func (s *InventoryService) enforceLotStock(tx *gorm.DB, orgID uuid.UUID, itemID uint, fromDate time.Time) error {
+	tracked, err := s.refreshLotBalances(tx, orgID, itemID)
+	if err != nil || !tracked {
+		return err
+	}
+
+	violation, err := s.Repo.WithTx(tx).FindLotViolation(tx, orgID, itemID, fromDate)
+	if err != nil || violation == nil {
+		return err
+	}
+	return fmt.Errorf("%w %s", ErrInsufficientLotStock, violation.LotNumber)
+}
@@ -0,0 +1,498 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+)
+
+var (
+	ErrMutationCounterpartNotFound = errors.New("mutation counterpart not found")
+	ErrMutationDirectionChanged    = errors.New("mutation amount cannot change direction")
+	ErrInvalidMutationQuantity     = errors.New("mutation quantity must be positive")
+)
+
+// ============ MUTATION LEGS ============
+// Mutasi selalu terdiri dari dua baris (leg) dengan RefID yang sama:
+// leg keluar (amount negatif) di FromOrganizationID dan leg masuk (amount positif)
+// di ToOrganizationID. Update, delete dan rollback harus selalu menyentuh keduanya.
+
+// isMutationLeg - Baris mutasi yang bisa dicari pasangannya
+func isMutationLeg(inv *models.Inventory) bool {
+	return inv.Type == models.InventoryTypeMutation && inv.RefID != nil &&
+		inv.FromOrganizationID != nil && inv.ToOrganizationID != nil
+}
+
+// counterpartOrganizationID - Organisasi dari leg pasangan
+func counterpartOrganizationID(inv *models.Inventory) uuid.UUID {
+	if inv.OrganizationID == *inv.FromOrganizationID {
+		return *inv.ToOrganizationID
+	}
+	return *inv.FromOrganizationID
+}
+
+// mutationLockKeys - Key lock untuk transaksi; mutasi mengunci org asal & tujuan
+func mutationLockKeys(inv *models.Inventory) []orgItemKey {
+	keys := []orgItemKey{{inv.OrganizationID, inv.ItemID}}
+	if isMutationLeg(inv) {
+		keys = append(keys, orgItemKey{counterpartOrganizationID(inv), inv.ItemID})
+	}
+	return keys
+}
+
+// lockTransactionLegs - Load transaksi, kunci org+item-nya (mutasi: org asal & tujuan), lalu reload
+// setelah lock didapat. Return leg pasangan untuk mutasi & pindah lokasi (nil untuk transaksi biasa)
+func (s *InventoryService) lockTransactionLegs(tx *gorm.DB, inventory *models.Inventory, inventoryID uuid.UUID) (*models.Inventory, error) {
+	if err := findInventory(tx, inventory, inventoryID); err != nil {
+		return nil, err
+	}
+	if err := s.lockOrgItems(tx, mutationLockKeys(inventory)...); err != nil {
+		return nil, err
+	}
+
+	// Bisa saja sudah diubah/dihapus oleh writer lain selama menunggu lock
+	*inventory = models.Inventory{}
+	if err := findInventory(tx, inventory, inventoryID); err != nil {
+		return nil, err
+	}
+	if !isMutationLeg(inventory) && !isLocationMoveLeg(inventory) {
+		return nil, nil
+	}
+
+	return s.findMutationCounterpart(tx, inventory)
+}
+
+// findInventory - Load transaksi aktif; ErrInventoryNotFound jika tidak ada / sudah dihapus
+func findInventory(tx *gorm.DB, inventory *models.Inventory, inventoryID uuid.UUID) error {
+	err := tx.First(inventory, inventoryID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInventoryNotFound
+	}
+	return err
+}
+
+// findMutationCounterpart - Leg pasangan yang masih aktif (RefID & type sama, arah berlawanan;
+// mutasi di org lawan, pindah lokasi di org yang sama)
+func (s *InventoryService) findMutationCounterpart(tx *gorm.DB, leg *models.Inventory) (*models.Inventory, error) {
+	orgID := leg.OrganizationID
+	if isMutationLeg(leg) {
+		orgID = counterpartOrganizationID(leg)
+	}
+	query := tx.Where("ref_id = ? AND item_id = ? AND organization_id = ? AND type = ? AND id <> ? AND deleted_at IS NULL",
+		leg.RefID, leg.ItemID, orgID, leg.Type, leg.ID)
+	if leg.Amount.IsNegative() {
+		query = query.Where("amount > 0")
+	} else {
+		query = query.Where("amount < 0")
+	}
+
+	var counterpart models.Inventory
+	err := query.Order("created_at DESC").Take(&counterpart).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMutationCounterpartNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &counterpart, nil
+}
+
+// deleteMutation - Soft delete kedua leg + history DELETE_BEFORE per leg + recalc kedua org
+func (s *InventoryService) deleteMutation(tx *gorm.DB, legs []models.Inventory, deletedBy string, reason *string) error {
+	for i := range legs {
+		if err := s.createHistory(tx, &legs[i], "DELETE_BEFORE", deletedBy, reason); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for i := range legs {
+		legs[i].DeletedBy = &deletedBy
+		legs[i].DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+		if err := tx.Save(&legs[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	log.Printf("MUTATION DELETE: ref=%v legs=%d", legs[0].RefID, len(legs))
+
+	for _, leg := range legs {
+		if err := s.recalculate(tx, leg.OrganizationID, leg.ItemID, leg.TxnDate, leg.TxnDate); err != nil {
+			return err
+		}
+	}
+	for _, leg := range legs {
+		if err := s.emitInventoryEvent(tx, EventTransactionDeleted, leg.ID, deletedBy, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateMutation - Ganti kedua leg (mutasi / pindah lokasi) dengan leg baru (amount berlawanan, tanggal sama),
+// history UPDATE_BEFORE/UPDATE_AFTER per leg, recalc kedua org dari tanggal terawal
+func (s *InventoryService) updateMutation(tx *gorm.DB, existing, counterpart models.Inventory, req UpdateTransactionRequest) error {
+	if req.Amount.IsZero() || req.Amount.IsPositive() != existing.Amount.IsPositive() {
+		return ErrMutationDirectionChanged
+	}
+
+	legs := []models.Inventory{existing, counterpart}
+	amounts := []decimal.Decimal{req.Amount, req.Amount.Neg()}
+
+	for i := range legs {
+		if err := s.createHistory(tx, &legs[i], "UPDATE_BEFORE", req.ChangedBy, req.Reason); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for i := range legs {
+		legs[i].DeletedBy = &req.ChangedBy
+		legs[i].DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+		if err := tx.Save(&legs[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	newLegs := make([]models.Inventory, len(legs))
+	createdAt := time.Now()
+	for i, leg := range legs {
+		prevBalance, err := s.getBalanceBeforeDate(tx, leg.OrganizationID, leg.ItemID, req.TxnDate, leg.ID)
+		if err != nil {
+			return err
+		}
+
+		newLeg := models.Inventory{
+			OrganizationID:     leg.OrganizationID,
+			ItemID:             leg.ItemID,
+			TxnDate:            req.TxnDate,
+			Amount:             amounts[i],
+			Balance:            prevBalance.Add(amounts[i]),
+			Type:               leg.Type,
+			RefID:              leg.RefID,
+			TargetID:           req.TargetID,
+			Source:             leg.Source,
+			FromOrganizationID: leg.FromOrganizationID,
+			ToOrganizationID:   leg.ToOrganizationID,
+			LocationID:         leg.LocationID,
+			LotNumber:          leg.LotNumber,
+			ExpiryDate:         leg.ExpiryDate,
+			SerialNumbers:      req.SerialNumbers,
+			PageCode:           leg.PageCode,
+			Notes:              req.Notes,
+			CreatedBy:          req.ChangedBy,
+			CreatedAt:          createdAt,
+		}
+		// Leg masuk sesudah leg keluar (pindah lokasi: keduanya di org yang sama)
+		if amounts[i].IsPositive() {
+			newLeg.CreatedAt = createdAt.Add(time.Microsecond)
+		}
+		if err := tx.Create(&newLeg).Error; err != nil {
+			return err
+		}
+		newLegs[i] = newLeg
+	}
+
+	earliestDate, latestDate := existing.TxnDate, req.TxnDate
+	if req.TxnDate.Before(earliestDate) {
+		earliestDate, latestDate = req.TxnDate, existing.TxnDate
+	}
+
+	log.Printf("MUTATION UPDATE: ref=%v amount=%s, recalculating both orgs from %v",
+		existing.RefID, req.Amount, earliestDate)
+
+	for _, leg := range legs {
+		if err := s.recalculate(tx, leg.OrganizationID, leg.ItemID, earliestDate, latestDate); err != nil {
+			return err
+		}
+	}
+
+	for i := range newLegs {
+		if err := s.createHistory(tx, &newLegs[i], "UPDATE_AFTER", req.ChangedBy, req.Reason); err != nil {
+			return err
+		}
+		if err := s.pairUpdateHistories(tx, legs[i].ID, newLegs[i].ID); err != nil {
+			return err
+		}
+		if err := s.emitTransactionUpdated(tx, &legs[i], newLegs[i].ID, req.ChangedBy, req.Reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ============ ROLLBACK RECONCILIATION ============
+
+// rollbackLockKeys - Org yang di-rollback + org pasangan dari leg mutasi di snapshot maupun kondisi sekarang
+func (s *InventoryService) rollbackLockKeys(tx *gorm.DB, history *models.InventoryHistory, snapshotItems []models.SnapshotItem) ([]orgItemKey, error) {
+	keys := []orgItemKey{{history.OrganizationID, history.ItemID}}
+
+	snapshotIDs := make([]uuid.UUID, 0)
+	for _, item := range snapshotItems {
+		if item.Type == string(models.InventoryTypeMutation) {
+			snapshotIDs = append(snapshotIDs, item.InventoryID)
+		}
+	}
+
+	var legs []models.Inventory
+	if err := tx.Where(
+		"organization_id = ? AND item_id = ? AND txn_date >= ? AND type = ? AND deleted_at IS NULL",
+		history.OrganizationID, history.ItemID, history.SnapshotFromDate, models.InventoryTypeMutation,
+	).Find(&legs).Error; err != nil {
+		return nil, err
+	}
+	if len(snapshotIDs) > 0 {
+		var snapshotLegs []models.Inventory
+		if err := tx.Unscoped().Where("id IN ?", snapshotIDs).Find(&snapshotLegs).Error; err != nil {
+			return nil, err
+		}
+		legs = append(legs, snapshotLegs...)
+	}
+
+	for i := range legs {
+		if isMutationLeg(&legs[i]) {
+			keys = append(keys, orgItemKey{counterpartOrganizationID(&legs[i]), history.ItemID})
+		}
+	}
+	return keys, nil
+}
+
+// mutationLegsByRef - Index leg mutasi per RefID (hanya yang punya org asal/tujuan)
+func mutationLegsByRef(inventories []models.Inventory) map[uuid.UUID]models.Inventory {
+	legs := make(map[uuid.UUID]models.Inventory)
+	for _, inv := range inventories {
+		if isMutationLeg(&inv) {
+			legs[*inv.RefID] = inv
+		}
+	}
+	return legs
+}
+
+// counterpartChange - Perubahan yang harus diterapkan ke leg pasangan di org lain
+type counterpartChange struct {
+	RefID   uuid.UUID
+	Current *models.Inventory // leg pasangan saat ini (nil = tidak ada)
+	Desired *models.Inventory // leg hasil rollback di org yang di-rollback (nil = leg dihapus)
+}
+
+// reconcileMutationCounterparts - Setelah satu org di-rollback, samakan leg pasangan di org lain.
+// replaced = baris aktif sebelum rollback, restored = baris hasil restore snapshot. Semua org pasangan
+// sudah dikunci applyRollback (locked); di sini hanya dipastikan.
+func (s *InventoryService) reconcileMutationCounterparts(tx *gorm.DB, itemID uint,
+	replaced, restored []models.Inventory, locked map[int64]bool, changedBy string, reason *string, preview *RollbackPreview) error {
+
+	before := mutationLegsByRef(replaced)
+	after := mutationLegsByRef(restored)
+
+	changes := make(map[uuid.UUID][]counterpartChange) // per org pasangan
+	orgOrder := make([]uuid.UUID, 0)
+
+	collect := func(refID uuid.UUID, cur, des *models.Inventory) error {
+		if cur != nil && des != nil && cur.Amount.Equal(des.Amount) && cur.TxnDate.Equal(des.TxnDate) {
+			return nil // leg tidak berubah, pasangan tetap valid
+		}
+
+		leg := des
+		if leg == nil {
+			leg = cur
+		}
+		otherOrg := counterpartOrganizationID(leg)
+
+		var current *models.Inventory
+		var counterpart models.Inventory
+		err := tx.Where("ref_id = ? AND item_id = ? AND organization_id = ? AND type = ? AND deleted_at IS NULL",
+			refID, itemID, otherOrg, models.InventoryTypeMutation).
+			Order("created_at DESC").Take(&counterpart).Error
+		if err == nil {
+			current = &counterpart
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if current == nil && des == nil {
+			return nil
+		}
+		if _, ok := changes[otherOrg]; !ok {
+			orgOrder = append(orgOrder, otherOrg)
+		}
+		changes[otherOrg] = append(changes[otherOrg], counterpartChange{RefID: refID, Current: current, Desired: des})
+		return nil
+	}
+
+	for refID, cur := range before {
+		cur := cur
+		var des *models.Inventory
+		if leg, ok := after[refID]; ok {
+			des = &leg
+		}
+		if err := collect(refID, &cur, des); err != nil {
+			return err
+		}
+	}
+	for refID, des := range after {
+		if _, ok := before[refID]; ok {
+			continue
+		}
+		des := des
+		if err := collect(refID, nil, &des); err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(orgOrder, func(i, j int) bool {
+		return repositories.OrgItemLockKey(orgOrder[i], itemID) < repositories.OrgItemLockKey(orgOrder[j], itemID)
+	})
+	for _, orgID := range orgOrder {
+		if !locked[repositories.OrgItemLockKey(orgID, itemID)] {
+			return fmt.Errorf("rollback counterpart organization %s is not locked", orgID)
+		}
+		if err := s.applyCounterpartChanges(tx, orgID, itemID, changes[orgID], changedBy, reason, preview); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyCounterpartChanges - Ganti leg pasangan di satu org, recalc, dan tulis history ROLLBACK untuk org tsb
+func (s *InventoryService) applyCounterpartChanges(tx *gorm.DB, orgID uuid.UUID, itemID uint,
+	changes []counterpartChange, changedBy string, reason *string, preview *RollbackPreview) error {
+
+	fromDate, throughDate := time.Time{}, time.Time{}
+	for _, change := range changes {
+		for _, leg := range []*models.Inventory{change.Current, change.Desired} {
+			if leg == nil {
+				continue
+			}
+			if fromDate.IsZero() || leg.TxnDate.Before(fromDate) {
+				fromDate = leg.TxnDate
+			}
+			if leg.TxnDate.After(throughDate) {
+				throughDate = leg.TxnDate
+			}
+		}
+	}
+
+	openingBalance, err := s.getBalanceBeforeDate(tx, orgID, itemID, fromDate, uuid.Nil)
+	if err != nil {
+		return err
+	}
+	beforeItems, err := s.takeSnapshot(tx, orgID, itemID, fromDate)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var recreatedIDs []uuid.UUID
+	for _, change := range changes {
+		if change.Current != nil {
+			preview.SoftDeleted = append(preview.SoftDeleted, toSnapshotItems([]models.Inventory{*change.Current})...)
+			if err := tx.Model(change.Current).Updates(map[string]interface{}{
+				"deleted_at": now,
+				"deleted_by": changedBy + " (rollback_delete)",
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if change.Desired == nil {
+			continue
+		}
+
+		refID := change.RefID
+		var locationID *uuid.UUID
+		if change.Current != nil {
+			locationID = change.Current.LocationID
+		}
+		leg := models.Inventory{
+			OrganizationID:     orgID,
+			ItemID:             itemID,
+			TxnDate:            change.Desired.TxnDate,
+			Amount:             change.Desired.Amount.Neg(),
+			Type:               models.InventoryTypeMutation,
+			RefID:              &refID,
+			FromOrganizationID: change.Desired.FromOrganizationID,
+			ToOrganizationID:   change.Desired.ToOrganizationID,
+			LocationID:         locationID,
+			LotNumber:          change.Desired.LotNumber,
+			ExpiryDate:         change.Desired.ExpiryDate,
+			SerialNumbers:      change.Desired.SerialNumbers,
+			Notes:              change.Desired.Notes,
+			CreatedBy:          changedBy + " (rollback_restore)",
+			CreatedAt:          time.Now(),
+		}
+		if err := tx.Create(&leg).Error; err != nil {
+			return err
+		}
+		recreatedIDs = append(recreatedIDs, leg.ID)
+	}
+
+	log.Printf("ROLLBACK COUNTERPART: org=%v item=%d, %d mutation legs replaced from %v",
+		orgID, itemID, len(changes), fromDate)
+
+	if err := s.recalculate(tx, orgID, itemID, fromDate, throughDate); err != nil {
+		return err
+	}
+
+	afterItems, err := s.takeSnapshot(tx, orgID, itemID, fromDate)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range afterItems {
+		for _, id := range recreatedIDs {
+			if item.InventoryID == id {
+				preview.Recreated = append(preview.Recreated, item)
+			}
+		}
+	}
+	preview.Balances = append(preview.Balances, balanceTimeline(orgID, openingBalance, beforeItems, afterItems)...)
+
+	beforeJSON, err := json.Marshal(beforeItems)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := json.Marshal(afterItems)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Create(&models.InventoryHistory{
+		OrganizationID:   orgID,
+		ItemID:           itemID,
+		SnapshotFromDate: fromDate,
+		DataBefore:       json.RawMessage(beforeJSON),
+		DataAfter:        json.RawMessage(afterJSON),
+		Action:           "ROLLBACK",
+		ChangedBy:        changedBy,
+		Reason:           reason,
+		CreatedAt:        time.Now(),
+	}).Error; err != nil {
+		return err
+	}
+
+	softDeleted := 0
+	for _, change := range changes {
+		if change.Current != nil {
+			softDeleted++
+		}
+	}
+	return emitEvent(tx, EventLedgerRolledBack, orgID, itemID, LedgerRolledBackEvent{
+		HistoryID:        preview.HistoryID,
+		OrganizationID:   orgID,
+		ItemID:           itemID,
+		SnapshotFromDate: fromDate,
+		SoftDeleted:      softDeleted,
+		Recreated:        len(recreatedIDs),
+		ChangedBy:        changedBy,
+		Reason:           reason,
+	})
+}
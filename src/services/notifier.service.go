@@ -0,0 +1,407 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"inventory-ledger/src/models"
+)
+
+// ============ ALERT NOTIFIERS ============
+
+// AlertNotifier - Kanal notifikasi alert stok. Dipanggil dari antrian alert_notifications
+// (at-least-once, satu baris per notifier), jadi notifier yang gagal di-retry sendiri.
+type AlertNotifier interface {
+	Name() string
+	Notify(ctx context.Context, eventType string, alert StockAlertEvent) error
+}
+
+// AlertNotifierSink - EventSink outbox yang mengantrekan StockAlertRaised/StockAlertResolved
+// untuk setiap notifier. Pengiriman dilakukan AlertNotificationDispatcher.Run, jadi SMTP/webhook
+// yang lambat tidak menahan event outbox berikutnya.
+type AlertNotifierSink struct {
+	Dispatcher *AlertNotificationDispatcher
+}
+
+func (AlertNotifierSink) Name() string { return "alert_notifier" }
+
+func (s AlertNotifierSink) Deliver(ctx context.Context, event *models.OutboxEvent) error {
+	if event.EventType != EventStockAlertRaised && event.EventType != EventStockAlertResolved {
+		return nil
+	}
+	return s.Dispatcher.enqueue(ctx, event)
+}
+
+// ============ NOTIFICATION QUEUE ============
+
+// AlertNotificationDispatcher - Kirim notifikasi alert pending ke notifier dengan exponential
+// backoff. Setelah MaxAttempts notifikasi berstatus failed.
+type AlertNotificationDispatcher struct {
+	DB        *gorm.DB
+	Notifiers []AlertNotifier
+
+	BatchSize    int           // default 20
+	PollInterval time.Duration // default 1s
+	Timeout      time.Duration // default 10s, per notifikasi
+	MaxAttempts  int           // default 8
+	BaseBackoff  time.Duration // default 1s
+	MaxBackoff   time.Duration // default 10m
+	Lease        time.Duration // default BatchSize × Timeout + 1m, sebelum notifikasi boleh diklaim ulang
+}
+
+// enqueue - Satu notifikasi per notifier; event yang dikirim ulang outbox tidak digandakan
+func (d *AlertNotificationDispatcher) enqueue(ctx context.Context, event *models.OutboxEvent) error {
+	if len(d.Notifiers) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	notifications := make([]models.AlertNotification, 0, len(d.Notifiers))
+	for _, notifier := range d.Notifiers {
+		notifications = append(notifications, models.AlertNotification{
+			Notifier:      notifier.Name(),
+			EventID:       event.ID,
+			EventType:     event.EventType,
+			Payload:       event.Payload,
+			Status:        models.AlertNotificationPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+
+	return d.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "notifier"}, {Name: "event_id"}},
+		DoNothing: true,
+	}).Create(&notifications).Error
+}
+
+// Run - Loop pengiriman notifikasi pending sampai ctx selesai
+func (d *AlertNotificationDispatcher) Run(ctx context.Context) {
+	log.Printf("Alert notification dispatcher started (%d notifiers)", len(d.Notifiers))
+
+	for {
+		processed, err := d.DispatchOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Alert notification dispatch failed: %v", err)
+		}
+		if err == nil && processed >= d.batchSize() {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Alert notification dispatcher stopped")
+			return
+		case <-time.After(d.pollInterval()):
+		}
+	}
+}
+
+// DispatchOnce - Klaim satu batch notifikasi yang jatuh tempo, kirim di luar transaksi,
+// lalu simpan hasil tiap notifikasi
+func (d *AlertNotificationDispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	notifications, err := d.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	byName := make(map[string]AlertNotifier, len(d.Notifiers))
+	for _, notifier := range d.Notifiers {
+		byName[notifier.Name()] = notifier
+	}
+
+	for i := range notifications {
+		notification := &notifications[i]
+		notifier, configured := byName[notification.Notifier]
+
+		var sendErr error
+		if !configured {
+			sendErr = errors.New("notifier is not configured")
+		} else {
+			sendErr = d.send(ctx, notifier, notification)
+		}
+
+		if err := d.record(notification, sendErr, !configured); err != nil {
+			return i, err
+		}
+	}
+	return len(notifications), nil
+}
+
+// claim - Ambil batch notifikasi (FOR UPDATE SKIP LOCKED) dan majukan next_attempt_at selama
+// lease, lalu commit sebelum notifier dipanggil
+func (d *AlertNotificationDispatcher) claim(ctx context.Context) ([]models.AlertNotification, error) {
+	var notifications []models.AlertNotification
+
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.AlertNotificationPending, now).
+			Order("next_attempt_at, created_at").
+			Limit(d.batchSize()).
+			Find(&notifications).Error
+		if err != nil || len(notifications) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, 0, len(notifications))
+		for _, notification := range notifications {
+			ids = append(ids, notification.ID)
+		}
+		return tx.Model(&models.AlertNotification{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(d.lease())).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// send - Panggil notifier dengan batas waktu per notifikasi
+func (d *AlertNotificationDispatcher) send(ctx context.Context, notifier AlertNotifier, notification *models.AlertNotification) error {
+	var alert StockAlertEvent
+	if err := json.Unmarshal(notification.Payload, &alert); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout())
+	defer cancel()
+	return notifier.Notify(ctx, notification.EventType, alert)
+}
+
+// record - Simpan hasil attempt: delivered, jadwal retry, atau failed (attempts habis / notifier dihapus dari config)
+func (d *AlertNotificationDispatcher) record(notification *models.AlertNotification, sendErr error, giveUp bool) error {
+	now := time.Now()
+	attempts := notification.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts}
+
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.AlertNotificationDelivered
+		updates["delivered_at"] = now
+		updates["last_error"] = nil
+	case giveUp || attempts >= d.maxAttempts():
+		log.Printf("☠️  Alert notification %v (%s via %s) failed after %d attempts: %v",
+			notification.ID, notification.EventType, notification.Notifier, attempts, sendErr)
+		updates["status"] = models.AlertNotificationFailed
+		updates["last_error"] = sendErr.Error()
+	default:
+		log.Printf("⚠️  Alert notification %v (%s via %s) attempt %d failed: %v",
+			notification.ID, notification.EventType, notification.Notifier, attempts, sendErr)
+		updates["next_attempt_at"] = now.Add(exponentialBackoff(d.BaseBackoff, d.MaxBackoff, attempts))
+		updates["last_error"] = sendErr.Error()
+	}
+
+	return d.DB.Model(notification).Updates(updates).Error
+}
+
+func (d *AlertNotificationDispatcher) batchSize() int {
+	if d.BatchSize <= 0 {
+		return 20
+	}
+	return d.BatchSize
+}
+
+func (d *AlertNotificationDispatcher) pollInterval() time.Duration {
+	if d.PollInterval <= 0 {
+		return time.Second
+	}
+	return d.PollInterval
+}
+
+func (d *AlertNotificationDispatcher) timeout() time.Duration {
+	if d.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return d.Timeout
+}
+
+func (d *AlertNotificationDispatcher) lease() time.Duration {
+	if d.Lease <= 0 {
+		return time.Duration(d.batchSize())*d.timeout() + time.Minute
+	}
+	return d.Lease
+}
+
+func (d *AlertNotificationDispatcher) maxAttempts() int {
+	if d.MaxAttempts <= 0 {
+		return 8
+	}
+	return d.MaxAttempts
+}
+
+// ============ NOTIFIERS ============
+
+// alertSummary - Satu baris ringkasan alert untuk log/subject email
+func alertSummary(eventType string, alert StockAlertEvent) string {
+	return fmt.Sprintf("%s %s: organization %s item %d balance %s (threshold %s)",
+		eventType, alert.AlertType, alert.OrganizationID, alert.ItemID, alert.Balance, alert.Threshold)
+}
+
+// LogNotifier - Tulis alert ke log aplikasi
+type LogNotifier struct{}
+
+func (LogNotifier) Name() string { return "log" }
+
+func (LogNotifier) Notify(ctx context.Context, eventType string, alert StockAlertEvent) error {
+	log.Printf("🔔 %s", alertSummary(eventType, alert))
+	return nil
+}
+
+// SMTPNotifier - Kirim email plain text lewat SMTP (misal MailHog/Mailpit lokal). Auth opsional.
+// Koneksi mengikuti deadline ctx (dispatcher memberi batas per notifikasi), maksimal Timeout.
+type SMTPNotifier struct {
+	Addr    string // host:port
+	From    string
+	To      []string
+	Auth    smtp.Auth
+	Timeout time.Duration // default 10s
+}
+
+func (SMTPNotifier) Name() string { return "smtp" }
+
+func (n SMTPNotifier) Notify(ctx context.Context, eventType string, alert StockAlertEvent) error {
+	body, err := json.MarshalIndent(alert, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	var msg strings.Builder
+	msg.WriteString("From: " + n.From + "\r\n")
+	msg.WriteString("To: " + strings.Join(n.To, ", ") + "\r\n")
+	msg.WriteString("Subject: [Stock Alert] " + alertSummary(eventType, alert) + "\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.Write(body)
+	msg.WriteString("\r\n")
+
+	return n.send(ctx, []byte(msg.String()))
+}
+
+// send - Setara smtp.SendMail, tapi dial dan seluruh percakapan SMTP dibatasi deadline
+func (n SMTPNotifier) send(ctx context.Context, msg []byte) error {
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", n.Addr)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	// ctx dibatalkan (shutdown) memutus koneksi yang sedang menunggu server
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	host, _, err := net.SplitHostPort(n.Addr)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.Auth != nil {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(n.Auth); err != nil {
+				return err
+			}
+		}
+	}
+	if err := client.Mail(n.From); err != nil {
+		return err
+	}
+	for _, to := range n.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// WebhookNotifier - POST alert ke satu URL tetap, ditandatangani seperti webhook subscription.
+// Untuk banyak penerima dengan filter, pakai webhook subscription dengan event StockAlertRaised.
+type WebhookNotifier struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+func (WebhookNotifier) Name() string { return "webhook" }
+
+func (n WebhookNotifier) Notify(ctx context.Context, eventType string, alert StockAlertEvent) error {
+	body, err := json.Marshal(map[string]interface{}{"type": eventType, "data": alert})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, eventType)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	if n.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(n.Secret, timestamp, body))
+	}
+
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("receiver responded %d", resp.StatusCode)
+	}
+	return nil
+}
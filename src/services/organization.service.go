@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+)
+
+var (
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrOrganizationInactive  = errors.New("organization is inactive")
+	ErrOrganizationCodeTaken = errors.New("organization code already exists")
+	ErrInvalidStockPolicy    = errors.New("negative_stock_policy must be allow, warn or block")
+)
+
+// ============ REQUEST STRUCTS ============
+type OrganizationRequest struct {
+	Name string
+	Code string
+
+	// Kosong = default (block) saat create, tidak diubah saat update
+	NegativeStockPolicy models.NegativeStockPolicy
+}
+
+// ============ ORGANIZATION SERVICE ============
+type OrganizationService struct {
+	DB   *gorm.DB
+	Repo *repositories.OrganizationRepository
+}
+
+// GetOrganization - Detail organisasi
+func (s *OrganizationService) GetOrganization(id uuid.UUID) (*models.Organization, error) {
+	org, err := s.Repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	return org, err
+}
+
+// ListOrganizations - List + pencarian code/name
+func (s *OrganizationService) ListOrganizations(filter repositories.MasterDataFilter) ([]models.Organization, int64, error) {
+	return s.Repo.List(filter)
+}
+
+// CreateOrganization - Buat organisasi baru (kode unik)
+func (s *OrganizationService) CreateOrganization(req OrganizationRequest) (*models.Organization, error) {
+	org := &models.Organization{
+		Name:                strings.TrimSpace(req.Name),
+		Code:                strings.TrimSpace(req.Code),
+		IsActive:            true,
+		NegativeStockPolicy: models.NegativeStockBlock,
+	}
+	if req.NegativeStockPolicy != "" {
+		if !isValidStockPolicy(req.NegativeStockPolicy) {
+			return nil, ErrInvalidStockPolicy
+		}
+		org.NegativeStockPolicy = req.NegativeStockPolicy
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		repo := s.Repo.WithTx(tx)
+		taken, err := repo.CodeExists(org.Code, uuid.Nil)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrOrganizationCodeTaken
+		}
+		return repo.Create(org)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("ORGANIZATION CREATED: %s (%s)", org.Code, org.ID)
+	return org, nil
+}
+
+// UpdateOrganization - Ubah name/code (kode tetap unik)
+func (s *OrganizationService) UpdateOrganization(id uuid.UUID, req OrganizationRequest) (*models.Organization, error) {
+	var org *models.Organization
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		repo := s.Repo.WithTx(tx)
+
+		var err error
+		org, err = repo.FindByID(id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOrganizationNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		org.Name = strings.TrimSpace(req.Name)
+		org.Code = strings.TrimSpace(req.Code)
+		if req.NegativeStockPolicy != "" {
+			if !isValidStockPolicy(req.NegativeStockPolicy) {
+				return ErrInvalidStockPolicy
+			}
+			org.NegativeStockPolicy = req.NegativeStockPolicy
+		}
+
+		taken, err := repo.CodeExists(org.Code, org.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrOrganizationCodeTaken
+		}
+		return repo.Save(org)
+	})
+
+	return org, err
+}
+
+// SetOrganizationActive - Nonaktifkan (soft) / aktifkan kembali organisasi.
+// Organisasi nonaktif tidak bisa menerima posting baru, ledger & history tetap utuh.
+func (s *OrganizationService) SetOrganizationActive(id uuid.UUID, active bool) (*models.Organization, error) {
+	org, err := s.GetOrganization(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.SetActive(org, active); err != nil {
+		return nil, err
+	}
+	org.IsActive = active
+
+	log.Printf("ORGANIZATION %s: active=%v", org.Code, active)
+	return org, nil
+}
+
+// isValidStockPolicy - allow / warn / block
+func isValidStockPolicy(policy models.NegativeStockPolicy) bool {
+	switch policy {
+	case models.NegativeStockAllow, models.NegativeStockWarn, models.NegativeStockBlock:
+		return true
+	}
+	return false
+}
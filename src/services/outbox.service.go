@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"inventory-ledger/src/models"
+)
+
+// ============ DOMAIN EVENTS ============
+const (
+	EventTransactionCreated = "TransactionCreated"
+	EventMutationPosted     = "MutationPosted"
+	EventLocationMoved      = "LocationMoved"
+	EventOpnameAdjusted     = "OpnameAdjusted"
+	EventTransactionUpdated = "TransactionUpdated"
+	EventTransactionDeleted = "TransactionDeleted"
+	EventLedgerRolledBack   = "LedgerRolledBack"
+	EventBalanceChanged     = "BalanceChanged"
+	EventStockAlertRaised   = "StockAlertRaised"
+	EventStockAlertResolved = "StockAlertResolved"
+)
+
+// EventTypes - Semua event type yang ditulis ke outbox
+var EventTypes = []string{
+	EventTransactionCreated,
+	EventMutationPosted,
+	EventLocationMoved,
+	EventOpnameAdjusted,
+	EventTransactionUpdated,
+	EventTransactionDeleted,
+	EventLedgerRolledBack,
+	EventBalanceChanged,
+	EventStockAlertRaised,
+	EventStockAlertResolved,
+}
+
+// InventoryEvent - Payload event yang menyangkut satu baris ledger.
+// TransactionCreated, MutationPosted & LocationMoved (satu event per leg), OpnameAdjusted & TransactionDeleted.
+type InventoryEvent struct {
+	InventoryID        uuid.UUID        `json:"inventory_id"`
+	OrganizationID     uuid.UUID        `json:"organization_id"`
+	ItemID             uint             `json:"item_id"`
+	Type               string           `json:"type"`
+	TxnDate            time.Time        `json:"txn_date"`
+	Amount             decimal.Decimal  `json:"amount"`
+	Balance            decimal.Decimal  `json:"balance"`
+	RefID              *uuid.UUID       `json:"ref_id,omitempty"`
+	FromOrganizationID *uuid.UUID       `json:"from_organization_id,omitempty"`
+	ToOrganizationID   *uuid.UUID       `json:"to_organization_id,omitempty"`
+	LocationID         *uuid.UUID       `json:"location_id,omitempty"`
+	PhysicalQty        *decimal.Decimal `json:"physical_qty,omitempty"`
+	SystemQty          *decimal.Decimal `json:"system_qty,omitempty"`
+	Difference         *decimal.Decimal `json:"difference,omitempty"`
+	Notes              *string          `json:"notes,omitempty"`
+	ChangedBy          string           `json:"changed_by"`
+	Reason             *string          `json:"reason,omitempty"`
+}
+
+// TransactionUpdatedEvent - Baris lama (sudah di-soft delete) dan baris pengganti
+type TransactionUpdatedEvent struct {
+	Previous InventoryEvent `json:"previous"`
+	Current  InventoryEvent `json:"current"`
+}
+
+// LedgerRolledBackEvent - Ringkasan rollback satu org+item (org pasangan mutasi mendapat event sendiri)
+type LedgerRolledBackEvent struct {
+	HistoryID        uuid.UUID `json:"history_id"`
+	OrganizationID   uuid.UUID `json:"organization_id"`
+	ItemID           uint      `json:"item_id"`
+	SnapshotFromDate time.Time `json:"snapshot_from_date"`
+	SoftDeleted      int       `json:"soft_deleted"`
+	Recreated        int       `json:"recreated"`
+	ChangedBy        string    `json:"changed_by"`
+	Reason           *string   `json:"reason,omitempty"`
+}
+
+// BalanceChangedEvent - Saldo terakhir org+item berubah setelah recalculation
+type BalanceChangedEvent struct {
+	OrganizationID  uuid.UUID       `json:"organization_id"`
+	ItemID          uint            `json:"item_id"`
+	PreviousBalance decimal.Decimal `json:"previous_balance"`
+	Balance         decimal.Decimal `json:"balance"`
+	Delta           decimal.Decimal `json:"delta"`
+	FromDate        time.Time       `json:"from_date"`
+}
+
+// ============ EMIT (di dalam transaksi write path) ============
+
+// emitEvent - Tulis event ke outbox lewat tx yang sama dengan perubahan ledger
+func emitEvent(tx *gorm.DB, eventType string, orgID uuid.UUID, itemID uint, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return tx.Create(&models.OutboxEvent{
+		EventType:      eventType,
+		OrganizationID: orgID,
+		ItemID:         itemID,
+		Payload:        payload,
+		Status:         models.OutboxStatusPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}).Error
+}
+
+// newInventoryEvent - Payload event dari baris inventory
+func newInventoryEvent(inv *models.Inventory, changedBy string, reason *string) InventoryEvent {
+	return InventoryEvent{
+		InventoryID:        inv.ID,
+		OrganizationID:     inv.OrganizationID,
+		ItemID:             inv.ItemID,
+		Type:               string(inv.Type),
+		TxnDate:            inv.TxnDate,
+		Amount:             inv.Amount,
+		Balance:            inv.Balance,
+		RefID:              inv.RefID,
+		FromOrganizationID: inv.FromOrganizationID,
+		ToOrganizationID:   inv.ToOrganizationID,
+		LocationID:         inv.LocationID,
+		PhysicalQty:        inv.PhysicalQty,
+		SystemQty:          inv.SystemQty,
+		Difference:         inv.Difference,
+		Notes:              inv.Notes,
+		ChangedBy:          changedBy,
+		Reason:             reason,
+	}
+}
+
+// emitInventoryEvent - Baca ulang baris (saldo final setelah recalculate) lalu tulis event
+func (s *InventoryService) emitInventoryEvent(tx *gorm.DB, eventType string, inventoryID uuid.UUID, changedBy string, reason *string) error {
+	var inv models.Inventory
+	if err := tx.Unscoped().Where("id = ?", inventoryID).Take(&inv).Error; err != nil {
+		return err
+	}
+	return emitEvent(tx, eventType, inv.OrganizationID, inv.ItemID, newInventoryEvent(&inv, changedBy, reason))
+}
+
+// emitTransactionUpdated - previous = baris lama apa adanya, current = baris pengganti setelah recalculate
+func (s *InventoryService) emitTransactionUpdated(tx *gorm.DB, previous *models.Inventory, currentID uuid.UUID, changedBy string, reason *string) error {
+	var current models.Inventory
+	if err := tx.Where("id = ?", currentID).Take(&current).Error; err != nil {
+		return err
+	}
+	return emitEvent(tx, EventTransactionUpdated, current.OrganizationID, current.ItemID, TransactionUpdatedEvent{
+		Previous: newInventoryEvent(previous, changedBy, reason),
+		Current:  newInventoryEvent(&current, changedBy, reason),
+	})
+}
+
+// projectedBalance - Saldo di projection stock_balances (belum ada = 0)
+func (s *InventoryService) projectedBalance(tx *gorm.DB, orgID uuid.UUID, itemID uint) (decimal.Decimal, error) {
+	var stock models.StockBalance
+	err := tx.Select("balance").Where("organization_id = ? AND item_id = ?", orgID, itemID).Take(&stock).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return decimal.Zero, nil
+	}
+	return stock.Balance, err
+}
+
+// emitBalanceChanged - BalanceChanged jika saldo projection berbeda dari sebelum recalculation
+func (s *InventoryService) emitBalanceChanged(tx *gorm.DB, orgID uuid.UUID, itemID uint, previous decimal.Decimal, fromDate time.Time) error {
+	balance, err := s.projectedBalance(tx, orgID, itemID)
+	if err != nil {
+		return err
+	}
+	if balance.Equal(previous) {
+		return nil
+	}
+	return emitEvent(tx, EventBalanceChanged, orgID, itemID, BalanceChangedEvent{
+		OrganizationID:  orgID,
+		ItemID:          itemID,
+		PreviousBalance: previous,
+		Balance:         balance,
+		Delta:           balance.Sub(previous),
+		FromDate:        fromDate,
+	})
+}
+
+// ============ DISPATCHER ============
+
+// EventSink - Tujuan pengiriman event. Delivery bersifat at-least-once: event yang gagal
+// di salah satu sink dikirim ulang ke semua sink, jadi sink harus idempotent terhadap event ID.
+type EventSink interface {
+	Name() string
+	Deliver(ctx context.Context, event *models.OutboxEvent) error
+}
+
+// LogSink - Sink default, tulis envelope event ke log aplikasi
+type LogSink struct{}
+
+func (LogSink) Name() string { return "log" }
+
+func (LogSink) Deliver(ctx context.Context, event *models.OutboxEvent) error {
+	body, err := json.Marshal(event.Envelope())
+	if err != nil {
+		return err
+	}
+	log.Printf("📣 EVENT %s", body)
+	return nil
+}
+
+// OutboxDispatcher - Kirim event pending ke semua sink, berurutan id per org+item.
+// Gagal = attempts+1 dengan exponential backoff; setelah MaxAttempts status jadi dead.
+type OutboxDispatcher struct {
+	DB    *gorm.DB
+	Sinks []EventSink
+
+	BatchSize    int           // default 100
+	PollInterval time.Duration // default 1s
+	MaxAttempts  int           // default 10
+	BaseBackoff  time.Duration // default 1s, dikali 2 setiap attempt
+	MaxBackoff   time.Duration // default 10m
+	Lease        time.Duration // default 1m, batas waktu satu batch sebelum event boleh diklaim ulang
+}
+
+// Run - Loop polling sampai ctx selesai. Batch penuh langsung diikuti batch berikutnya.
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	log.Printf("Outbox dispatcher started (%d sinks)", len(d.Sinks))
+
+	for {
+		processed, err := d.DispatchOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Outbox dispatch failed: %v", err)
+		}
+		if err == nil && processed >= d.batchSize() {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox dispatcher stopped")
+			return
+		case <-time.After(d.pollInterval()):
+		}
+	}
+}
+
+// DispatchOnce - Klaim satu batch event yang jatuh tempo, kirim ke sink di luar transaksi, dan
+// simpan hasil tiap event. Return jumlah event yang diproses.
+// Event yang masih punya event pending lebih awal untuk org+item yang sama ditahan dulu.
+func (d *OutboxDispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	events, err := d.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range events {
+		if err := d.record(&events[i], d.deliver(ctx, &events[i])); err != nil {
+			return i, err
+		}
+	}
+	return len(events), nil
+}
+
+// claim - Ambil batch event (FOR UPDATE SKIP LOCKED, aman untuk beberapa dispatcher) dan majukan
+// next_attempt_at selama lease, lalu commit sebelum sink dipanggil. Event tetap pending selama
+// dikirim (event berikutnya untuk org+item yang sama tetap ditahan); jika dispatcher mati di
+// tengah jalan, event diambil lagi setelah lease habis.
+func (d *OutboxDispatcher) claim(ctx context.Context) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.OutboxStatusPending, now).
+			Where(`NOT EXISTS (
+				SELECT 1 FROM outbox_events earlier
+				WHERE earlier.status = ? AND earlier.organization_id = outbox_events.organization_id
+					AND earlier.item_id = outbox_events.item_id AND earlier.id < outbox_events.id
+			)`, models.OutboxStatusPending).
+			Order("id").
+			Limit(d.batchSize()).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		ids := make([]uint64, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(d.lease())).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// deliver - Kirim event ke setiap sink, berhenti di sink pertama yang gagal
+func (d *OutboxDispatcher) deliver(ctx context.Context, event *models.OutboxEvent) error {
+	for _, sink := range d.Sinks {
+		if err := sink.Deliver(ctx, event); err != nil {
+			return fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
+
+// record - Simpan hasil delivery: delivered, jadwal retry, atau dead
+func (d *OutboxDispatcher) record(event *models.OutboxEvent, deliveryErr error) error {
+	now := time.Now()
+	updates := map[string]interface{}{"attempts": event.Attempts + 1}
+
+	switch {
+	case deliveryErr == nil:
+		updates["status"] = models.OutboxStatusDelivered
+		updates["delivered_at"] = now
+		updates["last_error"] = nil
+	case event.Attempts+1 >= d.maxAttempts():
+		log.Printf("☠️  Outbox event %d (%s) dead after %d attempts: %v",
+			event.ID, event.EventType, event.Attempts+1, deliveryErr)
+		updates["status"] = models.OutboxStatusDead
+		updates["last_error"] = deliveryErr.Error()
+	default:
+		log.Printf("⚠️  Outbox event %d (%s) attempt %d failed: %v",
+			event.ID, event.EventType, event.Attempts+1, deliveryErr)
+		updates["next_attempt_at"] = now.Add(d.backoff(event.Attempts + 1))
+		updates["last_error"] = deliveryErr.Error()
+	}
+
+	return d.DB.Model(event).Updates(updates).Error
+}
+
+// backoff - BaseBackoff * 2^(attempt-1), maksimal MaxBackoff
+func (d *OutboxDispatcher) backoff(attempt int) time.Duration {
+	return exponentialBackoff(d.BaseBackoff, d.MaxBackoff, attempt)
+}
+
+// exponentialBackoff - base * 2^(attempt-1), maksimal limit (default 1s dan 10m)
+func exponentialBackoff(base, limit time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		base = time.Second
+	}
+	if limit <= 0 {
+		limit = 10 * time.Minute
+	}
+
+	delay := base
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+func (d *OutboxDispatcher) batchSize() int {
+	if d.BatchSize <= 0 {
+		return 100
+	}
+	return d.BatchSize
+}
+
+func (d *OutboxDispatcher) pollInterval() time.Duration {
+	if d.PollInterval <= 0 {
+		return time.Second
+	}
+	return d.PollInterval
+}
+
+func (d *OutboxDispatcher) lease() time.Duration {
+	if d.Lease <= 0 {
+		return time.Minute
+	}
+	return d.Lease
+}
+
+func (d *OutboxDispatcher) maxAttempts() int {
+	if d.MaxAttempts <= 0 {
+		return 10
+	}
+	return d.MaxAttempts
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+)
+
+var (
+	// ErrPeriodClosed - Target errors.Is untuk PeriodClosedError
+	ErrPeriodClosed = errors.New("period closed")
+
+	ErrClosingNotFound        = errors.New("period closing not found")
+	ErrPeriodNotEnded         = errors.New("period_end must be before today")
+	ErrPeriodAlreadyClosed    = errors.New("period_end must be after the latest closed period")
+	ErrClosingAlreadyReopened = errors.New("period closing already reopened")
+	ErrClosingNotLatest       = errors.New("only the latest active period closing can be reopened")
+	ErrReopenReasonRequired   = errors.New("reopen reason is required")
+)
+
+// PeriodClosedError - Write path menyentuh tanggal di periode yang sudah ditutup
+type PeriodClosedError struct {
+	OrganizationID uuid.UUID
+	ClosingID      uuid.UUID
+	ClosedThrough  time.Time
+	TxnDate        time.Time
+}
+
+func (e *PeriodClosedError) Error() string {
+	return fmt.Sprintf("period closed through %s for organization %s, cannot change transactions from %s",
+		e.ClosedThrough.Format("2006-01-02"), e.OrganizationID, e.TxnDate.Format(time.RFC3339))
+}
+
+func (e *PeriodClosedError) Is(target error) bool {
+	return target == ErrPeriodClosed
+}
+
+// ============ REQUEST STRUCTS ============
+type ClosePeriodRequest struct {
+	OrganizationID uuid.UUID
+	PeriodEnd      time.Time // hanya tanggal yang dipakai, inklusif
+	ClosedBy       string
+	Reason         *string
+}
+
+type ReopenPeriodRequest struct {
+	OrganizationID uuid.UUID
+	ClosingID      uuid.UUID
+	ReopenedBy     string
+	Reason         string
+}
+
+// ============ PERIOD SERVICE ============
+type PeriodService struct {
+	DB        *gorm.DB
+	Inventory *InventoryService
+}
+
+// ClosePeriod - Tutup buku org sampai PeriodEnd: snapshot saldo akhir semua item lalu kunci posting
+func (s *PeriodService) ClosePeriod(req ClosePeriodRequest) (*models.PeriodClosing, error) {
+	periodEnd := calendarDate(req.PeriodEnd)
+	log.Printf("Starting ClosePeriod: org=%v period_end=%s", req.OrganizationID, periodEnd.Format("2006-01-02"))
+
+	if !periodEnd.Before(calendarDate(time.Now())) {
+		return nil, ErrPeriodNotEnded
+	}
+
+	var closing *models.PeriodClosing
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var org models.Organization
+		if err := tx.Select("id").Where("id = ?", req.OrganizationID).Take(&org).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrganizationNotFound
+			}
+			return err
+		}
+
+		// Exclusive: tunggu semua write path org ini (shared lock di recalculate) selesai
+		if err := s.Inventory.Repo.AdvisoryLock(tx, repositories.OrgPeriodLockKey(req.OrganizationID)); err != nil {
+			return err
+		}
+
+		latest, err := s.Inventory.activeClosing(tx, req.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if latest != nil && !periodEnd.After(latest.PeriodEnd) {
+			return ErrPeriodAlreadyClosed
+		}
+
+		closing = &models.PeriodClosing{
+			OrganizationID: req.OrganizationID,
+			PeriodEnd:      periodEnd,
+			ClosedBy:       req.ClosedBy,
+			Reason:         req.Reason,
+			ClosedAt:       time.Now(),
+		}
+		if err := tx.Create(closing).Error; err != nil {
+			return err
+		}
+
+		// Saldo akhir = baris aktif terakhir per item dengan txn_date <= period_end
+		result := tx.Exec(`
+			INSERT INTO period_closing_balances (closing_id, item_id, balance, last_txn_date, last_inventory_id)
+			SELECT DISTINCT ON (item_id) ?, item_id, balance, txn_date, id
+			FROM inventories
+			WHERE organization_id = ? AND txn_date < ? AND deleted_at IS NULL
+			ORDER BY item_id, txn_date DESC, created_at DESC`,
+			closing.ID, req.OrganizationID, periodEnd.AddDate(0, 0, 1))
+		if result.Error != nil {
+			return result.Error
+		}
+
+		closing.ItemCount = int(result.RowsAffected)
+		if err := tx.Model(closing).UpdateColumn("item_count", closing.ItemCount).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("closing_id = ?", closing.ID).Order("item_id").Find(&closing.Balances).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ Period closed: org=%v period_end=%s items=%d", req.OrganizationID,
+		periodEnd.Format("2006-01-02"), closing.ItemCount)
+	return closing, nil
+}
+
+// ReopenPeriod - Buka kembali closing aktif terakhir (alasan wajib, tercatat di closing)
+func (s *PeriodService) ReopenPeriod(req ReopenPeriodRequest) (*models.PeriodClosing, error) {
+	log.Printf("Starting ReopenPeriod: org=%v closing=%v", req.OrganizationID, req.ClosingID)
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, ErrReopenReasonRequired
+	}
+
+	var closing models.PeriodClosing
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.Inventory.Repo.AdvisoryLock(tx, repositories.OrgPeriodLockKey(req.OrganizationID)); err != nil {
+			return err
+		}
+
+		err := tx.Where("id = ? AND organization_id = ?", req.ClosingID, req.OrganizationID).Take(&closing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrClosingNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !closing.IsActive() {
+			return ErrClosingAlreadyReopened
+		}
+
+		// Reopen periode lama tidak membuka apa pun selama closing yang lebih baru masih aktif
+		latest, err := s.Inventory.activeClosing(tx, req.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if latest == nil || latest.ID != closing.ID {
+			return ErrClosingNotLatest
+		}
+
+		now := time.Now()
+		closing.ReopenedBy = &req.ReopenedBy
+		closing.ReopenReason = &reason
+		closing.ReopenedAt = &now
+		return tx.Model(&closing).Updates(map[string]interface{}{
+			"reopened_by":   req.ReopenedBy,
+			"reopen_reason": reason,
+			"reopened_at":   now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ Period reopened: org=%v period_end=%s by=%s", req.OrganizationID,
+		closing.PeriodEnd.Format("2006-01-02"), req.ReopenedBy)
+	return &closing, nil
+}
+
+// ListClosings - Riwayat tutup/buka buku org (terbaru dulu, tanpa saldo)
+func (s *PeriodService) ListClosings(orgID uuid.UUID) ([]models.PeriodClosing, error) {
+	var closings []models.PeriodClosing
+	err := s.DB.Where("organization_id = ?", orgID).
+		Order("period_end DESC, closed_at DESC").
+		Find(&closings).Error
+	return closings, err
+}
+
+// GetClosing - Detail closing beserta saldo akhir per item
+func (s *PeriodService) GetClosing(orgID, closingID uuid.UUID) (*models.PeriodClosing, error) {
+	var closing models.PeriodClosing
+	err := s.DB.
+		Preload("Balances", func(db *gorm.DB) *gorm.DB { return db.Order("item_id") }).
+		Where("id = ? AND organization_id = ?", closingID, orgID).
+		Take(&closing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrClosingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &closing, nil
+}
+
+// ============ POSTING LOCK ============
+
+// activeClosing - Closing aktif dengan period_end terbaru (nil = belum pernah tutup buku)
+func (s *InventoryService) activeClosing(tx *gorm.DB, orgID uuid.UUID) (*models.PeriodClosing, error) {
+	var closing models.PeriodClosing
+	err := tx.Where("organization_id = ? AND reopened_at IS NULL", orgID).
+		Order("period_end DESC").
+		Take(&closing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &closing, nil
+}
+
+// checkPeriodOpen - PeriodClosedError jika txnDate jatuh di periode yang masih ditutup
+func (s *InventoryService) checkPeriodOpen(tx *gorm.DB, orgID uuid.UUID, txnDate time.Time) error {
+	closing, err := s.activeClosing(tx, orgID)
+	if err != nil || closing == nil {
+		return err
+	}
+	if calendarDate(txnDate).After(closing.PeriodEnd) {
+		return nil
+	}
+	return &PeriodClosedError{
+		OrganizationID: orgID,
+		ClosingID:      closing.ID,
+		ClosedThrough:  closing.PeriodEnd,
+		TxnDate:        txnDate,
+	}
+}
+
+// enforcePeriodLock - Dipanggil dari recalculate dengan tanggal paling awal yang diubah.
+// Shared lock membuat ClosePeriod menunggu write path yang sedang berjalan (dan sebaliknya).
+func (s *InventoryService) enforcePeriodLock(tx *gorm.DB, orgID uuid.UUID, fromDate time.Time) error {
+	if err := s.Repo.AdvisoryLockShared(tx, repositories.OrgPeriodLockKey(orgID)); err != nil {
+		return err
+	}
+	return s.checkPeriodOpen(tx, orgID, fromDate)
+}
+
+// calendarDate - Tanggal (wall clock) tanpa jam, dibandingkan dengan kolom date period_end
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+)
+
+var (
+	// ErrRollbackBusy - Mutasi baru ke org lain sedang dikunci posting lain; aman di-retry
+	ErrRollbackBusy = errors.New("rollback is blocked by a concurrent mutation, retry the request")
+
+	// errRollbackPreview - Sentinel untuk membatalkan transaksi preview setelah hasilnya dikumpulkan
+	errRollbackPreview = errors.New("rollback preview")
+)
+
+// ============ ROLLBACK PREVIEW TYPES ============
+
+// RollbackBalancePoint - Saldo berjalan sebelum/sesudah rollback pada satu tanggal transaksi
+type RollbackBalancePoint struct {
+	OrganizationID uuid.UUID       `json:"organization_id"`
+	TxnDate        time.Time       `json:"txn_date"`
+	BalanceBefore  decimal.Decimal `json:"balance_before"`
+	BalanceAfter   decimal.Decimal `json:"balance_after"`
+}
+
+// RollbackPreview - Efek rollback: baris yang dihapus/dibuat ulang, saldo per tanggal, dan peringatan
+type RollbackPreview struct {
+	HistoryID        uuid.UUID              `json:"history_id"`
+	Action           string                 `json:"action"`
+	OrganizationID   uuid.UUID              `json:"organization_id"`
+	ItemID           uint                   `json:"item_id"`
+	SnapshotFromDate time.Time              `json:"snapshot_from_date"`
+	SoftDeleted      []models.SnapshotItem  `json:"soft_deleted"`
+	Recreated        []models.SnapshotItem  `json:"recreated"`
+	Balances         []RollbackBalancePoint `json:"balances"`
+	Warnings         []string               `json:"warnings"`
+}
+
+// ============ ROLLBACK ============
+
+// RollbackTransaction - Kembalikan ledger org+item ke snapshot history
+func (s *InventoryService) RollbackTransaction(historyID uuid.UUID, changedBy string, reason *string) error {
+	log.Printf("Starting RollbackTransaction: history_id=%v", historyID)
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := s.applyRollback(tx, historyID, changedBy, reason)
+		return err
+	})
+}
+
+// PreviewRollback - Jalankan logika rollback yang sama lalu batalkan transaksinya (dry-run)
+func (s *InventoryService) PreviewRollback(historyID uuid.UUID, changedBy string) (*RollbackPreview, error) {
+	log.Printf("Starting PreviewRollback: history_id=%v", historyID)
+
+	var preview *RollbackPreview
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		result, err := s.applyRollback(tx, historyID, changedBy, nil)
+		if err != nil {
+			return err
+		}
+		preview = result
+		return errRollbackPreview
+	})
+	if !errors.Is(err, errRollbackPreview) {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+// applyRollback - Inti rollback di dalam transaksi aktif. Mengembalikan ringkasan perubahan
+// (dipakai preview); pemanggil yang menentukan commit atau rollback transaksi.
+func (s *InventoryService) applyRollback(tx *gorm.DB, historyID uuid.UUID, changedBy string, reason *string) (*RollbackPreview, error) {
+	var history models.InventoryHistory
+	if err := tx.First(&history, historyID).Error; err != nil {
+		return nil, err
+	}
+
+	log.Printf("History found: action=%s, snapshot_from=%v",
+		history.Action, history.SnapshotFromDate)
+
+	var snapshotItems []models.SnapshotItem
+
+	var snapshotData json.RawMessage
+	switch history.Action {
+	case "CREATE", "BATCH_CREATE", "MUTATION_IN", "MUTATION_OUT", "LOCATION_MOVE_IN", "LOCATION_MOVE_OUT", "OPNAME":
+		snapshotData = history.DataAfter
+	case "UPDATE_BEFORE", "DELETE_BEFORE":
+		snapshotData = history.DataBefore
+	case "UPDATE_AFTER":
+		snapshotData = history.DataBefore
+	default:
+		return nil, errors.New("unsupported history action for rollback")
+	}
+
+	if err := json.Unmarshal(snapshotData, &snapshotItems); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Snapshot contains %d transactions", len(snapshotItems))
+
+	// Kunci org yang di-rollback + org pasangan dari leg mutasi (snapshot & kondisi sekarang) sekaligus,
+	// urut key. Leg dibaca ulang setelah lock: mutasi ke org baru yang commit di sela-selanya dikunci
+	// tanpa menunggu, karena lock di luar urutan bisa deadlock.
+	lockKeys, err := s.rollbackLockKeys(tx, &history, snapshotItems)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.lockOrgItems(tx, lockKeys...); err != nil {
+		return nil, err
+	}
+	locked := make(map[int64]bool, len(lockKeys))
+	for _, key := range lockKeys {
+		locked[repositories.OrgItemLockKey(key.OrganizationID, key.ItemID)] = true
+	}
+	if lockKeys, err = s.rollbackLockKeys(tx, &history, snapshotItems); err != nil {
+		return nil, err
+	}
+	if err := s.tryLockOrgItems(tx, locked, ErrRollbackBusy, lockKeys...); err != nil {
+		return nil, err
+	}
+
+	preview := &RollbackPreview{
+		HistoryID:        history.ID,
+		Action:           history.Action,
+		OrganizationID:   history.OrganizationID,
+		ItemID:           history.ItemID,
+		SnapshotFromDate: history.SnapshotFromDate,
+		SoftDeleted:      []models.SnapshotItem{},
+		Recreated:        []models.SnapshotItem{},
+		Balances:         []RollbackBalancePoint{},
+		Warnings:         []string{},
+	}
+
+	openingBalance, err := s.getBalanceBeforeDate(tx, history.OrganizationID, history.ItemID,
+		history.SnapshotFromDate, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var replaced []models.Inventory
+	if err := tx.Where(
+		"organization_id = ? AND item_id = ? AND txn_date >= ? AND deleted_at IS NULL",
+		history.OrganizationID, history.ItemID, history.SnapshotFromDate,
+	).Order("txn_date ASC, created_at ASC").Find(&replaced).Error; err != nil {
+		return nil, err
+	}
+
+	preview.SoftDeleted = append(preview.SoftDeleted, toSnapshotItems(replaced)...)
+	preview.Warnings = append(preview.Warnings, discardedAfterHistoryWarnings(&history, replaced, snapshotItems)...)
+
+	now := time.Now()
+	if err := tx.Model(&models.Inventory{}).
+		Where(
+			"organization_id = ? AND item_id = ? AND txn_date >= ? AND deleted_at IS NULL",
+			history.OrganizationID, history.ItemID, history.SnapshotFromDate,
+		).
+		Updates(map[string]interface{}{
+			"deleted_at": now,
+			"deleted_by": changedBy + " (rollback_delete)",
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	log.Printf("🧹 Soft-deleted transactions from %v onward", history.SnapshotFromDate)
+
+	restored := make([]models.Inventory, 0, len(snapshotItems))
+	restoredAt := time.Now()
+	for i, item := range snapshotItems {
+		inventoryType := models.InventoryType(item.Type)
+
+		newID := uuid.New()
+
+		inventory := models.Inventory{
+			ID:             newID,
+			OrganizationID: history.OrganizationID,
+			ItemID:         history.ItemID,
+			TxnDate:        item.TxnDate,
+			Amount:         item.Amount,
+			Balance:        item.Balance,
+			Type:           inventoryType,
+			UnitCost:       item.UnitCost,
+			LotNumber:      item.LotNumber,
+			ExpiryDate:     item.ExpiryDate,
+			SerialNumbers:  item.SerialNumbers,
+			LocationID:     item.LocationID,
+			CreatedBy:      changedBy + " (rollback_restore)",
+			// Urutan snapshot dipertahankan (leg keluar pindah lokasi sebelum leg masuk)
+			CreatedAt: restoredAt.Add(time.Duration(i) * time.Microsecond),
+		}
+
+		if item.RefID != nil {
+			refID, err := uuid.Parse(*item.RefID)
+			if err == nil {
+				inventory.RefID = &refID
+			}
+		}
+
+		if inventoryType == models.InventoryTypeMutation || inventoryType == models.InventoryTypeLocationMove {
+			var original models.Inventory
+			if err := tx.Unscoped().
+				Where("id = ?", item.InventoryID).
+				First(&original).Error; err == nil {
+				inventory.FromOrganizationID = original.FromOrganizationID
+				inventory.ToOrganizationID = original.ToOrganizationID
+				inventory.Notes = original.Notes
+			}
+		}
+
+		if inventoryType == models.InventoryTypeOpname {
+			var original models.Inventory
+			if err := tx.Unscoped().
+				Where("id = ?", item.InventoryID).
+				First(&original).Error; err == nil {
+				inventory.PhysicalQty = original.PhysicalQty
+				inventory.SystemQty = original.SystemQty
+				inventory.Difference = original.Difference
+			}
+		}
+
+		if err := tx.Create(&inventory).Error; err != nil {
+			log.Printf("Error creating restored transaction: %v", err)
+			return nil, err
+		}
+
+		log.Printf("Recreated transaction: new_id=%v, date=%v, amount=%s, balance=%s",
+			newID, item.TxnDate, item.Amount, item.Balance)
+		restored = append(restored, inventory)
+	}
+
+	log.Printf("Recalculating forward balances after rollback...")
+	if err := s.recalculateAll(tx, history.OrganizationID,
+		history.ItemID, history.SnapshotFromDate); err != nil {
+		return nil, err
+	}
+
+	// Leg mutasi yang berubah/hilang/muncul kembali harus diikuti leg pasangannya
+	if err := s.reconcileMutationCounterparts(tx, history.ItemID, replaced, restored, locked, changedBy, reason, preview); err != nil {
+		return nil, err
+	}
+
+	rollbackHistory := models.InventoryHistory{
+		OrganizationID:     history.OrganizationID,
+		ItemID:             history.ItemID,
+		TriggerInventoryID: history.TriggerInventoryID,
+		SnapshotFromDate:   history.SnapshotFromDate,
+		Action:             "ROLLBACK",
+		ChangedBy:          changedBy,
+		Reason:             reason,
+		CreatedAt:          time.Now(),
+	}
+
+	var currentSnapshots []models.Inventory
+	if err := tx.Where(
+		"organization_id = ? AND item_id = ? AND txn_date >= ? AND deleted_at IS NULL",
+		history.OrganizationID, history.ItemID, history.SnapshotFromDate,
+	).Order("txn_date ASC, created_at ASC").Find(&currentSnapshots).Error; err != nil {
+		return nil, err
+	}
+
+	afterItems := toSnapshotItems(currentSnapshots)
+	restoredIDs := make(map[uuid.UUID]bool, len(restored))
+	for _, inv := range restored {
+		restoredIDs[inv.ID] = true
+	}
+	for _, item := range afterItems {
+		if restoredIDs[item.InventoryID] {
+			preview.Recreated = append(preview.Recreated, item)
+		}
+	}
+	preview.Balances = append(balanceTimeline(history.OrganizationID, openingBalance,
+		preview.SoftDeleted, afterItems), preview.Balances...)
+
+	currentJSON, err := json.Marshal(afterItems)
+	if err != nil {
+		return nil, err
+	}
+
+	rollbackHistory.DataAfter = json.RawMessage(currentJSON)
+
+	var beforeSnapshots []models.Inventory
+	if err := tx.Unscoped().
+		Where(
+			"organization_id = ? AND item_id = ? AND txn_date >= ? AND deleted_at IS NOT NULL",
+			history.OrganizationID, history.ItemID, history.SnapshotFromDate,
+		).
+		Order("txn_date ASC, created_at ASC").
+		Find(&beforeSnapshots).Error; err != nil {
+		return nil, err
+	}
+
+	beforeJSON, err := json.Marshal(toSnapshotItems(beforeSnapshots))
+	if err != nil {
+		return nil, err
+	}
+
+	rollbackHistory.DataBefore = json.RawMessage(beforeJSON)
+
+	if err := tx.Create(&rollbackHistory).Error; err != nil {
+		return nil, err
+	}
+
+	if err := emitEvent(tx, EventLedgerRolledBack, history.OrganizationID, history.ItemID, LedgerRolledBackEvent{
+		HistoryID:        history.ID,
+		OrganizationID:   history.OrganizationID,
+		ItemID:           history.ItemID,
+		SnapshotFromDate: history.SnapshotFromDate,
+		SoftDeleted:      len(replaced),
+		Recreated:        len(restored),
+		ChangedBy:        changedBy,
+		Reason:           reason,
+	}); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Rollback completed for history %v", historyID)
+
+	return preview, nil
+}
+
+// ============ PREVIEW HELPERS ============
+
+// discardedAfterHistoryWarnings - Transaksi yang dibuat setelah history dan tidak ada di snapshot akan hilang
+func discardedAfterHistoryWarnings(history *models.InventoryHistory, replaced []models.Inventory, snapshotItems []models.SnapshotItem) []string {
+	inSnapshot := make(map[uuid.UUID]bool, len(snapshotItems))
+	for _, item := range snapshotItems {
+		inSnapshot[item.InventoryID] = true
+	}
+
+	var warnings []string
+	for _, inv := range replaced {
+		if inSnapshot[inv.ID] || !inv.CreatedAt.After(history.CreatedAt) {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"transaction %s (%s, amount %s, txn_date %s) was created after this history record at %s and would be discarded",
+			inv.ID, inv.Type, inv.Amount, inv.TxnDate.Format(time.RFC3339), inv.CreatedAt.Format(time.RFC3339)))
+	}
+	return warnings
+}
+
+// balanceTimeline - Saldo sebelum/sesudah per tanggal transaksi (gabungan tanggal kedua sisi).
+// before & after harus urut kronologis; opening = saldo sebelum tanggal pertama.
+func balanceTimeline(orgID uuid.UUID, opening decimal.Decimal, before, after []models.SnapshotItem) []RollbackBalancePoint {
+	dateSet := make(map[time.Time]bool)
+	for _, item := range before {
+		dateSet[item.TxnDate] = true
+	}
+	for _, item := range after {
+		dateSet[item.TxnDate] = true
+	}
+
+	dates := make([]time.Time, 0, len(dateSet))
+	for date := range dateSet {
+		dates = append(dates, date)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	balanceAt := func(items []models.SnapshotItem, date time.Time) decimal.Decimal {
+		balance := opening
+		for _, item := range items {
+			if item.TxnDate.After(date) {
+				break
+			}
+			balance = item.Balance
+		}
+		return balance
+	}
+
+	points := make([]RollbackBalancePoint, 0, len(dates))
+	for _, date := range dates {
+		points = append(points, RollbackBalancePoint{
+			OrganizationID: orgID,
+			TxnDate:        date,
+			BalanceBefore:  balanceAt(before, date),
+			BalanceAfter:   balanceAt(after, date),
+		})
+	}
+	return points
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+)
+
+var (
+	ErrSerialNotTracked     = errors.New("item is not serialized")
+	ErrSerialCountMismatch  = errors.New("serial_numbers must list exactly one serial per unit")
+	ErrDuplicateSerial      = errors.New("serial_numbers must be unique and not empty")
+	ErrSerialNotInStock     = errors.New("serial is not in stock")
+	ErrSerialAlreadyInStock = errors.New("serial is already in stock")
+	ErrSerialLotConflict    = errors.New("an item cannot track both lots and serials")
+	ErrSerializedLocked     = errors.New("serialized cannot change once the item has postings")
+	ErrSerialNotFound       = errors.New("serial number not found")
+)
+
+// ============ RESPONSE STRUCTS ============
+
+// SerialUnit - Satu unit (item + nomor seri): posisi sekarang dan jejak pergerakannya
+type SerialUnit struct {
+	ItemID           uint                `json:"item_id"`
+	ItemCode         string              `json:"item_code"`
+	ItemName         string              `json:"item_name"`
+	SerialNumber     string              `json:"serial_number"`
+	Status           models.SerialStatus `json:"status"`
+	OrganizationID   *uuid.UUID          `json:"organization_id"`
+	OrganizationCode *string             `json:"organization_code"`
+	Movements        []SerialMovement    `json:"movements"`
+}
+
+// SerialMovement - Baris jejak + arah (in/out)
+type SerialMovement struct {
+	repositories.SerialMovement
+	Direction string `json:"direction"`
+}
+
+// ============ PUBLIC METHODS ============
+
+// GetSerialTrail - Posisi & jejak pergerakan nomor seri lintas organisasi.
+// Nomor seri yang sama di item berbeda dikembalikan sebagai unit terpisah (itemID != 0 = satu item saja).
+func (s *InventoryService) GetSerialTrail(serial string, itemID uint) ([]SerialUnit, error) {
+	serial = strings.TrimSpace(serial)
+	registry, err := s.Repo.FindSerialNumbers(serial, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(registry) == 0 {
+		return nil, ErrSerialNotFound
+	}
+	movements, err := s.Repo.GetSerialTrail(serial, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	itemIDs := make([]uint, len(registry))
+	for i, entry := range registry {
+		itemIDs[i] = entry.ItemID
+	}
+	var items []models.Item
+	if err := s.DB.Where("id IN ?", itemIDs).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	itemsByID := make(map[uint]models.Item, len(items))
+	for _, item := range items {
+		itemsByID[item.ID] = item
+	}
+
+	units := make([]SerialUnit, len(registry))
+	unitByItem := make(map[uint]*SerialUnit, len(registry))
+	for i, entry := range registry {
+		item := itemsByID[entry.ItemID]
+		units[i] = SerialUnit{
+			ItemID:         entry.ItemID,
+			ItemCode:       item.Code,
+			ItemName:       item.Name,
+			SerialNumber:   entry.SerialNumber,
+			Status:         entry.Status,
+			OrganizationID: entry.OrganizationID,
+			Movements:      []SerialMovement{},
+		}
+		unitByItem[entry.ItemID] = &units[i]
+	}
+
+	for _, movement := range movements {
+		unit, ok := unitByItem[movement.ItemID]
+		if !ok {
+			continue
+		}
+		direction := "in"
+		if movement.Amount.IsNegative() {
+			direction = "out"
+		}
+		if unit.OrganizationID != nil && *unit.OrganizationID == movement.OrganizationID {
+			code := movement.OrganizationCode
+			unit.OrganizationCode = &code
+		}
+		unit.Movements = append(unit.Movements, SerialMovement{SerialMovement: movement, Direction: direction})
+	}
+	return units, nil
+}
+
+// ============ VALIDATION ============
+
+// normalizeSerials - Trim + urut (hash chain & pencarian stabil); kosong/duplikat ditolak
+func normalizeSerials(serials []string) ([]string, error) {
+	if len(serials) == 0 {
+		return nil, nil
+	}
+	normalized := make([]string, len(serials))
+	seen := make(map[string]bool, len(serials))
+	for i, serial := range serials {
+		serial = strings.TrimSpace(serial)
+		if serial == "" || len(serial) > 100 || seen[serial] {
+			return nil, ErrDuplicateSerial
+		}
+		seen[serial] = true
+		normalized[i] = serial
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// validateSerialRequest - Validasi nomor seri tanpa database (serialized/tidaknya item dicek saat posting)
+func validateSerialRequest(req CreateTransactionRequest) error {
+	if len(req.SerialNumbers) == 0 {
+		return nil
+	}
+	if req.LotNumber != nil || req.ExpiryDate != nil || len(req.Lots) > 0 {
+		return ErrSerialLotConflict
+	}
+	if _, err := normalizeSerials(req.SerialNumbers); err != nil {
+		return err
+	}
+	return checkSerialCount(req.SerialNumbers, req.Amount)
+}
+
+// checkSerialCount - Satu nomor seri per unit (item serialized hanya boleh qty bulat)
+func checkSerialCount(serials []string, amount decimal.Decimal) error {
+	if !amount.Abs().Equal(decimal.NewFromInt(int64(len(serials)))) {
+		return ErrSerialCountMismatch
+	}
+	return nil
+}
+
+// isSerialError - Error nomor seri dari input (bukan error database), dilaporkan per baris batch/import
+func isSerialError(err error) bool {
+	for _, target := range []error{ErrSerialNotTracked, ErrSerialCountMismatch, ErrDuplicateSerial,
+		ErrSerialNotInStock, ErrSerialAlreadyInStock, ErrSerialLotConflict} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============ TRACKING (di dalam transaksi write path) ============
+
+// serialTracker - Nomor seri org+item selama satu operasi (batch: penerimaan di batch yang sama
+// ikut bisa dipakai baris sesudahnya)
+type serialTracker struct {
+	available map[string]bool // ada pada tanggal posting dan masih ada sekarang → boleh keluar
+	present   map[string]bool // ada pada tanggal posting atau sekarang → tidak boleh masuk lagi
+}
+
+// newSerialTracker - Nomor seri org+item pada tanggal posting
+func (s *InventoryService) newSerialTracker(tx *gorm.DB, orgID uuid.UUID, itemID uint, at time.Time) (*serialTracker, error) {
+	stocks, err := s.Repo.WithTx(tx).GetSerialStocks(orgID, itemID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker := &serialTracker{
+		available: make(map[string]bool, len(stocks)),
+		present:   make(map[string]bool, len(stocks)),
+	}
+	for _, stock := range stocks {
+		tracker.available[stock.SerialNumber] = stock.PresenceAt == 1 && stock.Presence == 1
+		tracker.present[stock.SerialNumber] = stock.PresenceAt > 0 || stock.Presence > 0
+	}
+	return tracker, nil
+}
+
+// inStock - Nomor seri yang ada pada tanggal tracker dibuat (urut)
+func (t *serialTracker) inStock() []string {
+	var serials []string
+	for serial, ok := range t.available {
+		if ok {
+			serials = append(serials, serial)
+		}
+	}
+	sort.Strings(serials)
+	return serials
+}
+
+// receive - Unit masuk; nomor seri yang masih ada di org ditolak
+func (t *serialTracker) receive(serials []string) error {
+	for _, serial := range serials {
+		if t.present[serial] {
+			return fmt.Errorf("%w %s", ErrSerialAlreadyInStock, serial)
+		}
+	}
+	for _, serial := range serials {
+		t.available[serial] = true
+		t.present[serial] = true
+	}
+	return nil
+}
+
+// issue - Unit keluar; nomor seri harus ada di org pada tanggal posting
+func (t *serialTracker) issue(serials []string) error {
+	for _, serial := range serials {
+		if !t.available[serial] {
+			return fmt.Errorf("%w %s", ErrSerialNotInStock, serial)
+		}
+	}
+	for _, serial := range serials {
+		t.available[serial] = false
+		t.present[serial] = false
+	}
+	return nil
+}
+
+// itemSerialized - Flag serialized item (item tidak ditemukan = tidak)
+func (s *InventoryService) itemSerialized(tx *gorm.DB, itemID uint) (bool, error) {
+	var items []models.Item
+	if err := tx.Select("serialized").Where("id = ?", itemID).Limit(1).Find(&items).Error; err != nil {
+		return false, err
+	}
+	return len(items) > 0 && items[0].Serialized, nil
+}
+
+// transactionSerials - Nomor seri posting (ternormalisasi). Item serialized wajib menyebut satu
+// nomor seri per unit; tracker nil = dibuat dari nomor seri org pada tanggal posting.
+func (s *InventoryService) transactionSerials(tx *gorm.DB, orgID uuid.UUID, itemID uint, at time.Time,
+	amount decimal.Decimal, serials []string, tracker *serialTracker) ([]string, error) {
+	serialized, err := s.itemSerialized(tx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if !serialized {
+		if len(serials) > 0 {
+			return nil, ErrSerialNotTracked
+		}
+		return nil, nil
+	}
+
+	normalized, err := normalizeSerials(serials)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSerialCount(normalized, amount); err != nil {
+		return nil, err
+	}
+
+	if tracker == nil {
+		if tracker, err = s.newSerialTracker(tx, orgID, itemID, at); err != nil {
+			return nil, err
+		}
+	}
+	if amount.IsPositive() {
+		err = tracker.receive(normalized)
+	} else {
+		err = tracker.issue(normalized)
+	}
+	return normalized, err
+}
+
+// ============ PROJECTION (di dalam transaksi write path) ============
+
+// refreshSerialNumbers - Tulis ulang registry nomor seri yang pernah menyentuh org+item.
+// Return error unit yang ada di lebih dari satu organisasi (dipakai chokepoint).
+func (s *InventoryService) refreshSerialNumbers(tx *gorm.DB, orgID uuid.UUID, itemID uint) (bool, error) {
+	serialized, err := s.itemSerialized(tx, itemID)
+	if err != nil || !serialized {
+		return false, err
+	}
+
+	repo := s.Repo.WithTx(tx)
+	touched, positions, err := repo.GetSerialPositions(tx, orgID, itemID)
+	if err != nil {
+		return true, err
+	}
+
+	var duplicate error
+	registry := make(map[string]*models.SerialNumber, len(touched))
+	for _, position := range positions {
+		entry, ok := registry[position.SerialNumber]
+		if !ok {
+			entry = &models.SerialNumber{ItemID: itemID, SerialNumber: position.SerialNumber, Status: models.SerialOut}
+			registry[position.SerialNumber] = entry
+		}
+		if entry.LastTxnDate == nil || position.LastTxnDate.After(*entry.LastTxnDate) {
+			lastID, lastDate := position.LastInventoryID, position.LastTxnDate
+			entry.LastInventoryID, entry.LastTxnDate = &lastID, &lastDate
+		}
+		if position.Presence > 0 {
+			if entry.OrganizationID != nil && duplicate == nil {
+				duplicate = fmt.Errorf("%w %s", ErrSerialAlreadyInStock, position.SerialNumber)
+			}
+			if entry.OrganizationID == nil {
+				positionOrg := position.OrganizationID
+				entry.OrganizationID = &positionOrg
+				entry.Status = models.SerialInStock
+			}
+		}
+	}
+
+	serials := make([]models.SerialNumber, 0, len(registry))
+	for _, serial := range touched {
+		if entry, ok := registry[serial]; ok {
+			entry.UpdatedAt = time.Now()
+			serials = append(serials, *entry)
+		}
+	}
+	if err := repo.ReplaceSerialNumbers(tx, itemID, touched, serials); err != nil {
+		return true, err
+	}
+	return true, duplicate
+}
+
+// enforceSerialStock - Dipanggil chokepoint recalculation: setiap unit masuk sebelum keluar,
+// tidak masuk dua kali, hanya ada di satu organisasi, dan jumlah nomor seri tiap baris = |amount|
+func (s *InventoryService) enforceSerialStock(tx *gorm.DB, orgID uuid.UUID, itemID uint) error {
+	serialized, err := s.refreshSerialNumbers(tx, orgID, itemID)
+	if err != nil || !serialized {
+		return err
+	}
+
+	repo := s.Repo.WithTx(tx)
+	mismatch, err := repo.FindSerialCountMismatch(tx, orgID, itemID)
+	if err != nil {
+		return err
+	}
+	if mismatch != nil {
+		return fmt.Errorf("%w: %s on %s", ErrSerialCountMismatch, mismatch.Type, mismatch.TxnDate.Format("2006-01-02"))
+	}
+
+	violation, err := repo.FindSerialViolation(tx, orgID, itemID)
+	if err != nil || violation == nil {
+		return err
+	}
+	if violation.Presence < 0 {
+		return fmt.Errorf("%w %s on %s", ErrSerialNotInStock, violation.SerialNumber, violation.TxnDate.Format("2006-01-02"))
+	}
+	return fmt.Errorf("%w %s on %s", ErrSerialAlreadyInStock, violation.SerialNumber, violation.TxnDate.Format("2006-01-02"))
+}
+
+// updateSerials - Nomor seri baris pengganti (update): dari request atau baris lama,
+// untuk item serialized jumlahnya harus sama dengan |amount| baru
+func (s *InventoryService) updateSerials(tx *gorm.DB, existing models.Inventory, req UpdateTransactionRequest) ([]string, error) {
+	serialized, err := s.itemSerialized(tx, existing.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if !serialized {
+		if len(req.SerialNumbers) > 0 {
+			return nil, ErrSerialNotTracked
+		}
+		return existing.SerialNumbers, nil
+	}
+
+	serials := existing.SerialNumbers
+	if req.SerialNumbers != nil {
+		if serials, err = normalizeSerials(req.SerialNumbers); err != nil {
+			return nil, err
+		}
+	}
+	return serials, checkSerialCount(serials, req.Amount)
+}
+
+// serialOpnameInventories - Opname item serialized: nomor seri yang ada di sistem tapi tidak
+// dihitung keluar (baris minus), yang dihitung tapi tidak ada di sistem masuk (baris plus).
+// Item non-serialized: nil, opname biasa.
+func (s *InventoryService) serialOpnameInventories(tx *gorm.DB, req OpnameRequest, systemBalance decimal.Decimal) ([]*models.Inventory, error) {
+	serialized, err := s.itemSerialized(tx, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if !serialized {
+		if len(req.SerialNumbers) > 0 {
+			return nil, ErrSerialNotTracked
+		}
+		return nil, nil
+	}
+
+	counted, err := normalizeSerials(req.SerialNumbers)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSerialCount(counted, req.PhysicalQty); err != nil {
+		return nil, err
+	}
+
+	tracker, err := s.newSerialTracker(tx, req.OrganizationID, req.ItemID, req.TxnDate)
+	if err != nil {
+		return nil, err
+	}
+	countedSet := make(map[string]bool, len(counted))
+	for _, serial := range counted {
+		countedSet[serial] = true
+	}
+	var missing, extra []string
+	for _, serial := range tracker.inStock() {
+		if !countedSet[serial] {
+			missing = append(missing, serial)
+		}
+	}
+	for _, serial := range counted {
+		if !tracker.available[serial] {
+			extra = append(extra, serial)
+		}
+	}
+	if err := tracker.issue(missing); err != nil {
+		return nil, err
+	}
+	if err := tracker.receive(extra); err != nil {
+		return nil, err
+	}
+
+	log.Printf("OPNAME SERIAL: System=%s, Counted=%d, Missing=%d, Extra=%d",
+		systemBalance, len(counted), len(missing), len(extra))
+
+	// Baris minus dulu lalu plus; CreatedAt berurutan supaya urutan di ledger tetap
+	var inventories []*models.Inventory
+	systemQty := systemBalance
+	if len(missing) > 0 {
+		counted := systemQty.Sub(decimal.NewFromInt(int64(len(missing))))
+		inventories = append(inventories, newOpnameInventory(req, systemQty, counted, missing))
+		systemQty = counted
+	}
+	if len(extra) > 0 {
+		inv := newOpnameInventory(req, systemQty, systemQty.Add(decimal.NewFromInt(int64(len(extra)))), extra)
+		inv.CreatedAt = inv.CreatedAt.Add(time.Duration(len(inventories)) * time.Microsecond)
+		inventories = append(inventories, inv)
+	}
+	if len(inventories) == 0 {
+		inventories = append(inventories, newOpnameInventory(req, systemBalance, systemBalance, nil))
+	}
+	return inventories, nil
+}
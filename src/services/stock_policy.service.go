@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+)
+
+// ErrNegativeStock - Target errors.Is untuk NegativeStockError
+var ErrNegativeStock = errors.New("negative stock")
+
+// NegativeStockError - Saldo pertama yang akan negatif setelah recalculate (policy block)
+type NegativeStockError struct {
+	OrganizationID uuid.UUID
+	ItemID         uint
+	InventoryID    uuid.UUID
+	TxnDate        time.Time
+	Balance        decimal.Decimal
+}
+
+func (e *NegativeStockError) Error() string {
+	return fmt.Sprintf("stock would go negative on %s (balance %s) for organization %s item %d",
+		e.TxnDate.Format(time.RFC3339), e.Balance, e.OrganizationID, e.ItemID)
+}
+
+func (e *NegativeStockError) Is(target error) bool {
+	return target == ErrNegativeStock
+}
+
+// ============ NEGATIVE STOCK POLICY ============
+
+// negativeStockPolicy - Policy org (org tidak ditemukan = block)
+func (s *InventoryService) negativeStockPolicy(tx *gorm.DB, orgID uuid.UUID) (models.NegativeStockPolicy, error) {
+	var org models.Organization
+	err := tx.Select("negative_stock_policy").Where("id = ?", orgID).Take(&org).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && org.NegativeStockPolicy == "") {
+		return models.NegativeStockBlock, nil
+	}
+	return org.NegativeStockPolicy, err
+}
+
+// recalculate - Chokepoint semua write path: tolak fromDate di periode yang sudah ditutup,
+// recalculation, lalu cek setiap saldo mulai fromDate terhadap policy stok negatif org.
+// changedThrough = tanggal baris terakhir yang diubah write path; recalculation boleh berhenti
+// setelahnya begitu saldo tersimpan sudah cocok.
+func (s *InventoryService) recalculate(tx *gorm.DB, orgID uuid.UUID, itemID uint, fromDate, changedThrough time.Time) error {
+	return s.guardRecalculation(tx, orgID, itemID, fromDate, func() (*repositories.LedgerPosition, error) {
+		stats, err := s.Repo.RecalculateChanged(tx, orgID, itemID, fromDate, changedThrough)
+		if err != nil {
+			return nil, err
+		}
+		return stats.SettledAt, nil
+	})
+}
+
+// recalculateAll - Seperti recalculate tanpa short-circuit (rollback mengganti semua baris setelah
+// fromDate, integrity repair memperbaiki sampai baris terakhir)
+func (s *InventoryService) recalculateAll(tx *gorm.DB, orgID uuid.UUID, itemID uint, fromDate time.Time) error {
+	return s.guardRecalculation(tx, orgID, itemID, fromDate, func() (*repositories.LedgerPosition, error) {
+		return nil, s.Repo.RecalculateForward(tx, orgID, itemID, fromDate)
+	})
+}
+
+// guardRecalculation - Period lock, recalculation, policy stok negatif (org & per lokasi), saldo lot & nomor seri, valuation (cost),
+// event BalanceChanged, lalu evaluasi level stok (alert) terhadap saldo baru.
+// recalc mengembalikan posisi short-circuit recalculation, dipakai valuation untuk berhenti lebih awal.
+func (s *InventoryService) guardRecalculation(tx *gorm.DB, orgID uuid.UUID, itemID uint, fromDate time.Time,
+	recalc func() (*repositories.LedgerPosition, error)) error {
+	if err := s.enforcePeriodLock(tx, orgID, fromDate); err != nil {
+		return err
+	}
+	previous, err := s.projectedBalance(tx, orgID, itemID)
+	if err != nil {
+		return err
+	}
+	settled, err := recalc()
+	if err != nil {
+		return err
+	}
+	if err := s.enforceStockPolicy(tx, orgID, itemID, fromDate); err != nil {
+		return err
+	}
+	if err := s.enforceLocationStock(tx, orgID, itemID, fromDate); err != nil {
+		return err
+	}
+	if err := s.enforceLotStock(tx, orgID, itemID, fromDate); err != nil {
+		return err
+	}
+	if err := s.enforceSerialStock(tx, orgID, itemID); err != nil {
+		return err
+	}
+	if err := s.revalue(tx, orgID, itemID, fromDate, settled); err != nil {
+		return err
+	}
+	if err := s.emitBalanceChanged(tx, orgID, itemID, previous, fromDate); err != nil {
+		return err
+	}
+	return s.evaluateStockLevels(tx, orgID, itemID)
+}
+
+// enforceStockPolicy - Cari saldo negatif pertama mulai fromDate (urutan RecalculateForward)
+func (s *InventoryService) enforceStockPolicy(tx *gorm.DB, orgID uuid.UUID, itemID uint, fromDate time.Time) error {
+	policy, err := s.negativeStockPolicy(tx, orgID)
+	if err != nil {
+		return err
+	}
+	if policy == models.NegativeStockAllow {
+		return nil
+	}
+
+	var first models.Inventory
+	err = tx.
+		Where("organization_id = ? AND item_id = ? AND txn_date >= ? AND deleted_at IS NULL AND balance < 0",
+			orgID, itemID, fromDate).
+		Order("txn_date ASC, created_at ASC").
+		Take(&first).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	negative := &NegativeStockError{
+		OrganizationID: orgID,
+		ItemID:         itemID,
+		InventoryID:    first.ID,
+		TxnDate:        first.TxnDate,
+		Balance:        first.Balance,
+	}
+
+	if policy == models.NegativeStockWarn {
+		log.Printf("⚠️  NEGATIVE STOCK (warn): %v", negative)
+		return nil
+	}
+	return negative
+}
+
+// allowsInsufficientStock - Policy allow/warn melewati pengecekan stok sumber mutasi
+func (s *InventoryService) allowsInsufficientStock(tx *gorm.DB, orgID uuid.UUID) (bool, error) {
+	policy, err := s.negativeStockPolicy(tx, orgID)
+	if err != nil {
+		return false, err
+	}
+	return policy != models.NegativeStockBlock, nil
+}
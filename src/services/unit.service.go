@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+)
+
+var (
+	ErrInvalidQuantity    = errors.New("quantity must have at most 6 decimal places and fewer than 14 integer digits")
+	ErrInvalidUnit        = errors.New("unit must be 1-20 characters")
+	ErrUnitNotAllowed     = errors.New("unit is not allowed for this item")
+	ErrInvalidUnitFactor  = errors.New("unit factor must be positive")
+	ErrBaseUnitConversion = errors.New("base unit cannot have a conversion factor")
+	ErrItemUnitNotFound   = errors.New("item unit not found")
+	ErrBaseUnitLocked     = errors.New("base unit cannot be changed once the item has postings")
+)
+
+// maxQuantity - Batas atas |qty| kolom numeric(20,6)
+var maxQuantity = decimal.New(1, 20-models.QuantityScale)
+
+// validQuantity - Muat di numeric(20,6) tanpa pembulatan
+func validQuantity(qty decimal.Decimal) bool {
+	return qty.Equal(qty.Truncate(models.QuantityScale)) && qty.Abs().LessThan(maxQuantity)
+}
+
+// ItemUnits - Satuan dasar item + satuan alternatif beserta faktor konversinya
+type ItemUnits struct {
+	ItemID   uint              `json:"item_id"`
+	BaseUnit string            `json:"base_unit"`
+	Units    []models.ItemUnit `json:"units"`
+}
+
+// ============ ITEM UNITS (MASTER DATA) ============
+
+// GetItemUnits - Satuan yang boleh dipakai untuk posting item
+func (s *ItemService) GetItemUnits(itemID uint) (*ItemUnits, error) {
+	item, err := s.GetItem(itemID)
+	if err != nil {
+		return nil, err
+	}
+	units, err := s.Repo.FindUnits(itemID)
+	if err != nil {
+		return nil, err
+	}
+	return &ItemUnits{ItemID: item.ID, BaseUnit: item.Unit, Units: units}, nil
+}
+
+// SetItemUnit - Tambah / ubah faktor satuan alternatif (1 unit = factor x satuan dasar).
+// Nama satuan tidak case-sensitive; faktor baru hanya berlaku untuk posting berikutnya,
+// ledger sudah tersimpan dalam satuan dasar.
+func (s *ItemService) SetItemUnit(itemID uint, unit string, factor decimal.Decimal) (*models.ItemUnit, error) {
+	unit = strings.TrimSpace(unit)
+	if unit == "" || len(unit) > 20 {
+		return nil, ErrInvalidUnit
+	}
+	if !factor.IsPositive() || !validQuantity(factor) {
+		return nil, ErrInvalidUnitFactor
+	}
+
+	itemUnit := &models.ItemUnit{ItemID: itemID, Unit: unit, Factor: factor}
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		repo := s.Repo.WithTx(tx)
+		item, err := repo.FindByID(itemID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrItemNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(item.Unit, unit) {
+			return ErrBaseUnitConversion
+		}
+
+		units, err := repo.FindUnits(itemID)
+		if err != nil {
+			return err
+		}
+		if existing := findItemUnit(units, unit); existing != nil {
+			itemUnit.Unit = existing.Unit
+			itemUnit.CreatedAt = existing.CreatedAt
+		}
+		return repo.SaveUnit(itemUnit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("ITEM %d: unit %s = %s base", itemID, itemUnit.Unit, factor)
+	return itemUnit, nil
+}
+
+// DeleteItemUnit - Hapus satuan alternatif (posting lama tidak berubah)
+func (s *ItemService) DeleteItemUnit(itemID uint, unit string) error {
+	units, err := s.Repo.FindUnits(itemID)
+	if err != nil {
+		return err
+	}
+	existing := findItemUnit(units, strings.TrimSpace(unit))
+	if existing == nil {
+		return ErrItemUnitNotFound
+	}
+	return s.Repo.DeleteUnit(itemID, existing.Unit)
+}
+
+// findItemUnit - Cari satuan (case-insensitive)
+func findItemUnit(units []models.ItemUnit, unit string) *models.ItemUnit {
+	for i := range units {
+		if strings.EqualFold(units[i].Unit, unit) {
+			return &units[i]
+		}
+	}
+	return nil
+}
+
+// ============ CONVERSION (write path) ============
+
+// unitFactor - Faktor unit posting ke satuan dasar item (kosong / satuan dasar = 1)
+func (s *InventoryService) unitFactor(tx *gorm.DB, itemID uint, unit string) (decimal.Decimal, error) {
+	unit = strings.TrimSpace(unit)
+	if unit == "" {
+		return decimal.NewFromInt(1), nil
+	}
+
+	var item models.Item
+	err := tx.Select("id", "unit").Where("id = ?", itemID).Take(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return decimal.Zero, ErrItemNotFound
+	}
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if strings.EqualFold(item.Unit, unit) {
+		return decimal.NewFromInt(1), nil
+	}
+
+	var units []models.ItemUnit
+	if err := tx.Where("item_id = ?", itemID).Find(&units).Error; err != nil {
+		return decimal.Zero, err
+	}
+	itemUnit := findItemUnit(units, unit)
+	if itemUnit == nil {
+		return decimal.Zero, ErrUnitNotAllowed
+	}
+	return itemUnit.Factor, nil
+}
+
+// toBaseUnit - Konversi qty posting (dan qty per lot) dari unit ke satuan dasar item.
+// Hasil konversi harus tetap muat di numeric(20,6), tidak ada pembulatan diam-diam.
+func (s *InventoryService) toBaseUnit(tx *gorm.DB, itemID uint, unit string,
+	qty decimal.Decimal, lots []LotQuantity) (decimal.Decimal, []LotQuantity, error) {
+
+	factor, err := s.unitFactor(tx, itemID, unit)
+	if err != nil {
+		return decimal.Zero, nil, err
+	}
+
+	qty = qty.Mul(factor)
+	if !validQuantity(qty) {
+		return decimal.Zero, nil, ErrInvalidQuantity
+	}
+	if len(lots) == 0 {
+		return qty, lots, nil
+	}
+
+	converted := make([]LotQuantity, len(lots))
+	for i, lot := range lots {
+		lot.Quantity = lot.Quantity.Mul(factor)
+		if !validQuantity(lot.Quantity) {
+			return decimal.Zero, nil, ErrInvalidQuantity
+		}
+		converted[i] = lot
+	}
+	return qty, converted, nil
+}
+
+// isUnitError - Error konversi satuan (validasi, bukan error database)
+func isUnitError(err error) bool {
+	return errors.Is(err, ErrInvalidQuantity) || errors.Is(err, ErrUnitNotAllowed)
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+)
+
+var (
+	ErrInvalidUnitCost      = errors.New("unit_cost must not be negative")
+	ErrUnitCostNotAllowed   = errors.New("unit_cost is only allowed on incoming stok_awal and penerimaan")
+	ErrInvalidCostingMethod = errors.New("costing_method must be fifo or average")
+	ErrRevaluationCascade   = errors.New("revaluation did not settle across mutation counterparts")
+	// ErrRevaluationBusy - Org tujuan cascade sedang dikunci posting lain; aman di-retry
+	ErrRevaluationBusy = errors.New("revaluation cascade is blocked by a concurrent posting, retry the request")
+)
+
+// maxRevaluePasses - Batas revalue berantai antar organisasi lewat mutasi (satu pass = satu org)
+const maxRevaluePasses = 100
+
+// cascadeLockAttempts / cascadeLockBackoff - Lock org tujuan cascade dicoba tanpa menunggu.
+// Lock org asal sudah dipegang, jadi menunggu lock org lain bisa deadlock (A→C vs C→A).
+const (
+	cascadeLockAttempts = 5
+	cascadeLockBackoff  = 20 * time.Millisecond
+)
+
+// acceptsUnitCost - Harga input hanya untuk stok masuk dari luar (stok_awal/penerimaan positif)
+func acceptsUnitCost(txnType models.InventoryType, amount decimal.Decimal) bool {
+	return amount.IsPositive() && (txnType == models.InventoryTypeStokAwal || txnType == models.InventoryTypePenerimaan)
+}
+
+// validateUnitCost - Validasi unit_cost opsional untuk type+amount baris
+func validateUnitCost(unitCost *decimal.Decimal, txnType models.InventoryType, amount decimal.Decimal) error {
+	if unitCost == nil {
+		return nil
+	}
+	if unitCost.IsNegative() {
+		return ErrInvalidUnitCost
+	}
+	if !acceptsUnitCost(txnType, amount) {
+		return ErrUnitCostNotAllowed
+	}
+	return nil
+}
+
+// ============ PUBLIC METHODS ============
+
+// GetCostLayers - Layer FIFO yang masih terbuka (item average: kosong)
+func (s *InventoryService) GetCostLayers(orgID uuid.UUID, itemID uint) ([]models.CostLayer, error) {
+	return s.Repo.GetCostLayers(orgID, itemID)
+}
+
+// RevalueReport - Hasil RevalueAll; item yang cascade-nya menyentuh periode tertutup dilewati
+type RevalueReport struct {
+	Items    int           `json:"items"`
+	Revalued int           `json:"revalued"`
+	Skipped  []RevalueSkip `json:"skipped"`
+}
+
+// RevalueSkip - Item yang tidak dinilai ulang beserta alasannya
+type RevalueSkip struct {
+	ItemID uint   `json:"item_id"`
+	Reason string `json:"reason"`
+}
+
+// RevalueAll - Nilai ulang seluruh ledger (data sebelum valuation ada / setelah koreksi manual).
+// Satu DB transaction per item supaya mutasi antar organisasi ikut konsisten. Periode tertutup tidak
+// ditulis ulang: setiap org dinilai mulai hari setelah closing aktifnya, dan item yang cascade-nya
+// masuk periode tertutup org lain dilewati (dilaporkan di Skipped).
+func (s *InventoryService) RevalueAll() (*RevalueReport, error) {
+	var itemIDs []uint
+	if err := s.DB.Model(&models.Inventory{}).Distinct("item_id").Order("item_id").Pluck("item_id", &itemIDs).Error; err != nil {
+		return nil, err
+	}
+
+	report := &RevalueReport{Items: len(itemIDs), Skipped: []RevalueSkip{}}
+	for _, itemID := range itemIDs {
+		err := s.DB.Transaction(func(tx *gorm.DB) error {
+			return s.revalueItem(tx, itemID, true)
+		})
+		if errors.Is(err, ErrPeriodClosed) {
+			log.Printf("REVALUE SKIPPED: item=%d: %v", itemID, err)
+			report.Skipped = append(report.Skipped, RevalueSkip{ItemID: itemID, Reason: err.Error()})
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Revalued++
+	}
+	return report, nil
+}
+
+// ============ REVALUATION (di dalam transaksi write path) ============
+
+// revalueGuard - Dipanggil sebelum org lain ikut dinilai ulang (misal period lock)
+type revalueGuard func(tx *gorm.DB, orgID uuid.UUID, fromDate time.Time) error
+
+// costingMethod - Metode costing item (default average)
+func (s *InventoryService) costingMethod(tx *gorm.DB, itemID uint) (models.CostingMethod, error) {
+	var item models.Item
+	err := tx.Select("costing_method").Where("id = ?", itemID).Take(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && item.CostingMethod == "") {
+		return models.CostingAverage, nil
+	}
+	return item.CostingMethod, err
+}
+
+// revalueStart - Titik mulai valuation satu org. Settled = posisi mulai baris ledger sama dengan
+// yang dinilai valuation sebelumnya (nil = nilai sampai baris terakhir).
+type revalueStart struct {
+	FromDate time.Time
+	Settled  *repositories.LedgerPosition
+}
+
+// revalue - Dipanggil chokepoint recalculation setelah saldo final. Org tujuan mutasi yang
+// cost leg keluarnya berubah ikut dinilai ulang (period lock org itu tetap berlaku).
+func (s *InventoryService) revalue(tx *gorm.DB, orgID uuid.UUID, itemID uint, fromDate time.Time,
+	settled *repositories.LedgerPosition) error {
+	starts := map[uuid.UUID]revalueStart{orgID: {FromDate: fromDate, Settled: settled}}
+	return s.revalueFrom(tx, itemID, starts, s.enforcePeriodLock)
+}
+
+// revalueItem - Nilai ulang semua organisasi yang punya ledger item (ganti metode / RevalueAll), di bawah
+// period lock. openOnly=false mulai dari awal ledger (ditolak jika ada periode tertutup); openOnly=true
+// mulai hari setelah closing aktif tiap org.
+func (s *InventoryService) revalueItem(tx *gorm.DB, itemID uint, openOnly bool) error {
+	var orgIDs []uuid.UUID
+	if err := tx.Model(&models.Inventory{}).Where("item_id = ?", itemID).
+		Distinct("organization_id").Pluck("organization_id", &orgIDs).Error; err != nil {
+		return err
+	}
+
+	keys := make([]orgItemKey, 0, len(orgIDs))
+	starts := make(map[uuid.UUID]revalueStart, len(orgIDs))
+	for _, orgID := range orgIDs {
+		var fromDate time.Time
+		if openOnly {
+			closing, err := s.activeClosing(tx, orgID)
+			if err != nil {
+				return err
+			}
+			if closing != nil {
+				fromDate = closing.PeriodEnd.AddDate(0, 0, 1)
+			}
+		}
+		if err := s.enforcePeriodLock(tx, orgID, fromDate); err != nil {
+			return err
+		}
+		keys = append(keys, orgItemKey{orgID, itemID})
+		starts[orgID] = revalueStart{FromDate: fromDate}
+	}
+	if err := s.lockOrgItems(tx, keys...); err != nil {
+		return err
+	}
+	return s.revalueFrom(tx, itemID, starts, s.enforcePeriodLock)
+}
+
+// revalueFrom - Antrian org+tanggal yang harus dinilai ulang; mutasi keluar yang berubah
+// menambahkan org tujuan (atau memajukan tanggalnya) sampai tidak ada lagi yang berubah.
+// Saldo org tujuan tidak diubah cascade, jadi valuation-nya boleh berhenti mulai tanggal mutasi.
+func (s *InventoryService) revalueFrom(tx *gorm.DB, itemID uint, starts map[uuid.UUID]revalueStart, guard revalueGuard) error {
+	method, err := s.costingMethod(tx, itemID)
+	if err != nil {
+		return err
+	}
+
+	pending := make(map[uuid.UUID]revalueStart, len(starts))
+	queue := make([]uuid.UUID, 0, len(starts))
+	for orgID, start := range starts {
+		pending[orgID] = start
+		queue = append(queue, orgID)
+	}
+	sort.Slice(queue, func(i, j int) bool { return queue[i].String() < queue[j].String() })
+
+	// Org awal sudah dikunci pemanggil; pg_try_advisory_xact_lock reentrant di sesi yang sama
+	locked := make(map[int64]bool, len(starts))
+	for orgID := range starts {
+		locked[repositories.OrgItemLockKey(orgID, itemID)] = true
+	}
+
+	for passes := 0; len(queue) > 0; passes++ {
+		if passes == maxRevaluePasses {
+			return ErrRevaluationCascade
+		}
+		orgID := queue[0]
+		queue = queue[1:]
+		start := pending[orgID]
+		delete(pending, orgID)
+
+		stats, err := s.Repo.Revalue(tx, orgID, itemID, start.FromDate, start.Settled, method)
+		if err != nil {
+			return err
+		}
+
+		targets := make([]orgItemKey, 0, len(stats.ChangedTransfers))
+		for _, change := range stats.ChangedTransfers {
+			queued, ok := pending[change.ToOrganizationID]
+			if ok && !change.TxnDate.Before(queued.FromDate) {
+				continue
+			}
+			if guard != nil {
+				if err := guard(tx, change.ToOrganizationID, change.TxnDate); err != nil {
+					return err
+				}
+			}
+			if ok {
+				// Tetap pakai settled antrian lama: hanya tanggal mulai yang maju
+				queued.FromDate = change.TxnDate
+				pending[change.ToOrganizationID] = queued
+				continue
+			}
+			targets = append(targets, orgItemKey{change.ToOrganizationID, itemID})
+			queue = append(queue, change.ToOrganizationID)
+			pending[change.ToOrganizationID] = revalueStart{
+				FromDate: change.TxnDate,
+				Settled:  &repositories.LedgerPosition{TxnDate: change.TxnDate},
+			}
+			log.Printf("REVALUE CASCADE: org=%v item=%d from %v (mutation cost changed)",
+				change.ToOrganizationID, itemID, change.TxnDate)
+		}
+		if err := s.tryLockOrgItems(tx, locked, ErrRevaluationBusy, targets...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tryLockOrgItems - Kunci org tambahan (urut key seperti lockOrgItems) tanpa menunggu, untuk lock yang
+// baru ketahuan setelah lock lain dipegang. Lock yang tidak didapat setelah beberapa percobaan → busy,
+// transaksi dibatalkan supaya dua operasi yang saling silang tidak deadlock. Lock yang sudah dipegang
+// dicatat di locked.
+func (s *InventoryService) tryLockOrgItems(tx *gorm.DB, locked map[int64]bool, busy error, keys ...orgItemKey) error {
+	lockKeys := make([]int64, 0, len(keys))
+	for _, key := range keys {
+		lockKey := repositories.OrgItemLockKey(key.OrganizationID, key.ItemID)
+		if !locked[lockKey] {
+			locked[lockKey] = true
+			lockKeys = append(lockKeys, lockKey)
+		}
+	}
+	sort.Slice(lockKeys, func(i, j int) bool { return lockKeys[i] < lockKeys[j] })
+
+	for _, lockKey := range lockKeys {
+		acquired := false
+		for attempt := 1; !acquired; attempt++ {
+			ok, err := s.Repo.TryAdvisoryLock(tx, lockKey)
+			if err != nil {
+				return err
+			}
+			acquired = ok
+			if !acquired {
+				if attempt == cascadeLockAttempts {
+					return busy
+				}
+				time.Sleep(time.Duration(attempt) * cascadeLockBackoff)
+			}
+		}
+	}
+	return nil
+}
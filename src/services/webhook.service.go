@@ -0,0 +1,526 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"inventory-ledger/src/models"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookInvalidURL       = errors.New("webhook url must be an absolute http or https url")
+	ErrWebhookUnknownEvent     = errors.New("unknown event type")
+	ErrWebhookSecretTooShort   = errors.New("webhook secret must be at least 16 characters")
+	ErrWebhookDeliveryPending  = errors.New("webhook delivery is still pending")
+)
+
+// Header yang dikirim ke receiver. Signature = "sha256=" + hex(HMAC-SHA256(secret, "<timestamp>.<body>")).
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// webhookResponseLimit - Potongan body response receiver yang disimpan di delivery log
+const webhookResponseLimit = 1024
+
+// ============ REQUEST STRUCTS ============
+type WebhookSubscriptionRequest struct {
+	Name            string
+	URL             string
+	Secret          string // kosong = generate (create) / tidak diubah (update)
+	EventTypes      []string
+	OrganizationIDs []uuid.UUID
+	IsActive        *bool
+}
+
+// ============ WEBHOOK SERVICE ============
+// WebhookService - CRUD subscription, fan-out event outbox ke delivery, dan pengiriman HTTP
+// dengan exponential backoff. Setelah MaxAttempts delivery berstatus failed (bisa redeliver manual).
+type WebhookService struct {
+	DB     *gorm.DB
+	Client *http.Client // default timeout 10s
+
+	BatchSize    int           // default 20
+	PollInterval time.Duration // default 1s
+	MaxAttempts  int           // default 8
+	BaseBackoff  time.Duration // default 1s
+	MaxBackoff   time.Duration // default 10m
+	Lease        time.Duration // default BatchSize × timeout client + 1m, sebelum delivery boleh diklaim ulang
+}
+
+// ============ SUBSCRIPTIONS ============
+
+// ListSubscriptions - Semua subscription, terbaru dulu
+func (s *WebhookService) ListSubscriptions() ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := s.DB.Order("created_at DESC").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// GetSubscription - Detail subscription
+func (s *WebhookService) GetSubscription(id uuid.UUID) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	err := s.DB.Where("id = ?", id).Take(&subscription).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// CreateSubscription - Buat subscription; secret di-generate jika tidak diisi
+func (s *WebhookService) CreateSubscription(req WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	subscription := &models.WebhookSubscription{IsActive: true}
+	if req.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		req.Secret = secret
+	}
+	if err := s.applySubscriptionRequest(subscription, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.Create(subscription).Error; err != nil {
+		return nil, err
+	}
+
+	log.Printf("WEBHOOK CREATED: %s -> %s", subscription.Name, subscription.URL)
+	return subscription, nil
+}
+
+// UpdateSubscription - Ubah subscription (secret kosong = tetap)
+func (s *WebhookService) UpdateSubscription(id uuid.UUID, req WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	subscription, err := s.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applySubscriptionRequest(subscription, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.Save(subscription).Error; err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// DeleteSubscription - Hapus subscription beserta delivery log-nya
+func (s *WebhookService) DeleteSubscription(id uuid.UUID) error {
+	result := s.DB.Where("id = ?", id).Delete(&models.WebhookSubscription{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// applySubscriptionRequest - Validasi URL, secret, event type & organisasi lalu salin ke model
+func (s *WebhookService) applySubscriptionRequest(subscription *models.WebhookSubscription, req WebhookSubscriptionRequest) error {
+	parsed, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrWebhookInvalidURL
+	}
+	if req.Secret != "" && len(req.Secret) < 16 {
+		return ErrWebhookSecretTooShort
+	}
+	for _, eventType := range req.EventTypes {
+		if !isValidEventType(eventType) {
+			return fmt.Errorf("%w %q", ErrWebhookUnknownEvent, eventType)
+		}
+	}
+	for _, orgID := range req.OrganizationIDs {
+		var count int64
+		if err := s.DB.Model(&models.Organization{}).Where("id = ?", orgID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrOrganizationNotFound
+		}
+	}
+
+	subscription.Name = strings.TrimSpace(req.Name)
+	subscription.URL = parsed.String()
+	if req.Secret != "" {
+		subscription.Secret = req.Secret
+	}
+	subscription.EventTypes = append([]string{}, req.EventTypes...)
+	subscription.OrganizationIDs = append([]uuid.UUID{}, req.OrganizationIDs...)
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// ============ DELIVERY LOG ============
+
+// ListDeliveries - Delivery log subscription (opsional filter status), terbaru dulu
+func (s *WebhookService) ListDeliveries(subscriptionID uuid.UUID, status string, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	if _, err := s.GetSubscription(subscriptionID); err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	var total int64
+
+	query := s.DB.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order("created_at DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&deliveries).Error
+
+	return deliveries, total, err
+}
+
+// GetDelivery - Detail satu delivery milik subscription
+func (s *WebhookService) GetDelivery(subscriptionID, deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := s.DB.Where("id = ? AND subscription_id = ?", deliveryID, subscriptionID).Take(&delivery).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Redeliver - Kirim ulang payload yang sama sebagai delivery baru (log delivery lama tidak diubah)
+func (s *WebhookService) Redeliver(subscriptionID, deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
+	original, err := s.GetDelivery(subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if original.Status == models.WebhookDeliveryPending {
+		return nil, ErrWebhookDeliveryPending
+	}
+
+	now := time.Now()
+	redelivery := &models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		RedeliveryOf:   &original.ID,
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+	if err := s.DB.Create(redelivery).Error; err != nil {
+		return nil, err
+	}
+
+	log.Printf("WEBHOOK REDELIVERY: delivery %v -> %v (event %d)", original.ID, redelivery.ID, original.EventID)
+	return redelivery, nil
+}
+
+// ============ FAN-OUT (OUTBOX SINK) ============
+
+// WebhookSink - Sink outbox yang membuat delivery untuk setiap subscription yang cocok.
+// Pengiriman HTTP dilakukan terpisah oleh WebhookService.Run, jadi receiver yang lambat
+// tidak menahan event outbox untuk sink lain.
+type WebhookSink struct {
+	Service *WebhookService
+}
+
+func (WebhookSink) Name() string { return "webhook" }
+
+func (w WebhookSink) Deliver(ctx context.Context, event *models.OutboxEvent) error {
+	return w.Service.enqueue(ctx, event)
+}
+
+// enqueue - Satu delivery per subscription cocok; event yang dikirim ulang outbox tidak digandakan
+func (s *WebhookService) enqueue(ctx context.Context, event *models.OutboxEvent) error {
+	var subscriptions []models.WebhookSubscription
+	if err := s.DB.WithContext(ctx).Where("is_active = ?", true).Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event.Envelope())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, 0)
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(event.EventType, event.OrganizationID) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.EventType,
+			Payload:        payload,
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "subscription_id"}, {Name: "event_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "redelivery_of IS NULL"}}},
+		DoNothing:   true,
+	}).Create(&deliveries).Error
+}
+
+// ============ HTTP DELIVERY ============
+
+// SignWebhookPayload - Signature yang dikirim di X-Webhook-Signature (dipakai juga receiver untuk verifikasi)
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run - Loop pengiriman delivery pending sampai ctx selesai
+func (s *WebhookService) Run(ctx context.Context) {
+	log.Println("Webhook dispatcher started")
+
+	for {
+		processed, err := s.DispatchOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Webhook dispatch failed: %v", err)
+		}
+		if err == nil && processed >= s.batchSize() {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Webhook dispatcher stopped")
+			return
+		case <-time.After(s.pollInterval()):
+		}
+	}
+}
+
+// DispatchOnce - Klaim satu batch delivery pending yang jatuh tempo, kirim HTTP di luar transaksi,
+// lalu simpan hasil tiap delivery
+func (s *WebhookService) DispatchOnce(ctx context.Context) (int, error) {
+	deliveries, err := s.claim(ctx)
+	if err != nil || len(deliveries) == 0 {
+		return 0, err
+	}
+
+	subscriptionIDs := make([]uuid.UUID, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		subscriptionIDs = append(subscriptionIDs, delivery.SubscriptionID)
+	}
+	var subscriptions []models.WebhookSubscription
+	if err := s.DB.WithContext(ctx).Where("id IN ?", subscriptionIDs).Find(&subscriptions).Error; err != nil {
+		return 0, err
+	}
+	byID := make(map[uuid.UUID]*models.WebhookSubscription, len(subscriptions))
+	for i := range subscriptions {
+		byID[subscriptions[i].ID] = &subscriptions[i]
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		subscription := byID[delivery.SubscriptionID]
+
+		inactive := subscription == nil || !subscription.IsActive
+
+		var statusCode int
+		var response string
+		var sendErr error
+		if inactive {
+			sendErr = errors.New("subscription is inactive")
+		} else {
+			statusCode, response, sendErr = s.send(ctx, subscription, delivery)
+		}
+
+		if err := s.record(delivery, statusCode, response, sendErr, inactive); err != nil {
+			return i, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// claim - Ambil batch delivery (FOR UPDATE SKIP LOCKED) dan majukan next_attempt_at selama lease,
+// lalu commit sebelum request HTTP dikirim. Receiver yang lambat tidak menahan lock/koneksi DB;
+// jika dispatcher mati di tengah jalan, delivery dikirim lagi setelah lease habis.
+func (s *WebhookService) claim(ctx context.Context) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Order("next_attempt_at, created_at").
+			Limit(s.batchSize()).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(s.lease())).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// send - POST payload bertanda tangan ke URL subscription; sukses = 2xx
+func (s *WebhookService) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, string, error) {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "inventory-ledger-webhook/1")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(subscription.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(body), fmt.Errorf("receiver responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// record - Simpan hasil attempt: delivered, jadwal retry, atau failed (attempts habis / subscription nonaktif)
+func (s *WebhookService) record(delivery *models.WebhookDelivery, statusCode int, response string, sendErr error, giveUp bool) error {
+	now := time.Now()
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":         attempts,
+		"last_status_code": nil,
+		"last_response":    nil,
+	}
+	if statusCode != 0 {
+		updates["last_status_code"] = statusCode
+		updates["last_response"] = response
+	}
+
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.WebhookDeliveryDelivered
+		updates["delivered_at"] = now
+		updates["last_error"] = nil
+	case giveUp || attempts >= s.maxAttempts():
+		log.Printf("☠️  Webhook delivery %v (%s) failed after %d attempts: %v",
+			delivery.ID, delivery.EventType, attempts, sendErr)
+		updates["status"] = models.WebhookDeliveryFailed
+		updates["last_error"] = sendErr.Error()
+	default:
+		log.Printf("⚠️  Webhook delivery %v (%s) attempt %d failed: %v",
+			delivery.ID, delivery.EventType, attempts, sendErr)
+		updates["next_attempt_at"] = now.Add(exponentialBackoff(s.BaseBackoff, s.MaxBackoff, attempts))
+		updates["last_error"] = sendErr.Error()
+	}
+
+	return s.DB.Model(delivery).Updates(updates).Error
+}
+
+// ============ HELPERS ============
+
+func isValidEventType(eventType string) bool {
+	for _, known := range EventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// generateWebhookSecret - 32 byte acak, hex
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+func (s *WebhookService) client() *http.Client {
+	if s.Client == nil {
+		return &http.Client{Timeout: 10 * time.Second}
+	}
+	return s.Client
+}
+
+func (s *WebhookService) batchSize() int {
+	if s.BatchSize <= 0 {
+		return 20
+	}
+	return s.BatchSize
+}
+
+func (s *WebhookService) pollInterval() time.Duration {
+	if s.PollInterval <= 0 {
+		return time.Second
+	}
+	return s.PollInterval
+}
+
+func (s *WebhookService) lease() time.Duration {
+	if s.Lease <= 0 {
+		return time.Duration(s.batchSize())*s.client().Timeout + time.Minute
+	}
+	return s.Lease
+}
+
+func (s *WebhookService) maxAttempts() int {
+	if s.MaxAttempts <= 0 {
+		return 8
+	}
+	return s.MaxAttempts
+}
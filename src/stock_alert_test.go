@@ -0,0 +1,255 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/services"
+)
+
+// flakyNotifier - Notifier test yang gagal sebanyak failures kali pertama
+type flakyNotifier struct {
+	failures int
+	notified int
+}
+
+func (n *flakyNotifier) Name() string { return "flaky" }
+
+func (n *flakyNotifier) Notify(ctx context.Context, eventType string, alert services.StockAlertEvent) error {
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("mail server unavailable")
+	}
+	n.notified++
+	return nil
+}
+
+// ============ TEST SCENARIO 22: STOCK LEVEL ALERTS ============
+func TestStockLevelAlerts(t *testing.T) {
+	assertNoError(t, testDB.Exec("DELETE FROM outbox_events").Error)
+
+	orgID := uuid.New()
+	branchID := uuid.New()
+	testDB.Create(&models.Organization{ID: orgID, Name: "Alert Org", Code: "ORG-ALERT"})
+	testDB.Create(&models.Organization{ID: branchID, Name: "Alert Branch", Code: "ORG-ALERT-2"})
+
+	alertService := &services.AlertService{DB: testDB, Inventory: testService}
+	base := time.Date(2025, 5, 1, 8, 0, 0, 0, time.UTC)
+	qtyPtr := func(v int64) *decimal.Decimal { return models.QtyPtr(models.Qty(v)) }
+
+	activeAlert := func(alertType models.StockAlertType) *models.StockAlert {
+		alerts, _, err := alertService.ListAlerts(services.AlertFilter{
+			OrganizationID: orgID, ItemID: testItemID, AlertType: alertType, Page: 1, Limit: 10,
+		})
+		assertNoError(t, err)
+		for i := range alerts {
+			if alerts[i].IsActive() {
+				return &alerts[i]
+			}
+		}
+		return nil
+	}
+
+	t.Run("SC47: Postings raise and resolve alerts as the balance crosses levels", func(t *testing.T) {
+		_, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: testItemID, TxnDate: base, Amount: models.Qty(100), Type: "stok_awal", ChangedBy: "alert_test",
+		})
+		assertNoError(t, err)
+
+		_, err = alertService.SetStockLevel(services.StockLevelRequest{
+			OrganizationID: orgID, ItemID: testItemID, MinQty: qtyPtr(30), ReorderPoint: qtyPtr(20), UpdatedBy: "alert_test",
+		})
+		assertEqual(t, services.ErrInvalidStockLevels, err, "reorder below min rejected")
+
+		_, err = alertService.SetStockLevel(services.StockLevelRequest{
+			OrganizationID: orgID, ItemID: testItemID,
+			MinQty: qtyPtr(20), ReorderPoint: qtyPtr(30), MaxQty: qtyPtr(90), UpdatedBy: "alert_test",
+		})
+		assertNoError(t, err)
+
+		// Saldo 100 sudah di atas max saat level di-set
+		aboveMax := activeAlert(models.StockAlertAboveMax)
+		assertEqual(t, true, aboveMax != nil, "above_max raised on set")
+		assertEqual(t, 100, aboveMax.Balance, "above_max balance")
+
+		// Mutasi keluar 75 -> saldo 25: above_max resolved, reorder raised
+		assertNoError(t, testService.CreateMutation(services.MutationRequest{
+			FromOrganizationID: orgID, ToOrganizationID: branchID, ItemID: testItemID,
+			Quantity: models.Qty(75), TxnDate: base.Add(time.Hour), ChangedBy: "alert_test",
+		}))
+		assertEqual(t, true, activeAlert(models.StockAlertAboveMax) == nil, "above_max resolved")
+		assertEqual(t, true, activeAlert(models.StockAlertReorder) != nil, "reorder raised")
+		assertEqual(t, true, activeAlert(models.StockAlertBelowMin) == nil, "below_min not raised yet")
+
+		// Opname 10 -> below_min raised, reorder tetap satu alert aktif
+		_, err = testService.CreateOpname(services.OpnameRequest{
+			OrganizationID: orgID, ItemID: testItemID, PhysicalQty: models.Qty(10), TxnDate: base.Add(2 * time.Hour), ChangedBy: "alert_test",
+		})
+		assertNoError(t, err)
+		belowMin := activeAlert(models.StockAlertBelowMin)
+		assertEqual(t, true, belowMin != nil, "below_min raised")
+		assertEqual(t, 20, belowMin.Threshold, "below_min threshold")
+
+		acknowledged, err := alertService.AcknowledgeAlert(services.AcknowledgeAlertRequest{
+			AlertID: belowMin.ID, AcknowledgedBy: "alert_test",
+		})
+		assertNoError(t, err)
+		assertEqual(t, models.StockAlertAcknowledged, acknowledged.State, "acknowledged state")
+		_, err = alertService.AcknowledgeAlert(services.AcknowledgeAlertRequest{AlertID: belowMin.ID, AcknowledgedBy: "alert_test"})
+		assertEqual(t, services.ErrAlertNotOpen, err, "acknowledge twice")
+
+		// Penerimaan 50 -> saldo 60: below_min (acknowledged) & reorder resolved
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: testItemID, TxnDate: base.Add(3 * time.Hour), Amount: models.Qty(50), Type: "penerimaan", ChangedBy: "alert_test",
+		})
+		assertNoError(t, err)
+		resolved, err := alertService.GetAlert(belowMin.ID)
+		assertNoError(t, err)
+		assertEqual(t, models.StockAlertResolved, resolved.State, "below_min resolved")
+		assertEqual(t, 60, *resolved.ResolvedBalance, "resolved balance")
+		assertEqual(t, true, activeAlert(models.StockAlertReorder) == nil, "reorder resolved")
+
+		var raisedCount, resolvedCount int64
+		testDB.Model(&models.OutboxEvent{}).Where("organization_id = ? AND event_type = ?", orgID, services.EventStockAlertRaised).Count(&raisedCount)
+		testDB.Model(&models.OutboxEvent{}).Where("organization_id = ? AND event_type = ?", orgID, services.EventStockAlertResolved).Count(&resolvedCount)
+		assertEqual(t, int64(3), raisedCount, "raised events")
+		assertEqual(t, int64(3), resolvedCount, "resolved events")
+
+		// Org tujuan mutasi tanpa level stok: tidak ada alert
+		_, branchTotal, err := alertService.ListAlerts(services.AlertFilter{OrganizationID: branchID, Page: 1, Limit: 10})
+		assertNoError(t, err)
+		assertEqual(t, int64(0), branchTotal, "branch alerts")
+	})
+
+	t.Run("SC48: Alert events reach notifiers through the outbox", func(t *testing.T) {
+		type received struct {
+			event     string
+			signature string
+			timestamp string
+			body      []byte
+		}
+		calls := make(chan received, 10)
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			calls <- received{
+				event:     r.Header.Get(services.WebhookEventHeader),
+				signature: r.Header.Get(services.WebhookSignatureHeader),
+				timestamp: r.Header.Get(services.WebhookTimestampHeader),
+				body:      body,
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		assertNoError(t, testDB.Exec("DELETE FROM outbox_events").Error)
+
+		// Saldo 60 -> 5: reorder & below_min raised
+		_, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: testItemID, TxnDate: base.Add(4 * time.Hour), Amount: models.Qty(-55), Type: "pemakaian", ChangedBy: "alert_test",
+		})
+		assertNoError(t, err)
+
+		flaky := &flakyNotifier{failures: 1}
+		notifications := &services.AlertNotificationDispatcher{
+			DB: testDB,
+			Notifiers: []services.AlertNotifier{
+				services.LogNotifier{},
+				services.WebhookNotifier{URL: receiver.URL, Secret: "alert-secret-0123456789"},
+				flaky,
+			},
+			BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond,
+		}
+		dispatcher := &services.OutboxDispatcher{
+			DB:    testDB,
+			Sinks: []services.EventSink{services.AlertNotifierSink{Dispatcher: notifications}},
+		}
+		for {
+			processed, err := dispatcher.DispatchOnce(context.Background())
+			assertNoError(t, err)
+			if processed == 0 {
+				break
+			}
+		}
+
+		// Outbox selesai tanpa menunggu notifier: notifikasi diantrekan per notifier
+		var pendingEvents, queued int64
+		testDB.Model(&models.OutboxEvent{}).Where("status = ?", models.OutboxStatusPending).Count(&pendingEvents)
+		testDB.Model(&models.AlertNotification{}).Where("status = ?", models.AlertNotificationPending).Count(&queued)
+		assertEqual(t, int64(0), pendingEvents, "outbox drained")
+		assertEqual(t, int64(6), queued, "two alerts for three notifiers")
+
+		// Notifier yang gagal di-retry sendiri tanpa mengirim ulang notifier lain
+		_, err = notifications.DispatchOnce(context.Background())
+		assertNoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+		_, err = notifications.DispatchOnce(context.Background())
+		assertNoError(t, err)
+		assertEqual(t, 2, flaky.notified, "flaky notifier after retry")
+
+		var retried models.AlertNotification
+		assertNoError(t, testDB.Where("notifier = ? AND attempts = 2", "flaky").Take(&retried).Error)
+		assertEqual(t, models.AlertNotificationDelivered, retried.Status, "retried notification delivered")
+
+		// BalanceChanged & TransactionCreated dilewati notifier, hanya 2 alert yang dikirim
+		assertEqual(t, 2, len(calls), "notifier calls")
+		for i := 0; i < 2; i++ {
+			call := <-calls
+			assertEqual(t, services.EventStockAlertRaised, call.event, "notified event")
+
+			timestamp, err := strconv.ParseInt(call.timestamp, 10, 64)
+			assertNoError(t, err)
+			assertEqual(t, services.SignWebhookPayload("alert-secret-0123456789", timestamp, call.body), call.signature, "signature")
+
+			var payload struct {
+				Data services.StockAlertEvent `json:"data"`
+			}
+			assertNoError(t, json.Unmarshal(call.body, &payload))
+			assertEqual(t, 5, payload.Data.Balance, "notified balance")
+		}
+
+		// Level dihapus: alert aktif resolved
+		assertNoError(t, alertService.DeleteStockLevel(orgID, testItemID))
+		assertEqual(t, true, activeAlert(models.StockAlertBelowMin) == nil, "below_min resolved on delete")
+		assertEqual(t, true, activeAlert(models.StockAlertReorder) == nil, "reorder resolved on delete")
+		assertEqual(t, services.ErrStockLevelNotFound, alertService.DeleteStockLevel(orgID, testItemID), "delete twice")
+	})
+
+	t.Run("SC65: SMTP notifier gives up on a server that never answers", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assertNoError(t, err)
+		defer listener.Close()
+		go func() {
+			// Terima koneksi tapi tidak pernah mengirim greeting SMTP
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+			}
+		}()
+
+		notifier := services.SMTPNotifier{
+			Addr: listener.Addr().String(), From: "alerts@example.com", To: []string{"ops@example.com"},
+			Timeout: 50 * time.Millisecond,
+		}
+		started := time.Now()
+		err = notifier.Notify(context.Background(), services.EventStockAlertRaised, services.StockAlertEvent{})
+		if err == nil {
+			t.Fatal("expected timeout error")
+		}
+		assertEqual(t, true, time.Since(started) < time.Second, "notify bounded by timeout")
+	})
+}
@@ -0,0 +1,138 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"inventory-ledger/src/migrations"
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/services"
+)
+
+// ============ TEST SCENARIO 17: NEGATIVE STOCK POLICY ============
+func TestNegativeStockPolicy(t *testing.T) {
+	base := time.Date(2025, 3, 3, 8, 0, 0, 0, time.UTC)
+
+	newOrg := func(code string, policy models.NegativeStockPolicy) uuid.UUID {
+		orgID := uuid.New()
+		org := models.Organization{ID: orgID, Name: "Policy " + code, Code: code, NegativeStockPolicy: policy}
+		assertNoError(t, testDB.Create(&org).Error)
+		return orgID
+	}
+	post := func(orgID uuid.UUID, offset time.Duration, amount int, txnType string) (*models.Inventory, error) {
+		return testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID,
+			ItemID:         testItemID,
+			TxnDate:        base.Add(offset),
+			Amount:         models.Qty(int64(amount)),
+			Type:           txnType,
+			ChangedBy:      "policy_test",
+		})
+	}
+
+	t.Run("SC36: Block rejects postings and backdated changes that go negative", func(t *testing.T) {
+		orgID := newOrg("ORG-POLICY-BLOCK", "")
+
+		_, err := post(orgID, 0, 100, "stok_awal")
+		assertNoError(t, err)
+		receipt, err := post(orgID, 24*time.Hour, 20, "penerimaan")
+		assertNoError(t, err)
+		_, err = post(orgID, 72*time.Hour, -110, "pemakaian")
+		assertNoError(t, err)
+
+		// Langsung negatif
+		_, err = post(orgID, 96*time.Hour, -20, "pemakaian")
+		if !errors.Is(err, services.ErrNegativeStock) {
+			t.Fatalf("expected ErrNegativeStock, got %v", err)
+		}
+
+		// Backdated: saldo hari ke-2 masih 70, tapi hari ke-3 jadi -20
+		_, err = post(orgID, 48*time.Hour, -50, "pemakaian")
+		var negative *services.NegativeStockError
+		if !errors.As(err, &negative) {
+			t.Fatalf("expected NegativeStockError, got %v", err)
+		}
+		assertEqual(t, true, negative.TxnDate.Equal(base.Add(72*time.Hour)), "first negative date")
+		assertEqual(t, -40, negative.Balance, "first negative balance")
+
+		// Delete penerimaan membuat pemakaian hari ke-3 negatif
+		err = testService.DeleteTransaction(receipt.ID, "policy_test", nil)
+		if !errors.Is(err, services.ErrNegativeStock) {
+			t.Fatalf("expected ErrNegativeStock on delete, got %v", err)
+		}
+
+		// Semua penolakan di-rollback
+		assertEqual(t, 10, assertBalanceChain(t, orgID, testItemID), "balance unchanged")
+	})
+
+	t.Run("SC37: Warn and allow post negative balances", func(t *testing.T) {
+		for _, policy := range []models.NegativeStockPolicy{models.NegativeStockWarn, models.NegativeStockAllow} {
+			orgID := newOrg("ORG-POLICY-"+string(policy), policy)
+
+			_, err := post(orgID, 0, 10, "stok_awal")
+			assertNoError(t, err)
+			inv, err := post(orgID, 24*time.Hour, -25, "pemakaian")
+			assertNoError(t, err)
+			assertEqual(t, -15, inv.Balance, string(policy)+" balance")
+		}
+	})
+
+	t.Run("SC38: Mutation stock check follows source policy", func(t *testing.T) {
+		blockOrg := newOrg("ORG-POLICY-MUT-BLOCK", models.NegativeStockBlock)
+		warnOrg := newOrg("ORG-POLICY-MUT-WARN", models.NegativeStockWarn)
+		for _, orgID := range []uuid.UUID{blockOrg, warnOrg} {
+			_, err := post(orgID, 0, 10, "stok_awal")
+			assertNoError(t, err)
+		}
+
+		err := testService.CreateMutation(services.MutationRequest{
+			FromOrganizationID: blockOrg, ToOrganizationID: warnOrg, ItemID: testItemID,
+			Quantity: models.Qty(15), TxnDate: base.Add(time.Hour), ChangedBy: "policy_test",
+		})
+		assertError(t, err, "insufficient stock in source organization")
+
+		err = testService.CreateMutation(services.MutationRequest{
+			FromOrganizationID: warnOrg, ToOrganizationID: blockOrg, ItemID: testItemID,
+			Quantity: models.Qty(15), TxnDate: base.Add(time.Hour), ChangedBy: "policy_test",
+		})
+		assertNoError(t, err)
+		assertEqual(t, -5, assertBalanceChain(t, warnOrg, testItemID), "warn org balance")
+		assertEqual(t, 25, assertBalanceChain(t, blockOrg, testItemID), "block org balance")
+	})
+	t.Run("SC69: Organizations that predate the policy are backfilled to block", func(t *testing.T) {
+		all, err := migrations.Load()
+		assertNoError(t, err)
+		var policyMigration *migrations.Migration
+		for i := range all {
+			if all[i].Version == 5 {
+				policyMigration = &all[i]
+			}
+		}
+		if policyMigration == nil {
+			t.Fatal("migration 0005 not found")
+		}
+
+		// Ulang migrasi 0005 di atas organisasi lama; semuanya di-rollback di akhir
+		errRollback := errors.New("rollback")
+		err = testDB.Transaction(func(tx *gorm.DB) error {
+			assertNoError(t, tx.Exec(policyMigration.Down).Error)
+			legacyID := uuid.New()
+			assertNoError(t, tx.Exec("INSERT INTO organizations (id, name, code) VALUES (?, ?, ?)",
+				legacyID, "Policy Legacy", "ORG-POLICY-LEGACY").Error)
+			assertNoError(t, tx.Exec(policyMigration.Up).Error)
+
+			var policy models.NegativeStockPolicy
+			assertNoError(t, tx.Table("organizations").Select("negative_stock_policy").
+				Where("id = ?", legacyID).Scan(&policy).Error)
+			assertEqual(t, models.NegativeStockBlock, policy, "legacy organization policy")
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("expected rollback, got %v", err)
+		}
+	})
+}
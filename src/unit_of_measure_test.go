@@ -0,0 +1,123 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+	"inventory-ledger/src/services"
+)
+
+// ============ TEST SCENARIO 26: DECIMAL QUANTITIES & UNITS OF MEASURE ============
+func TestUnitsOfMeasure(t *testing.T) {
+	itemService := &services.ItemService{
+		DB:        testDB,
+		Repo:      &repositories.ItemRepository{DB: testDB},
+		Inventory: testService,
+	}
+
+	orgID := uuid.New()
+	branchID := uuid.New()
+	testDB.Create(&models.Organization{ID: orgID, Name: "UoM Org", Code: "ORG-UOM"})
+	testDB.Create(&models.Organization{ID: branchID, Name: "UoM Branch", Code: "ORG-UOM-2"})
+
+	base := time.Date(2025, 9, 1, 8, 0, 0, 0, time.UTC)
+	qty := func(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+	item, err := itemService.CreateItem(services.ItemRequest{Code: "ITEM-UOM", Name: "Kabel NYM", Unit: "m"})
+	assertNoError(t, err)
+
+	t.Run("SC55: Postings in any allowed unit are stored in the base unit", func(t *testing.T) {
+		_, err := itemService.SetItemUnit(item.ID, "M", qty("2"))
+		assertError(t, err, services.ErrBaseUnitConversion.Error())
+		_, err = itemService.SetItemUnit(item.ID, "roll", decimal.Zero)
+		assertError(t, err, services.ErrInvalidUnitFactor.Error())
+
+		_, err = itemService.SetItemUnit(item.ID, "roll", qty("50"))
+		assertNoError(t, err)
+		_, err = itemService.SetItemUnit(item.ID, "cm", qty("0.01"))
+		assertNoError(t, err)
+
+		units, err := itemService.GetItemUnits(item.ID)
+		assertNoError(t, err)
+		assertEqual(t, "m", units.BaseUnit, "base unit")
+		assertEqual(t, 2, len(units.Units), "alternative units")
+
+		// 2 roll = 100 m
+		receipt, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base, Amount: qty("2"), Unit: "ROLL",
+			Type: "stok_awal", ChangedBy: "uom_test",
+		})
+		assertNoError(t, err)
+		assertEqual(t, 100, receipt.Amount, "receipt converted to base unit")
+
+		// Pecahan dalam satuan dasar
+		usage, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.Add(time.Hour), Amount: qty("-12.75"),
+			Type: "pemakaian", ChangedBy: "uom_test",
+		})
+		assertNoError(t, err)
+		assertEqual(t, "87.25", usage.Balance, "fractional balance")
+
+		// 250 cm = 2.5 m
+		assertNoError(t, testService.CreateMutation(services.MutationRequest{
+			FromOrganizationID: orgID, ToOrganizationID: branchID, ItemID: item.ID,
+			Quantity: qty("250"), Unit: "cm", TxnDate: base.Add(2 * time.Hour), ChangedBy: "uom_test",
+		}))
+		assertEqual(t, "84.75", assertBalanceChain(t, orgID, item.ID), "source balance after mutation")
+		assertEqual(t, "2.5", assertBalanceChain(t, branchID, item.ID), "destination balance after mutation")
+
+		// Backdated pecahan: RecalculateForward menjaga saldo desimal
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.Add(30 * time.Minute), Amount: qty("0.125"),
+			Type: "penerimaan", ChangedBy: "uom_test",
+		})
+		assertNoError(t, err)
+		assertEqual(t, "84.875", assertBalanceChain(t, orgID, item.ID), "balance after backdated receipt")
+
+		opname, err := testService.CreateOpname(services.OpnameRequest{
+			OrganizationID: orgID, ItemID: item.ID, PhysicalQty: qty("1.5"), Unit: "roll",
+			TxnDate: base.Add(3 * time.Hour), ChangedBy: "uom_test",
+		})
+		assertNoError(t, err)
+		assertEqual(t, 75, *opname.PhysicalQty, "opname counted in rolls")
+		assertEqual(t, "-9.875", *opname.Difference, "opname difference")
+	})
+
+	t.Run("SC56: Unknown units, excess precision and base unit changes are rejected", func(t *testing.T) {
+		post := func(amount decimal.Decimal, unit string) error {
+			_, err := testService.CreateTransaction(services.CreateTransactionRequest{
+				OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 1), Amount: amount, Unit: unit,
+				Type: "penerimaan", ChangedBy: "uom_test",
+			})
+			return err
+		}
+
+		assertError(t, post(qty("1"), "box"), services.ErrUnitNotAllowed.Error())
+		assertError(t, post(qty("0.0000001"), ""), services.ErrInvalidQuantity.Error())
+		// 0.00001 cm = 0.0000001 m: tidak dibulatkan diam-diam
+		assertError(t, post(qty("0.00001"), "cm"), services.ErrInvalidQuantity.Error())
+
+		// Faktor baru hanya untuk posting berikutnya; ledger lama tetap
+		_, err := itemService.SetItemUnit(item.ID, "Roll", qty("100"))
+		assertNoError(t, err)
+		units, err := itemService.GetItemUnits(item.ID)
+		assertNoError(t, err)
+		assertEqual(t, "roll", units.Units[1].Unit, "existing unit spelling kept")
+		assertEqual(t, 75, assertBalanceChain(t, orgID, item.ID), "ledger unchanged by new factor")
+
+		assertError(t, itemService.DeleteItemUnit(item.ID, "box"), services.ErrItemUnitNotFound.Error())
+		assertNoError(t, itemService.DeleteItemUnit(item.ID, "CM"))
+		assertError(t, post(qty("10"), "cm"), services.ErrUnitNotAllowed.Error())
+
+		_, err = itemService.UpdateItem(item.ID, services.ItemRequest{Code: "ITEM-UOM", Name: "Kabel NYM", Unit: "cm"})
+		assertError(t, err, services.ErrBaseUnitLocked.Error())
+		renamed, err := itemService.UpdateItem(item.ID, services.ItemRequest{Code: "ITEM-UOM", Name: "Kabel NYM", Unit: "M"})
+		assertNoError(t, err)
+		assertEqual(t, "M", renamed.Unit, "base unit spelling may change")
+	})
+}
@@ -0,0 +1,237 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/repositories"
+	"inventory-ledger/src/services"
+)
+
+// ============ TEST SCENARIO 23: INVENTORY VALUATION ============
+func TestInventoryValuation(t *testing.T) {
+	itemService := &services.ItemService{
+		DB:        testDB,
+		Repo:      &repositories.ItemRepository{DB: testDB},
+		Inventory: testService,
+	}
+
+	orgID := uuid.New()
+	branchID := uuid.New()
+	testDB.Create(&models.Organization{ID: orgID, Name: "Valuation Org", Code: "ORG-VAL"})
+	testDB.Create(&models.Organization{ID: branchID, Name: "Valuation Branch", Code: "ORG-VAL-2"})
+
+	base := time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)
+	cost := func(v int64) *decimal.Decimal {
+		d := decimal.NewFromInt(v)
+		return &d
+	}
+
+	stockValue := func(orgID uuid.UUID, itemID uint) string {
+		var sb models.StockBalance
+		assertNoError(t, testDB.Where("organization_id = ? AND item_id = ?", orgID, itemID).Take(&sb).Error)
+		return sb.BalanceValue.String()
+	}
+	costAmount := func(id uuid.UUID) string {
+		var inv models.Inventory
+		assertNoError(t, testDB.Where("id = ?", id).Take(&inv).Error)
+		if inv.CostAmount == nil {
+			return "<nil>"
+		}
+		return inv.CostAmount.String()
+	}
+
+	t.Run("SC49: FIFO and moving average cost issues and re-cost backdated receipts", func(t *testing.T) {
+		fifo, err := itemService.CreateItem(services.ItemRequest{Code: "ITEM-FIFO", Name: "FIFO Item", Unit: "pcs", CostingMethod: models.CostingFIFO})
+		assertNoError(t, err)
+		average, err := itemService.CreateItem(services.ItemRequest{Code: "ITEM-AVG", Name: "Average Item", Unit: "pcs"})
+		assertNoError(t, err)
+		assertEqual(t, models.CostingAverage, average.CostingMethod, "default costing method")
+
+		issues := make(map[uint]uuid.UUID)
+		for _, itemID := range []uint{fifo.ID, average.ID} {
+			_, err := testService.CreateTransaction(services.CreateTransactionRequest{
+				OrganizationID: orgID, ItemID: itemID, TxnDate: base, Amount: models.Qty(10), Type: "stok_awal",
+				UnitCost: cost(100), ChangedBy: "valuation_test",
+			})
+			assertNoError(t, err)
+			_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+				OrganizationID: orgID, ItemID: itemID, TxnDate: base.AddDate(0, 0, 2), Amount: models.Qty(10), Type: "penerimaan",
+				UnitCost: cost(130), ChangedBy: "valuation_test",
+			})
+			assertNoError(t, err)
+			issue, err := testService.CreateTransaction(services.CreateTransactionRequest{
+				OrganizationID: orgID, ItemID: itemID, TxnDate: base.AddDate(0, 0, 3), Amount: models.Qty(-15), Type: "pemakaian",
+				ChangedBy: "valuation_test",
+			})
+			assertNoError(t, err)
+			issues[itemID] = issue.ID
+		}
+
+		// FIFO: 10 x 100 + 5 x 130, sisa 5 x 130. Average: 15 x 115, sisa 5 x 115
+		assertEqual(t, "-1650", costAmount(issues[fifo.ID]), "fifo issue cost")
+		assertEqual(t, "650", stockValue(orgID, fifo.ID), "fifo stock value")
+		assertEqual(t, "-1725", costAmount(issues[average.ID]), "average issue cost")
+		assertEqual(t, "575", stockValue(orgID, average.ID), "average stock value")
+
+		layers, err := testService.GetCostLayers(orgID, fifo.ID)
+		assertNoError(t, err)
+		assertEqual(t, 1, len(layers), "fifo open layers")
+		assertEqual(t, 5, layers[0].RemainingQty, "fifo layer remaining")
+		layers, err = testService.GetCostLayers(orgID, average.ID)
+		assertNoError(t, err)
+		assertEqual(t, 0, len(layers), "average has no layers")
+
+		// Penerimaan backdated 10 x 70 di antara stok awal dan penerimaan kedua
+		for _, itemID := range []uint{fifo.ID, average.ID} {
+			_, err := testService.CreateTransaction(services.CreateTransactionRequest{
+				OrganizationID: orgID, ItemID: itemID, TxnDate: base.AddDate(0, 0, 1), Amount: models.Qty(10), Type: "penerimaan",
+				UnitCost: cost(70), ChangedBy: "valuation_test",
+			})
+			assertNoError(t, err)
+		}
+
+		// FIFO: 10 x 100 + 5 x 70, sisa 5 x 70 + 10 x 130. Average: rata-rata 100 setelah penerimaan kedua
+		assertEqual(t, "-1350", costAmount(issues[fifo.ID]), "fifo issue re-costed")
+		assertEqual(t, "1650", stockValue(orgID, fifo.ID), "fifo stock value re-costed")
+		assertEqual(t, "-1500", costAmount(issues[average.ID]), "average issue re-costed")
+		assertEqual(t, "1500", stockValue(orgID, average.ID), "average stock value re-costed")
+
+		layers, err = testService.GetCostLayers(orgID, fifo.ID)
+		assertNoError(t, err)
+		assertEqual(t, 2, len(layers), "fifo open layers after backdate")
+
+		// unit_cost hanya untuk stok masuk
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: fifo.ID, TxnDate: base.AddDate(0, 0, 4), Amount: models.Qty(-1), Type: "pemakaian",
+			UnitCost: cost(10), ChangedBy: "valuation_test",
+		})
+		assertError(t, err, "unit_cost is only allowed on incoming stok_awal and penerimaan")
+	})
+
+	t.Run("SC50: Mutations carry source cost and follow re-costing and method changes", func(t *testing.T) {
+		item, err := itemService.CreateItem(services.ItemRequest{Code: "ITEM-VAL-MUT", Name: "Mutation Item", Unit: "pcs"})
+		assertNoError(t, err)
+
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base, Amount: models.Qty(10), Type: "stok_awal",
+			UnitCost: cost(50), ChangedBy: "valuation_test",
+		})
+		assertNoError(t, err)
+		assertNoError(t, testService.CreateMutation(services.MutationRequest{
+			FromOrganizationID: orgID, ToOrganizationID: branchID, ItemID: item.ID,
+			Quantity: models.Qty(4), TxnDate: base.AddDate(0, 0, 2), ChangedBy: "valuation_test",
+		}))
+		assertEqual(t, "200", stockValue(branchID, item.ID), "branch receives source cost")
+
+		// Penerimaan backdated di org asal mengubah cost mutasi -> cabang ikut dinilai ulang
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 1), Amount: models.Qty(10), Type: "penerimaan",
+			UnitCost: cost(150), ChangedBy: "valuation_test",
+		})
+		assertNoError(t, err)
+		assertEqual(t, "1600", stockValue(orgID, item.ID), "source value after backdate")
+		assertEqual(t, "400", stockValue(branchID, item.ID), "branch re-costed through mutation")
+
+		summary, err := testService.GetItemSummary(item.ID)
+		assertNoError(t, err)
+		for _, row := range summary {
+			if row["organization_id"] == branchID {
+				assertEqual(t, "100", row["average_cost"].(decimal.Decimal).String(), "branch average cost")
+			}
+		}
+
+		// Ganti ke FIFO: mutasi keluar mengambil layer 50 dulu
+		_, err = itemService.UpdateItem(item.ID, services.ItemRequest{
+			Code: item.Code, Name: item.Name, Unit: item.Unit, CostingMethod: models.CostingFIFO,
+		})
+		assertNoError(t, err)
+		assertEqual(t, "1800", stockValue(orgID, item.ID), "source value after switching to fifo")
+		assertEqual(t, "200", stockValue(branchID, item.ID), "branch value after switching to fifo")
+	})
+
+	t.Run("SC59: Valuation stops where the position matches the previous valuation", func(t *testing.T) {
+		item, err := itemService.CreateItem(services.ItemRequest{Code: "ITEM-VAL-STOP", Name: "Short-circuit Item", Unit: "pcs", CostingMethod: models.CostingFIFO})
+		assertNoError(t, err)
+		post := func(days int, amount int64, txnType string, unitCost *decimal.Decimal) *models.Inventory {
+			inv, err := testService.CreateTransaction(services.CreateTransactionRequest{
+				OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, days), Amount: models.Qty(amount), Type: txnType,
+				UnitCost: unitCost, ChangedBy: "valuation_test",
+			})
+			assertNoError(t, err)
+			return inv
+		}
+
+		post(0, 10, "stok_awal", cost(100))
+		_, err = testService.CreateOpname(services.OpnameRequest{
+			OrganizationID: orgID, ItemID: item.ID, PhysicalQty: models.Qty(0), TxnDate: base.AddDate(0, 0, 2), ChangedBy: "valuation_test",
+		})
+		assertNoError(t, err)
+		receipt := post(3, 5, "penerimaan", cost(120))
+		post(4, -2, "pemakaian", nil)
+		last := post(5, -1, "pemakaian", nil)
+
+		// Pemakaian backdated sebelum opname: saldo & nilai kembali sama di penerimaan hari ke-3
+		backdated := post(1, -3, "pemakaian", nil)
+		assertEqual(t, "-300", costAmount(backdated.ID), "backdated issue cost")
+		assertEqual(t, "-120", costAmount(last.ID), "issue after settled position")
+		assertEqual(t, "240", stockValue(orgID, item.ID), "stock value")
+
+		layers, err := testService.GetCostLayers(orgID, item.ID)
+		assertNoError(t, err)
+		assertEqual(t, 1, len(layers), "open layers kept")
+		assertEqual(t, receipt.ID, layers[0].InventoryID, "layer source")
+		assertEqual(t, 2, layers[0].RemainingQty, "layer remaining")
+
+		// Ledger sudah konsisten: berhenti di penerimaan setelah opname
+		var stored models.Inventory
+		assertNoError(t, testDB.Where("id = ?", receipt.ID).Take(&stored).Error)
+		settled := &repositories.LedgerPosition{TxnDate: stored.TxnDate, CreatedAt: stored.CreatedAt, ID: stored.ID}
+		stats, err := testService.Repo.Revalue(testDB, orgID, item.ID, backdated.TxnDate, settled, models.CostingFIFO)
+		assertNoError(t, err)
+		assertEqual(t, true, stats.ShortCircuited, "short circuited")
+		assertEqual(t, 2, stats.Scanned, "rows scanned")
+		assertEqual(t, 0, stats.Updated, "rows updated")
+	})
+
+	t.Run("SC60: Cascade into a locked destination aborts with a retryable error", func(t *testing.T) {
+		item, err := itemService.CreateItem(services.ItemRequest{Code: "ITEM-VAL-BUSY", Name: "Busy Item", Unit: "pcs"})
+		assertNoError(t, err)
+
+		_, err = testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base, Amount: models.Qty(10), Type: "stok_awal",
+			UnitCost: cost(50), ChangedBy: "valuation_test",
+		})
+		assertNoError(t, err)
+		assertNoError(t, testService.CreateMutation(services.MutationRequest{
+			FromOrganizationID: orgID, ToOrganizationID: branchID, ItemID: item.ID,
+			Quantity: models.Qty(4), TxnDate: base.AddDate(0, 0, 2), ChangedBy: "valuation_test",
+		}))
+
+		// Posting lain memegang lock cabang: cascade tidak menunggu (bisa deadlock), tapi dibatalkan
+		holder := testDB.Begin()
+		assertNoError(t, holder.Exec("SELECT pg_advisory_xact_lock(?)", repositories.OrgItemLockKey(branchID, item.ID)).Error)
+
+		backdated := services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: item.ID, TxnDate: base.AddDate(0, 0, 1), Amount: models.Qty(10), Type: "penerimaan",
+			UnitCost: cost(150), ChangedBy: "valuation_test",
+		}
+		_, err = testService.CreateTransaction(backdated)
+		holder.Rollback()
+		if !errors.Is(err, services.ErrRevaluationBusy) {
+			t.Fatalf("expected revaluation busy, got %v", err)
+		}
+		assertEqual(t, "300", stockValue(orgID, item.ID), "source value unchanged after abort")
+		assertEqual(t, "200", stockValue(branchID, item.ID), "branch value unchanged after abort")
+
+		// Retry setelah lock dilepas berhasil
+		_, err = testService.CreateTransaction(backdated)
+		assertNoError(t, err)
+		assertEqual(t, "400", stockValue(branchID, item.ID), "branch re-costed on retry")
+	})
+}
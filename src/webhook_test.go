@@ -0,0 +1,182 @@
+package services_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"inventory-ledger/src/models"
+	"inventory-ledger/src/services"
+)
+
+// webhookReceiver - Receiver httptest lokal; path yang ada di failing dibalas 500
+type webhookReceiver struct {
+	mu       sync.Mutex
+	requests map[string][]*http.Request
+	bodies   map[string][][]byte
+	failing  map[string]bool
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests[req.URL.Path] = append(r.requests[req.URL.Path], req)
+	r.bodies[req.URL.Path] = append(r.bodies[req.URL.Path], body)
+	if r.failing[req.URL.Path] {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("downstream unavailable"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *webhookReceiver) count(path string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests[path])
+}
+
+// ============ TEST SCENARIO 21: OUTGOING WEBHOOKS ============
+func TestWebhooks(t *testing.T) {
+	assertNoError(t, testDB.Exec("DELETE FROM outbox_events").Error)
+
+	receiver := &webhookReceiver{
+		requests: map[string][]*http.Request{},
+		bodies:   map[string][][]byte{},
+		failing:  map[string]bool{"/procurement": true},
+	}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	posOrgID := uuid.New()
+	warehouseOrgID := uuid.New()
+	testDB.Create(&models.Organization{ID: posOrgID, Name: "Webhook POS", Code: "ORG-WEBHOOK-POS"})
+	testDB.Create(&models.Organization{ID: warehouseOrgID, Name: "Webhook Warehouse", Code: "ORG-WEBHOOK-WH"})
+
+	webhookService := &services.WebhookService{
+		DB: testDB, Client: server.Client(),
+		MaxAttempts: 2, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond,
+	}
+	outbox := &services.OutboxDispatcher{DB: testDB, Sinks: []services.EventSink{services.WebhookSink{Service: webhookService}}}
+
+	const posSecret = "pos-secret-0123456789"
+	pos, err := webhookService.CreateSubscription(services.WebhookSubscriptionRequest{
+		Name: "POS", URL: server.URL + "/pos", Secret: posSecret,
+		EventTypes: []string{services.EventTransactionCreated}, OrganizationIDs: []uuid.UUID{posOrgID},
+	})
+	assertNoError(t, err)
+	procurement, err := webhookService.CreateSubscription(services.WebhookSubscriptionRequest{
+		Name: "Procurement", URL: server.URL + "/procurement", OrganizationIDs: []uuid.UUID{warehouseOrgID},
+	})
+	assertNoError(t, err)
+
+	_, err = webhookService.CreateSubscription(services.WebhookSubscriptionRequest{
+		Name: "Typo", URL: server.URL, EventTypes: []string{"StockMoved"},
+	})
+	assertEqual(t, true, errors.Is(err, services.ErrWebhookUnknownEvent), "unknown event type rejected")
+
+	for _, orgID := range []uuid.UUID{posOrgID, warehouseOrgID} {
+		_, err := testService.CreateTransaction(services.CreateTransactionRequest{
+			OrganizationID: orgID, ItemID: testItemID, TxnDate: time.Date(2025, 5, 2, 8, 0, 0, 0, time.UTC),
+			Amount: models.Qty(25), Type: "stok_awal", ChangedBy: "webhook_test",
+		})
+		assertNoError(t, err)
+	}
+	for {
+		processed, err := outbox.DispatchOnce(context.Background())
+		assertNoError(t, err)
+		if processed == 0 {
+			break
+		}
+	}
+
+	deliveriesOf := func(subscriptionID uuid.UUID) []models.WebhookDelivery {
+		deliveries, _, err := webhookService.ListDeliveries(subscriptionID, "", 1, 100)
+		assertNoError(t, err)
+		return deliveries
+	}
+
+	t.Run("SC45: Filtered fan-out with HMAC-signed payloads", func(t *testing.T) {
+		posDeliveries := deliveriesOf(pos.ID)
+		assertEqual(t, 1, len(posDeliveries), "pos: TransactionCreated of its org only")
+		assertEqual(t, 2, len(deliveriesOf(procurement.ID)), "procurement: every event of warehouse org")
+
+		// Outbox mengirim ulang event yang sama (at-least-once): delivery tidak digandakan
+		var event models.OutboxEvent
+		assertNoError(t, testDB.Where("id = ?", posDeliveries[0].EventID).Take(&event).Error)
+		assertNoError(t, services.WebhookSink{Service: webhookService}.Deliver(context.Background(), &event))
+		assertEqual(t, 1, len(deliveriesOf(pos.ID)), "duplicate outbox delivery ignored")
+
+		_, err := webhookService.DispatchOnce(context.Background())
+		assertNoError(t, err)
+		assertEqual(t, 1, receiver.count("/pos"), "pos requests")
+
+		req := receiver.requests["/pos"][0]
+		body := receiver.bodies["/pos"][0]
+		mac := hmac.New(sha256.New, []byte(posSecret))
+		mac.Write([]byte(req.Header.Get(services.WebhookTimestampHeader) + "."))
+		mac.Write(body)
+		assertEqual(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), req.Header.Get(services.WebhookSignatureHeader), "signature")
+		assertEqual(t, services.EventTransactionCreated, req.Header.Get(services.WebhookEventHeader), "event header")
+
+		var envelope models.OutboxEnvelope
+		assertNoError(t, json.Unmarshal(body, &envelope))
+		assertEqual(t, event.ID, envelope.ID, "envelope id")
+		assertEqual(t, posOrgID, envelope.OrganizationID, "envelope organization")
+
+		delivered, err := webhookService.GetDelivery(pos.ID, posDeliveries[0].ID)
+		assertNoError(t, err)
+		assertEqual(t, models.WebhookDeliveryDelivered, delivered.Status, "pos delivery status")
+		assertEqual(t, http.StatusNoContent, *delivered.LastStatusCode, "pos status code")
+	})
+
+	t.Run("SC46: Failed deliveries back off, give up, and can be redelivered", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			time.Sleep(5 * time.Millisecond)
+			_, err := webhookService.DispatchOnce(context.Background())
+			assertNoError(t, err)
+		}
+		assertEqual(t, 4, receiver.count("/procurement"), "two attempts per delivery")
+
+		failed, _, err := webhookService.ListDeliveries(procurement.ID, string(models.WebhookDeliveryFailed), 1, 100)
+		assertNoError(t, err)
+		assertEqual(t, 2, len(failed), "failed deliveries")
+		assertEqual(t, 2, failed[0].Attempts, "attempts")
+		assertEqual(t, http.StatusInternalServerError, *failed[0].LastStatusCode, "last status code")
+		assertEqual(t, "downstream unavailable", *failed[0].LastResponse, "last response")
+
+		receiver.mu.Lock()
+		receiver.failing["/procurement"] = false
+		receiver.mu.Unlock()
+
+		redelivery, err := webhookService.Redeliver(procurement.ID, failed[0].ID)
+		assertNoError(t, err)
+		_, err = webhookService.Redeliver(procurement.ID, redelivery.ID)
+		assertEqual(t, services.ErrWebhookDeliveryPending, err, "redeliver pending delivery")
+
+		_, err = webhookService.DispatchOnce(context.Background())
+		assertNoError(t, err)
+
+		redelivered, err := webhookService.GetDelivery(procurement.ID, redelivery.ID)
+		assertNoError(t, err)
+		assertEqual(t, models.WebhookDeliveryDelivered, redelivered.Status, "redelivery status")
+		assertEqual(t, failed[0].ID, *redelivered.RedeliveryOf, "redelivery link")
+
+		original, err := webhookService.GetDelivery(procurement.ID, failed[0].ID)
+		assertNoError(t, err)
+		assertEqual(t, models.WebhookDeliveryFailed, original.Status, "original delivery log unchanged")
+	})
+}